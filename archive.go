@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 解压成功后对上传压缩包的处理方式
+const (
+	archiveKeep   = "keep"   // 保留在原位置 (默认)
+	archiveDelete = "delete" // 直接删除
+	archiveMove   = "move"   // 移动到归档目录，按数量保留
+)
+
+// ArchiveConfig 压缩包处理配置
+type ArchiveConfig struct {
+	KeepArchive string `json:"keep_archive"` // 默认处理方式: keep / delete / move (路径策略中的 keep_archive 优先)
+	Dir         string `json:"dir"`          // 归档目录 (相对路径基于程序目录)
+	Retention   int    `json:"retention"`    // 每个路径标识保留的归档数量
+}
+
+// ArchiveResult 压缩包处理结果
+type ArchiveResult struct {
+	Action string `json:"action"`
+	Path   string `json:"path,omitempty"`
+	Pruned int    `json:"pruned,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// parseKeepArchive 解析 keep_archive 参数，兼容 true/false 写法
+func parseKeepArchive(v string) (string, error) {
+	switch strings.ToLower(v) {
+	case "true", "keep", "1":
+		return archiveKeep, nil
+	case "false", "delete", "0":
+		return archiveDelete, nil
+	case "move", "archive":
+		return archiveMove, nil
+	}
+	return "", fmt.Errorf("无效的 keep_archive 参数: %s (应为 keep/delete/move)", v)
+}
+
+// archivePolicy 按 请求参数 > 路径标识配置 > 全局默认 的顺序确定处理方式
+func archivePolicy(pathKey, requested string) (string, error) {
+	if requested != "" {
+		return parseKeepArchive(requested)
+	}
+	if p, ok := config.Paths[pathKey]; ok && p.KeepArchive != "" {
+		return parseKeepArchive(p.KeepArchive)
+	}
+	if config.Archive.KeepArchive != "" {
+		return parseKeepArchive(config.Archive.KeepArchive)
+	}
+	return archiveKeep, nil
+}
+
+// archiveDir 返回路径标识对应的归档目录
+func archiveDir(pathKey string) string {
+	dir := config.Archive.Dir
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(exePath, dir)
+	}
+	return filepath.Join(dir, pathKey)
+}
+
+// handleArchive 解压成功后按策略处理上传的压缩包
+func handleArchive(pathKey, zipPath, filename, policy string) (*ArchiveResult, error) {
+	switch policy {
+	case archiveDelete:
+		if err := os.Remove(zipPath); err != nil {
+			return nil, err
+		}
+		return &ArchiveResult{Action: "deleted"}, nil
+
+	case archiveMove:
+		dir := archiveDir(pathKey)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
+		}
+
+		name := time.Now().Format("20060102_150405") + "_" + strings.ReplaceAll(filepath.ToSlash(filename), "/", "_")
+		dst := filepath.Join(dir, name)
+		if err := replaceFile(zipPath, dst); err != nil {
+			return nil, err
+		}
+
+		pruned, err := pruneArchives(dir, config.Archive.Retention)
+		if err != nil {
+			logWarn("清理归档失败 [%s]: %v", pathKey, err)
+		}
+		return &ArchiveResult{Action: "moved", Path: dst, Pruned: pruned}, nil
+	}
+
+	return &ArchiveResult{Action: "kept", Path: zipPath}, nil
+}
+
+// pruneArchives 只保留最新的 keep 个归档文件
+func pruneArchives(dir string, keep int) (int, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var files []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			files = append(files, e.Name())
+		}
+	}
+	if len(files) <= keep {
+		return 0, nil
+	}
+
+	// 文件名以时间戳开头，按名称倒序即为从新到旧
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+
+	pruned := 0
+	for _, name := range files[keep:] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
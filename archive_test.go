@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestArchivePolicy(t *testing.T) {
+	defer func(paths map[string]PathConfig, archive ArchiveConfig) {
+		config.Paths, config.Archive = paths, archive
+	}(config.Paths, config.Archive)
+
+	config.Paths = map[string]PathConfig{
+		"web": {Dir: "web", KeepArchive: "delete"},
+		"api": {Dir: "api"},
+	}
+	tests := []struct {
+		global    string
+		pathKey   string
+		requested string
+		want      string
+		wantErr   bool
+	}{
+		{"", "api", "", archiveKeep, false},
+		{"move", "api", "", archiveMove, false},
+		{"move", "web", "", archiveDelete, false},
+		{"move", "web", "keep", archiveKeep, false},
+		{"", "api", "false", archiveDelete, false},
+		{"", "api", "bogus", "", true},
+		{"", "unknown", "", archiveKeep, false},
+	}
+	for _, tt := range tests {
+		config.Archive = ArchiveConfig{KeepArchive: tt.global}
+		got, err := archivePolicy(tt.pathKey, tt.requested)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("archivePolicy(%q, %q) with global %q = %q, %v, want %q", tt.pathKey, tt.requested, tt.global, got, err, tt.want)
+		}
+	}
+}
@@ -2,47 +2,240 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"client-gui/internal/bundle"
 	"client-gui/internal/crypto"
 	"client-gui/internal/database"
+	"client-gui/internal/deploy"
+	"client-gui/internal/health"
+	"client-gui/internal/ignore"
+	"client-gui/internal/keystore"
+	"client-gui/internal/lock"
+	"client-gui/internal/notify"
+	"client-gui/internal/queue"
+	"client-gui/internal/recipe"
+	"client-gui/internal/scheduler"
 	"client-gui/internal/uploader"
+	"client-gui/internal/watcher"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
 // App struct
 type App struct {
-	ctx context.Context
-	db  *database.DB
+	ctx       context.Context
+	host      host
+	db        *database.DB
+	keys      *keystore.Store
+	watchers  *watcher.Manager
+	scheduler *scheduler.Scheduler
+	queue     *queue.Dispatcher
+	health    *health.Monitor
+
+	// 监控、定时任务和上传队列 (自动化引擎) 同时只在一个进程中运行，
+	// 后台服务已在运行时图形界面只管理配置
+	engineMode string
+	engineMu   sync.Mutex
+	engineLock *lock.Lock
+	engines    atomic.Bool
+
+	// 系统托盘: 开启后关闭窗口只隐藏到托盘，从托盘退出时 quitting 为 true
+	trayRunning   atomic.Bool
+	trayHinted    atomic.Bool
+	quitting      atomic.Bool
+	watchesPaused atomic.Bool
+
+	// 每种事件的系统通知在 notifyInterval 内只显示一次，避免批量上传时连续弹出
+	notifyMu   sync.Mutex
+	notifyLast map[string]time.Time
+
+	// 上传任务，uploadCtx 在程序退出时取消
+	uploadCtx     context.Context
+	cancelUploads context.CancelFunc
+	jobsMu        sync.Mutex
+	jobs          map[string]*uploadJob
+
+	// 正在运行的部署配方，按配方 ID
+	recipesMu  sync.Mutex
+	recipeRuns map[string]*recipeRunState
 }
 
+// 自动化引擎的运行模式，记录在锁文件中
+const (
+	engineModeGUI    = "gui"
+	engineModeDaemon = "daemon"
+)
+
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &App{
+		engineMode:    engineModeGUI,
+		uploadCtx:     ctx,
+		cancelUploads: cancel,
+		jobs:          make(map[string]*uploadJob),
+		recipeRuns:    make(map[string]*recipeRunState),
+		notifyLast:    make(map[string]time.Time),
+	}
 }
 
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.host = &wailsHost{ctx: ctx}
+
+	if err := a.open(); err != nil {
+		a.host.Error(fmt.Sprintf("数据库初始化失败: %v", err))
+		return
+	}
+	if err := a.startEngines(); err != nil {
+		a.host.Warn(fmt.Sprintf("未启动监控和定时任务: %v", err))
+	}
+	a.health.Start()
+	a.startTray()
+}
 
-	// 初始化数据库
+// open 打开数据库并创建各组件，不启动后台任务
+func (a *App) open() error {
 	db, err := database.New()
 	if err != nil {
-		runtime.LogError(ctx, fmt.Sprintf("数据库初始化失败: %v", err))
-		return
+		return err
 	}
 	a.db = db
+
+	// 私钥加密存储，旧版本的明文私钥在这里迁移
+	a.keys = keystore.New(db, func() {
+		a.host.Emit("key:locked", nil)
+	})
+	protection, err := a.keys.Migrate()
+	if err != nil {
+		a.host.Error(fmt.Sprintf("私钥迁移失败: %v", err))
+	}
+	if st, err := a.keys.Status(); err == nil && st.NeedsPassword {
+		// 设置保护方式之前不使用私钥，需要签名的上传留在队列中
+		a.host.Error(keystore.ErrUnprotected.Error())
+		a.host.Emit("key:unprotected", st)
+	} else if protection == keystore.ProtectionPlain {
+		a.host.Warn("私钥以明文保存，建议在密钥管理中设置主密码")
+	}
+
+	a.queue = queue.New(db, a.runQueueJob, a.onQueueUpdate, func(err error) bool {
+		// 私钥解锁或设置主密码后即可成功
+		return errors.Is(err, keystore.ErrLocked) || errors.Is(err, keystore.ErrUnprotected)
+	})
+	a.watchers = watcher.NewManager(a.onWatchChanges, a.onWatchError)
+	a.scheduler = scheduler.New(a.runSchedule, a.onScheduleUpdate)
+
+	// 后台检测服务器状态
+	interval := health.DefaultInterval
+	if v, err := db.GetSetting(settingHealthInterval); err == nil && v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			interval = time.Duration(seconds) * time.Second
+		}
+	}
+	a.health = health.New(db, interval, a.onHealthUpdate, func(server database.Server) {
+		a.host.Emit("server:paths", server)
+	})
+	return nil
+}
+
+// engineLockPath 自动化引擎的锁文件
+func engineLockPath() string {
+	return filepath.Join(database.GetDataDir(), "engines.lock")
+}
+
+// startEngines 取得引擎锁后恢复上传队列、启动监控和定时任务，锁被占用时返回错误
+func (a *App) startEngines() error {
+	a.engineMu.Lock()
+	defer a.engineMu.Unlock()
+	if a.engines.Load() {
+		return nil
+	}
+
+	l, err := lock.Acquire(engineLockPath(), a.engineMode)
+	if errors.Is(err, lock.ErrLocked) {
+		if owner := lock.Owner(engineLockPath()); owner != "" {
+			return fmt.Errorf("自动化引擎已在另一个进程中运行 (%s)", owner)
+		}
+		return errors.New("自动化引擎已在另一个进程中运行")
+	}
+	if err != nil {
+		return err
+	}
+	a.engineLock = l
+	a.engines.Store(true)
+
+	// 上次退出时未结束的运行，只有持有锁的进程可以确定它们已中断
+	if err := a.db.AbortRunningRecipeRuns(); err != nil {
+		a.host.Error(fmt.Sprintf("恢复配方运行记录失败: %v", err))
+	}
+
+	// 上传队列，恢复上次未完成的任务
+	if err := a.queue.Start(); err != nil {
+		a.host.Error(fmt.Sprintf("上传队列启动失败: %v", err))
+	}
+
+	// 启动已启用的文件夹监控
+	a.startWatches()
+
+	// 启动定时任务调度
+	a.startSchedules()
+	a.scheduler.Start()
+
+	a.host.Emit("engines:status", a.GetEngineStatus())
+	return nil
+}
+
+// EngineStatus 自动化引擎状态
+type EngineStatus struct {
+	Running bool   `json:"running"` // 在本进程中运行
+	Owner   string `json:"owner"`   // 在其他进程中运行时为持有者信息
+}
+
+// GetEngineStatus 获取监控、定时任务和上传队列是否在本进程中运行
+func (a *App) GetEngineStatus() EngineStatus {
+	if a.engines.Load() {
+		return EngineStatus{Running: true}
+	}
+	return EngineStatus{Owner: lock.Owner(engineLockPath())}
+}
+
+// StartEngines 在后台服务停止后由图形界面接管监控、定时任务和上传队列
+func (a *App) StartEngines() error {
+	return a.startEngines()
 }
 
 // shutdown is called when the app is shutting down
 func (a *App) shutdown(ctx context.Context) {
+	a.stopTray()
+	a.cancelUploads()
+	if a.watchers != nil {
+		a.watchers.StopAll()
+	}
+	if a.scheduler != nil {
+		a.scheduler.Stop()
+	}
+	if a.queue != nil {
+		a.queue.Stop()
+	}
+	if a.health != nil {
+		a.health.Stop()
+	}
 	if a.db != nil {
 		a.db.Close()
 	}
+	a.engineLock.Release()
 }
 
 // ============= 服务器管理 =============
@@ -56,13 +249,42 @@ func (a *App) GetServers() ([]database.Server, error) {
 func (a *App) SaveServer(server database.Server) error {
 	if server.ID == "" {
 		server.ID = fmt.Sprintf("server_%d", time.Now().UnixNano())
+	} else if existing, err := a.getServer(server.ID); err == nil {
+		// 默认服务器只通过 SetDefaultServer 修改，编辑表单不影响
+		server.IsDefault = existing.IsDefault
+	}
+	if server.Workers < 0 || server.Workers > uploader.MaxWorkers {
+		return fmt.Errorf("并发数应在 1-%d 之间", uploader.MaxWorkers)
+	}
+	if _, err := ignore.New(server.Excludes); err != nil {
+		return err
+	}
+	if err := uploader.ValidateConn(server.Conn); err != nil {
+		return err
+	}
+	if server.KeyID != "" {
+		kp, err := a.db.GetKeyPair(server.KeyID)
+		if err != nil {
+			return err
+		}
+		if kp == nil {
+			return fmt.Errorf("密钥不存在: %s", server.KeyID)
+		}
+	}
+	if err := a.db.SaveServer(server); err != nil {
+		return err
 	}
-	return a.db.SaveServer(server)
+	a.health.Refresh()
+	return nil
 }
 
 // DeleteServer 删除服务器
 func (a *App) DeleteServer(id string) error {
-	return a.db.DeleteServer(id)
+	if err := a.db.DeleteServer(id); err != nil {
+		return err
+	}
+	a.health.Refresh()
+	return nil
 }
 
 // SetDefaultServer 设置默认服务器
@@ -70,14 +292,83 @@ func (a *App) SetDefaultServer(id string) error {
 	return a.db.SetDefaultServer(id)
 }
 
-// TestConnection 测试服务器连接
+// TestConnection 使用默认连接设置测试服务器地址
 func (a *App) TestConnection(serverURL string) error {
-	return uploader.TestConnection(serverURL)
+	return uploader.TestConnection(a.ctx, uploader.DefaultClient(serverURL))
+}
+
+// TestServerConnection 使用编辑中的服务器配置 (代理、TLS、请求头等) 测试连接，不需要先保存
+func (a *App) TestServerConnection(server database.Server) error {
+	if err := uploader.ValidateConn(server.Conn); err != nil {
+		return err
+	}
+	return uploader.TestConnection(a.ctx, serverClient(&server))
 }
 
-// GetServerInfo 获取服务器信息
+// GetServerInfo 获取服务器信息，地址属于已保存的服务器时使用其连接设置
 func (a *App) GetServerInfo(serverURL string) (map[string]interface{}, error) {
-	return uploader.GetServerInfo(serverURL)
+	client := uploader.DefaultClient(serverURL)
+	if servers, err := a.db.GetServers(); err == nil {
+		for i := range servers {
+			if servers[i].URL == serverURL {
+				client = serverClient(&servers[i])
+				break
+			}
+		}
+	}
+	return uploader.GetServerInfo(a.ctx, client)
+}
+
+// settingHealthInterval 服务器状态检测间隔 (秒)
+const settingHealthInterval = "health.interval_seconds"
+
+// GetServerHealth 获取所有服务器的最新状态 (延迟、版本、安全模式和最近的采样)
+func (a *App) GetServerHealth() []health.Status {
+	return a.health.Statuses()
+}
+
+// CheckServerHealth 立即检测一台服务器
+func (a *App) CheckServerHealth(id string) (*health.Status, error) {
+	server, err := a.getServer(id)
+	if err != nil {
+		return nil, err
+	}
+	st := a.health.Check(*server)
+	return &st, nil
+}
+
+// GetHealthInterval 获取状态检测间隔 (秒)
+func (a *App) GetHealthInterval() int {
+	return int(a.health.Interval() / time.Second)
+}
+
+// SetHealthInterval 设置状态检测间隔 (秒)
+func (a *App) SetHealthInterval(seconds int) error {
+	min := int(health.MinInterval / time.Second)
+	if seconds < min {
+		return fmt.Errorf("检测间隔不能小于 %d 秒", min)
+	}
+	if err := a.db.SetSetting(settingHealthInterval, strconv.Itoa(seconds)); err != nil {
+		return err
+	}
+	a.health.SetInterval(time.Duration(seconds) * time.Second)
+	return nil
+}
+
+// onHealthUpdate 推送服务器状态，可达状态变化时另外发送 health:change
+func (a *App) onHealthUpdate(st health.Status, changed bool) {
+	a.host.Emit("health:update", st)
+	if !changed {
+		return
+	}
+	if st.Reachable {
+		a.host.Info(fmt.Sprintf("服务器恢复: %s", st.Name))
+		a.notifyEvent(notify.ServerUp, "服务器恢复", st.Name)
+	} else {
+		a.host.Warn(fmt.Sprintf("服务器不可达: %s (%s)", st.Name, st.Error))
+		a.notifyEvent(notify.ServerDown, "服务器不可达", fmt.Sprintf("%s: %s", st.Name, st.Error))
+	}
+	a.host.Emit("health:change", st)
 }
 
 // ============= 密钥管理 =============
@@ -94,18 +385,120 @@ func (a *App) GenerateKeyPair() (map[string]string, error) {
 	}, nil
 }
 
-// SaveKeyPair 保存密钥对
+// SaveKeyPair 替换默认密钥的私钥 (按当前保护方式加密)，没有密钥时新建
 func (a *App) SaveKeyPair(privateKey string) error {
-	publicKey, err := crypto.GetPublicKeyFromPrivate(privateKey)
+	return a.keys.Replace("", privateKey)
+}
+
+// GetKeyPair 获取默认密钥，锁定状态下不返回私钥
+func (a *App) GetKeyPair() (*database.KeyPair, error) {
+	return a.keys.Get("")
+}
+
+// GetKeys 获取所有密钥 (不含私钥)
+func (a *App) GetKeys() ([]database.KeyPair, error) {
+	return a.keys.List()
+}
+
+// GetKey 获取密钥，锁定状态下不返回私钥
+func (a *App) GetKey(id string) (*database.KeyPair, error) {
+	return a.keys.Get(id)
+}
+
+// CreateKey 新建密钥，privateKey 为空时生成新密钥对
+func (a *App) CreateKey(name, notes, privateKey string) (*database.KeyPair, error) {
+	return a.keys.Create(name, notes, privateKey)
+}
+
+// UpdateKey 修改密钥名称和备注
+func (a *App) UpdateKey(id, name, notes string) error {
+	return a.keys.Update(id, name, notes)
+}
+
+// DeleteKey 删除密钥
+func (a *App) DeleteKey(id string) error {
+	return a.keys.Delete(id)
+}
+
+// SetDefaultKey 设置默认密钥
+func (a *App) SetDefaultKey(id string) error {
+	return a.keys.SetDefault(id)
+}
+
+// ExportKey 导出密钥到文件，passphrase 为空时私钥以明文导出，返回保存路径
+func (a *App) ExportKey(id, passphrase string) (string, error) {
+	data, err := a.keys.Export(id, passphrase)
+	if err != nil {
+		return "", err
+	}
+
+	kp, err := a.db.GetKeyPair(id)
+	if err != nil {
+		return "", err
+	}
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出密钥",
+		DefaultFilename: kp.Name + ".key.json",
+		Filters:         []runtime.FileFilter{{DisplayName: "密钥文件 (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		return "", fmt.Errorf("写入文件失败: %v", err)
+	}
+	return path, nil
+}
+
+// ImportKey 导入密钥，data 为导出的密钥文件内容或十六进制私钥
+func (a *App) ImportKey(data, passphrase, name string) (*database.KeyPair, error) {
+	return a.keys.Import(data, passphrase, name)
+}
+
+// ImportKeyFile 选择密钥文件并导入，取消选择时返回 nil
+func (a *App) ImportKeyFile(passphrase, name string) (*database.KeyPair, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "导入密钥",
+		Filters: []runtime.FileFilter{{DisplayName: "密钥文件 (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
 	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return a.keys.Import(string(data), passphrase, name)
+}
+
+// GetKeyStatus 获取私钥保护状态
+func (a *App) GetKeyStatus() (keystore.Status, error) {
+	return a.keys.Status()
+}
+
+// UnlockKey 使用主密码解锁私钥
+func (a *App) UnlockKey(password string) error {
+	if err := a.keys.Unlock(password); err != nil {
 		return err
 	}
-	return a.db.SaveKeyPair(privateKey, publicKey)
+	a.host.Emit("key:unlocked", nil)
+	return nil
 }
 
-// GetKeyPair 获取密钥对
-func (a *App) GetKeyPair() (*database.KeyPair, error) {
-	return a.db.GetKeyPair()
+// LockKey 立即锁定私钥
+func (a *App) LockKey() {
+	a.keys.Lock()
+	a.host.Emit("key:locked", nil)
+}
+
+// SetKeyProtection 切换私钥保护方式: plain / password / keyring
+func (a *App) SetKeyProtection(protection, password string) error {
+	return a.keys.SetProtection(protection, password)
+}
+
+// SetAutoLock 设置自动锁定时间 (分钟)，0 表示不自动锁定
+func (a *App) SetAutoLock(minutes int) error {
+	return a.keys.SetAutoLock(minutes)
 }
 
 // GetPublicKeyFromPrivate 从私钥获取公钥
@@ -119,59 +512,63 @@ func (a *App) GetPublicKeyFromPrivate(privateKey string) (string, error) {
 type UploadResultWrapper struct {
 	uploader.UploadResult
 	ServerName string `json:"serverName"`
+	JobID      string `json:"jobId"`
+
+	// 文件夹上传的逐个文件结果 (相对路径)
+	Queued         int      `json:"queued"` // 加入上传队列等待重试的文件数
+	CompletedFiles []string `json:"completedFiles,omitempty"`
+	FailedFiles    []string `json:"failedFiles,omitempty"`
+	SkippedFiles   []string `json:"skippedFiles,omitempty"` // 取消时未上传或被中断的文件
 }
 
-// UploadFile 上传文件或文件夹（文件夹会逐个上传文件，保持目录结构）
+// UploadFile 上传文件或文件夹（文件夹会并发上传文件，保持目录结构）
+//
+// 文件夹遵循其中的 .deployignore 和服务器的排除规则；extract 为 true 或服务器开启了
+// 打包上传时，文件夹打包为一个 zip 边压缩边上传，由服务器解压。
+// 可重试的失败 (网络错误等) 会加入上传队列自动重试。
 func (a *App) UploadFile(serverID, pathKey, filePath string, extract bool) (*UploadResultWrapper, error) {
+	result, err := a.upload(a.uploadCtx, serverID, pathKey, filePath, extract, queueSourceManual, "")
+	if result != nil {
+		a.notifyUpload(filepath.Base(filePath), result.ServerName, result.Success, result.Canceled, result.Error)
+	}
+	return result, err
+}
+
+// upload 上传文件或文件夹，ctx 取消时中断上传，source/sourceID 记录失败重试时的来源
+func (a *App) upload(ctx context.Context, serverID, pathKey, filePath string, extract bool, source, sourceID string) (*UploadResultWrapper, error) {
 	// 获取服务器信息
-	servers, err := a.db.GetServers()
+	server, err := a.getServer(serverID)
 	if err != nil {
 		return nil, err
 	}
 
-	var server *database.Server
-	for _, s := range servers {
-		if s.ID == serverID {
-			server = &s
-			break
-		}
-	}
-	if server == nil {
-		return nil, fmt.Errorf("服务器不存在: %s", serverID)
-	}
-
-	// 获取私钥
-	keyPair, err := a.db.GetKeyPair()
+	// 获取服务器使用的私钥
+	privateKey, err := a.getPrivateKey(server)
 	if err != nil {
 		return nil, err
 	}
 
-	privateKey := ""
-	if keyPair != nil {
-		privateKey = keyPair.PrivateKey
-	}
-
 	// 检查是文件还是文件夹
 	info, err := os.Stat(filePath)
 	if err != nil {
 		return nil, fmt.Errorf("无法获取路径信息: %v", err)
 	}
 
+	// 登记上传任务，便于取消和暂停
+	job := a.startJob(ctx, filepath.Base(filePath), server.Name)
+	defer a.finishJob(job)
+
 	if info.IsDir() {
-		// 文件夹：列出所有文件并逐个上传
-		return a.uploadFolder(server, pathKey, filePath, privateKey)
+		// 文件夹：列出所有文件并发上传，或打包为一个压缩包上传
+		result, err := a.uploadFolder(job.ctx, server, pathKey, filePath, privateKey, extract || server.ZipFolders, source, sourceID)
+		if result != nil {
+			result.JobID = job.ID
+		}
+		return result, err
 	}
 
 	// 单个文件：直接上传
-	result, err := uploader.UploadFile(server.URL, pathKey, filePath, privateKey, extract, func(sent, total int64) {
-		runtime.EventsEmit(a.ctx, "upload:progress", map[string]interface{}{
-			"filename": filepath.Base(filePath),
-			"sent":     sent,
-			"total":    total,
-			"percent":  float64(sent) / float64(total) * 100,
-		})
-	})
-
+	result, err := a.sendFile(job.ctx, server, pathKey, filePath, privateKey, extract)
 	if err != nil {
 		return nil, err
 	}
@@ -183,23 +580,180 @@ func (a *App) UploadFile(serverID, pathKey, filePath string, extract bool) (*Upl
 		PathKey:    pathKey,
 		Filename:   filepath.Base(filePath),
 		FileSize:   result.Size,
-		Status:     map[bool]string{true: "success", false: "failed"}[result.Success],
+		Status:     historyStatus(result),
 		ErrorMsg:   result.Error,
+		DurationMs: result.DurationMs,
+		Files:      []database.HistoryFile{historyFile(filepath.Base(filePath), info.Size(), result)},
 	})
 
-	return &UploadResultWrapper{
+	wrapper := &UploadResultWrapper{
 		UploadResult: *result,
 		ServerName:   server.Name,
-	}, nil
+		JobID:        job.ID,
+	}
+	if a.retryLater(result, database.QueueJob{
+		ServerID: serverID,
+		PathKey:  pathKey,
+		FilePath: filePath,
+		Extract:  extract,
+		Source:   source,
+		SourceID: sourceID,
+	}) {
+		wrapper.Queued = 1
+	}
+	return wrapper, nil
+}
+
+// sendFile 上传单个文件并推送进度
+func (a *App) sendFile(ctx context.Context, server *database.Server, pathKey, filePath, privateKey string, extract bool) (*uploader.UploadResult, error) {
+	return uploader.UploadFile(ctx, serverClient(server), pathKey, filePath, privateKey, extract, func(sent, total int64) {
+		a.host.Emit("upload:progress", map[string]interface{}{
+			"filename": filepath.Base(filePath),
+			"server":   server.Name,
+			"sent":     sent,
+			"total":    total,
+			"percent":  float64(sent) / float64(total) * 100,
+		})
+	})
+}
+
+// sendFolder 按服务器配置的并发数上传文件夹中的文件，推送逐个文件的事件和汇总进度
+func (a *App) sendFolder(ctx context.Context, server *database.Server, pathKey, folderName string, files []uploader.FileToUpload, privateKey string) []uploader.BatchResult {
+	return uploader.UploadBatch(ctx, serverClient(server), pathKey, files, privateKey, uploader.BatchOptions{
+		Workers: server.Workers,
+		OnFileStart: func(index, started int, f uploader.FileToUpload) {
+			a.host.Emit("upload:file-start", map[string]interface{}{
+				"filename": f.RelPath,
+				"server":   server.Name,
+				"index":    started,
+				"total":    len(files),
+			})
+		},
+		OnFileDone: func(index int, f uploader.FileToUpload, r *uploader.UploadResult) {
+			a.host.Emit("upload:file-done", map[string]interface{}{
+				"filename": f.RelPath,
+				"server":   server.Name,
+				"success":  r.Success,
+				"error":    r.Error,
+			})
+		},
+		OnProgress: func(sent, total int64) {
+			percent := 100.0
+			if total > 0 {
+				percent = float64(sent) / float64(total) * 100
+			}
+			a.host.Emit("upload:progress", map[string]interface{}{
+				"filename": folderName,
+				"server":   server.Name,
+				"sent":     sent,
+				"total":    total,
+				"percent":  percent,
+			})
+		},
+	})
+}
+
+// sendArchive 把文件夹打包为一个 zip 边压缩边上传，推送压缩进度
+func (a *App) sendArchive(ctx context.Context, server *database.Server, pathKey, folderName string, files []uploader.FileToUpload, privateKey string) *uploader.UploadResult {
+	result, err := uploader.UploadArchive(ctx, serverClient(server), pathKey, folderName+".zip", files, privateKey, func(sent, total int64) {
+		percent := 100.0
+		if total > 0 {
+			percent = float64(sent) / float64(total) * 100
+		}
+		a.host.Emit("upload:progress", map[string]interface{}{
+			"filename": folderName,
+			"server":   server.Name,
+			"sent":     sent,
+			"total":    total,
+			"percent":  percent,
+		})
+	})
+	if err != nil {
+		return uploader.FailedResult(err)
+	}
+	return result
+}
+
+// listFolder 列出要上传的文件，遵循文件夹中的 .deployignore 和服务器的排除规则 (server 可为 nil)
+func listFolder(folderPath string, server *database.Server) ([]uploader.FileToUpload, error) {
+	exclude, err := folderExcludes(folderPath, server)
+	if err != nil {
+		return nil, err
+	}
+	return uploader.ListFilesInDir(folderPath, exclude)
+}
+
+// folderExcludes 文件夹的 .deployignore 加上服务器的排除模式，server 可为 nil
+func folderExcludes(folderPath string, server *database.Server) (*ignore.Matcher, error) {
+	var excludes []string
+	if server != nil {
+		excludes = server.Excludes
+	}
+	return ignore.Load(folderPath, excludes)
+}
+
+// historyStatus 单个文件上传结果对应的历史状态
+func historyStatus(r *uploader.UploadResult) string {
+	switch {
+	case r.Success:
+		return "success"
+	case r.Canceled:
+		return "canceled"
+	default:
+		return "failed"
+	}
+}
+
+// historyFile 单个文件的上传结果对应的历史明细
+func historyFile(relPath string, size int64, r *uploader.UploadResult) database.HistoryFile {
+	return database.HistoryFile{
+		RelPath:    relPath,
+		FileSize:   size,
+		SHA256:     r.SHA256,
+		DurationMs: r.DurationMs,
+		HTTPStatus: r.HTTPStatus,
+		ServerPath: r.Path,
+		Status:     historyStatus(r),
+		ErrorMsg:   r.Error,
+
+		ServerSHA256: r.ServerSHA256,
+	}
+}
+
+// getServer 按 ID 查找服务器
+func (a *App) getServer(serverID string) (*database.Server, error) {
+	servers, err := a.db.GetServers()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range servers {
+		if s.ID == serverID {
+			return &s, nil
+		}
+	}
+	return nil, fmt.Errorf("服务器不存在: %s", serverID)
+}
+
+// serverClient 按服务器的连接设置获取请求客户端
+func serverClient(server *database.Server) *uploader.Client {
+	return uploader.ClientFor(server.URL, server.Conn)
+}
+
+// getPrivateKey 获取服务器签名用的私钥，服务器未指定密钥时使用默认密钥
+//
+// 未配置任何密钥时返回空字符串，锁定时返回 keystore.ErrLocked，旧版本的明文私钥
+// 尚未设置保护方式时返回 keystore.ErrUnprotected。
+func (a *App) getPrivateKey(server *database.Server) (string, error) {
+	return a.keys.PrivateKey(server.KeyID)
 }
 
-// uploadFolder 上传文件夹（逐个上传文件，保持目录结构）
-func (a *App) uploadFolder(server *database.Server, pathKey, folderPath, privateKey string) (*UploadResultWrapper, error) {
+// uploadFolder 上传文件夹（并发上传文件，保持目录结构），archive 为 true 时打包为一个压缩包上传
+func (a *App) uploadFolder(ctx context.Context, server *database.Server, pathKey, folderPath, privateKey string, archive bool, source, sourceID string) (*UploadResultWrapper, error) {
 	// 获取文件夹名称用于显示
 	folderName := filepath.Base(folderPath)
 
 	// 列出所有文件
-	files, err := uploader.ListFilesInDir(folderPath)
+	files, err := listFolder(folderPath, server)
 	if err != nil {
 		return &UploadResultWrapper{
 			UploadResult: uploader.UploadResult{Success: false, Error: fmt.Sprintf("列出文件失败: %v", err)},
@@ -214,63 +768,64 @@ func (a *App) uploadFolder(server *database.Server, pathKey, folderPath, private
 		}, nil
 	}
 
+	if archive {
+		return a.uploadFolderArchive(ctx, server, pathKey, folderPath, files, privateKey, source, sourceID), nil
+	}
+	return a.uploadFiles(ctx, server, pathKey, folderName, files, privateKey, source, sourceID), nil
+}
+
+// uploadFiles 并发上传文件夹中的一组文件并记录为一条历史，可重试的失败文件逐个加入上传队列
+func (a *App) uploadFiles(ctx context.Context, server *database.Server, pathKey, folderName string, files []uploader.FileToUpload, privateKey, source, sourceID string) *UploadResultWrapper {
 	// 计算总大小
 	var totalSize int64
 	for _, f := range files {
 		totalSize += f.Size
 	}
 
-	// 逐个上传
-	var uploadedSize int64
-	var successCount, failCount int
-	var lastError string
-
-	for i, f := range files {
-		// 直接使用相对路径，不加文件夹名
-		serverRelPath := f.RelPath
-
-		// 发送文件开始上传事件
-		runtime.EventsEmit(a.ctx, "upload:file-start", map[string]interface{}{
-			"filename": f.RelPath,
-			"index":    i + 1,
-			"total":    len(files),
-		})
-
-		result, err := uploader.UploadSingleFile(server.URL, pathKey, f.AbsPath, serverRelPath, privateKey, func(sent, total int64) {
-			// 计算总体进度
-			currentProgress := uploadedSize + sent
-			runtime.EventsEmit(a.ctx, "upload:progress", map[string]interface{}{
-				"filename": f.RelPath,
-				"sent":     currentProgress,
-				"total":    totalSize,
-				"percent":  float64(currentProgress) / float64(totalSize) * 100,
-			})
-		})
-
-		if err != nil {
-			failCount++
-			lastError = fmt.Sprintf("%s: %v", f.RelPath, err)
-		} else if !result.Success {
-			failCount++
-			lastError = fmt.Sprintf("%s: %s", f.RelPath, result.Error)
-		} else {
-			successCount++
+	started := time.Now()
+	results := a.sendFolder(ctx, server, pathKey, folderName, files, privateKey)
+	sum := uploader.SummarizeBatch(results)
+	duration := time.Since(started).Milliseconds()
+
+	// 可重试的失败文件逐个加入上传队列
+	queued := 0
+	historyFiles := make([]database.HistoryFile, 0, len(results))
+	for _, r := range results {
+		historyFiles = append(historyFiles, historyFile(r.File.RelPath, r.File.Size, r.Result))
+
+		if a.retryLater(r.Result, database.QueueJob{
+			ServerID: server.ID,
+			PathKey:  pathKey,
+			FilePath: r.File.AbsPath,
+			RelPath:  r.File.RelPath,
+			Source:   source,
+			SourceID: sourceID,
+		}) {
+			queued++
 		}
-
-		uploadedSize += f.Size
 	}
 
 	// 记录历史
 	status := "success"
-	errorMsg := ""
-	if failCount > 0 {
-		if successCount == 0 {
-			status = "failed"
-		} else {
-			status = "partial"
-		}
-		errorMsg = fmt.Sprintf("%d 个文件失败，最后错误: %s", failCount, lastError)
+	var problems []string
+	if sum.Failed > 0 {
+		problems = append(problems, fmt.Sprintf("%d 个文件失败，首个错误: %s", sum.Failed, sum.FirstError))
 	}
+	if sum.Canceled > 0 {
+		problems = append(problems, fmt.Sprintf("已取消，%d 个文件未上传", sum.Canceled))
+	}
+	if queued > 0 {
+		problems = append(problems, fmt.Sprintf("%d 个文件已加入重试队列", queued))
+	}
+	switch {
+	case sum.Canceled > 0:
+		status = "canceled"
+	case sum.Failed > 0 && sum.Success == 0:
+		status = "failed"
+	case sum.Failed > 0:
+		status = "partial"
+	}
+	errorMsg := strings.Join(problems, "; ")
 
 	a.db.AddHistory(database.HistoryEntry{
 		ServerID:   server.ID,
@@ -280,98 +835,1297 @@ func (a *App) uploadFolder(server *database.Server, pathKey, folderPath, private
 		FileSize:   totalSize,
 		Status:     status,
 		ErrorMsg:   errorMsg,
+		DurationMs: duration,
+		Files:      historyFiles,
 	})
 
 	return &UploadResultWrapper{
 		UploadResult: uploader.UploadResult{
-			Success: failCount == 0,
-			Status:  fmt.Sprintf("成功 %d/%d 个文件", successCount, len(files)),
-			Size:    totalSize,
-			Error:   errorMsg,
+			Success:  sum.Failed == 0 && sum.Canceled == 0,
+			Status:   fmt.Sprintf("成功 %d/%d 个文件", sum.Success, len(files)),
+			Size:     totalSize,
+			Error:    errorMsg,
+			Canceled: sum.Canceled > 0,
 		},
-		ServerName: server.Name,
-	}, nil
+		ServerName:     server.Name,
+		Queued:         queued,
+		CompletedFiles: sum.Completed,
+		FailedFiles:    sum.FailedList,
+		SkippedFiles:   sum.Skipped,
+	}
 }
 
-// SelectFile 选择文件对话框
-func (a *App) SelectFile() (string, error) {
-	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "选择文件",
-	})
-	return path, err
-}
+// uploadFolderArchive 把文件夹打包为一个压缩包上传，可重试的失败把整个文件夹加入上传队列
+func (a *App) uploadFolderArchive(ctx context.Context, server *database.Server, pathKey, folderPath string, files []uploader.FileToUpload, privateKey, source, sourceID string) *UploadResultWrapper {
+	folderName := filepath.Base(folderPath)
+	var totalSize int64
+	for _, f := range files {
+		totalSize += f.Size
+	}
+	result := a.sendArchive(ctx, server, pathKey, folderName, files, privateKey)
+
+	errorMsg := result.Error
+	queued := 0
+	if a.retryLater(result, database.QueueJob{
+		ServerID: server.ID,
+		PathKey:  pathKey,
+		FilePath: folderPath,
+		Extract:  true,
+		Source:   source,
+		SourceID: sourceID,
+	}) {
+		queued = 1
+		errorMsg += "; 已加入重试队列"
+	}
 
-// SelectFolder 选择文件夹对话框
-func (a *App) SelectFolder() (string, error) {
-	path, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "选择文件夹",
-	})
+	a.db.AddHistory(database.HistoryEntry{
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		PathKey:    pathKey,
+		Filename:   folderName + fmt.Sprintf(" (%d 个文件，压缩包)", len(files)),
+		FileSize:   totalSize,
+		Status:     historyStatus(result),
+		ErrorMsg:   errorMsg,
+		DurationMs: result.DurationMs,
+		Files:      []database.HistoryFile{historyFile(folderName+".zip", totalSize, result)},
+	})
+
+	wrapper := &UploadResultWrapper{
+		UploadResult: *result,
+		ServerName:   server.Name,
+		Queued:       queued,
+	}
+	wrapper.Size = totalSize
+	if result.Success {
+		wrapper.Status = fmt.Sprintf("已打包上传 %d 个文件", len(files))
+	} else {
+		wrapper.Error = errorMsg
+	}
+	return wrapper
+}
+
+// ============= 部署预览 =============
+
+// DeployPreview 本地文件夹与服务器路径标识下内容的差异
+type DeployPreview struct {
+	ServerID   string `json:"serverId"`
+	ServerName string `json:"serverName"`
+	PathKey    string `json:"pathKey"`
+	FolderPath string `json:"folderPath"`
+	uploader.Diff
+}
+
+// PreviewDeploy 对比本地文件夹与服务器上路径标识根目录的内容，不上传任何文件
+//
+// 遵循文件夹中的 .deployignore 和服务器的排除规则；大小相同的文件按 SHA-256 比较。
+// 确认后用 DeploySelected 上传选中的文件 (默认为新增和修改的文件)。
+func (a *App) PreviewDeploy(serverID, pathKey, folderPath string) (*DeployPreview, error) {
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := a.getPrivateKey(server)
+	if err != nil {
+		return nil, err
+	}
+
+	exclude, err := folderExcludes(folderPath, server)
+	if err != nil {
+		return nil, fmt.Errorf("列出文件失败: %v", err)
+	}
+	local, err := uploader.ListFilesInDir(folderPath, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("列出文件失败: %v", err)
+	}
+	remote, err := uploader.ListRemote(a.uploadCtx, serverClient(server), pathKey, "", privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("获取服务器文件列表失败: %v", err)
+	}
+	// 被排除的文件不上传，也不报告为只在服务器上存在
+	diff, err := uploader.Compare(local, uploader.FilterRemote(remote, exclude))
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeployPreview{
+		ServerID:   server.ID,
+		ServerName: server.Name,
+		PathKey:    pathKey,
+		FolderPath: folderPath,
+		Diff:       *diff,
+	}, nil
+}
+
+// DeploySelected 上传预览后选中的文件 (相对路径)，逐个文件上传并记录为一条历史
+//
+// 不在文件夹中或被排除规则排除的路径会报错，避免预览后文件夹变化时误传。
+func (a *App) DeploySelected(serverID, pathKey, folderPath string, relPaths []string) (*UploadResultWrapper, error) {
+	if len(relPaths) == 0 {
+		return nil, errors.New("未选择要上传的文件")
+	}
+	server, err := a.getServer(serverID)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := a.getPrivateKey(server)
+	if err != nil {
+		return nil, err
+	}
+
+	listed, err := listFolder(folderPath, server)
+	if err != nil {
+		return nil, fmt.Errorf("列出文件失败: %v", err)
+	}
+	byPath := make(map[string]uploader.FileToUpload, len(listed))
+	for _, f := range listed {
+		byPath[f.RelPath] = f
+	}
+	files := make([]uploader.FileToUpload, 0, len(relPaths))
+	seen := make(map[string]bool, len(relPaths))
+	for _, rel := range relPaths {
+		rel = filepath.ToSlash(rel)
+		if seen[rel] {
+			continue
+		}
+		seen[rel] = true
+		f, ok := byPath[rel]
+		if !ok {
+			return nil, fmt.Errorf("文件不在文件夹中或已被排除: %s", rel)
+		}
+		files = append(files, f)
+	}
+
+	folderName := filepath.Base(folderPath)
+	job := a.startJob(a.uploadCtx, folderName, server.Name)
+	defer a.finishJob(job)
+
+	result := a.uploadFiles(job.ctx, server, pathKey, folderName, files, privateKey, queueSourceManual, "")
+	result.JobID = job.ID
+	a.notifyUpload(folderName, server.Name, result.Success, result.Canceled, result.Error)
+	return result, nil
+}
+
+// ============= 服务器组 =============
+
+// DeployResult 部署到服务器组的结果
+type DeployResult struct {
+	GroupID   string          `json:"groupId"`
+	GroupName string          `json:"groupName"`
+	JobID     string          `json:"jobId"`
+	Success   bool            `json:"success"`
+	Status    string          `json:"status"` // success / partial / failed / canceled
+	Error     string          `json:"error"`
+	Results   []deploy.Result `json:"results"` // 每台服务器的结果，顺序与服务器组一致
+}
+
+// GetServerGroups 获取所有服务器组
+func (a *App) GetServerGroups() ([]database.ServerGroup, error) {
+	return a.db.GetServerGroups()
+}
+
+// SaveServerGroup 保存服务器组
+func (a *App) SaveServerGroup(group database.ServerGroup) error {
+	if group.ID == "" {
+		group.ID = fmt.Sprintf("group_%d", time.Now().UnixNano())
+	}
+	if group.Strategy == "" {
+		group.Strategy = database.StrategyParallel
+	}
+	if group.MaxUnavailable <= 0 {
+		group.MaxUnavailable = 1
+	}
+	if err := deploy.Validate(group); err != nil {
+		return err
+	}
+	for _, id := range group.ServerIDs {
+		if _, err := a.getServer(id); err != nil {
+			return err
+		}
+	}
+	return a.db.SaveServerGroup(group)
+}
+
+// DeleteServerGroup 删除服务器组
+func (a *App) DeleteServerGroup(id string) error {
+	return a.db.DeleteServerGroup(id)
+}
+
+// DeployToGroup 按服务器组的策略把文件或文件夹部署到组内所有服务器
+//
+// 每台服务器的结果单独返回，历史中记录为一条服务器组记录。失败不加入上传队列，
+// 以免重试打乱部署顺序。
+func (a *App) DeployToGroup(groupID, pathKey, filePath string, extract bool) (*DeployResult, error) {
+	result, err := a.deployToGroup(a.uploadCtx, groupID, pathKey, filePath, extract)
+	if result != nil {
+		a.notifyUpload(filepath.Base(filePath), result.GroupName, result.Success, result.Status == deploy.StatusCanceled, result.Error)
+	}
+	return result, err
+}
+
+// deployToGroup 部署到服务器组，ctx 取消时中断所有服务器的上传
+func (a *App) deployToGroup(ctx context.Context, groupID, pathKey, filePath string, extract bool) (*DeployResult, error) {
+	group, err := a.db.GetServerGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, fmt.Errorf("服务器组不存在: %s", groupID)
+	}
+
+	// 开始前取得所有服务器和私钥，私钥锁定时不部署任何服务器
+	servers := make([]database.Server, 0, len(group.ServerIDs))
+	privateKeys := make(map[string]string, len(group.ServerIDs))
+	for _, id := range group.ServerIDs {
+		server, err := a.getServer(id)
+		if err != nil {
+			return nil, err
+		}
+		if privateKeys[id], err = a.getPrivateKey(server); err != nil {
+			return nil, err
+		}
+		servers = append(servers, *server)
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("服务器组中没有服务器")
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("无法获取路径信息: %v", err)
+	}
+	name := filepath.Base(filePath)
+	size := info.Size()
+	// 文件夹按各服务器的排除规则分别列出文件，记录最大的总大小
+	files := make(map[string][]uploader.FileToUpload)
+	if info.IsDir() {
+		size = 0
+		for i := range servers {
+			list, err := listFolder(filePath, &servers[i])
+			if err != nil {
+				return nil, fmt.Errorf("列出文件失败: %v", err)
+			}
+			if len(list) == 0 {
+				return nil, fmt.Errorf("文件夹为空 (%s)", servers[i].Name)
+			}
+			var total int64
+			for _, f := range list {
+				total += f.Size
+			}
+			if total > size {
+				size = total
+			}
+			files[servers[i].ID] = list
+		}
+	}
+
+	job := a.startJob(ctx, name, group.Name)
+	defer a.finishJob(job)
+
+	started := time.Now()
+	results := deploy.Run(job.ctx, servers, deploy.Options{
+		Strategy:       group.Strategy,
+		MaxUnavailable: group.MaxUnavailable,
+		StopOnFailure:  group.StopOnFailure,
+		OnStart: func(server database.Server) {
+			a.host.Emit("deploy:server-start", map[string]interface{}{
+				"groupId":    group.ID,
+				"serverId":   server.ID,
+				"serverName": server.Name,
+			})
+		},
+		OnDone: func(r deploy.Result) {
+			a.host.Emit("deploy:server-done", map[string]interface{}{
+				"groupId": group.ID,
+				"result":  r,
+			})
+		},
+	}, func(ctx context.Context, server database.Server) deploy.Result {
+		if info.IsDir() {
+			if extract || server.ZipFolders {
+				return a.deployArchive(ctx, &server, pathKey, name, files[server.ID], privateKeys[server.ID])
+			}
+			return a.deployFolder(ctx, &server, pathKey, name, files[server.ID], privateKeys[server.ID])
+		}
+		return a.deployFile(ctx, &server, pathKey, filePath, size, privateKeys[server.ID], extract)
+	})
+	sum := deploy.Summarize(results)
+
+	var historyFiles []database.HistoryFile
+	for _, r := range results {
+		historyFiles = append(historyFiles, r.Files...)
+	}
+	a.db.AddHistory(database.HistoryEntry{
+		ServerName: group.Name,
+		GroupID:    group.ID,
+		PathKey:    pathKey,
+		Filename:   fmt.Sprintf("%s (%d 台服务器)", name, len(servers)),
+		FileSize:   size,
+		Status:     sum.Status,
+		ErrorMsg:   sum.Error,
+		DurationMs: time.Since(started).Milliseconds(),
+		Files:      historyFiles,
+	})
+
+	return &DeployResult{
+		GroupID:   group.ID,
+		GroupName: group.Name,
+		JobID:     job.ID,
+		Success:   sum.Status == deploy.StatusSuccess,
+		Status:    sum.Status,
+		Error:     sum.Error,
+		Results:   results,
+	}, nil
+}
+
+// deployFile 部署单个文件到一台服务器
+func (a *App) deployFile(ctx context.Context, server *database.Server, pathKey, filePath string, size int64, privateKey string, extract bool) deploy.Result {
+	r, err := a.sendFile(ctx, server, pathKey, filePath, privateKey, extract)
+	if err != nil {
+		r = uploader.FailedResult(err)
+	}
+	f := historyFile(filepath.Base(filePath), size, r)
+	f.ServerID, f.ServerName = server.ID, server.Name
+	return deploy.Result{
+		Status:     historyStatus(r),
+		Error:      r.Error,
+		DurationMs: r.DurationMs,
+		Files:      []database.HistoryFile{f},
+	}
+}
+
+// deployArchive 把文件夹打包为一个压缩包部署到一台服务器
+func (a *App) deployArchive(ctx context.Context, server *database.Server, pathKey, folderName string, files []uploader.FileToUpload, privateKey string) deploy.Result {
+	r := a.sendArchive(ctx, server, pathKey, folderName, files, privateKey)
+	var size int64
+	for _, f := range files {
+		size += f.Size
+	}
+	f := historyFile(folderName+".zip", size, r)
+	f.ServerID, f.ServerName = server.ID, server.Name
+	return deploy.Result{
+		Status:     historyStatus(r),
+		Error:      r.Error,
+		DurationMs: r.DurationMs,
+		Files:      []database.HistoryFile{f},
+	}
+}
+
+// deployFolder 部署文件夹到一台服务器
+func (a *App) deployFolder(ctx context.Context, server *database.Server, pathKey, folderName string, files []uploader.FileToUpload, privateKey string) deploy.Result {
+	results := a.sendFolder(ctx, server, pathKey, folderName, files, privateKey)
+	sum := uploader.SummarizeBatch(results)
+
+	r := deploy.Result{Status: deploy.StatusSuccess}
+	for _, br := range results {
+		f := historyFile(br.File.RelPath, br.File.Size, br.Result)
+		f.ServerID, f.ServerName = server.ID, server.Name
+		r.Files = append(r.Files, f)
+	}
+	switch {
+	case sum.Canceled > 0:
+		r.Status = deploy.StatusCanceled
+		r.Error = fmt.Sprintf("已取消，%d 个文件未上传", sum.Canceled)
+	case sum.Failed > 0 && sum.Success == 0:
+		r.Status = deploy.StatusFailed
+		r.Error = sum.FirstError
+	case sum.Failed > 0:
+		r.Status = deploy.StatusPartial
+		r.Error = fmt.Sprintf("%d 个文件失败，首个错误: %s", sum.Failed, sum.FirstError)
+	}
+	return r
+}
+
+// ============= 上传任务 =============
+
+// uploadJob 正在进行的上传任务
+type uploadJob struct {
+	ID         string
+	Name       string
+	ServerName string
+	StartedAt  time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	gate   *uploader.Gate
+}
+
+// UploadJobInfo 上传任务状态
+type UploadJobInfo struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	ServerName string `json:"serverName"`
+	StartedAt  string `json:"startedAt"`
+	Paused     bool   `json:"paused"`
+}
+
+// startJob 登记上传任务，程序退出时所有任务一并取消
+func (a *App) startJob(parent context.Context, name, serverName string) *uploadJob {
+	ctx, cancel := context.WithCancel(parent)
+	gate := uploader.NewGate()
+	job := &uploadJob{
+		ID:         fmt.Sprintf("job_%d", time.Now().UnixNano()),
+		Name:       name,
+		ServerName: serverName,
+		StartedAt:  time.Now(),
+		ctx:        uploader.WithGate(ctx, gate),
+		cancel:     cancel,
+		gate:       gate,
+	}
+
+	a.jobsMu.Lock()
+	a.jobs[job.ID] = job
+	a.jobsMu.Unlock()
+
+	a.host.Emit("upload:job-start", map[string]interface{}{
+		"jobId":      job.ID,
+		"name":       name,
+		"serverName": serverName,
+	})
+	return job
+}
+
+// finishJob 移除已结束的上传任务
+func (a *App) finishJob(job *uploadJob) {
+	a.jobsMu.Lock()
+	delete(a.jobs, job.ID)
+	a.jobsMu.Unlock()
+
+	canceled := job.ctx.Err() != nil
+	job.cancel()
+	a.host.Emit("upload:job-end", map[string]interface{}{
+		"jobId":    job.ID,
+		"canceled": canceled,
+	})
+}
+
+// findJob 按 ID 查找上传任务
+func (a *App) findJob(jobID string) (*uploadJob, error) {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+	job, ok := a.jobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("上传任务不存在或已结束: %s", jobID)
+	}
+	return job, nil
+}
+
+// GetActiveUploads 获取正在进行的上传任务
+func (a *App) GetActiveUploads() []UploadJobInfo {
+	a.jobsMu.Lock()
+	defer a.jobsMu.Unlock()
+
+	infos := make([]UploadJobInfo, 0, len(a.jobs))
+	for _, job := range a.jobs {
+		infos = append(infos, UploadJobInfo{
+			ID:         job.ID,
+			Name:       job.Name,
+			ServerName: job.ServerName,
+			StartedAt:  formatTime(job.StartedAt),
+			Paused:     job.gate.Paused(),
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].StartedAt < infos[j].StartedAt })
+	return infos
+}
+
+// CancelUpload 取消上传任务，正在上传的文件被中断，文件夹上传中尚未开始的文件不再上传
+func (a *App) CancelUpload(jobID string) error {
+	job, err := a.findJob(jobID)
+	if err != nil {
+		return err
+	}
+	job.cancel()
+	a.host.Emit("upload:canceled", map[string]interface{}{"jobId": jobID})
+	return nil
+}
+
+// PauseUpload 暂停上传任务，正在上传的文件停止发送数据，文件夹上传不再开始新文件
+func (a *App) PauseUpload(jobID string) error {
+	job, err := a.findJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.gate.Pause() {
+		a.host.Emit("upload:paused", map[string]interface{}{"jobId": jobID})
+	}
+	return nil
+}
+
+// ResumeUpload 恢复已暂停的上传任务
+func (a *App) ResumeUpload(jobID string) error {
+	job, err := a.findJob(jobID)
+	if err != nil {
+		return err
+	}
+	if job.gate.Resume() {
+		a.host.Emit("upload:resumed", map[string]interface{}{"jobId": jobID})
+	}
+	return nil
+}
+
+// ============= 上传队列 =============
+
+// 队列任务来源
+const (
+	queueSourceManual   = "manual"
+	queueSourceWatch    = "watch"
+	queueSourceSchedule = "schedule"
+	queueSourceRecipe   = "recipe" // 配方中的上传失败由配方处理，不加入队列
+)
+
+// GetQueue 获取上传队列，status 为空时返回全部
+func (a *App) GetQueue(status string) ([]database.QueueJob, error) {
+	return a.db.GetQueueJobs(status)
+}
+
+// RetryQueueJob 立即重试队列任务
+func (a *App) RetryQueueJob(id string) error {
+	return a.queue.Retry(id)
+}
+
+// DropQueueJob 从队列中删除任务，正在执行时中断
+func (a *App) DropQueueJob(id string) error {
+	if err := a.queue.Drop(id); err != nil {
+		return err
+	}
+	a.host.Emit("queue:dropped", map[string]interface{}{"id": id})
+	return nil
+}
+
+// ClearQueue 清除已完成 (done) 或已失败 (failed) 的任务
+func (a *App) ClearQueue(status string) error {
+	if status != database.QueueDone && status != database.QueueFailed {
+		return fmt.Errorf("只能清除已完成或已失败的任务: %s", status)
+	}
+	return a.db.ClearQueueJobs(status)
+}
+
+// retryLater 失败可重试时加入上传队列，返回是否已加入
+func (a *App) retryLater(result *uploader.UploadResult, job database.QueueJob) bool {
+	if result == nil || result.Success || job.Source == queueSourceRecipe || !uploader.IsRetryable(result.Err()) {
+		return false
+	}
+	job.LastError = result.Error
+	job.ErrorCode = result.Code
+	if _, err := a.queue.Enqueue(job); err != nil {
+		a.host.Error(fmt.Sprintf("加入上传队列失败: %v", err))
+		return false
+	}
+	return true
+}
+
+// runQueueJob 执行队列中的一次上传
+func (a *App) runQueueJob(ctx context.Context, job database.QueueJob) *uploader.UploadResult {
+	server, err := a.getServer(job.ServerID)
+	if err != nil {
+		return uploader.FailedResult(err)
+	}
+	privateKey, err := a.getPrivateKey(server)
+	if err != nil {
+		return uploader.FailedResult(err)
+	}
+
+	var result *uploader.UploadResult
+	if job.RelPath != "" {
+		result, err = uploader.UploadSingleFile(ctx, serverClient(server), job.PathKey, job.FilePath, job.RelPath, privateKey, nil)
+	} else if info, serr := os.Stat(job.FilePath); serr == nil && info.IsDir() {
+		// 打包上传失败的文件夹，重新列出文件后打包
+		files, lerr := listFolder(job.FilePath, server)
+		if lerr != nil {
+			return uploader.FailedResult(lerr)
+		}
+		result, err = uploader.UploadArchive(ctx, serverClient(server), job.PathKey, filepath.Base(job.FilePath)+".zip", files, privateKey, nil)
+	} else {
+		result, err = uploader.UploadFile(ctx, serverClient(server), job.PathKey, job.FilePath, privateKey, job.Extract, nil)
+	}
+	if err != nil {
+		return uploader.FailedResult(err)
+	}
+	return result
+}
+
+// onQueueUpdate 通知前端，任务结束 (成功或最终失败) 时记录历史
+func (a *App) onQueueUpdate(job database.QueueJob, result *uploader.UploadResult) {
+	a.host.Emit("queue:update", job)
+
+	if result == nil || (job.Status != database.QueueDone && job.Status != database.QueueFailed) {
+		return
+	}
+
+	filename := job.RelPath
+	if filename == "" {
+		filename = filepath.Base(job.FilePath)
+	}
+	var size int64
+	if info, err := os.Stat(job.FilePath); err == nil {
+		size = info.Size()
+		if info.IsDir() {
+			// 打包上传的文件夹，记录服务器收到的压缩包大小
+			filename += ".zip"
+			size = result.Size
+		}
+	}
+	serverName := job.ServerID
+	if server, err := a.getServer(job.ServerID); err == nil {
+		serverName = server.Name
+	}
+
+	a.db.AddHistory(database.HistoryEntry{
+		ServerID:   job.ServerID,
+		ServerName: serverName,
+		PathKey:    job.PathKey,
+		Filename:   filename,
+		FileSize:   size,
+		Status:     historyStatus(result),
+		ErrorMsg:   result.Error,
+		DurationMs: result.DurationMs,
+		Files:      []database.HistoryFile{historyFile(filename, size, result)},
+	})
+
+	switch {
+	case job.Source == queueSourceWatch:
+		a.host.Emit("watch:upload", map[string]interface{}{
+			"id":       job.SourceID,
+			"filename": filename,
+			"success":  result.Success,
+			"error":    result.Error,
+		})
+		if result.Success {
+			a.notifyEvent(notify.WatchSuccess, "监控上传成功", fmt.Sprintf("%s → %s", filename, serverName))
+		} else {
+			a.notifyEvent(notify.WatchFailed, "监控上传失败", fmt.Sprintf("%s → %s: %s", filename, serverName, result.Error))
+		}
+	case job.Status == database.QueueFailed:
+		a.notifyEvent(notify.QueueFailed, "重试上传失败", fmt.Sprintf("%s → %s: %s", filename, serverName, result.Error))
+	}
+}
+
+// SelectFile 选择文件对话框
+func (a *App) SelectFile() (string, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择文件",
+	})
 	return path, err
 }
 
-// GetFileInfo 获取文件信息（文件夹会返回总大小和文件数量）
-func (a *App) GetFileInfo(filePath string) (map[string]interface{}, error) {
-	info, err := os.Stat(filePath)
+// SelectFolder 选择文件夹对话框
+func (a *App) SelectFolder() (string, error) {
+	path, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "选择文件夹",
+	})
+	return path, err
+}
+
+// GetFileInfo 获取文件信息（文件夹会返回总大小和文件数量）
+func (a *App) GetFileInfo(filePath string) (map[string]interface{}, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]interface{}{
+		"name":    info.Name(),
+		"size":    info.Size(),
+		"isDir":   info.IsDir(),
+		"modTime": info.ModTime().Format(time.RFC3339),
+	}
+
+	// 如果是文件夹，计算总大小和文件数量 (不含 .deployignore 排除的文件)
+	if info.IsDir() {
+		files, err := listFolder(filePath, nil)
+		if err == nil {
+			var totalSize int64
+			for _, f := range files {
+				totalSize += f.Size
+			}
+			result["size"] = totalSize
+			result["fileCount"] = len(files)
+		}
+	}
+
+	return result, nil
+}
+
+// ============= 部署配方 =============
+
+// 配方的触发方式
+const (
+	recipeTriggerManual   = "manual"
+	recipeTriggerWatch    = "watch"
+	recipeTriggerSchedule = "schedule"
+)
+
+// errRecipeRunning 配方正在运行
+var errRecipeRunning = errors.New("配方正在运行")
+
+// recipeRunState 正在运行的配方
+type recipeRunState struct {
+	runID   int64
+	cancel  context.CancelFunc
+	pending string // 运行期间被监控再次触发时记录触发方式，结束后再运行一次
+}
+
+// GetRecipes 获取所有部署配方
+func (a *App) GetRecipes() ([]database.Recipe, error) {
+	return a.db.GetRecipes()
+}
+
+// SaveRecipe 保存部署配方
+func (a *App) SaveRecipe(r database.Recipe) error {
+	if r.ID == "" {
+		r.ID = fmt.Sprintf("recipe_%d", time.Now().UnixNano())
+	}
+	if err := recipe.Validate(r); err != nil {
+		return err
+	}
+	for i, step := range r.Steps {
+		if step.Type != database.StepUpload {
+			continue
+		}
+		if step.ServerID != "" {
+			if _, err := a.getServer(step.ServerID); err != nil {
+				return fmt.Errorf("步骤 %d: %v", i+1, err)
+			}
+		} else if g, err := a.db.GetServerGroup(step.GroupID); err != nil {
+			return err
+		} else if g == nil {
+			return fmt.Errorf("步骤 %d: 服务器组不存在: %s", i+1, step.GroupID)
+		}
+	}
+	return a.db.SaveRecipe(r)
+}
+
+// DeleteRecipe 删除部署配方，仍被监控或定时任务使用时拒绝
+func (a *App) DeleteRecipe(id string) error {
+	users, err := a.db.GetRecipeUsers(id)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		return fmt.Errorf("配方正在被使用: %s", strings.Join(users, ", "))
+	}
+	return a.db.DeleteRecipe(id)
+}
+
+// RunRecipe 立即运行配方，等待运行结束后返回运行记录 (步骤输出通过 recipe:output 事件实时推送)
+func (a *App) RunRecipe(id string) (*database.RecipeRun, error) {
+	return a.runRecipe(id, recipeTriggerManual)
+}
+
+// CancelRecipe 取消正在运行的配方
+func (a *App) CancelRecipe(id string) error {
+	a.recipesMu.Lock()
+	defer a.recipesMu.Unlock()
+	st, ok := a.recipeRuns[id]
+	if !ok {
+		return fmt.Errorf("配方没有在运行: %s", id)
+	}
+	st.pending = ""
+	st.cancel()
+	return nil
+}
+
+// GetRunningRecipes 获取正在运行的配方 ID
+func (a *App) GetRunningRecipes() []string {
+	a.recipesMu.Lock()
+	defer a.recipesMu.Unlock()
+	ids := make([]string, 0, len(a.recipeRuns))
+	for id := range a.recipeRuns {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GetRecipeRuns 获取最近的运行记录 (不含日志)，recipeID 为空时返回所有配方的记录
+func (a *App) GetRecipeRuns(recipeID string, limit int) ([]database.RecipeRun, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	return a.db.GetRecipeRuns(recipeID, limit)
+}
+
+// GetRecipeRun 获取一次运行的完整记录和日志
+func (a *App) GetRecipeRun(id int64) (*database.RecipeRun, error) {
+	return a.db.GetRecipeRun(id)
+}
+
+// ClearRecipeRuns 清空已结束的运行记录
+func (a *App) ClearRecipeRuns() error {
+	return a.db.ClearRecipeRuns()
+}
+
+// checkRecipe 检查监控或定时任务关联的配方存在，id 为空时不检查
+func (a *App) checkRecipe(id string) error {
+	if id == "" {
+		return nil
+	}
+	r, err := a.db.GetRecipe(id)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return fmt.Errorf("配方不存在: %s", id)
+	}
+	return nil
+}
+
+// runRecipe 运行配方并记录，同一配方同时只运行一次
+//
+// 正在运行时返回 errRecipeRunning；由监控触发时记下，结束后再运行一次。
+func (a *App) runRecipe(id, trigger string) (*database.RecipeRun, error) {
+	r, err := a.db.GetRecipe(id)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, fmt.Errorf("配方不存在: %s", id)
+	}
+
+	a.recipesMu.Lock()
+	if st, ok := a.recipeRuns[id]; ok {
+		if trigger == recipeTriggerWatch {
+			st.pending = trigger
+		}
+		a.recipesMu.Unlock()
+		return nil, fmt.Errorf("%w: %s", errRecipeRunning, r.Name)
+	}
+	ctx, cancel := context.WithCancel(a.uploadCtx)
+	st := &recipeRunState{cancel: cancel}
+	a.recipeRuns[id] = st
+	a.recipesMu.Unlock()
+
+	defer func() {
+		cancel()
+		a.recipesMu.Lock()
+		delete(a.recipeRuns, id)
+		pending := st.pending
+		a.recipesMu.Unlock()
+
+		if pending != "" && a.uploadCtx.Err() == nil {
+			go a.runRecipe(id, pending)
+		}
+	}()
+
+	runID, err := a.db.AddRecipeRun(database.RecipeRun{
+		RecipeID:   r.ID,
+		RecipeName: r.Name,
+		Trigger:    trigger,
+		Status:     database.RunRunning,
+		StartedAt:  time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+	a.recipesMu.Lock()
+	st.runID = runID
+	a.recipesMu.Unlock()
+
+	a.host.Emit("recipe:start", map[string]interface{}{
+		"runId":    runID,
+		"recipeId": r.ID,
+		"name":     r.Name,
+		"trigger":  trigger,
+	})
+
+	run := recipe.Run(ctx, *r, recipe.Env{
+		Upload: a.recipeUpload,
+		Notify: func(title, message string) {
+			a.notifyEvent("", title, message)
+		},
+		Output: func(step int, line string) {
+			a.host.Emit("recipe:output", map[string]interface{}{
+				"runId": runID,
+				"step":  step,
+				"line":  line,
+			})
+		},
+		OnStep: func(step int, result database.RecipeStepResult) {
+			a.host.Emit("recipe:step", map[string]interface{}{
+				"runId":  runID,
+				"step":   step,
+				"result": result,
+			})
+		},
+	})
+	run.ID = runID
+	run.Trigger = trigger
+	if err := a.db.FinishRecipeRun(run); err != nil {
+		a.host.Error(fmt.Sprintf("保存配方运行记录失败: %v", err))
+	}
+
+	event := run
+	event.Log = ""
+	a.host.Emit("recipe:end", event)
+	if run.Status == database.RunSuccess {
+		a.notifyEvent(notify.RecipeSuccess, "部署配方成功", r.Name)
+	} else {
+		a.notifyEvent(notify.RecipeFailed, "部署配方失败", fmt.Sprintf("%s: %s", r.Name, run.ErrorMsg))
+	}
+	return &run, nil
+}
+
+// recipeUpload 配方中的上传步骤，上传到服务器或服务器组
+func (a *App) recipeUpload(ctx context.Context, step database.RecipeStep, path string) (string, error) {
+	if step.GroupID != "" {
+		result, err := a.deployToGroup(ctx, step.GroupID, step.PathKey, path, step.Extract)
+		if err != nil {
+			return "", err
+		}
+		if !result.Success {
+			return "", errors.New(result.Error)
+		}
+		return fmt.Sprintf("已部署到服务器组 %s (%d 台服务器)", result.GroupName, len(result.Results)), nil
+	}
+
+	result, err := a.upload(ctx, step.ServerID, step.PathKey, path, step.Extract, queueSourceRecipe, "")
+	if err != nil {
+		return "", err
+	}
+	if !result.Success {
+		return "", errors.New(result.Error)
+	}
+	return fmt.Sprintf("已上传到 %s: %s", result.ServerName, result.Status), nil
+}
+
+// ============= 历史记录 =============
+
+// GetHistory 获取历史记录
+func (a *App) GetHistory(limit int) ([]database.HistoryEntry, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+	return a.db.GetHistory(limit)
+}
+
+// QueryHistory 按服务器、路径标识、状态、日期范围筛选历史记录并分页
+func (a *App) QueryHistory(filter database.HistoryFilter) (*database.HistoryPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = 50
+	}
+	return a.db.QueryHistory(filter)
+}
+
+// GetHistoryFiles 获取一条历史记录的文件明细
+func (a *App) GetHistoryFiles(historyID int64) ([]database.HistoryFile, error) {
+	return a.db.GetHistoryFiles(historyID)
+}
+
+// ExportHistory 导出筛选后的全部历史记录到文件，format 为 csv 或 json，返回保存路径
+func (a *App) ExportHistory(filter database.HistoryFilter, format string) (string, error) {
+	if format != database.ExportCSV && format != database.ExportJSON {
+		return "", fmt.Errorf("不支持的导出格式: %s", format)
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出历史记录",
+		DefaultFilename: fmt.Sprintf("history_%s.%s", time.Now().Format("20060102_150405"), format),
+		Filters: []runtime.FileFilter{{
+			DisplayName: strings.ToUpper(format) + " (*." + format + ")",
+			Pattern:     "*." + format,
+		}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("创建文件失败: %v", err)
+	}
+	if _, err := a.db.ExportHistory(f, filter, format); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// ClearHistory 清空历史记录
+func (a *App) ClearHistory() error {
+	return a.db.ClearHistory()
+}
+
+// ============= 文件夹监控 =============
+
+// GetWatches 获取所有监控配置
+func (a *App) GetWatches() ([]database.WatchConfig, error) {
+	return a.db.GetWatches()
+}
+
+// SaveWatch 保存监控配置，并按启用状态启动或停止监控
+func (a *App) SaveWatch(watch database.WatchConfig) error {
+	if watch.ID == "" {
+		watch.ID = fmt.Sprintf("watch_%d", time.Now().UnixNano())
+	}
+	if err := a.checkRecipe(watch.RecipeID); err != nil {
+		return err
+	}
+	if _, err := ignore.New(watch.Excludes); err != nil {
+		return err
+	}
+	if err := a.db.SaveWatch(watch); err != nil {
+		return err
+	}
+	return a.applyWatch(watch)
+}
+
+// DeleteWatch 删除监控配置
+func (a *App) DeleteWatch(id string) error {
+	a.watchers.Stop(id)
+	a.emitWatchStatus(id)
+	return a.db.DeleteWatch(id)
+}
+
+// GetRunningWatches 获取正在运行的监控 ID
+func (a *App) GetRunningWatches() []string {
+	return a.watchers.Running()
+}
+
+// startWatches 启动所有已启用的监控，单个失败不影响其他
+func (a *App) startWatches() {
+	watches, err := a.db.GetWatches()
+	if err != nil {
+		a.host.Error(fmt.Sprintf("读取监控配置失败: %v", err))
+		return
+	}
+	for _, w := range watches {
+		if err := a.applyWatch(w); err != nil {
+			a.host.Error(fmt.Sprintf("启动监控失败 [%s]: %v", w.FolderPath, err))
+		}
+	}
+}
+
+// applyWatch 按配置启动或停止单个监控
+func (a *App) applyWatch(w database.WatchConfig) error {
+	defer a.emitWatchStatus(w.ID)
+
+	// 由运行自动化引擎的进程启动
+	if !a.engines.Load() {
+		return nil
+	}
+	if !w.Enabled || a.watchesPaused.Load() {
+		a.watchers.Stop(w.ID)
+		return nil
+	}
+	return a.watchers.Start(watcher.Config{
+		ID:         w.ID,
+		FolderPath: w.FolderPath,
+		Patterns:   w.Patterns,
+		Excludes:   w.Excludes,
+		DebounceMs: w.DebounceMs,
+	})
+}
+
+// PauseWatches 暂停所有文件夹监控，不修改各监控的启用状态，程序重启后恢复
+//
+// 暂停期间的变化不会上传，恢复后可用同步上传变化的文件。
+func (a *App) PauseWatches() error {
+	if !a.engines.Load() {
+		return errors.New("文件夹监控由另一个进程 (后台服务) 运行")
+	}
+	if a.watchesPaused.Swap(true) {
+		return nil
+	}
+	running := a.watchers.Running()
+	a.watchers.StopAll()
+	for _, id := range running {
+		a.emitWatchStatus(id)
+	}
+	a.host.Info("已暂停所有文件夹监控")
+	a.host.Emit("watch:paused", true)
+	return nil
+}
+
+// ResumeWatches 恢复所有已启用的文件夹监控
+func (a *App) ResumeWatches() error {
+	if !a.watchesPaused.Swap(false) {
+		return nil
+	}
+	a.startWatches()
+	a.host.Info("已恢复文件夹监控")
+	a.host.Emit("watch:paused", false)
+	return nil
+}
+
+// GetWatchesPaused 文件夹监控是否已暂停
+func (a *App) GetWatchesPaused() bool {
+	return a.watchesPaused.Load()
+}
+
+func (a *App) emitWatchStatus(id string) {
+	a.host.Emit("watch:status", map[string]interface{}{
+		"id":      id,
+		"running": a.watchers.IsRunning(id),
+	})
+}
+
+// onWatchChanges 上传监控到的一批变化文件，保持相对路径
+func (a *App) onWatchChanges(cfg watcher.Config, files []watcher.ChangedFile) {
+	w, err := a.findWatch(cfg.ID)
+	if err != nil {
+		a.onWatchError(cfg, err)
+		return
+	}
+
+	// 关联了配方时运行配方 (例如重新构建后部署)，运行中再次变化时结束后再运行一次
+	if w.RecipeID != "" {
+		a.host.Emit("watch:changes", map[string]interface{}{
+			"id":    cfg.ID,
+			"count": len(files),
+		})
+		go func() {
+			if _, err := a.runRecipe(w.RecipeID, recipeTriggerWatch); err != nil && !errors.Is(err, errRecipeRunning) {
+				a.onWatchError(cfg, err)
+			}
+		}()
+		return
+	}
+
+	server, err := a.getServer(w.ServerID)
+	if err != nil {
+		a.onWatchError(cfg, err)
+		return
+	}
+
+	// 监控自身的排除规则已在监控中生效，这里再按服务器当前的排除规则过滤
+	if len(server.Excludes) > 0 {
+		exclude, err := ignore.New(server.Excludes)
+		if err != nil {
+			a.onWatchError(cfg, err)
+			return
+		}
+		kept := files[:0]
+		for _, f := range files {
+			if !exclude.Match(f.RelPath, false) {
+				kept = append(kept, f)
+			}
+		}
+		if files = kept; len(files) == 0 {
+			return
+		}
+	}
+
+	a.host.Emit("watch:changes", map[string]interface{}{
+		"id":    cfg.ID,
+		"count": len(files),
+	})
+
+	// 通过上传队列执行，网络中断或程序重启后会自动重试
+	for _, f := range files {
+		if _, err := a.queue.Enqueue(database.QueueJob{
+			ServerID: w.ServerID,
+			PathKey:  w.PathKey,
+			FilePath: f.AbsPath,
+			RelPath:  f.RelPath,
+			Source:   queueSourceWatch,
+			SourceID: cfg.ID,
+		}); err != nil {
+			a.onWatchError(cfg, fmt.Errorf("加入上传队列失败: %v", err))
+		}
+	}
+}
+
+func (a *App) onWatchError(cfg watcher.Config, err error) {
+	a.host.Error(fmt.Sprintf("文件夹监控错误 [%s]: %v", cfg.FolderPath, err))
+	a.host.Emit("watch:error", map[string]interface{}{
+		"id":    cfg.ID,
+		"error": err.Error(),
+	})
+}
+
+// findWatch 读取最新的监控配置 (服务器、路径标识可能已被修改)
+func (a *App) findWatch(id string) (*database.WatchConfig, error) {
+	watches, err := a.db.GetWatches()
 	if err != nil {
 		return nil, err
 	}
-
-	result := map[string]interface{}{
-		"name":    info.Name(),
-		"size":    info.Size(),
-		"isDir":   info.IsDir(),
-		"modTime": info.ModTime().Format(time.RFC3339),
-	}
-
-	// 如果是文件夹，计算总大小和文件数量
-	if info.IsDir() {
-		files, err := uploader.ListFilesInDir(filePath)
-		if err == nil {
-			var totalSize int64
-			for _, f := range files {
-				totalSize += f.Size
-			}
-			result["size"] = totalSize
-			result["fileCount"] = len(files)
+	for _, w := range watches {
+		if w.ID == id {
+			return &w, nil
 		}
 	}
-
-	return result, nil
+	return nil, fmt.Errorf("监控配置不存在: %s", id)
 }
 
-// ============= 历史记录 =============
-
-// GetHistory 获取历史记录
-func (a *App) GetHistory(limit int) ([]database.HistoryEntry, error) {
-	if limit <= 0 {
-		limit = 100
-	}
-	return a.db.GetHistory(limit)
+// SyncResult 同步监控文件夹的结果
+type SyncResult struct {
+	WatchID    string               `json:"watchId"`
+	FolderPath string               `json:"folderPath"`
+	ServerName string               `json:"serverName"`
+	Total      int                  `json:"total"`   // 符合模式和排除规则的文件数
+	Changed    []string             `json:"changed"` // 与上次成功上传的内容不同 (或从未上传) 的文件
+	Upload     *UploadResultWrapper `json:"upload,omitempty"`
 }
 
-// ClearHistory 清空历史记录
-func (a *App) ClearHistory() error {
-	return a.db.ClearHistory()
+// SyncWatch 把监控文件夹中变化的文件一次性上传，不依赖监控是否在运行
+//
+// 按历史记录中每个文件最近一次成功上传的 SHA-256 判断是否变化，force 时上传所有文件；
+// dryRun 时只列出变化的文件。遵循监控的模式和排除规则以及服务器的排除规则。
+func (a *App) SyncWatch(id string, dryRun, force bool) (*SyncResult, error) {
+	return a.syncWatch(a.uploadCtx, id, dryRun, force)
 }
 
-// ============= 文件夹监控 =============
+func (a *App) syncWatch(ctx context.Context, id string, dryRun, force bool) (*SyncResult, error) {
+	w, err := a.findWatch(id)
+	if err != nil {
+		return nil, err
+	}
+	if w.RecipeID != "" {
+		return nil, errors.New("监控关联了部署配方，请直接运行配方")
+	}
+	server, err := a.getServer(w.ServerID)
+	if err != nil {
+		return nil, err
+	}
 
-// GetWatches 获取所有监控配置
-func (a *App) GetWatches() ([]database.WatchConfig, error) {
-	return a.db.GetWatches()
-}
+	excludes := append(append([]string{}, w.Excludes...), server.Excludes...)
+	exclude, err := ignore.Load(w.FolderPath, excludes)
+	if err != nil {
+		return nil, err
+	}
+	listed, err := uploader.ListFilesInDir(w.FolderPath, exclude)
+	if err != nil {
+		return nil, fmt.Errorf("列出文件失败: %v", err)
+	}
+	uploaded, err := a.db.UploadedHashes(server.ID, w.PathKey)
+	if err != nil {
+		return nil, err
+	}
 
-// SaveWatch 保存监控配置
-func (a *App) SaveWatch(watch database.WatchConfig) error {
-	if watch.ID == "" {
-		watch.ID = fmt.Sprintf("watch_%d", time.Now().UnixNano())
+	result := &SyncResult{WatchID: w.ID, FolderPath: w.FolderPath, ServerName: server.Name, Changed: []string{}}
+	var changed []uploader.FileToUpload
+	for _, f := range listed {
+		if !watcher.MatchPatterns(f.RelPath, w.Patterns) {
+			continue
+		}
+		result.Total++
+		if !force {
+			sum, err := uploader.FileSHA256(f.AbsPath)
+			if err != nil {
+				return nil, fmt.Errorf("读取文件失败: %v", err)
+			}
+			if uploaded[f.RelPath] == sum {
+				continue
+			}
+		}
+		changed = append(changed, f)
+		result.Changed = append(result.Changed, f.RelPath)
+	}
+	if dryRun || len(changed) == 0 {
+		return result, nil
 	}
-	return a.db.SaveWatch(watch)
-}
 
-// DeleteWatch 删除监控配置
-func (a *App) DeleteWatch(id string) error {
-	return a.db.DeleteWatch(id)
+	privateKey, err := a.getPrivateKey(server)
+	if err != nil {
+		return nil, err
+	}
+	folderName := filepath.Base(w.FolderPath)
+	job := a.startJob(ctx, folderName, server.Name)
+	defer a.finishJob(job)
+
+	result.Upload = a.uploadFiles(job.ctx, server, w.PathKey, folderName, changed, privateKey, queueSourceWatch, w.ID)
+	result.Upload.JobID = job.ID
+	return result, nil
 }
 
 // ============= 定时任务 =============
@@ -381,21 +2135,485 @@ func (a *App) GetSchedules() ([]database.Schedule, error) {
 	return a.db.GetSchedules()
 }
 
-// SaveSchedule 保存定时任务
+// SaveSchedule 保存定时任务，并按启用状态加入或移出调度
 func (a *App) SaveSchedule(schedule database.Schedule) error {
 	if schedule.ID == "" {
 		schedule.ID = fmt.Sprintf("schedule_%d", time.Now().UnixNano())
 	}
-	return a.db.SaveSchedule(schedule)
+	if _, err := scheduler.ParseCron(schedule.CronExpr); err != nil {
+		return err
+	}
+	if err := a.checkRecipe(schedule.RecipeID); err != nil {
+		return err
+	}
+	if err := a.db.SaveSchedule(schedule); err != nil {
+		return err
+	}
+	return a.applySchedule(schedule, false)
 }
 
 // DeleteSchedule 删除定时任务
 func (a *App) DeleteSchedule(id string) error {
+	a.scheduler.Remove(id)
 	return a.db.DeleteSchedule(id)
 }
 
+// RunScheduleNow 立即执行一次定时任务
+func (a *App) RunScheduleNow(id string) error {
+	if !a.engines.Load() {
+		return errors.New("定时任务由另一个进程 (后台服务) 运行")
+	}
+	if !a.scheduler.RunNow(id) {
+		return fmt.Errorf("定时任务未启用: %s", id)
+	}
+	return nil
+}
+
+// PreviewCron 预览 cron 表达式接下来的运行时间
+func (a *App) PreviewCron(expr string, count int) ([]string, error) {
+	c, err := scheduler.ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	if count <= 0 {
+		count = 5
+	}
+
+	times := make([]string, 0, count)
+	t := time.Now()
+	for i := 0; i < count; i++ {
+		t = c.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t.Format(time.RFC3339))
+	}
+	return times, nil
+}
+
+// startSchedules 加载所有已启用的定时任务，按补跑策略处理关闭期间错过的运行
+func (a *App) startSchedules() {
+	schedules, err := a.db.GetSchedules()
+	if err != nil {
+		a.host.Error(fmt.Sprintf("读取定时任务失败: %v", err))
+		return
+	}
+	for _, s := range schedules {
+		if err := a.applySchedule(s, true); err != nil {
+			a.host.Error(fmt.Sprintf("加载定时任务失败 [%s]: %v", s.Name, err))
+		}
+	}
+}
+
+// applySchedule 按配置加入或移出调度，startup 为 true 时使用持久化的下一次运行时间判断补跑
+func (a *App) applySchedule(s database.Schedule, startup bool) error {
+	// 由运行自动化引擎的进程调度
+	if !a.engines.Load() {
+		return nil
+	}
+	if !s.Enabled {
+		a.scheduler.Remove(s.ID)
+		return a.db.UpdateScheduleNextRun(s.ID, "")
+	}
+
+	job := scheduler.Job{
+		ID:      s.ID,
+		Expr:    s.CronExpr,
+		Overlap: s.Overlap,
+		CatchUp: s.CatchUp,
+	}
+	if startup && s.NextRun != "" {
+		job.NextRun, _ = time.Parse(time.RFC3339, s.NextRun)
+	}
+
+	next, err := a.scheduler.Add(job)
+	if err != nil {
+		return err
+	}
+	return a.db.UpdateScheduleNextRun(s.ID, formatTime(next))
+}
+
+// runSchedule 执行定时任务，与手动上传走同一条路径
+func (a *App) runSchedule(id string) (string, error) {
+	s, err := a.findSchedule(id)
+	if err != nil {
+		return "", err
+	}
+
+	a.host.Emit("schedule:start", map[string]interface{}{
+		"id":   s.ID,
+		"name": s.Name,
+	})
+
+	if s.RecipeID != "" {
+		run, err := a.runRecipe(s.RecipeID, recipeTriggerSchedule)
+		if err != nil {
+			return "", err
+		}
+		if run.Status != database.RunSuccess {
+			return run.Status, errors.New(run.ErrorMsg)
+		}
+		return fmt.Sprintf("配方运行成功 (%d 个步骤)", len(run.Steps)), nil
+	}
+
+	result, err := a.upload(a.uploadCtx, s.ServerID, s.PathKey, s.FilePath, s.Extract, queueSourceSchedule, s.ID)
+	if err != nil {
+		return "", err
+	}
+	if !result.Success {
+		if result.Queued > 0 {
+			return result.Status, fmt.Errorf("%s (已加入重试队列)", result.Error)
+		}
+		return result.Status, fmt.Errorf("%s", result.Error)
+	}
+	if result.Status == "ok" {
+		return fmt.Sprintf("成功 (%d 字节)", result.Size), nil
+	}
+	return result.Status, nil
+}
+
+// findSchedule 读取最新的定时任务配置
+func (a *App) findSchedule(id string) (*database.Schedule, error) {
+	schedules, err := a.db.GetSchedules()
+	if err != nil {
+		return nil, err
+	}
+	for i := range schedules {
+		if schedules[i].ID == id {
+			return &schedules[i], nil
+		}
+	}
+	return nil, fmt.Errorf("定时任务不存在: %s", id)
+}
+
+// onScheduleUpdate 持久化运行状态并通知前端
+func (a *App) onScheduleUpdate(info scheduler.RunInfo) {
+	lastResult := info.Result
+	switch {
+	case info.Skipped:
+		lastResult = "跳过: 上一次运行尚未结束"
+	case info.Err != nil:
+		lastResult = "失败: " + info.Err.Error()
+	}
+
+	lastRun := formatTime(info.Started)
+	nextRun := formatTime(info.NextRun)
+	if err := a.db.UpdateScheduleRun(info.ID, lastRun, nextRun, lastResult); err != nil {
+		a.host.Error(fmt.Sprintf("保存定时任务状态失败: %v", err))
+	}
+
+	a.host.Emit("schedule:update", map[string]interface{}{
+		"id":         info.ID,
+		"lastRun":    lastRun,
+		"nextRun":    nextRun,
+		"lastResult": lastResult,
+		"success":    info.Err == nil && !info.Skipped,
+	})
+
+	if info.Skipped {
+		return
+	}
+	name := info.ID
+	if s, err := a.findSchedule(info.ID); err == nil {
+		name = s.Name
+	}
+	if info.Err != nil {
+		a.notifyEvent(notify.ScheduleFailed, "定时任务失败", fmt.Sprintf("%s: %v", name, info.Err))
+	} else {
+		a.notifyEvent(notify.ScheduleSuccess, "定时任务完成", fmt.Sprintf("%s: %s", name, info.Result))
+	}
+}
+
+// ============= 配置导入导出 =============
+
+// ExportConfig 导出服务器、监控、定时任务 (可选包含密钥) 到配置包文件，返回保存路径
+//
+// 包含密钥时必须提供密码，整个配置包被加密。
+func (a *App) ExportConfig(includeKeys bool, passphrase string) (string, error) {
+	data, err := bundle.Export(a.db, a.keys, bundle.ExportOptions{IncludeKeys: includeKeys, Passphrase: passphrase})
+	if err != nil {
+		return "", err
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "导出配置",
+		DefaultFilename: "deploy-config-" + time.Now().Format("20060102") + ".json",
+		Filters:         []runtime.FileFilter{{DisplayName: "配置文件 (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("写入文件失败: %v", err)
+	}
+	return path, nil
+}
+
+// ImportConfig 导入配置包内容，mode 为 merge (默认) 或 replace
+func (a *App) ImportConfig(data, passphrase, mode string) (*bundle.ImportResult, error) {
+	b, err := bundle.Parse([]byte(data), passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, err := a.db.GetSchedules()
+	if err != nil {
+		return nil, err
+	}
+	result, err := bundle.Import(a.db, a.keys, b, mode)
+	if err != nil {
+		return nil, err
+	}
+	a.reloadAutomation(schedules)
+	return result, nil
+}
+
+// ImportConfigFile 选择配置包文件并导入，取消选择时返回 nil
+func (a *App) ImportConfigFile(passphrase, mode string) (*bundle.ImportResult, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:   "导入配置",
+		Filters: []runtime.FileFilter{{DisplayName: "配置文件 (*.json)", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+	return a.ImportConfig(string(data), passphrase, mode)
+}
+
+// reloadAutomation 导入后按新配置重新启动监控和定时任务，previous 为导入前的定时任务
+func (a *App) reloadAutomation(previous []database.Schedule) {
+	a.watchers.StopAll()
+	for _, s := range previous {
+		a.scheduler.Remove(s.ID)
+	}
+
+	a.startWatches()
+	schedules, err := a.db.GetSchedules()
+	if err != nil {
+		a.host.Error(fmt.Sprintf("读取定时任务失败: %v", err))
+		return
+	}
+	for _, s := range schedules {
+		if err := a.applySchedule(s, false); err != nil {
+			a.host.Error(fmt.Sprintf("加载定时任务失败 [%s]: %v", s.Name, err))
+		}
+	}
+	a.health.Refresh()
+	a.host.Emit("config:imported", nil)
+}
+
+// ============= 托盘和通知 =============
+
+// 通知和托盘设置
+const (
+	settingNotifyNative = "notify.native" // 是否显示系统通知，关闭后只推送给前端
+	settingNotifyEvents = "notify.events" // 各事件是否通知，JSON，只保存与默认值不同的项
+	settingCloseToTray  = "tray.close_to_tray"
+)
+
+// notifyInterval 同一事件两次系统通知的最短间隔
+const notifyInterval = 5 * time.Second
+
+// trayRecentCount 托盘菜单中显示的最近上传条数
+const trayRecentCount = 8
+
+// NotifyPref 单个事件的通知设置
+type NotifyPref struct {
+	notify.Event
+	Enabled bool `json:"enabled"`
+}
+
+// NotifySettings 通知设置
+type NotifySettings struct {
+	Native bool         `json:"native"`
+	Events []NotifyPref `json:"events"`
+}
+
+// GetNotifySettings 获取通知设置，未设置的事件使用默认值
+func (a *App) GetNotifySettings() (*NotifySettings, error) {
+	overrides, err := a.notifyOverrides()
+	if err != nil {
+		return nil, err
+	}
+	settings := &NotifySettings{Native: a.notifyNative()}
+	for _, e := range notify.Events {
+		enabled := e.Default
+		if v, ok := overrides[e.ID]; ok {
+			enabled = v
+		}
+		settings.Events = append(settings.Events, NotifyPref{Event: e, Enabled: enabled})
+	}
+	return settings, nil
+}
+
+// SetNotifySettings 保存通知设置
+func (a *App) SetNotifySettings(settings NotifySettings) error {
+	overrides := make(map[string]bool)
+	for _, p := range settings.Events {
+		if !notify.Known(p.ID) {
+			return fmt.Errorf("未知的通知事件: %s", p.ID)
+		}
+		if p.Enabled != notify.Default(p.ID) {
+			overrides[p.ID] = p.Enabled
+		}
+	}
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return err
+	}
+	if err := a.db.SetSetting(settingNotifyEvents, string(data)); err != nil {
+		return err
+	}
+	return a.db.SetSetting(settingNotifyNative, strconv.FormatBool(settings.Native))
+}
+
+// TestNotification 显示一条测试通知
+func (a *App) TestNotification() {
+	a.notifyEvent("", "测试通知", "通知显示正常")
+}
+
+func (a *App) notifyOverrides() (map[string]bool, error) {
+	overrides := make(map[string]bool)
+	v, err := a.db.GetSetting(settingNotifyEvents)
+	if err != nil || v == "" {
+		return overrides, err
+	}
+	if err := json.Unmarshal([]byte(v), &overrides); err != nil {
+		return nil, fmt.Errorf("通知设置无效: %v", err)
+	}
+	return overrides, nil
+}
+
+// notifyNative 是否显示系统通知，默认显示
+func (a *App) notifyNative() bool {
+	v, err := a.db.GetSetting(settingNotifyNative)
+	if err != nil || v == "" {
+		return true
+	}
+	native, _ := strconv.ParseBool(v)
+	return native
+}
+
+// notifyEvent 按设置发送通知: 推送 notify 事件给前端并显示系统通知
+//
+// event 为空时 (配方中的通知步骤、测试通知) 总是发送，不限流。
+func (a *App) notifyEvent(event, title, message string) {
+	if event != "" {
+		overrides, err := a.notifyOverrides()
+		if err != nil {
+			a.host.Warn(err.Error())
+		}
+		enabled := notify.Default(event)
+		if v, ok := overrides[event]; ok {
+			enabled = v
+		}
+		if !enabled {
+			return
+		}
+	}
+
+	a.host.Info(fmt.Sprintf("%s: %s", title, message))
+	a.host.Emit("notify", map[string]interface{}{
+		"event":   event,
+		"title":   title,
+		"message": message,
+	})
+	if !a.notifyNative() {
+		return
+	}
+
+	if event != "" {
+		a.notifyMu.Lock()
+		last := a.notifyLast[event]
+		throttled := time.Since(last) < notifyInterval
+		if !throttled {
+			a.notifyLast[event] = time.Now()
+		}
+		a.notifyMu.Unlock()
+		if throttled {
+			return
+		}
+	}
+	a.host.Notify(title, message)
+}
+
+// notifyUpload 手动上传或部署到服务器组结束后通知，取消的不通知
+func (a *App) notifyUpload(name, target string, success, canceled bool, errMsg string) {
+	switch {
+	case canceled:
+	case success:
+		a.notifyEvent(notify.UploadSuccess, "上传成功", fmt.Sprintf("%s → %s", name, target))
+	default:
+		a.notifyEvent(notify.UploadFailed, "上传失败", fmt.Sprintf("%s → %s: %s", name, target, errMsg))
+	}
+}
+
+// TraySettings 系统托盘设置
+type TraySettings struct {
+	Available   bool `json:"available"`   // 当前系统是否支持托盘
+	CloseToTray bool `json:"closeToTray"` // 关闭窗口时隐藏到托盘，继续运行监控和定时任务
+
+	Unsupported string `json:"unsupported,omitempty"` // 当前系统不支持托盘时在设置中显示的说明
+}
+
+// GetTraySettings 获取系统托盘设置
+func (a *App) GetTraySettings() TraySettings {
+	return TraySettings{Available: a.trayRunning.Load(), CloseToTray: a.closeToTray(), Unsupported: trayUnsupported}
+}
+
+// SetCloseToTray 设置关闭窗口时是否隐藏到托盘
+func (a *App) SetCloseToTray(enabled bool) error {
+	return a.db.SetSetting(settingCloseToTray, strconv.FormatBool(enabled))
+}
+
+// closeToTray 默认隐藏到托盘
+func (a *App) closeToTray() bool {
+	v, err := a.db.GetSetting(settingCloseToTray)
+	if err != nil || v == "" {
+		return true
+	}
+	enabled, _ := strconv.ParseBool(v)
+	return enabled
+}
+
+// beforeClose 关闭窗口时隐藏到托盘，返回 true 阻止退出
+func (a *App) beforeClose(ctx context.Context) bool {
+	if a.quitting.Load() || !a.trayRunning.Load() || a.db == nil || !a.closeToTray() {
+		return false
+	}
+	runtime.WindowHide(ctx)
+	if !a.trayHinted.Swap(true) {
+		a.host.Notify("程序仍在后台运行", "文件夹监控和定时任务继续运行，可从系统托盘打开窗口或退出")
+	}
+	return true
+}
+
+// showWindow 从托盘打开窗口
+func (a *App) showWindow() {
+	runtime.WindowShow(a.ctx)
+	runtime.WindowUnminimise(a.ctx)
+}
+
+// quit 从托盘退出程序
+func (a *App) quit() {
+	a.quitting.Store(true)
+	runtime.Quit(a.ctx)
+}
+
 // ============= 工具方法 =============
 
+// formatTime 格式化时间，零值返回空字符串
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
 // GetDataDir 获取数据目录
 func (a *App) GetDataDir() string {
 	return database.GetDataDir()
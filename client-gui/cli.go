@@ -0,0 +1,684 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"client-gui/internal/database"
+	"client-gui/internal/health"
+	"client-gui/internal/keystore"
+)
+
+// 命令行模式: 不打开窗口，使用与图形界面相同的数据库 (GetDataDir)
+//
+//	client-gui daemon   后台运行监控、定时任务和上传队列
+//	client-gui upload   上传文件或文件夹
+//	client-gui sync     按历史记录上传监控文件夹中变化的文件
+//	client-gui history  查看上传历史
+//	client-gui servers  列出服务器
+const cliUsage = `用法: client-gui <命令> [参数]
+
+命令:
+  daemon   后台运行文件夹监控、定时任务和上传队列 (无界面)
+  upload   上传文件或文件夹到服务器或服务器组
+  sync     上传监控文件夹中自上次成功上传后变化的文件
+  history  查看上传历史
+  servers  列出服务器
+  help     显示帮助
+
+不带命令时启动图形界面。使用 "client-gui <命令> -h" 查看命令的参数。
+私钥设置了主密码时，通过环境变量 DEPLOY_CLIENT_PASSWORD 或 --password-file 解锁。
+`
+
+// daemonLogMaxSize 后台服务日志超过该大小时在启动时轮转
+const daemonLogMaxSize = 10 << 20
+
+// cliCommands 命令行子命令
+var cliCommands = map[string]func(args []string) int{
+	"daemon":  cmdDaemon,
+	"upload":  cmdUpload,
+	"sync":    cmdSync,
+	"history": cmdHistory,
+	"servers": cmdServers,
+}
+
+// runCLI 处理命令行子命令，不是子命令时返回 false 启动图形界面
+func runCLI(args []string) (bool, int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+	switch args[0] {
+	case "help", "-h", "--help":
+		attachConsole()
+		fmt.Print(cliUsage)
+		return true, 0
+	}
+	cmd, ok := cliCommands[args[0]]
+	if !ok {
+		return false, 0
+	}
+	attachConsole()
+	return true, cmd(args[1:])
+}
+
+// newFlagSet 创建子命令的参数解析，-h 时输出用法
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: client-gui %s %s\n\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析参数，-h 返回 0，参数错误返回 2，成功返回 -1
+func parseFlags(fs *flag.FlagSet, args []string) int {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	return -1
+}
+
+// openLog 打开日志文件 (追加)，超过 maxSize 时先把旧日志重命名为 .1
+func openLog(path string, maxSize int64) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && maxSize > 0 && info.Size() > maxSize {
+		os.Rename(path, path+".1")
+	}
+	return os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+}
+
+// logPath 日志文件的默认位置
+func logPath(name string) string {
+	return filepath.Join(database.GetDataDir(), "logs", name)
+}
+
+// openCLI 打开数据库，日志写入 logFile (同时写到 extra，可为 nil)
+func openCLI(mode, logFile string, extra io.Writer) (*App, *os.File, error) {
+	f, err := openLog(logFile, daemonLogMaxSize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("无法打开日志文件: %v", err)
+	}
+	var w io.Writer = f
+	if extra != nil {
+		w = io.MultiWriter(f, extra)
+	}
+
+	a := NewApp()
+	a.ctx = context.Background()
+	a.engineMode = mode
+	a.host = &logHost{logger: log.New(w, "", log.LstdFlags)}
+	if err := a.open(); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("数据库初始化失败: %v", err)
+	}
+	return a, f, nil
+}
+
+// close 命令结束时释放资源
+func (a *App) close() {
+	a.shutdown(a.ctx)
+}
+
+// unlockFromEnv 私钥设置了主密码时，用环境变量或密码文件中的密码解锁
+//
+// 旧版本的明文私钥等待设置主密码时，用同样的密码加密；没有提供密码时返回 keystore.ErrUnprotected。
+func (a *App) unlockFromEnv(passwordFile string) error {
+	st, err := a.keys.Status()
+	if err != nil {
+		return err
+	}
+	if st.NeedsPassword {
+		password, err := passwordFromEnv(passwordFile)
+		if err != nil {
+			return err
+		}
+		if password == "" {
+			return fmt.Errorf("%w (没有图形界面时设置 DEPLOY_CLIENT_PASSWORD 或使用 --password-file 作为主密码)", keystore.ErrUnprotected)
+		}
+		if err := a.keys.SetProtection(keystore.ProtectionPassword, password); err != nil {
+			return err
+		}
+		a.host.Info("旧版本的明文私钥已用主密码加密")
+		return nil
+	}
+	if !st.Locked || st.Protection != keystore.ProtectionPassword {
+		return nil
+	}
+
+	password, err := passwordFromEnv(passwordFile)
+	if err != nil {
+		return err
+	}
+	if password == "" {
+		return errors.New("私钥已加密，请设置 DEPLOY_CLIENT_PASSWORD 或使用 --password-file")
+	}
+	return a.keys.Unlock(password)
+}
+
+// passwordFromEnv 读取密码文件，没有指定时使用环境变量 DEPLOY_CLIENT_PASSWORD
+func passwordFromEnv(passwordFile string) (string, error) {
+	if passwordFile == "" {
+		return os.Getenv("DEPLOY_CLIENT_PASSWORD"), nil
+	}
+	data, err := os.ReadFile(passwordFile)
+	if err != nil {
+		return "", fmt.Errorf("读取密码文件失败: %v", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// handleSignals 收到 Ctrl+C 或终止信号时取消 ctx
+func handleSignals(cancel context.CancelFunc) func() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-ch; ok {
+			cancel()
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(ch)
+	}
+}
+
+// printJSON 以缩进格式输出 JSON
+func printJSON(v interface{}) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// fail 输出错误并返回退出码 1
+func fail(err error) int {
+	fmt.Fprintln(os.Stderr, "错误:", err)
+	return 1
+}
+
+// ============= daemon =============
+
+// cmdDaemon 后台运行监控、定时任务和上传队列，直到收到终止信号
+//
+// 图形界面和后台服务通过锁文件保证引擎只在一个进程中运行。图形界面中修改的
+// 监控和定时任务保存在数据库中，后台服务定期检查并重新加载。
+func cmdDaemon(args []string) int {
+	fs := newFlagSet("daemon", "[--log FILE] [--password-file FILE] [--reload SECONDS]")
+	logFile := fs.String("log", logPath("daemon.log"), "日志文件")
+	passwordFile := fs.String("password-file", "", "私钥主密码文件 (默认读取 DEPLOY_CLIENT_PASSWORD)")
+	reload := fs.Int("reload", 10, "检查配置变化的间隔 (秒)")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+	if *reload < 1 {
+		*reload = 1
+	}
+
+	a, f, err := openCLI(engineModeDaemon, *logFile, os.Stderr)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	defer a.close()
+
+	if err := a.unlockFromEnv(*passwordFile); errors.Is(err, keystore.ErrUnprotected) {
+		// 仍然启动，需要私钥的上传进入队列等待设置主密码
+		a.host.Error(err.Error())
+	} else if err != nil {
+		// 仍然启动，需要私钥的上传进入队列等待
+		a.host.Warn(fmt.Sprintf("私钥未解锁: %v", err))
+	}
+	if err := a.startEngines(); err != nil {
+		a.host.Error(err.Error())
+		return 1
+	}
+	a.health.Start()
+	a.host.Info(fmt.Sprintf("后台服务已启动 (pid %d)，数据目录: %s", os.Getpid(), database.GetDataDir()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer handleSignals(cancel)()
+
+	a.watchConfig(ctx, time.Duration(*reload)*time.Second)
+	a.host.Info("后台服务正在退出")
+	return 0
+}
+
+// watchConfig 定期检查监控和定时任务的配置，变化时重新加载，直到 ctx 取消
+func (a *App) watchConfig(ctx context.Context, interval time.Duration) {
+	last, schedules := a.automationFingerprint()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		fp, current := a.automationFingerprint()
+		if fp == "" || fp == last {
+			continue
+		}
+		a.host.Info("监控或定时任务的配置已变化，重新加载")
+		a.reloadAutomation(schedules)
+		last, schedules = fp, current
+	}
+}
+
+// automationFingerprint 监控和定时任务配置的摘要，不含调度器维护的运行状态
+func (a *App) automationFingerprint() (string, []database.Schedule) {
+	watches, err := a.db.GetWatches()
+	if err != nil {
+		return "", nil
+	}
+	schedules, err := a.db.GetSchedules()
+	if err != nil {
+		return "", nil
+	}
+	stripped := make([]database.Schedule, len(schedules))
+	for i, s := range schedules {
+		s.LastRun, s.NextRun, s.LastResult = "", "", ""
+		stripped[i] = s
+	}
+	data, _ := json.Marshal(struct {
+		Watches   []database.WatchConfig
+		Schedules []database.Schedule
+	}{watches, stripped})
+	return string(data), schedules
+}
+
+// ============= upload =============
+
+// cmdUpload 上传文件或文件夹，失败时退出码为 1
+func cmdUpload(args []string) int {
+	fs := newFlagSet("upload", "(--server NAME|ID | --group NAME|ID) --path KEY [--extract] PATH...")
+	serverArg := fs.String("server", "", "服务器名称或 ID (默认使用默认服务器)")
+	groupArg := fs.String("group", "", "服务器组名称或 ID")
+	pathKey := fs.String("path", "", "服务器上的路径标识")
+	extract := fs.Bool("extract", false, "上传后解压 zip；文件夹打包为 zip 上传")
+	passwordFile := fs.String("password-file", "", "私钥主密码文件 (默认读取 DEPLOY_CLIENT_PASSWORD)")
+	jsonOut := fs.Bool("json", false, "以 JSON 输出结果")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+	if fs.NArg() == 0 || *pathKey == "" {
+		fs.Usage()
+		return 2
+	}
+	if *serverArg != "" && *groupArg != "" {
+		return fail(errors.New("--server 和 --group 只能指定一个"))
+	}
+
+	a, f, err := openCLI(engineModeGUI, logPath("cli.log"), nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	defer a.close()
+	if err := a.unlockFromEnv(*passwordFile); err != nil {
+		return fail(err)
+	}
+
+	ctx, cancel := context.WithCancel(a.uploadCtx)
+	defer cancel()
+	defer handleSignals(cancel)()
+
+	var results []interface{}
+	code := 0
+	for _, path := range fs.Args() {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return fail(err)
+		}
+
+		if *groupArg != "" {
+			group, err := a.findGroup(*groupArg)
+			if err != nil {
+				return fail(err)
+			}
+			result, err := a.deployToGroup(ctx, group.ID, *pathKey, abs, *extract)
+			if err != nil {
+				return fail(err)
+			}
+			a.host.Info(fmt.Sprintf("部署 %s 到服务器组 %s: %s", abs, group.Name, result.Status))
+			if !result.Success {
+				code = 1
+			}
+			results = append(results, result)
+			if !*jsonOut {
+				fmt.Printf("%s -> %s: %s\n", path, group.Name, result.Status)
+				for _, r := range result.Results {
+					fmt.Printf("  %-20s %-9s %s\n", r.ServerName, r.Status, r.Error)
+				}
+			}
+			continue
+		}
+
+		server, err := a.findServer(*serverArg)
+		if err != nil {
+			return fail(err)
+		}
+		result, err := a.upload(ctx, server.ID, *pathKey, abs, *extract, queueSourceManual, "")
+		if err != nil {
+			return fail(err)
+		}
+		a.host.Info(fmt.Sprintf("上传 %s 到 %s: %s", abs, server.Name, historyStatus(&result.UploadResult)))
+		if !result.Success {
+			code = 1
+		}
+		results = append(results, result)
+		if !*jsonOut {
+			printUploadResult(path, result)
+		}
+	}
+	if *jsonOut {
+		printJSON(results)
+	}
+	return code
+}
+
+// printUploadResult 输出一次上传的结果
+func printUploadResult(path string, r *UploadResultWrapper) {
+	status := historyStatus(&r.UploadResult)
+	fmt.Printf("%s -> %s: %s (%s, %d ms)\n", path, r.ServerName, status, formatSize(r.Size), r.DurationMs)
+	if r.Error != "" {
+		fmt.Printf("  错误: %s\n", r.Error)
+	}
+	if r.Verified {
+		fmt.Printf("  SHA-256: %s (已与服务器校验)\n", r.SHA256)
+	}
+	for _, f := range r.FailedFiles {
+		fmt.Printf("  失败: %s\n", f)
+	}
+	if r.Queued > 0 {
+		fmt.Printf("  %d 个文件已加入上传队列，由图形界面或后台服务重试\n", r.Queued)
+	}
+}
+
+// findServer 按 ID 或名称查找服务器，为空时使用默认服务器
+func (a *App) findServer(nameOrID string) (*database.Server, error) {
+	servers, err := a.db.GetServers()
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range servers {
+		if nameOrID == "" && s.IsDefault || nameOrID != "" && (s.ID == nameOrID || s.Name == nameOrID) {
+			return &servers[i], nil
+		}
+	}
+	if nameOrID == "" {
+		return nil, errors.New("没有默认服务器，请使用 --server 指定")
+	}
+	return nil, fmt.Errorf("服务器不存在: %s", nameOrID)
+}
+
+// findGroup 按 ID 或名称查找服务器组
+func (a *App) findGroup(nameOrID string) (*database.ServerGroup, error) {
+	groups, err := a.db.GetServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	for i, g := range groups {
+		if g.ID == nameOrID || g.Name == nameOrID {
+			return &groups[i], nil
+		}
+	}
+	return nil, fmt.Errorf("服务器组不存在: %s", nameOrID)
+}
+
+// formatSize 以易读的单位显示字节数
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// ============= sync =============
+
+// cmdSync 上传监控文件夹中变化的文件，不指定监控时同步所有已启用的监控
+func cmdSync(args []string) int {
+	fs := newFlagSet("sync", "[--dry-run] [--force] [WATCH-ID...]")
+	dryRun := fs.Bool("dry-run", false, "只列出需要上传的文件")
+	force := fs.Bool("force", false, "上传所有文件，不比较历史记录")
+	passwordFile := fs.String("password-file", "", "私钥主密码文件 (默认读取 DEPLOY_CLIENT_PASSWORD)")
+	jsonOut := fs.Bool("json", false, "以 JSON 输出结果")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	a, f, err := openCLI(engineModeGUI, logPath("cli.log"), nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	defer a.close()
+	if !*dryRun {
+		if err := a.unlockFromEnv(*passwordFile); err != nil {
+			return fail(err)
+		}
+	}
+
+	ids := fs.Args()
+	if len(ids) == 0 {
+		watches, err := a.db.GetWatches()
+		if err != nil {
+			return fail(err)
+		}
+		for _, w := range watches {
+			if w.Enabled && w.RecipeID == "" {
+				ids = append(ids, w.ID)
+			}
+		}
+		if len(ids) == 0 {
+			fmt.Println("没有需要同步的文件夹监控")
+			return 0
+		}
+	}
+
+	ctx, cancel := context.WithCancel(a.uploadCtx)
+	defer cancel()
+	defer handleSignals(cancel)()
+
+	var results []*SyncResult
+	code := 0
+	for _, id := range ids {
+		result, err := a.syncWatch(ctx, id, *dryRun, *force)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %s: %v\n", id, err)
+			code = 1
+			continue
+		}
+		results = append(results, result)
+		if result.Upload != nil {
+			a.host.Info(fmt.Sprintf("同步 %s 到 %s: %d 个文件, %s", result.FolderPath, result.ServerName, len(result.Changed), historyStatus(&result.Upload.UploadResult)))
+			if !result.Upload.Success {
+				code = 1
+			}
+		}
+		if *jsonOut {
+			continue
+		}
+
+		fmt.Printf("%s -> %s: %d/%d 个文件需要上传\n", result.FolderPath, result.ServerName, len(result.Changed), result.Total)
+		if *dryRun {
+			for _, rel := range result.Changed {
+				fmt.Printf("  %s\n", rel)
+			}
+		} else if result.Upload != nil {
+			printUploadResult(result.FolderPath, result.Upload)
+		}
+	}
+	if *jsonOut {
+		printJSON(results)
+	}
+	return code
+}
+
+// ============= history =============
+
+// cmdHistory 输出上传历史，默认最近 20 条
+func cmdHistory(args []string) int {
+	fs := newFlagSet("history", "[--limit N] [--server NAME|ID] [--status STATUS] [--json]")
+	limit := fs.Int("limit", 20, "显示的条数")
+	serverArg := fs.String("server", "", "只显示该服务器 (名称或 ID)")
+	status := fs.String("status", "", "只显示该状态: success / partial / failed / canceled")
+	jsonOut := fs.Bool("json", false, "以 JSON 输出")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	a, f, err := openCLI(engineModeGUI, logPath("cli.log"), nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	defer a.close()
+
+	filter := database.HistoryFilter{Status: *status, Limit: *limit}
+	if *serverArg != "" {
+		server, err := a.findServer(*serverArg)
+		if err != nil {
+			return fail(err)
+		}
+		filter.ServerID = server.ID
+	}
+	page, err := a.db.QueryHistory(filter)
+	if err != nil {
+		return fail(err)
+	}
+	if *jsonOut {
+		return printJSON(page.Entries)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "时间\t服务器\t路径\t文件\t大小\t状态\t错误")
+	for _, h := range page.Entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", h.UploadedAt, h.ServerName, h.PathKey, h.Filename, formatSize(h.FileSize), h.Status, h.ErrorMsg)
+	}
+	tw.Flush()
+	if page.Total > len(page.Entries) {
+		fmt.Printf("(共 %d 条，显示最近 %d 条)\n", page.Total, len(page.Entries))
+	}
+	return 0
+}
+
+// ============= servers =============
+
+// cmdServers 列出服务器，--check 时检测连通性
+func cmdServers(args []string) int {
+	fs := newFlagSet("servers", "[--check] [--json]")
+	check := fs.Bool("check", false, "检测每台服务器的连通性")
+	jsonOut := fs.Bool("json", false, "以 JSON 输出")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	a, f, err := openCLI(engineModeGUI, logPath("cli.log"), nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer f.Close()
+	defer a.close()
+
+	servers, err := a.db.GetServers()
+	if err != nil {
+		return fail(err)
+	}
+
+	type serverInfo struct {
+		database.Server
+		Status *health.Status `json:"status,omitempty"`
+	}
+	list := make([]serverInfo, len(servers))
+	code := 0
+	for i, s := range servers {
+		list[i].Server = redactServer(s)
+		if *check {
+			st := a.health.Check(s)
+			list[i].Status = &st
+			if !st.Reachable {
+				code = 1
+			}
+		}
+	}
+	if *jsonOut {
+		printJSON(list)
+		return code
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if *check {
+		fmt.Fprintln(tw, "ID\t名称\t地址\t路径\t状态\t版本")
+	} else {
+		fmt.Fprintln(tw, "ID\t名称\t地址\t路径")
+	}
+	for _, s := range list {
+		name := s.Name
+		if s.IsDefault {
+			name += " (默认)"
+		}
+		line := fmt.Sprintf("%s\t%s\t%s\t%s", s.ID, name, s.URL, strings.Join(s.Paths, ","))
+		if st := s.Status; st != nil {
+			state := fmt.Sprintf("在线 %d ms", st.LatencyMs)
+			if !st.Reachable {
+				state = "离线: " + st.Error
+			}
+			line += fmt.Sprintf("\t%s\t%s", state, st.Version)
+		}
+		fmt.Fprintln(tw, line)
+	}
+	tw.Flush()
+	return code
+}
+
+// redactServer 隐藏服务器配置中的凭据 (地址和代理中的密码、请求头的值)，命令行输出常被写入 CI 日志
+func redactServer(s database.Server) database.Server {
+	s.URL = redactURL(s.URL)
+	s.Conn.ProxyURL = redactURL(s.Conn.ProxyURL)
+	if len(s.Conn.Headers) > 0 {
+		headers := make(map[string]string, len(s.Conn.Headers))
+		for k := range s.Conn.Headers {
+			headers[k] = "xxxxx"
+		}
+		s.Conn.Headers = headers
+	}
+	return s
+}
+
+// redactURL 把地址中的密码替换为 xxxxx，无法解析时原样返回
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
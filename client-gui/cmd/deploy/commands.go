@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"client-gui/internal/crypto"
+	"client-gui/internal/uploader"
+)
+
+// ============= verify =============
+
+// VerifyReport verify 的结果
+type VerifyReport struct {
+	Path    string         `json:"path"`
+	Server  string         `json:"server"`
+	PathKey string         `json:"pathKey"`
+	Match   bool           `json:"match"` // 本地文件在服务器上都存在且内容相同
+	Diff    *uploader.Diff `json:"diff"`
+}
+
+// cmdVerify 检查本地文件夹 (或单个文件) 是否已完整部署到服务器上路径标识的根目录
+//
+// 本地文件在服务器上缺失或内容不同时退出码为 exitMismatch；--strict 时服务器上
+// 多出的文件也视为差异。
+func cmdVerify(args []string) int {
+	fs := newFlagSet("verify", "[--profile NAME] [--path KEY] [--strict] PATH")
+	opts := addOptions(fs, true)
+	strict := fs.Bool("strict", false, "服务器上多出的文件也视为差异")
+	var excludes listFlag
+	fs.Var(&excludes, "exclude", "排除规则 (.deployignore 语法)，可重复")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	path := fs.Arg(0)
+
+	t, err := opts.resolve()
+	if err == nil {
+		err = t.requirePath()
+	}
+	if err != nil {
+		return fail(*opts.jsonOut, err)
+	}
+	t.Excludes = append(t.Excludes, excludes...)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	var diff *uploader.Diff
+	if info, statErr := os.Stat(path); statErr == nil && !info.IsDir() {
+		diff, err = t.compareFile(ctx, path, info.Size())
+	} else {
+		diff, err = t.compare(ctx, path)
+	}
+	if err != nil {
+		return fail(*opts.jsonOut, err)
+	}
+
+	report := &VerifyReport{Path: path, Server: t.Server, PathKey: t.PathKey, Diff: diff}
+	report.Match = len(diff.Added) == 0 && len(diff.Modified) == 0 && (!*strict || len(diff.Deleted) == 0)
+	code := exitOK
+	if !report.Match {
+		code = exitMismatch
+	}
+
+	if *opts.jsonOut {
+		printJSON(report)
+		return code
+	}
+	status := "一致"
+	if !report.Match {
+		status = "不一致"
+	}
+	fmt.Printf("%s -> %s/%s: %s (相同 %d, 缺失 %d, 不同 %d, 仅服务器上存在 %d)\n",
+		path, t.Server, t.PathKey, status, len(diff.Unchanged), len(diff.Added), len(diff.Modified), len(diff.Deleted))
+	printEntries("缺失", diff.Added)
+	printEntries("不同", diff.Modified)
+	if *strict {
+		printEntries("多余", diff.Deleted)
+	}
+	printHidden(diff)
+	return code
+}
+
+// compareFile 对比单个文件与服务器路径标识根目录下的同名文件
+func (t *target) compareFile(ctx context.Context, path string, size int64) (*uploader.Diff, error) {
+	remote, err := uploader.ListRemote(ctx, t.client(), t.PathKey, "", t.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(path)
+	var matched []uploader.RemoteFile
+	for _, f := range remote {
+		if f.Path == name {
+			matched = append(matched, f)
+		}
+	}
+	diff, err := uploader.Compare([]uploader.FileToUpload{{AbsPath: path, RelPath: name, Size: size}}, matched)
+	if err != nil {
+		return nil, &configError{err: err}
+	}
+	return diff, nil
+}
+
+// ============= info =============
+
+// cmdInfo 输出接收端版本、安全模式和路径标识
+func cmdInfo(args []string) int {
+	fs := newFlagSet("info", "[--profile NAME] [--server URL]")
+	opts := addOptions(fs, false)
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	t, err := opts.resolve()
+	if err != nil {
+		return fail(*opts.jsonOut, err)
+	}
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	info, err := uploader.GetServerInfo(ctx, t.client())
+	if err != nil {
+		return fail(*opts.jsonOut, &uploader.NetworkError{Err: err})
+	}
+	if *opts.jsonOut {
+		printJSON(info)
+		return exitOK
+	}
+
+	fmt.Printf("服务器:   %s\n", t.Server)
+	fmt.Printf("服务:     %v %v\n", info["service"], info["version"])
+	fmt.Printf("状态:     %v\n", info["status"])
+	fmt.Printf("安全认证: %v\n", info["security"])
+	if paths, ok := info["paths"].([]interface{}); ok {
+		keys := make([]string, 0, len(paths))
+		for _, p := range paths {
+			keys = append(keys, fmt.Sprint(p))
+		}
+		sort.Strings(keys)
+		fmt.Printf("路径标识: %s\n", strings.Join(keys, ", "))
+	}
+	return exitOK
+}
+
+// ============= health =============
+
+// HealthReport 一台服务器的检查结果
+type HealthReport struct {
+	Profile   string `json:"profile,omitempty"`
+	Server    string `json:"server"`
+	Reachable bool   `json:"reachable"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// cmdHealth 检查接收端是否可达，--all 时检查配置文件中的所有 profile
+func cmdHealth(args []string) int {
+	fs := newFlagSet("health", "[--profile NAME [--server URL] | --all]")
+	opts := addOptions(fs, false)
+	all := fs.Bool("all", false, "检查配置文件中的所有 profile")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	profiles := []string{*opts.profile}
+	if *all {
+		// 每个 profile 检查自己的服务器，--server 会让所有 profile 检查同一个地址
+		if *opts.server != "" {
+			fmt.Fprintln(os.Stderr, "错误: --all 不能与 --server 同时使用")
+			return exitUsage
+		}
+		opts.perProfile = true
+		names, err := opts.profileNames()
+		if err != nil {
+			return fail(*opts.jsonOut, err)
+		}
+		profiles = names
+	}
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	var reports []HealthReport
+	code := exitOK
+	for _, name := range profiles {
+		*opts.profile = name
+		t, err := opts.resolve()
+		if err != nil {
+			return fail(*opts.jsonOut, err)
+		}
+
+		report := HealthReport{Profile: t.Profile, Server: t.Server}
+		started := time.Now()
+		err = uploader.TestConnection(ctx, t.client())
+		report.LatencyMs = time.Since(started).Milliseconds()
+		if err != nil {
+			report.Error = err.Error()
+			if code == exitOK {
+				code = exitNetwork
+			}
+		} else {
+			report.Reachable = true
+		}
+		reports = append(reports, report)
+	}
+
+	if *opts.jsonOut {
+		printJSON(reports)
+		return code
+	}
+	for _, r := range reports {
+		name := r.Server
+		if r.Profile != "" {
+			name = r.Profile + " (" + r.Server + ")"
+		}
+		if r.Reachable {
+			fmt.Printf("%s: 正常 (%d ms)\n", name, r.LatencyMs)
+		} else {
+			fmt.Printf("%s: 不可达: %s\n", name, r.Error)
+		}
+	}
+	return code
+}
+
+// ============= keygen =============
+
+// KeygenReport 生成的密钥对，私钥写入文件时不输出私钥
+type KeygenReport struct {
+	PublicKey      string `json:"publicKey"`
+	PrivateKey     string `json:"privateKey,omitempty"`
+	PrivateKeyFile string `json:"privateKeyFile,omitempty"`
+	Fingerprint    string `json:"fingerprint"`
+}
+
+// cmdKeygen 生成 Ed25519 密钥对，公钥放到接收端 config.json 的 security.public_key
+func cmdKeygen(args []string) int {
+	fs := newFlagSet("keygen", "[--out FILE] [--json]")
+	out := fs.String("out", "", "把私钥写入该文件 (权限 0600)，不在输出中显示私钥")
+	force := fs.Bool("force", false, "覆盖已存在的私钥文件")
+	jsonOut := fs.Bool("json", false, "以 JSON 输出结果")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+
+	privateKey, publicKey, err := crypto.GenerateKeyPair()
+	if err != nil {
+		return fail(*jsonOut, err)
+	}
+	fingerprint, _ := crypto.Fingerprint(publicKey)
+	report := KeygenReport{PublicKey: publicKey, Fingerprint: fingerprint}
+
+	if *out != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if !*force {
+			flags |= os.O_EXCL
+		}
+		f, err := os.OpenFile(*out, flags, 0600)
+		if err != nil {
+			return fail(*jsonOut, &configError{err: err})
+		}
+		_, err = f.WriteString(privateKey + "\n")
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return fail(*jsonOut, &configError{err: err})
+		}
+		report.PrivateKeyFile = *out
+	} else {
+		report.PrivateKey = privateKey
+	}
+
+	if *jsonOut {
+		printJSON(report)
+		return exitOK
+	}
+	fmt.Println("公钥 (放到接收端 config.json 的 security.public_key):")
+	fmt.Println(publicKey)
+	fmt.Println()
+	if *out != "" {
+		fmt.Printf("私钥已写入: %s\n", *out)
+	} else {
+		fmt.Println("私钥 (只保存在本机，不要上传到服务器):")
+		fmt.Println(privateKey)
+	}
+	fmt.Printf("\n指纹: %s\n", fingerprint)
+	return exitOK
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"client-gui/internal/crypto"
+	"client-gui/internal/uploader"
+)
+
+// Config 配置文件 (JSON)
+//
+//	{
+//	  "default": "prod",
+//	  "profiles": {
+//	    "prod": {"server": "https://deploy.example.com", "private_key_file": "~/.deploy/prod.key", "path_key": "web"}
+//	  }
+//	}
+type Config struct {
+	Default  string             `json:"default"`  // 未指定 profile 时使用
+	Profiles map[string]Profile `json:"profiles"` // 按名称
+}
+
+// Profile 一台服务器的连接和上传设置
+type Profile struct {
+	Server         string                `json:"server"`
+	PrivateKey     string                `json:"private_key"`      // 私钥 (十六进制)，建议改用 private_key_file
+	PrivateKeyFile string                `json:"private_key_file"` // 私钥文件，相对路径基于配置文件所在目录
+	PathKey        string                `json:"path_key"`         // 默认路径标识
+	Excludes       []string              `json:"excludes"`         // 文件夹上传的排除规则，追加在 .deployignore 之后
+	Workers        int                   `json:"workers"`          // 文件夹并发上传数，0 表示默认
+	Retries        *int                  `json:"retries"`          // 可重试的失败 (网络错误等) 的重试次数，不设置时为 2，0 表示不重试
+	CACertFile     string                `json:"ca_cert_file"`     // 额外信任的 CA 证书文件
+	Conn           uploader.ConnSettings `json:"conn"`             // 限速、代理、TLS、超时和请求头，与图形界面的连接设置相同
+}
+
+// defaultRetries 未配置时的重试次数
+const defaultRetries = 2
+
+// configPaths 按顺序查找的配置文件
+func configPaths() []string {
+	paths := []string{"deploy.json"}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "deploy-receiver", "deploy.json"))
+	}
+	return paths
+}
+
+// loadConfig 读取配置文件，path 为空时按默认位置查找，都不存在时返回空配置
+func loadConfig(path string) (*Config, string, error) {
+	explicit := path != ""
+	if !explicit {
+		for _, p := range configPaths() {
+			if _, err := os.Stat(p); err == nil {
+				path = p
+				break
+			}
+		}
+		if path == "" {
+			return &Config{}, "", nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", configErrorf("无法读取配置文件: %v", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, "", configErrorf("配置文件格式错误 (%s): %v", path, err)
+	}
+	return &cfg, path, nil
+}
+
+// options 各命令共用的参数
+type options struct {
+	config  *string
+	profile *string
+	server  *string
+	key     *string
+	keyFile *string
+	pathKey *string
+	jsonOut *bool
+
+	perProfile bool // 逐个解析配置文件中的 profile (health --all)，不使用 DEPLOY_SERVER 覆盖服务器
+}
+
+// addOptions 注册共用参数，withPath 为 false 的命令不需要路径标识
+func addOptions(fs *flag.FlagSet, withPath bool) *options {
+	o := &options{
+		config:  fs.String("config", "", "配置文件 (默认读取 DEPLOY_CONFIG)"),
+		profile: fs.String("profile", "", "使用的 profile (默认读取 DEPLOY_PROFILE 或配置文件的 default)"),
+		server:  fs.String("server", "", "服务器地址，覆盖 profile"),
+		key:     fs.String("key", "", "Ed25519 私钥 (十六进制)，建议使用 --key-file 或环境变量"),
+		keyFile: fs.String("key-file", "", "私钥文件"),
+		jsonOut: fs.Bool("json", false, "以 JSON 输出结果"),
+	}
+	if withPath {
+		o.pathKey = fs.String("path", "", "服务器上的路径标识，覆盖 profile")
+	}
+	return o
+}
+
+// target 确定后的服务器、私钥和上传设置
+type target struct {
+	Profile    string
+	Server     string
+	PrivateKey string
+	PathKey    string
+	Excludes   []string
+	Workers    int
+	Retries    int
+	Conn       uploader.ConnSettings
+}
+
+// client 发往该服务器的请求使用的客户端
+func (t *target) client() *uploader.Client {
+	return uploader.ClientFor(t.Server, t.Conn)
+}
+
+// resolve 按 命令行参数 > 环境变量 > profile 确定服务器和私钥
+func (o *options) resolve() (*target, error) {
+	configPath := first(*o.config, os.Getenv("DEPLOY_CONFIG"))
+	cfg, configPath, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &target{Profile: first(*o.profile, os.Getenv("DEPLOY_PROFILE"), cfg.Default), Retries: defaultRetries}
+	var p Profile
+	if t.Profile != "" {
+		var ok bool
+		if p, ok = cfg.Profiles[t.Profile]; !ok {
+			return nil, configErrorf("profile 不存在: %s", t.Profile)
+		}
+		if p.Retries != nil {
+			if *p.Retries < 0 {
+				return nil, configErrorf("profile %s 的 retries 不能为负数", t.Profile)
+			}
+			t.Retries = *p.Retries
+		}
+		t.Excludes, t.Workers, t.Conn = p.Excludes, p.Workers, p.Conn
+	}
+	baseDir := filepath.Dir(configPath)
+
+	serverEnv := os.Getenv("DEPLOY_SERVER")
+	if o.perProfile {
+		serverEnv = ""
+	}
+	t.Server = strings.TrimRight(first(*o.server, serverEnv, p.Server), "/")
+	if t.Server == "" {
+		return nil, configErrorf("未指定服务器，请使用 --server、DEPLOY_SERVER 或配置文件中的 profile")
+	}
+	if !strings.HasPrefix(t.Server, "http://") && !strings.HasPrefix(t.Server, "https://") {
+		return nil, configErrorf("服务器地址必须以 http:// 或 https:// 开头: %s", t.Server)
+	}
+	if o.pathKey != nil {
+		t.PathKey = first(*o.pathKey, os.Getenv("DEPLOY_PATH_KEY"), p.PathKey)
+	}
+
+	// 命令行的私钥优先于环境变量，环境变量优先于 profile；同一来源中私钥优先于私钥文件
+	switch {
+	case *o.key != "" || *o.keyFile != "":
+		t.PrivateKey, err = readKey(*o.key, *o.keyFile, "")
+	case os.Getenv("DEPLOY_PRIVATE_KEY") != "" || os.Getenv("DEPLOY_PRIVATE_KEY_FILE") != "":
+		t.PrivateKey, err = readKey(os.Getenv("DEPLOY_PRIVATE_KEY"), os.Getenv("DEPLOY_PRIVATE_KEY_FILE"), "")
+	default:
+		t.PrivateKey, err = readKey(p.PrivateKey, p.PrivateKeyFile, baseDir)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.CACertFile != "" {
+		data, err := os.ReadFile(resolvePath(p.CACertFile, baseDir))
+		if err != nil {
+			return nil, configErrorf("无法读取 CA 证书: %v", err)
+		}
+		t.Conn.CACert = string(data)
+	}
+	if err := uploader.ValidateConn(t.Conn); err != nil {
+		return nil, configErrorf("profile %s 的连接设置无效: %v", t.Profile, err)
+	}
+	return t, nil
+}
+
+// requirePath 需要路径标识的命令检查是否已指定
+func (t *target) requirePath() error {
+	if t.PathKey == "" {
+		return configErrorf("未指定路径标识，请使用 --path、DEPLOY_PATH_KEY 或 profile 的 path_key")
+	}
+	return nil
+}
+
+// readKey 读取私钥并检查格式，都为空时返回空字符串 (接收端未开启安全认证)
+func readKey(key, keyFile, baseDir string) (string, error) {
+	if key == "" && keyFile != "" {
+		data, err := os.ReadFile(resolvePath(keyFile, baseDir))
+		if err != nil {
+			return "", configErrorf("无法读取私钥文件: %v", err)
+		}
+		key = string(data)
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", nil
+	}
+	if _, err := crypto.GetPublicKeyFromPrivate(key); err != nil {
+		return "", &configError{err: err}
+	}
+	return key, nil
+}
+
+// resolvePath 展开 ~ 并把相对路径解析为相对于 baseDir
+func resolvePath(p, baseDir string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = filepath.Join(home, p[1:])
+		}
+	}
+	if baseDir != "" && !filepath.IsAbs(p) {
+		p = filepath.Join(baseDir, p)
+	}
+	return p
+}
+
+// profileNames 配置文件中的所有 profile，按名称排序
+func (o *options) profileNames() ([]string, error) {
+	cfg, _, err := loadConfig(first(*o.config, os.Getenv("DEPLOY_CONFIG")))
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Profiles) == 0 {
+		return nil, configErrorf("配置文件中没有 profile")
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// first 返回第一个非空字符串
+func first(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// formatSize 以易读的单位显示字节数
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
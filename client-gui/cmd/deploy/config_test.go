@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"client-gui/internal/crypto"
+)
+
+// deployEnv 测试中清空的环境变量，避免受运行环境影响
+var deployEnv = []string{
+	"DEPLOY_CONFIG", "DEPLOY_PROFILE", "DEPLOY_SERVER",
+	"DEPLOY_PRIVATE_KEY", "DEPLOY_PRIVATE_KEY_FILE", "DEPLOY_PATH_KEY",
+}
+
+// writeConfig 在临时目录写入配置文件和 prod 的私钥文件，返回配置文件路径和两个私钥
+func writeConfig(t *testing.T) (path, prodKey, otherKey string) {
+	t.Helper()
+	for _, name := range deployEnv {
+		t.Setenv(name, "")
+	}
+	dir := t.TempDir()
+	// 用户配置目录指向临时目录，未指定配置文件时不会读到本机的配置
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("APPDATA", dir)
+	prodKey, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _, err = crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "prod.key"), []byte(prodKey+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	config := `{
+		"default": "prod",
+		"profiles": {
+			"prod": {"server": "https://prod.example.com/", "private_key_file": "prod.key", "path_key": "web", "workers": 4, "excludes": ["*.map"]},
+			"staging": {"server": "http://staging.example.com", "path_key": "api", "retries": 0},
+			"negative": {"server": "http://x.example.com", "retries": -1},
+			"badconn": {"server": "http://x.example.com", "conn": {"timeout": -1}}
+		}
+	}`
+	path = filepath.Join(dir, "deploy.json")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path, prodKey, otherKey
+}
+
+// parseOptions 像子命令一样解析共用参数
+func parseOptions(t *testing.T, args ...string) *options {
+	t.Helper()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	opts := addOptions(fs, true)
+	if err := fs.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return opts
+}
+
+func TestResolvePrecedence(t *testing.T) {
+	configPath, prodKey, otherKey := writeConfig(t)
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		server  string
+		pathKey string
+		key     string
+		retries int
+	}{
+		{"default profile", nil, []string{"--config", configPath},
+			"https://prod.example.com", "web", prodKey, defaultRetries},
+		{"config from env", map[string]string{"DEPLOY_CONFIG": configPath}, nil,
+			"https://prod.example.com", "web", prodKey, defaultRetries},
+		{"profile from env", map[string]string{"DEPLOY_CONFIG": configPath, "DEPLOY_PROFILE": "staging"}, nil,
+			"http://staging.example.com", "api", "", 0},
+		{"flag profile over env", map[string]string{"DEPLOY_CONFIG": configPath, "DEPLOY_PROFILE": "staging"}, []string{"--profile", "prod"},
+			"https://prod.example.com", "web", prodKey, defaultRetries},
+		{"env over profile", map[string]string{"DEPLOY_CONFIG": configPath, "DEPLOY_SERVER": "http://env.example.com", "DEPLOY_PATH_KEY": "env", "DEPLOY_PRIVATE_KEY": otherKey}, nil,
+			"http://env.example.com", "env", otherKey, defaultRetries},
+		{"flags over env", map[string]string{"DEPLOY_CONFIG": configPath, "DEPLOY_SERVER": "http://env.example.com", "DEPLOY_PATH_KEY": "env", "DEPLOY_PRIVATE_KEY": otherKey},
+			[]string{"--server", "http://flag.example.com/", "--path", "flag", "--key", prodKey},
+			"http://flag.example.com", "flag", prodKey, defaultRetries},
+		{"no config", nil, []string{"--server", "http://flag.example.com", "--path", "web"},
+			"http://flag.example.com", "web", "", defaultRetries},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			target, err := parseOptions(t, tt.args...).resolve()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target.Server != tt.server || target.PathKey != tt.pathKey || target.PrivateKey != tt.key || target.Retries != tt.retries {
+				t.Errorf("target = server %s path %s retries %d key match %v, want %s %s %d",
+					target.Server, target.PathKey, target.Retries, target.PrivateKey == tt.key, tt.server, tt.pathKey, tt.retries)
+			}
+		})
+	}
+
+	// profile 的上传设置随 profile 一起生效
+	target, err := parseOptions(t, "--config", configPath).resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target.Workers != 4 || len(target.Excludes) != 1 || target.Excludes[0] != "*.map" {
+		t.Errorf("profile settings = workers %d excludes %v", target.Workers, target.Excludes)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	configPath, _, _ := writeConfig(t)
+	keyDir := t.TempDir()
+	badKey := filepath.Join(keyDir, "bad.key")
+	if err := os.WriteFile(badKey, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	badJSON := filepath.Join(keyDir, "deploy.json")
+	if err := os.WriteFile(badJSON, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown profile", []string{"--config", configPath, "--profile", "missing"}},
+		{"missing config file", []string{"--config", filepath.Join(keyDir, "missing.json")}},
+		{"invalid config file", []string{"--config", badJSON}},
+		{"no server", []string{"--path", "web"}},
+		{"server without scheme", []string{"--server", "deploy.example.com"}},
+		{"negative retries", []string{"--config", configPath, "--profile", "negative"}},
+		{"invalid connection settings", []string{"--config", configPath, "--profile", "badconn"}},
+		{"invalid key", []string{"--config", configPath, "--key", "abc"}},
+		{"missing key file", []string{"--config", configPath, "--key-file", filepath.Join(keyDir, "missing.key")}},
+		{"invalid key file", []string{"--config", configPath, "--key-file", badKey}},
+	}
+	for _, tt := range tests {
+		_, err := parseOptions(t, tt.args...).resolve()
+		var ce *configError
+		if !errors.As(err, &ce) || exitCode(err) != exitConfig {
+			t.Errorf("%s: err = %v, want a config error", tt.name, err)
+		}
+	}
+}
+
+func TestResolvePath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	base := filepath.Join("etc", "deploy")
+	abs, _ := filepath.Abs("key")
+	tests := []struct {
+		path, baseDir, want string
+	}{
+		{"prod.key", base, filepath.Join(base, "prod.key")},
+		{"prod.key", "", "prod.key"},
+		{abs, base, abs},
+		{"~", base, home},
+		{"~/keys/prod.key", base, filepath.Join(home, "keys", "prod.key")},
+		{"~user/prod.key", "", "~user/prod.key"},
+	}
+	for _, tt := range tests {
+		if got := resolvePath(tt.path, tt.baseDir); got != tt.want {
+			t.Errorf("resolvePath(%q, %q) = %q, want %q", tt.path, tt.baseDir, got, tt.want)
+		}
+	}
+}
+
+func TestFirst(t *testing.T) {
+	if got := first("", "b", "c"); got != "b" {
+		t.Errorf("first = %q, want b", got)
+	}
+	if got := first("", ""); got != "" {
+		t.Errorf("first of empty values = %q", got)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{5 << 20, "5.0 MiB"},
+		{3 << 30, "3.0 GiB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
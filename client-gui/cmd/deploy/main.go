@@ -0,0 +1,210 @@
+// deploy 跨平台命令行客户端，与图形界面共用签名和上传逻辑，适合 Jenkins 等 CI 环境
+//
+// 不使用图形界面的数据库，服务器和私钥来自配置文件中的 profile、环境变量和命令行参数。
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"client-gui/internal/uploader"
+)
+
+const cliUsage = `用法: deploy <命令> [参数]
+
+命令:
+  upload   上传文件或文件夹 (文件夹并发上传或打包为 zip 上传)
+  sync     只上传与服务器上内容不同的文件 (按 SHA-256 对比)
+  verify   检查服务器上的文件是否与本地一致
+  info     查看接收端版本、安全模式和路径标识
+  health   检查接收端是否可达
+  keygen   生成 Ed25519 密钥对
+  help     显示帮助
+
+使用 "deploy <命令> -h" 查看命令的参数。
+
+服务器和私钥按 命令行参数 > 环境变量 > 配置文件中的 profile 的顺序确定:
+  DEPLOY_CONFIG            配置文件 (默认 ./deploy.json，其次为用户配置目录下的 deploy-receiver/deploy.json)
+  DEPLOY_PROFILE           使用的 profile
+  DEPLOY_SERVER            服务器地址
+  DEPLOY_PRIVATE_KEY       Ed25519 私钥 (十六进制)
+  DEPLOY_PRIVATE_KEY_FILE  私钥文件
+  DEPLOY_PATH_KEY          路径标识
+
+退出码:
+  0    成功
+  1    上传失败或部分文件失败
+  2    参数错误
+  3    配置、私钥或本地文件错误
+  4    认证失败 (签名、时间戳、IP 白名单)
+  5    无法连接服务器或请求超时
+  6    verify 发现差异
+  130  被中断
+`
+
+// 退出码 (CI 脚本依赖这些值，只增不改)
+const (
+	exitOK       = 0
+	exitFailed   = 1
+	exitUsage    = 2
+	exitConfig   = 3
+	exitAuth     = 4
+	exitNetwork  = 5
+	exitMismatch = 6
+	exitCanceled = 130
+)
+
+// commands 子命令
+var commands = map[string]func(args []string) int{
+	"upload": cmdUpload,
+	"sync":   cmdSync,
+	"verify": cmdVerify,
+	"info":   cmdInfo,
+	"health": cmdHealth,
+	"keygen": cmdKeygen,
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, cliUsage)
+		os.Exit(exitUsage)
+	}
+	switch os.Args[1] {
+	case "help", "-h", "--help":
+		fmt.Print(cliUsage)
+		return
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令: %s\n\n%s", os.Args[1], cliUsage)
+		os.Exit(exitUsage)
+	}
+	os.Exit(cmd(os.Args[2:]))
+}
+
+// newFlagSet 创建子命令的参数解析，-h 时输出用法
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: deploy %s %s\n\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFlags 解析参数，参数可以出现在路径之后，-h 返回 0，参数错误返回 exitUsage，成功返回 -1
+func parseFlags(fs *flag.FlagSet, args []string) int {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return exitOK
+			}
+			return exitUsage
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			break
+		}
+		// "--" 之后的都是路径
+		if len(args) > len(rest) && args[len(args)-len(rest)-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	fs.Parse(append([]string{"--"}, positional...))
+	return -1
+}
+
+// listFlag 可重复的字符串参数
+type listFlag []string
+
+func (l *listFlag) String() string { return fmt.Sprint(*l) }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// signalContext 收到 Ctrl+C 或终止信号时取消的 context
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// configError 配置、私钥或本地文件错误，退出码为 exitConfig
+type configError struct {
+	err error
+}
+
+func (e *configError) Error() string { return e.err.Error() }
+
+func (e *configError) Unwrap() error { return e.err }
+
+// configErrorf 构造 configError
+func configErrorf(format string, args ...interface{}) error {
+	return &configError{err: fmt.Errorf(format, args...)}
+}
+
+// exitCode 错误对应的退出码
+func exitCode(err error) int {
+	var ce *configError
+	var ne *uploader.NetworkError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, uploader.ErrCanceled), errors.Is(err, context.Canceled):
+		return exitCanceled
+	case errors.As(err, &ce):
+		return exitConfig
+	case uploader.IsAuthError(err):
+		return exitAuth
+	case errors.As(err, &ne), errors.Is(err, context.DeadlineExceeded):
+		return exitNetwork
+	}
+	return exitFailed
+}
+
+// resultCode 上传结果对应的退出码
+func resultCode(r *uploader.UploadResult) int {
+	switch {
+	case r.Success:
+		return exitOK
+	case r.Canceled:
+		return exitCanceled
+	}
+	return exitCode(r.Err())
+}
+
+// printJSON 以缩进格式输出 JSON
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+// errorOutput 以 JSON 输出时的错误结果
+type errorOutput struct {
+	Success  bool   `json:"success"`
+	Error    string `json:"error"`
+	ExitCode int    `json:"exitCode"`
+}
+
+// fail 输出错误并返回对应的退出码，jsonOut 时同时在标准输出输出 JSON
+func fail(jsonOut bool, err error) int {
+	code := exitCode(err)
+	fmt.Fprintln(os.Stderr, "错误:", err)
+	if jsonOut {
+		printJSON(errorOutput{Success: false, Error: err.Error(), ExitCode: code})
+	}
+	return code
+}
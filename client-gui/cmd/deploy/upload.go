@@ -0,0 +1,437 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"client-gui/internal/ignore"
+	"client-gui/internal/uploader"
+)
+
+// retryDelay 第一次重试前的等待时间，之后每次加倍
+const retryDelay = 2 * time.Second
+
+// UploadReport 一个文件或文件夹的上传结果
+type UploadReport struct {
+	Path       string       `json:"path"`
+	Server     string       `json:"server"`
+	PathKey    string       `json:"pathKey"`
+	Success    bool         `json:"success"`
+	Files      int          `json:"files"`     // 上传的文件数 (打包上传时为压缩包中的文件数)
+	Succeeded  int          `json:"succeeded"` // 成功的文件数
+	Size       int64        `json:"size"`
+	DurationMs int64        `json:"durationMs"`
+	SHA256     string       `json:"sha256,omitempty"` // 单个文件或压缩包的 SHA-256
+	Verified   bool         `json:"verified"`         // 所有文件都与接收端计算的哈希一致
+	Error      string       `json:"error,omitempty"`
+	Code       string       `json:"code,omitempty"` // 接收端错误码
+	RequestID  string       `json:"requestId,omitempty"`
+	Failed     []FileReport `json:"failed,omitempty"`
+	ExitCode   int          `json:"exitCode"`
+}
+
+// FileReport 文件夹上传中失败的文件
+type FileReport struct {
+	RelPath string `json:"relPath"`
+	Error   string `json:"error"`
+	Code    string `json:"code,omitempty"`
+}
+
+// ============= upload =============
+
+// cmdUpload 上传文件或文件夹，多个路径依次上传，退出码取第一个失败的路径
+func cmdUpload(args []string) int {
+	fs := newFlagSet("upload", "[--profile NAME] [--path KEY] [--extract] [--zip] PATH...")
+	opts := addOptions(fs, true)
+	extract := fs.Bool("extract", false, "上传 zip 文件后由接收端解压")
+	zipFolder := fs.Bool("zip", false, "文件夹打包为一个 zip 边压缩边上传，由接收端解压到路径根目录")
+	workers := fs.Int("workers", 0, "文件夹并发上传数，覆盖 profile")
+	retries := fs.Int("retries", -1, "可重试的失败 (网络错误等) 的重试次数，覆盖 profile")
+	var excludes listFlag
+	fs.Var(&excludes, "exclude", "排除规则 (.deployignore 语法)，可重复")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	t, err := opts.resolve()
+	if err == nil {
+		err = t.requirePath()
+	}
+	if err != nil {
+		return fail(*opts.jsonOut, err)
+	}
+	if *workers > 0 {
+		t.Workers = *workers
+	}
+	if *retries >= 0 {
+		t.Retries = *retries
+	}
+	t.Excludes = append(t.Excludes, excludes...)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	reports := make([]*UploadReport, 0, fs.NArg())
+	code := exitOK
+	for _, path := range fs.Args() {
+		report := t.upload(ctx, path, *extract, *zipFolder, !*opts.jsonOut)
+		reports = append(reports, report)
+		if code == exitOK {
+			code = report.ExitCode
+		}
+		if !*opts.jsonOut {
+			printReport(report)
+		}
+	}
+	if *opts.jsonOut {
+		printJSON(reports)
+	}
+	return code
+}
+
+// upload 上传一个文件或文件夹
+func (t *target) upload(ctx context.Context, path string, extract, zipFolder, verbose bool) *UploadReport {
+	report := &UploadReport{Path: path, Server: t.Server, PathKey: t.PathKey}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return report.fail(configErrorf("无法获取路径信息: %v", err))
+	}
+	started := time.Now()
+	defer func() { report.DurationMs = time.Since(started).Milliseconds() }()
+
+	if !info.IsDir() {
+		result := t.retry(ctx, path, verbose, func() *uploader.UploadResult {
+			r, err := uploader.UploadFile(ctx, t.client(), t.PathKey, path, t.PrivateKey, extract, nil)
+			if err != nil {
+				return uploader.FailedResult(err)
+			}
+			return r
+		})
+		report.Files, report.Size = 1, info.Size()
+		report.single(result)
+		return report
+	}
+
+	files, err := t.listFolder(path)
+	if err != nil {
+		return report.fail(err)
+	}
+	if len(files) == 0 {
+		return report.fail(configErrorf("文件夹为空: %s", path))
+	}
+	report.Files = len(files)
+	for _, f := range files {
+		report.Size += f.Size
+	}
+
+	if zipFolder {
+		name := filepath.Base(path)
+		result := t.retry(ctx, name+".zip", verbose, func() *uploader.UploadResult {
+			r, err := uploader.UploadArchive(ctx, t.client(), t.PathKey, name, files, t.PrivateKey, nil)
+			if err != nil {
+				return uploader.FailedResult(err)
+			}
+			return r
+		})
+		report.single(result)
+		if result.Success {
+			report.Succeeded = len(files)
+		}
+		return report
+	}
+
+	report.batch(t.uploadBatch(ctx, files, verbose))
+	return report
+}
+
+// listFolder 列出文件夹中要上传的文件，遵循 .deployignore 和 profile 的排除规则
+func (t *target) listFolder(dir string) ([]uploader.FileToUpload, error) {
+	exclude, err := t.matcher(dir)
+	if err != nil {
+		return nil, err
+	}
+	return listFiles(dir, exclude)
+}
+
+// matcher 文件夹的 .deployignore 加上 profile 和命令行的排除规则
+func (t *target) matcher(dir string) (*ignore.Matcher, error) {
+	exclude, err := ignore.Load(dir, t.Excludes)
+	if err != nil {
+		return nil, &configError{err: err}
+	}
+	return exclude, nil
+}
+
+// listFiles 列出文件夹中未被排除的文件
+func listFiles(dir string, exclude *ignore.Matcher) ([]uploader.FileToUpload, error) {
+	files, err := uploader.ListFilesInDir(dir, exclude)
+	if err != nil {
+		return nil, configErrorf("列出文件失败: %v", err)
+	}
+	return files, nil
+}
+
+// uploadBatch 并发上传文件，可重试的失败文件在整批结束后重新上传，最多 Retries 轮
+func (t *target) uploadBatch(ctx context.Context, files []uploader.FileToUpload, verbose bool) []uploader.BatchResult {
+	results := uploader.UploadBatch(ctx, t.client(), t.PathKey, files, t.PrivateKey, uploader.BatchOptions{Workers: t.Workers})
+
+	for attempt := 1; attempt <= t.Retries; attempt++ {
+		var pending []int
+		for i, r := range results {
+			if !r.Result.Success && uploader.IsRetryable(r.Result.Err()) {
+				pending = append(pending, i)
+			}
+		}
+		if len(pending) == 0 || !sleep(ctx, retryDelay<<(attempt-1)) {
+			break
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "重试 %d 个文件 (%d/%d)\n", len(pending), attempt, t.Retries)
+		}
+
+		retryFiles := make([]uploader.FileToUpload, len(pending))
+		for j, i := range pending {
+			retryFiles[j] = files[i]
+		}
+		retried := uploader.UploadBatch(ctx, t.client(), t.PathKey, retryFiles, t.PrivateKey, uploader.BatchOptions{Workers: t.Workers})
+		for j, i := range pending {
+			results[i] = retried[j]
+		}
+	}
+	return results
+}
+
+// retry 上传一次，可重试的失败按 retryDelay 加倍等待后重试
+func (t *target) retry(ctx context.Context, name string, verbose bool, upload func() *uploader.UploadResult) *uploader.UploadResult {
+	result := upload()
+	for attempt := 1; attempt <= t.Retries; attempt++ {
+		if result.Success || !uploader.IsRetryable(result.Err()) {
+			break
+		}
+		if verbose {
+			fmt.Fprintf(os.Stderr, "%s: %s，重试 (%d/%d)\n", name, result.Error, attempt, t.Retries)
+		}
+		if !sleep(ctx, retryDelay<<(attempt-1)) {
+			break
+		}
+		result = upload()
+	}
+	return result
+}
+
+// sleep 等待 d，ctx 取消时返回 false
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// fail 记录上传前的错误
+func (r *UploadReport) fail(err error) *UploadReport {
+	r.Error = err.Error()
+	r.ExitCode = exitCode(err)
+	return r
+}
+
+// single 记录单个请求 (文件或压缩包) 的结果
+func (r *UploadReport) single(result *uploader.UploadResult) {
+	r.Success = result.Success
+	r.SHA256 = result.SHA256
+	r.Verified = result.Verified
+	r.Error = result.Error
+	r.Code = result.Code
+	r.RequestID = result.RequestID
+	r.ExitCode = resultCode(result)
+	if result.Success {
+		r.Succeeded = 1
+	}
+}
+
+// batch 汇总文件夹逐个上传的结果，退出码取第一个失败的文件
+func (r *UploadReport) batch(results []uploader.BatchResult) {
+	r.Verified = true
+	for _, br := range results {
+		if br.Result.Success {
+			r.Succeeded++
+			r.Verified = r.Verified && br.Result.Verified
+			continue
+		}
+		r.Verified = false
+		r.Failed = append(r.Failed, FileReport{RelPath: br.File.RelPath, Error: br.Result.Error, Code: br.Result.Code})
+		if r.ExitCode == exitOK {
+			r.ExitCode = resultCode(br.Result)
+		}
+	}
+	r.Success = len(r.Failed) == 0
+	if !r.Success {
+		r.Error = fmt.Sprintf("%d 个文件失败，首个错误: %s", len(r.Failed), r.Failed[0].Error)
+	}
+}
+
+// printReport 以文本输出上传结果
+func printReport(r *UploadReport) {
+	status := "成功"
+	if !r.Success {
+		status = "失败"
+	}
+	fmt.Printf("%s -> %s/%s: %s %d/%d 个文件 (%s, %d ms)\n", r.Path, r.Server, r.PathKey, status, r.Succeeded, r.Files, formatSize(r.Size), r.DurationMs)
+	if r.Verified && r.SHA256 != "" {
+		fmt.Printf("  SHA-256: %s (已与服务器校验)\n", r.SHA256)
+	}
+	for _, f := range r.Failed {
+		fmt.Printf("  失败: %s: %s\n", f.RelPath, f.Error)
+	}
+	if r.Error != "" && len(r.Failed) == 0 {
+		fmt.Printf("  错误: %s\n", r.Error)
+	}
+}
+
+// ============= sync =============
+
+// SyncReport sync 的结果
+type SyncReport struct {
+	Path    string         `json:"path"`
+	Server  string         `json:"server"`
+	PathKey string         `json:"pathKey"`
+	DryRun  bool           `json:"dryRun"`
+	Diff    *uploader.Diff `json:"diff"`
+	Upload  *UploadReport  `json:"upload,omitempty"`
+}
+
+// cmdSync 对比本地文件夹与服务器上路径标识根目录的内容，只上传新增和修改的文件
+func cmdSync(args []string) int {
+	fs := newFlagSet("sync", "[--profile NAME] [--path KEY] [--dry-run] FOLDER")
+	opts := addOptions(fs, true)
+	dryRun := fs.Bool("dry-run", false, "只列出需要上传的文件")
+	workers := fs.Int("workers", 0, "并发上传数，覆盖 profile")
+	retries := fs.Int("retries", -1, "可重试的失败的重试次数，覆盖 profile")
+	var excludes listFlag
+	fs.Var(&excludes, "exclude", "排除规则 (.deployignore 语法)，可重复")
+	if code := parseFlags(fs, args); code >= 0 {
+		return code
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+	folder := fs.Arg(0)
+
+	t, err := opts.resolve()
+	if err == nil {
+		err = t.requirePath()
+	}
+	if err != nil {
+		return fail(*opts.jsonOut, err)
+	}
+	if *workers > 0 {
+		t.Workers = *workers
+	}
+	if *retries >= 0 {
+		t.Retries = *retries
+	}
+	t.Excludes = append(t.Excludes, excludes...)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	diff, err := t.compare(ctx, folder)
+	if err != nil {
+		return fail(*opts.jsonOut, err)
+	}
+	report := &SyncReport{Path: folder, Server: t.Server, PathKey: t.PathKey, DryRun: *dryRun, Diff: diff}
+	changed := diff.Changed()
+
+	code := exitOK
+	if !*dryRun && len(changed) > 0 {
+		var size int64
+		for _, f := range changed {
+			size += f.Size
+		}
+		started := time.Now()
+		report.Upload = &UploadReport{Path: folder, Server: t.Server, PathKey: t.PathKey, Files: len(changed), Size: size}
+		report.Upload.batch(t.uploadBatch(ctx, changed, !*opts.jsonOut))
+		report.Upload.DurationMs = time.Since(started).Milliseconds()
+		code = report.Upload.ExitCode
+	}
+
+	if *opts.jsonOut {
+		printJSON(report)
+		return code
+	}
+	fmt.Printf("%s -> %s/%s: 新增 %d, 修改 %d, 未变化 %d, 仅服务器上存在 %d\n",
+		folder, t.Server, t.PathKey, len(diff.Added), len(diff.Modified), len(diff.Unchanged), len(diff.Deleted))
+	if *dryRun {
+		printEntries("+", diff.Added)
+		printEntries("M", diff.Modified)
+	}
+	printHidden(diff)
+	if report.Upload != nil {
+		printReport(report.Upload)
+	}
+	return code
+}
+
+// compare 获取服务器文件列表并与本地文件夹对比
+func (t *target) compare(ctx context.Context, folder string) (*uploader.Diff, error) {
+	info, err := os.Stat(folder)
+	if err != nil {
+		return nil, configErrorf("无法获取路径信息: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, configErrorf("不是文件夹: %s", folder)
+	}
+	exclude, err := t.matcher(folder)
+	if err != nil {
+		return nil, err
+	}
+	local, err := listFiles(folder, exclude)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := uploader.ListRemote(ctx, t.client(), t.PathKey, "", t.PrivateKey)
+	if err != nil {
+		if errors.Is(err, uploader.ErrListUnsupported) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("获取服务器文件列表失败: %w", err)
+	}
+	// 被排除的文件不上传，也不报告为只在服务器上存在
+	diff, err := uploader.Compare(local, uploader.FilterRemote(remote, exclude))
+	if err != nil {
+		return nil, &configError{err: err}
+	}
+	return diff, nil
+}
+
+// printHidden 提示未对比的隐藏文件，接收端不接受这些文件
+func printHidden(diff *uploader.Diff) {
+	if len(diff.Hidden) > 0 {
+		fmt.Printf("跳过 %d 个隐藏文件 (以 . 开头，接收端不接受):\n", len(diff.Hidden))
+		printEntries("-", diff.Hidden)
+	}
+}
+
+// printEntries 逐行输出差异中的文件
+func printEntries(mark string, entries []uploader.DiffEntry) {
+	for _, e := range entries {
+		size := e.LocalSize
+		if e.File == nil {
+			size = e.RemoteSize
+		}
+		fmt.Printf("  %s %s (%s)\n", mark, e.RelPath, formatSize(size))
+	}
+}
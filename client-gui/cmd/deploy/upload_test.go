@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"client-gui/internal/uploader"
+)
+
+// fakeServer 模拟接收端的上传接口，按路径依次返回预设的错误，之后返回成功
+type fakeServer struct {
+	mu       sync.Mutex
+	failures map[string][]int // 相对路径 -> 依次返回的 HTTP 状态码
+	uploaded map[string]int   // 相对路径 -> 请求次数
+	remote   []uploader.RemoteFile
+}
+
+// errorCodes 预设状态码对应的接收端错误码，未列出的状态码只返回文本
+var errorCodes = map[int]string{
+	http.StatusForbidden: uploader.CodeAuthBadSignature,
+	http.StatusConflict:  uploader.CodePathReadOnly,
+}
+
+func newFakeServer(t *testing.T, failures map[string][]int) (*fakeServer, *target) {
+	t.Helper()
+	fs := &fakeServer{failures: failures, uploaded: make(map[string]int)}
+	srv := httptest.NewServer(fs)
+	t.Cleanup(srv.Close)
+	return fs, &target{Server: srv.URL, PathKey: "web"}
+}
+
+func (fs *fakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/list/web") {
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "ok", "files": fs.remote})
+		return
+	}
+	rel := strings.TrimPrefix(r.URL.Path, "/upload/web/")
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+
+	fs.mu.Lock()
+	fs.uploaded[rel]++
+	status := http.StatusOK
+	if queue := fs.failures[rel]; len(queue) > 0 {
+		status, fs.failures[rel] = queue[0], queue[1:]
+	}
+	fs.mu.Unlock()
+
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		if code := errorCodes[status]; code != "" {
+			json.NewEncoder(w).Encode(map[string]string{"status": "error", "code": code, "message": code})
+		}
+		return
+	}
+	sum := sha256.Sum256(body)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok", "path_key": "web", "filename": rel, "size": len(body), "sha256": hex.EncodeToString(sum[:]),
+	})
+}
+
+// requests 各路径收到的请求次数
+func (fs *fakeServer) requests() map[string]int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	out := make(map[string]int, len(fs.uploaded))
+	for k, v := range fs.uploaded {
+		out[k] = v
+	}
+	return out
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"app.zip": "zip content"})
+	file := filepath.Join(dir, "app.zip")
+
+	tests := []struct {
+		name     string
+		failures []int
+		retries  int
+		requests int
+		success  bool
+		exitCode int
+	}{
+		{"success", nil, 2, 1, true, exitOK},
+		{"retried after server error", []int{http.StatusServiceUnavailable}, 1, 2, true, exitOK},
+		{"retries disabled", []int{http.StatusServiceUnavailable}, 0, 1, false, exitFailed},
+		{"auth error is not retried", []int{http.StatusForbidden}, 2, 1, false, exitAuth},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs, target := newFakeServer(t, map[string][]int{"app.zip": tt.failures})
+			target.Retries = tt.retries
+
+			r := target.upload(context.Background(), file, false, false, false)
+			if r.Success != tt.success || r.ExitCode != tt.exitCode || r.Files != 1 || r.Size != int64(len("zip content")) {
+				t.Errorf("report = %+v", r)
+			}
+			if tt.success && (!r.Verified || r.SHA256 == "" || r.Succeeded != 1) {
+				t.Errorf("successful upload not verified: %+v", r)
+			}
+			if got := fs.requests()["app.zip"]; got != tt.requests {
+				t.Errorf("requests = %d, want %d", got, tt.requests)
+			}
+		})
+	}
+
+	_, target := newFakeServer(t, nil)
+	if r := target.upload(context.Background(), filepath.Join(dir, "missing.zip"), false, false, false); r.ExitCode != exitConfig {
+		t.Errorf("missing file: exit code = %d, want %d", r.ExitCode, exitConfig)
+	}
+}
+
+func TestUploadFolder(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".deployignore":  "*.log\n",
+		"index.html":     "<html>",
+		"assets/app.js":  "js",
+		"assets/app.map": "map",
+		"debug.log":      "log",
+	})
+
+	fs, target := newFakeServer(t, map[string][]int{"assets/app.js": {http.StatusConflict}})
+	target.Excludes = []string{"*.map"}
+	r := target.upload(context.Background(), dir, false, false, false)
+
+	if r.Success || r.ExitCode != exitFailed || r.Files != 2 || r.Succeeded != 1 || r.Verified {
+		t.Errorf("report = %+v", r)
+	}
+	if len(r.Failed) != 1 || r.Failed[0].RelPath != "assets/app.js" || r.Failed[0].Code != uploader.CodePathReadOnly {
+		t.Errorf("failed = %+v", r.Failed)
+	}
+
+	// .deployignore 和 profile 的排除规则都生效
+	var got []string
+	for rel := range fs.requests() {
+		got = append(got, rel)
+	}
+	sort.Strings(got)
+	if fmt.Sprint(got) != "[assets/app.js index.html]" {
+		t.Errorf("uploaded = %v", got)
+	}
+
+	// 打包上传只发送一个请求
+	fs, target = newFakeServer(t, nil)
+	target.Excludes = []string{"*.map"}
+	r = target.upload(context.Background(), dir, false, true, false)
+	if !r.Success || r.Files != 2 || r.Succeeded != 2 || len(fs.requests()) != 1 {
+		t.Errorf("zip upload: report = %+v, requests = %v", r, fs.requests())
+	}
+
+	empty := t.TempDir()
+	if r := target.upload(context.Background(), empty, false, false, false); r.ExitCode != exitConfig {
+		t.Errorf("empty folder: exit code = %d, want %d", r.ExitCode, exitConfig)
+	}
+}
+
+func TestCompareIgnoresExcludedRemoteFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".deployignore": "logs/\n",
+		"index.html":    "<html>",
+		"app.map":       "map",
+	})
+
+	fs, target := newFakeServer(t, nil)
+	target.Excludes = []string{"*.map"}
+	fs.remote = []uploader.RemoteFile{
+		{Path: "index.html", Size: 3},
+		{Path: "app.map", Size: 3},
+		{Path: "logs/today.log", Size: 10},
+		{Path: "old.html", Size: 5},
+	}
+
+	diff, err := target.compare(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var deleted []string
+	for _, e := range diff.Deleted {
+		deleted = append(deleted, e.RelPath)
+	}
+	if fmt.Sprint(deleted) != "[old.html]" {
+		t.Errorf("deleted = %v, want only old.html", deleted)
+	}
+	if len(diff.Modified) != 1 || diff.Modified[0].RelPath != "index.html" || len(diff.Added) != 0 {
+		t.Errorf("diff = %+v", diff)
+	}
+}
+
+func TestUploadReportBatch(t *testing.T) {
+	ok := func(rel string) uploader.BatchResult {
+		return uploader.BatchResult{File: uploader.FileToUpload{RelPath: rel}, Result: &uploader.UploadResult{Success: true, Verified: true}}
+	}
+	failed := func(rel string, err error) uploader.BatchResult {
+		return uploader.BatchResult{File: uploader.FileToUpload{RelPath: rel}, Result: uploader.FailedResult(err)}
+	}
+
+	tests := []struct {
+		name      string
+		results   []uploader.BatchResult
+		success   bool
+		succeeded int
+		failed    []string
+		exitCode  int
+	}{
+		{"all succeeded", []uploader.BatchResult{ok("a"), ok("b")}, true, 2, nil, exitOK},
+		{"first failure decides the exit code", []uploader.BatchResult{
+			ok("a"),
+			failed("b", uploader.ErrAuthBadSignature),
+			failed("c", &uploader.NetworkError{Err: errors.New("timeout")}),
+		}, false, 1, []string{"b", "c"}, exitAuth},
+		{"canceled", []uploader.BatchResult{
+			{File: uploader.FileToUpload{RelPath: "a"}, Result: &uploader.UploadResult{Canceled: true, Error: uploader.ErrCanceled.Error()}},
+		}, false, 0, []string{"a"}, exitCanceled},
+	}
+	for _, tt := range tests {
+		r := &UploadReport{}
+		r.batch(tt.results)
+		var failedPaths []string
+		for _, f := range r.Failed {
+			failedPaths = append(failedPaths, f.RelPath)
+		}
+		if r.Success != tt.success || r.Succeeded != tt.succeeded || r.ExitCode != tt.exitCode || fmt.Sprint(failedPaths) != fmt.Sprint(tt.failed) {
+			t.Errorf("%s: report = %+v", tt.name, r)
+		}
+		if r.Verified != tt.success || (r.Error == "") != tt.success {
+			t.Errorf("%s: verified = %v error = %q", tt.name, r.Verified, r.Error)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"nil", nil, exitOK},
+		{"config", configErrorf("bad"), exitConfig},
+		{"wrapped config", fmt.Errorf("load: %w", configErrorf("bad")), exitConfig},
+		{"canceled", uploader.ErrCanceled, exitCanceled},
+		{"context canceled", context.Canceled, exitCanceled},
+		{"auth code", uploader.ErrAuthIPDenied, exitAuth},
+		{"auth status", &uploader.ServerError{StatusCode: http.StatusUnauthorized}, exitAuth},
+		{"network", &uploader.NetworkError{Err: errors.New("refused")}, exitNetwork},
+		{"deadline", context.DeadlineExceeded, exitNetwork},
+		{"other server error", uploader.ErrPathReadOnly, exitFailed},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("%s: exitCode = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	if got := resultCode(&uploader.UploadResult{Success: true}); got != exitOK {
+		t.Errorf("resultCode(success) = %d", got)
+	}
+	if got := resultCode(&uploader.UploadResult{Canceled: true}); got != exitCanceled {
+		t.Errorf("resultCode(canceled) = %d", got)
+	}
+	if got := resultCode(uploader.FailedResult(uploader.ErrAuthExpired)); got != exitAuth {
+		t.Errorf("resultCode(auth) = %d", got)
+	}
+}
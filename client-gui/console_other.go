@@ -0,0 +1,6 @@
+//go:build !windows
+
+package main
+
+// attachConsole 其他平台的命令行模式直接使用终端
+func attachConsole() {}
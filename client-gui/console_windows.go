@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// attachConsole 图形界面程序在 Windows 下没有控制台，命令行模式时连接到启动它的控制台
+func attachConsole() {
+	const attachParentProcess = ^uint32(0) // ATTACH_PARENT_PROCESS
+	proc := syscall.NewLazyDLL("kernel32.dll").NewProc("AttachConsole")
+	if r, _, _ := proc.Call(uintptr(attachParentProcess)); r == 0 {
+		return
+	}
+	// 输出未被重定向时才改用控制台
+	if h, err := syscall.Open("CONOUT$", syscall.O_RDWR, 0); err == nil {
+		out := os.NewFile(uintptr(h), "CONOUT$")
+		if os.Stdout.Fd() == 0 || os.Stdout.Fd() == uintptr(syscall.InvalidHandle) {
+			os.Stdout = out
+		}
+		if os.Stderr.Fd() == 0 || os.Stderr.Fd() == uintptr(syscall.InvalidHandle) {
+			os.Stderr = out
+		}
+	}
+}
@@ -6,6 +6,8 @@ import KeysPage from './pages/Keys';
 import HistoryPage from './pages/History';
 import WatchPage from './pages/Watch';
 import SchedulePage from './pages/Schedule';
+import QueuePage from './pages/Queue';
+import RecipesPage from './pages/Recipes';
 import './style.css';
 
 function App() {
@@ -19,6 +21,8 @@ function App() {
           <Route path="history" element={<HistoryPage />} />
           <Route path="watch" element={<WatchPage />} />
           <Route path="schedule" element={<SchedulePage />} />
+          <Route path="recipes" element={<RecipesPage />} />
+          <Route path="queue" element={<QueuePage />} />
         </Route>
       </Routes>
     </BrowserRouter>
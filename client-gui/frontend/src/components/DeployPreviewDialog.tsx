@@ -0,0 +1,177 @@
+import { useState } from 'react';
+import { X, Plus, Pencil, Minus, EyeOff } from 'lucide-react';
+import { main, uploader } from '../../wailsjs/go/models';
+
+interface Props {
+  preview: main.DeployPreview;
+  formatSize: (bytes: number) => string;
+  onConfirm: (relPaths: string[]) => void;
+  onCancel: () => void;
+}
+
+// DeployPreviewDialog 上传前的差异预览，新增和修改的文件默认全选，可以取消勾选不上传的文件
+export default function DeployPreviewDialog({ preview, formatSize, onConfirm, onCancel }: Props) {
+  const added = preview.added || [];
+  const modified = preview.modified || [];
+  const deleted = preview.deleted || [];
+  const hidden = preview.hidden || [];
+  const unchanged = preview.unchanged || [];
+  const changed = [...added, ...modified];
+
+  const [selected, setSelected] = useState<Set<string>>(() => new Set(changed.map(e => e.relPath)));
+
+  const toggle = (relPath: string) => {
+    setSelected(prev => {
+      const next = new Set(prev);
+      if (next.has(relPath)) next.delete(relPath);
+      else next.add(relPath);
+      return next;
+    });
+  };
+
+  const selectedSize = changed
+    .filter(e => selected.has(e.relPath))
+    .reduce((sum, e) => sum + e.localSize, 0);
+
+  const renderChanged = (entries: uploader.DiffEntry[], kind: 'added' | 'modified') =>
+    entries.map(e => (
+      <label
+        key={e.relPath}
+        className="flex items-center gap-3 px-4 py-2 cursor-pointer select-none hover:bg-zinc-50 dark:hover:bg-zinc-800/50"
+      >
+        <input
+          type="checkbox"
+          checked={selected.has(e.relPath)}
+          onChange={() => toggle(e.relPath)}
+          className="w-4 h-4 rounded border-zinc-300 dark:border-zinc-600 text-zinc-900 dark:text-white focus:ring-zinc-900 dark:focus:ring-white"
+        />
+        {kind === 'added' ? (
+          <Plus size={14} className="text-emerald-600 dark:text-emerald-400 flex-shrink-0" />
+        ) : (
+          <Pencil size={14} className="text-amber-600 dark:text-amber-400 flex-shrink-0" />
+        )}
+        <span className="flex-1 min-w-0 text-sm text-zinc-900 dark:text-white truncate font-mono" title={e.relPath}>
+          {e.relPath}
+        </span>
+        <span className="text-xs text-zinc-500 dark:text-zinc-400 flex-shrink-0">
+          {kind === 'modified' ? `${formatSize(e.remoteSize)} → ${formatSize(e.localSize)}` : formatSize(e.localSize)}
+        </span>
+      </label>
+    ));
+
+  const renderReadOnly = (entries: uploader.DiffEntry[], icon: React.ReactNode, size: (e: uploader.DiffEntry) => number) =>
+    entries.map(e => (
+      <div key={e.relPath} className="flex items-center gap-3 px-4 py-2">
+        {icon}
+        <span className="flex-1 min-w-0 text-sm text-zinc-500 dark:text-zinc-400 truncate font-mono" title={e.relPath}>
+          {e.relPath}
+        </span>
+        <span className="text-xs text-zinc-500 dark:text-zinc-400 flex-shrink-0">{formatSize(size(e))}</span>
+      </div>
+    ));
+
+  return (
+    <div className="fixed inset-0 z-50 flex items-center justify-center bg-black/40 p-6">
+      <div className="w-full max-w-2xl max-h-full flex flex-col bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 shadow-xl">
+        {/* 头部 */}
+        <div className="flex justify-between items-start px-6 py-4 border-b border-zinc-200 dark:border-zinc-800">
+          <div className="min-w-0">
+            <h2 className="text-sm font-medium text-zinc-900 dark:text-white">上传预览</h2>
+            <p className="text-xs text-zinc-500 dark:text-zinc-400 mt-1 truncate" title={preview.folderPath}>
+              {preview.folderPath} → {preview.serverName} / {preview.pathKey}
+            </p>
+          </div>
+          <button
+            onClick={onCancel}
+            className="p-1.5 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 transition-colors"
+          >
+            <X size={18} />
+          </button>
+        </div>
+
+        {/* 统计 */}
+        <div className="flex flex-wrap gap-x-4 gap-y-1 px-6 py-3 text-xs text-zinc-500 dark:text-zinc-400 border-b border-zinc-200 dark:border-zinc-800">
+          <span className="text-emerald-600 dark:text-emerald-400">新增 {added.length}</span>
+          <span className="text-amber-600 dark:text-amber-400">修改 {modified.length}</span>
+          <span>未变 {unchanged.length}</span>
+          <span>仅服务器 {deleted.length}</span>
+          {hidden.length > 0 && <span>隐藏 {hidden.length}</span>}
+        </div>
+
+        <div className="flex-1 overflow-y-auto">
+          {changed.length === 0 ? (
+            <p className="px-6 py-8 text-sm text-center text-zinc-500 dark:text-zinc-400">服务器上的文件已是最新，没有需要上传的文件</p>
+          ) : (
+            <div>
+              <div className="flex justify-between items-center px-4 py-2 bg-zinc-50 dark:bg-zinc-800/50">
+                <span className="text-xs font-medium text-zinc-700 dark:text-zinc-300">待上传</span>
+                <div className="flex gap-3 text-xs">
+                  <button
+                    onClick={() => setSelected(new Set(changed.map(e => e.relPath)))}
+                    className="text-zinc-500 hover:text-zinc-900 dark:text-zinc-400 dark:hover:text-white"
+                  >
+                    全选
+                  </button>
+                  <button
+                    onClick={() => setSelected(new Set())}
+                    className="text-zinc-500 hover:text-zinc-900 dark:text-zinc-400 dark:hover:text-white"
+                  >
+                    全不选
+                  </button>
+                </div>
+              </div>
+              <div className="divide-y divide-zinc-100 dark:divide-zinc-800">
+                {renderChanged(added, 'added')}
+                {renderChanged(modified, 'modified')}
+              </div>
+            </div>
+          )}
+
+          {deleted.length > 0 && (
+            <div>
+              <div className="px-4 py-2 bg-zinc-50 dark:bg-zinc-800/50 text-xs font-medium text-zinc-700 dark:text-zinc-300">
+                仅在服务器上存在 <span className="font-normal text-zinc-500 dark:text-zinc-400">(上传不会删除)</span>
+              </div>
+              <div className="divide-y divide-zinc-100 dark:divide-zinc-800">
+                {renderReadOnly(deleted, <Minus size={14} className="text-red-500 dark:text-red-400 flex-shrink-0" />, e => e.remoteSize)}
+              </div>
+            </div>
+          )}
+
+          {hidden.length > 0 && (
+            <div>
+              <div className="px-4 py-2 bg-zinc-50 dark:bg-zinc-800/50 text-xs font-medium text-zinc-700 dark:text-zinc-300">
+                隐藏文件 <span className="font-normal text-zinc-500 dark:text-zinc-400">(接收端不接受，不会上传)</span>
+              </div>
+              <div className="divide-y divide-zinc-100 dark:divide-zinc-800">
+                {renderReadOnly(hidden, <EyeOff size={14} className="text-zinc-400 flex-shrink-0" />, e => e.localSize)}
+              </div>
+            </div>
+          )}
+        </div>
+
+        {/* 底部 */}
+        <div className="flex justify-between items-center px-6 py-4 border-t border-zinc-200 dark:border-zinc-800">
+          <span className="text-sm text-zinc-500 dark:text-zinc-400">
+            已选 {selected.size}/{changed.length} 个文件，共 {formatSize(selectedSize)}
+          </span>
+          <div className="flex gap-2">
+            <button
+              onClick={onCancel}
+              className="px-4 py-2 text-sm font-medium rounded-lg border border-zinc-300 dark:border-zinc-700 text-zinc-700 dark:text-zinc-300 hover:bg-zinc-50 dark:hover:bg-zinc-800 transition-colors"
+            >
+              取消
+            </button>
+            <button
+              onClick={() => onConfirm(changed.filter(e => selected.has(e.relPath)).map(e => e.relPath))}
+              disabled={selected.size === 0}
+              className="px-4 py-2 text-sm font-medium rounded-lg bg-zinc-900 dark:bg-white text-white dark:text-zinc-900 hover:bg-zinc-700 dark:hover:bg-zinc-200 disabled:opacity-50 disabled:cursor-not-allowed transition-colors"
+            >
+              上传选中文件
+            </button>
+          </div>
+        </div>
+      </div>
+    </div>
+  );
+}
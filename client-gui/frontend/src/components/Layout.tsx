@@ -1,5 +1,8 @@
+import { useState, useEffect } from 'react';
 import { NavLink, Outlet, useLocation } from 'react-router-dom';
-import { Upload, Server, Key, History, Eye, Clock, ChevronRight } from 'lucide-react';
+import { Upload, Server, Key, History, Eye, Clock, Workflow, ListOrdered, ChevronRight, Lock, AlertTriangle } from 'lucide-react';
+import { GetKeyStatus } from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
 
 const navItems = [
   { path: '/', icon: Upload, label: '文件上传' },
@@ -8,11 +11,29 @@ const navItems = [
   { path: '/history', icon: History, label: '历史记录' },
   { path: '/watch', icon: Eye, label: '文件夹监控' },
   { path: '/schedule', icon: Clock, label: '定时任务' },
+  { path: '/recipes', icon: Workflow, label: '部署配方' },
+  { path: '/queue', icon: ListOrdered, label: '上传队列' },
 ];
 
 export default function Layout() {
   const location = useLocation();
   const currentPage = navItems.find(item => item.path === location.pathname);
+  const [keyAlert, setKeyAlert] = useState<'locked' | 'unprotected' | null>(null);
+
+  // 私钥锁定或等待设置保护方式时上传会失败，在所有页面提示
+  useEffect(() => {
+    const refresh = async () => {
+      try {
+        const st = await GetKeyStatus();
+        setKeyAlert(st.needsPassword ? 'unprotected' : st.locked ? 'locked' : null);
+      } catch (err) {
+        console.error('获取密钥状态失败:', err);
+      }
+    };
+    refresh();
+    const offs = ['key:locked', 'key:unlocked', 'key:unprotected', 'config:imported'].map(e => EventsOn(e, refresh));
+    return () => offs.forEach(off => off());
+  }, [location.pathname]);
 
   return (
     <div className="flex h-screen bg-white dark:bg-zinc-900">
@@ -67,6 +88,18 @@ export default function Layout() {
           </div>
         </header>
 
+        {keyAlert && location.pathname !== '/keys' && (
+          <NavLink
+            to="/keys"
+            className="flex items-center gap-2 px-8 py-2.5 text-sm bg-amber-50 dark:bg-amber-900/20 border-b border-amber-200 dark:border-amber-800 text-amber-800 dark:text-amber-200 hover:bg-amber-100 dark:hover:bg-amber-900/30"
+          >
+            {keyAlert === 'locked' ? <Lock size={16} /> : <AlertTriangle size={16} />}
+            {keyAlert === 'locked'
+              ? '私钥已锁定，解锁之前无法签名上传。点击前往密钥管理输入主密码'
+              : '私钥仍以明文保存，设置保护方式之前不能上传。点击前往密钥管理'}
+          </NavLink>
+        )}
+
         {/* 内容区域 */}
         <main className="flex-1 overflow-auto p-8 bg-zinc-50 dark:bg-zinc-950">
           <div className="max-w-5xl mx-auto">
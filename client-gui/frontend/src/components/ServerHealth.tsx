@@ -0,0 +1,68 @@
+import { health } from '../../wailsjs/go/models';
+
+// HealthBadge 服务器可达状态指示，未检测过时显示灰色
+export function HealthBadge({ status }: { status?: health.Status }) {
+  const checked = status?.checked;
+  const reachable = checked && status?.reachable;
+  const label = !checked ? '未检测' : reachable ? `在线 ${status!.latencyMs} ms` : '离线';
+  const since = checked && status?.since ? `，自 ${new Date(status.since).toLocaleString()}` : '';
+  const title = !checked ? '等待首次检测' : reachable ? `接收端 ${status!.version || ''}${since}` : `${status!.error}${since}`;
+
+  return (
+    <span
+      className={`inline-flex items-center gap-1.5 px-2 py-0.5 text-xs font-medium rounded-full ${
+        !checked
+          ? 'bg-zinc-100 dark:bg-zinc-800 text-zinc-500 dark:text-zinc-400'
+          : reachable
+          ? 'bg-emerald-100 dark:bg-emerald-900/30 text-emerald-700 dark:text-emerald-400'
+          : 'bg-red-100 dark:bg-red-900/30 text-red-700 dark:text-red-400'
+      }`}
+      title={title}
+    >
+      <span className={`w-1.5 h-1.5 rounded-full ${!checked ? 'bg-zinc-400' : reachable ? 'bg-emerald-500' : 'bg-red-500'}`} />
+      {label}
+    </span>
+  );
+}
+
+// LatencySparkline 最近几次检测的延迟折线，不可达的检测以红点标在底部
+export function LatencySparkline({ history, width = 120, height = 24 }: { history?: health.Sample[]; width?: number; height?: number }) {
+  if (!history || history.length < 2) return null;
+
+  const pad = 2;
+  const max = Math.max(1, ...history.filter(s => s.reachable).map(s => s.latencyMs));
+  const step = (width - pad * 2) / (history.length - 1);
+  const x = (i: number) => pad + i * step;
+  const y = (ms: number) => height - pad - (ms / max) * (height - pad * 2);
+
+  // 不可达的检测断开折线
+  const segments: string[] = [];
+  let current: string[] = [];
+  history.forEach((s, i) => {
+    if (s.reachable) {
+      current.push(`${x(i).toFixed(1)},${y(s.latencyMs).toFixed(1)}`);
+    } else if (current.length > 0) {
+      segments.push(current.join(' '));
+      current = [];
+    }
+  });
+  if (current.length > 0) segments.push(current.join(' '));
+
+  const latest = history[history.length - 1];
+  return (
+    <svg width={width} height={height} className="overflow-visible" aria-label="延迟趋势">
+      <title>{`最近 ${history.length} 次检测，最高 ${max} ms`}</title>
+      {segments.map((points, i) =>
+        points.includes(' ') ? (
+          <polyline key={i} points={points} fill="none" strokeWidth={1.5} className="stroke-emerald-500" />
+        ) : (
+          <circle key={i} cx={points.split(',')[0]} cy={points.split(',')[1]} r={1.5} className="fill-emerald-500" />
+        )
+      )}
+      {history.map((s, i) =>
+        s.reachable ? null : <circle key={`down-${i}`} cx={x(i)} cy={height - pad} r={2} className="fill-red-500" />
+      )}
+      {latest.reachable && <circle cx={x(history.length - 1)} cy={y(latest.latencyMs)} r={2} className="fill-emerald-500" />}
+    </svg>
+  );
+}
@@ -1,216 +1,550 @@
 import { useState, useEffect } from 'react';
-import { Key, RefreshCw, Copy, Check, Eye, EyeOff, Save, Shield, AlertTriangle } from 'lucide-react';
-import { GenerateKeyPair, SaveKeyPair, GetKeyPair, GetPublicKeyFromPrivate } from '../../wailsjs/go/main/App';
+import { Key, Plus, Copy, Check, Eye, EyeOff, Star, Trash2, Download, Upload, Lock, LockOpen, Shield, AlertTriangle, X } from 'lucide-react';
+import {
+  GetKeys, GetKey, GetKeyStatus, CreateKey, DeleteKey, SetDefaultKey, ExportKey, ImportKeyFile,
+  UnlockKey, LockKey, SetKeyProtection, SetAutoLock,
+} from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
 
-interface KeyPairData {
-  privateKey: string;
+interface KeyItem {
+  id: string;
+  name: string;
   publicKey: string;
-  updatedAt: string;
+  fingerprint: string;
+  notes: string;
+  protection: string;
+  isDefault: boolean;
+  createdAt: string;
+  locked: boolean;
 }
 
+interface KeyStatus {
+  hasKey: boolean;
+  keyCount: number;
+  protection: string;
+  locked: boolean;
+  autoLockMinutes: number;
+  keyringAvailable: boolean;
+  needsPassword: boolean;
+}
+
+const MIN_PASSWORD_LEN = 8;
+
+const protectionLabels: Record<string, string> = {
+  plain: '明文保存',
+  password: '主密码加密',
+  keyring: '系统钥匙串',
+};
+
+const inputClass = 'w-full h-10 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent';
+const primaryButton = 'inline-flex items-center gap-2 px-4 py-2.5 text-sm font-medium rounded-lg bg-zinc-900 dark:bg-white text-white dark:text-zinc-900 hover:bg-zinc-700 dark:hover:bg-zinc-200 disabled:opacity-50 transition-colors';
+const secondaryButton = 'inline-flex items-center gap-2 px-4 py-2.5 text-sm font-medium rounded-lg border border-zinc-300 dark:border-zinc-700 text-zinc-700 dark:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 disabled:opacity-50 transition-colors';
+const iconButton = 'p-2 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors';
+
 export default function KeysPage() {
-  const [keyPair, setKeyPair] = useState<KeyPairData | null>(null);
-  const [showPrivateKey, setShowPrivateKey] = useState(false);
-  const [copied, setCopied] = useState<'public' | 'private' | null>(null);
-  const [isGenerating, setIsGenerating] = useState(false);
-  const [importKey, setImportKey] = useState('');
-  const [isImporting, setIsImporting] = useState(false);
+  const [keys, setKeys] = useState<KeyItem[]>([]);
+  const [status, setStatus] = useState<KeyStatus | null>(null);
+  const [copied, setCopied] = useState<string | null>(null);
+  const [revealed, setRevealed] = useState<{ id: string; privateKey: string } | null>(null);
+
+  // 解锁
+  const [unlockPassword, setUnlockPassword] = useState('');
+  const [unlocking, setUnlocking] = useState(false);
+
+  // 保护方式
+  const [protection, setProtection] = useState('password');
+  const [newPassword, setNewPassword] = useState('');
+  const [confirmPassword, setConfirmPassword] = useState('');
+  const [savingProtection, setSavingProtection] = useState(false);
+
+  // 新建 / 导入
+  const [isAdding, setIsAdding] = useState(false);
+  const [form, setForm] = useState({ name: '', notes: '', privateKey: '' });
+  const [importPassphrase, setImportPassphrase] = useState('');
 
   useEffect(() => {
-    loadKeyPair();
+    load();
+    const offs = [
+      EventsOn('key:locked', load),
+      EventsOn('key:unlocked', load),
+      EventsOn('key:unprotected', load),
+      EventsOn('config:imported', load),
+    ];
+    return () => offs.forEach(off => off());
   }, []);
 
-  const loadKeyPair = async () => {
+  const load = async () => {
     try {
-      const kp = await GetKeyPair();
-      setKeyPair(kp);
+      const [list, st] = await Promise.all([GetKeys(), GetKeyStatus()]);
+      setKeys(list || []);
+      setStatus(st);
+      if (st.locked) setRevealed(null);
     } catch (err) {
       console.error('加载密钥失败:', err);
     }
   };
 
-  const handleGenerate = async () => {
-    if (keyPair && !confirm('生成新密钥将覆盖现有密钥，确定继续吗？')) {
-      return;
+  const copyToClipboard = async (text: string, id: string) => {
+    try {
+      await navigator.clipboard.writeText(text);
+      setCopied(id);
+      setTimeout(() => setCopied(null), 2000);
+    } catch (err) {
+      console.error('复制失败:', err);
     }
+  };
 
-    setIsGenerating(true);
+  const handleUnlock = async () => {
+    setUnlocking(true);
     try {
-      const newKeyPair = await GenerateKeyPair();
-      await SaveKeyPair(newKeyPair.privateKey);
-      await loadKeyPair();
+      await UnlockKey(unlockPassword);
+      setUnlockPassword('');
+      await load();
     } catch (err) {
-      console.error('生成密钥失败:', err);
-      alert('生成密钥失败: ' + err);
+      alert('解锁失败: ' + err);
     } finally {
-      setIsGenerating(false);
+      setUnlocking(false);
     }
   };
 
-  const handleImport = async () => {
-    if (!importKey.trim()) {
-      alert('请输入私钥');
+  const handleLock = async () => {
+    await LockKey();
+    await load();
+  };
+
+  const handleSetProtection = async (target: string) => {
+    if (target === 'password') {
+      if (newPassword.length < MIN_PASSWORD_LEN) {
+        alert(`主密码至少 ${MIN_PASSWORD_LEN} 个字符`);
+        return;
+      }
+      if (newPassword !== confirmPassword) {
+        alert('两次输入的主密码不一致');
+        return;
+      }
+    }
+    if (target === 'plain' && !confirm('私钥将以明文保存在本机数据库中，确定继续吗？')) {
       return;
     }
 
-    if (keyPair && !confirm('导入新密钥将覆盖现有密钥，确定继续吗？')) {
+    setSavingProtection(true);
+    try {
+      await SetKeyProtection(target, target === 'password' ? newPassword : '');
+      setNewPassword('');
+      setConfirmPassword('');
+      await load();
+    } catch (err) {
+      alert('设置保护方式失败: ' + err);
+    } finally {
+      setSavingProtection(false);
+    }
+  };
+
+  const handleAutoLock = async (minutes: number) => {
+    try {
+      await SetAutoLock(minutes);
+      await load();
+    } catch (err) {
+      alert('设置自动锁定失败: ' + err);
+    }
+  };
+
+  const handleCreate = async () => {
+    if (!form.name.trim()) {
+      alert('请填写密钥名称');
       return;
     }
+    try {
+      await CreateKey(form.name, form.notes, form.privateKey.trim());
+      setIsAdding(false);
+      setForm({ name: '', notes: '', privateKey: '' });
+      await load();
+    } catch (err) {
+      alert('保存密钥失败: ' + err);
+    }
+  };
 
-    setIsImporting(true);
+  const handleImportFile = async () => {
     try {
-      await GetPublicKeyFromPrivate(importKey.trim());
-      await SaveKeyPair(importKey.trim());
-      setImportKey('');
-      await loadKeyPair();
-      alert('导入成功');
+      const kp = await ImportKeyFile(importPassphrase, '');
+      if (kp) {
+        setImportPassphrase('');
+        await load();
+      }
     } catch (err) {
-      alert('无效的私钥格式: ' + err);
-    } finally {
-      setIsImporting(false);
+      alert('导入失败: ' + err);
     }
   };
 
-  const copyToClipboard = async (text: string, type: 'public' | 'private') => {
+  const handleExport = async (key: KeyItem) => {
+    const passphrase = prompt(`设置导出密码 (至少 ${MIN_PASSWORD_LEN} 个字符)，留空则以明文导出私钥`);
+    if (passphrase === null) return;
+    if (passphrase === '' && !confirm('私钥将以明文写入导出文件，确定继续吗？')) return;
     try {
-      await navigator.clipboard.writeText(text);
-      setCopied(type);
-      setTimeout(() => setCopied(null), 2000);
+      const path = await ExportKey(key.id, passphrase);
+      if (path) alert('已导出到: ' + path);
     } catch (err) {
-      console.error('复制失败:', err);
+      alert('导出失败: ' + err);
+    }
+  };
+
+  const handleReveal = async (key: KeyItem) => {
+    if (revealed?.id === key.id) {
+      setRevealed(null);
+      return;
+    }
+    try {
+      const kp = await GetKey(key.id);
+      if (kp.locked) {
+        alert('私钥已锁定，请先输入主密码解锁');
+        return;
+      }
+      setRevealed({ id: key.id, privateKey: kp.privateKey });
+    } catch (err) {
+      alert('读取私钥失败: ' + err);
+    }
+  };
+
+  const handleSetDefault = async (id: string) => {
+    try {
+      await SetDefaultKey(id);
+      await load();
+    } catch (err) {
+      alert('设置默认密钥失败: ' + err);
     }
   };
 
-  const maskKey = (key: string) => {
-    if (key.length <= 16) return key;
-    return key.slice(0, 8) + '...' + key.slice(-8);
+  const handleDelete = async (key: KeyItem) => {
+    if (!confirm(`确定要删除密钥「${key.name}」吗？使用该公钥的服务器将无法再验证上传。`)) return;
+    try {
+      await DeleteKey(key.id);
+      await load();
+    } catch (err) {
+      alert('删除失败: ' + err);
+    }
   };
 
+  const passwordFields = (
+    <div className="grid grid-cols-2 gap-3">
+      <input
+        type="password"
+        value={newPassword}
+        onChange={e => setNewPassword(e.target.value)}
+        placeholder={`新主密码 (至少 ${MIN_PASSWORD_LEN} 个字符)`}
+        className={inputClass}
+      />
+      <input
+        type="password"
+        value={confirmPassword}
+        onChange={e => setConfirmPassword(e.target.value)}
+        placeholder="再次输入主密码"
+        className={inputClass}
+      />
+    </div>
+  );
+
   return (
     <div className="space-y-6">
       {/* 头部 */}
-      <div>
-        <h1 className="text-lg font-semibold text-zinc-900 dark:text-white">密钥管理</h1>
-        <p className="text-sm text-zinc-500 dark:text-zinc-400 mt-1">管理 Ed25519 签名密钥对</p>
+      <div className="flex justify-between items-center">
+        <div>
+          <h1 className="text-lg font-semibold text-zinc-900 dark:text-white">密钥管理</h1>
+          <p className="text-sm text-zinc-500 dark:text-zinc-400 mt-1">管理 Ed25519 签名密钥和私钥保护方式</p>
+        </div>
+        {!isAdding && !status?.locked && !status?.needsPassword && (
+          <button onClick={() => setIsAdding(true)} className={primaryButton + ' shadow-sm'}>
+            <Plus size={16} />
+            新建密钥
+          </button>
+        )}
       </div>
 
-      {/* 当前密钥 */}
-      {keyPair ? (
-        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 overflow-hidden">
-          <div className="px-6 py-4 border-b border-zinc-200 dark:border-zinc-800 flex items-center justify-between">
+      {/* 旧版本明文私钥: 选择保护方式之前不能上传 */}
+      {status?.needsPassword && (
+        <div className="bg-amber-50 dark:bg-amber-900/20 border border-amber-200 dark:border-amber-800 rounded-xl p-6 space-y-4">
+          <div className="flex gap-3">
+            <AlertTriangle className="w-5 h-5 text-amber-600 dark:text-amber-400 flex-shrink-0 mt-0.5" />
+            <div>
+              <h2 className="text-sm font-medium text-amber-800 dark:text-amber-200">私钥仍以明文保存</h2>
+              <p className="text-sm text-amber-700 dark:text-amber-300 mt-1">
+                旧版本保存的私钥尚未加密{status.keyringAvailable ? '' : '，且本机没有可用的系统钥匙串'}。选择保护方式之前上传会被拒绝。
+              </p>
+            </div>
+          </div>
+          {passwordFields}
+          <div className="flex flex-wrap gap-3">
+            <button onClick={() => handleSetProtection('password')} disabled={savingProtection} className={primaryButton}>
+              <Lock size={16} />
+              设置主密码
+            </button>
+            {status.keyringAvailable && (
+              <button onClick={() => handleSetProtection('keyring')} disabled={savingProtection} className={secondaryButton}>
+                <Shield size={16} />
+                使用系统钥匙串
+              </button>
+            )}
+            <button onClick={() => handleSetProtection('plain')} disabled={savingProtection} className={secondaryButton}>
+              继续明文保存
+            </button>
+          </div>
+        </div>
+      )}
+
+      {/* 解锁 */}
+      {status?.locked && (
+        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-6">
+          <div className="flex items-center gap-3 mb-4">
+            <div className="w-10 h-10 rounded-lg bg-zinc-100 dark:bg-zinc-800 flex items-center justify-center">
+              <Lock className="w-5 h-5 text-zinc-500 dark:text-zinc-400" />
+            </div>
+            <div>
+              <h2 className="text-sm font-medium text-zinc-900 dark:text-white">私钥已锁定</h2>
+              <p className="text-xs text-zinc-500 dark:text-zinc-400">输入主密码解锁后才能上传和管理密钥</p>
+            </div>
+          </div>
+          <div className="flex gap-2">
+            <input
+              type="password"
+              value={unlockPassword}
+              onChange={e => setUnlockPassword(e.target.value)}
+              onKeyDown={e => e.key === 'Enter' && unlockPassword && handleUnlock()}
+              placeholder="主密码"
+              className={inputClass + ' flex-1'}
+              autoFocus
+            />
+            <button onClick={handleUnlock} disabled={unlocking || !unlockPassword} className={primaryButton}>
+              <LockOpen size={16} />
+              {unlocking ? '解锁中...' : '解锁'}
+            </button>
+          </div>
+        </div>
+      )}
+
+      {/* 保护方式 */}
+      {status?.hasKey && !status.needsPassword && (
+        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-6 space-y-4">
+          <div className="flex justify-between items-start">
             <div className="flex items-center gap-3">
               <div className="w-10 h-10 rounded-lg bg-emerald-100 dark:bg-emerald-900/30 flex items-center justify-center">
                 <Shield className="w-5 h-5 text-emerald-600 dark:text-emerald-400" />
               </div>
               <div>
-                <h2 className="text-sm font-medium text-zinc-900 dark:text-white">当前密钥对</h2>
+                <h2 className="text-sm font-medium text-zinc-900 dark:text-white">
+                  私钥保护: {protectionLabels[status.protection] || status.protection}
+                </h2>
                 <p className="text-xs text-zinc-500 dark:text-zinc-400">
-                  更新于 {new Date(keyPair.updatedAt).toLocaleString()}
+                  共 {status.keyCount} 个密钥，所有密钥使用同一种保护方式
                 </p>
               </div>
             </div>
+            {status.protection === 'password' && !status.locked && (
+              <button onClick={handleLock} className={secondaryButton}>
+                <Lock size={16} />
+                立即锁定
+              </button>
+            )}
           </div>
 
-          <div className="p-6 space-y-5">
-            {/* 公钥 */}
-            <div>
-              <label className="block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-2">
-                公钥 <span className="font-normal text-zinc-500">(复制到服务器 config.json)</span>
-              </label>
-              <div className="flex items-center gap-2">
-                <code className="flex-1 px-4 py-3 rounded-lg bg-zinc-100 dark:bg-zinc-800 text-sm font-mono text-emerald-600 dark:text-emerald-400 break-all">
-                  {keyPair.publicKey}
-                </code>
-                <button
-                  onClick={() => copyToClipboard(keyPair.publicKey, 'public')}
-                  className="flex-shrink-0 p-2.5 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors"
-                  title="复制公钥"
+          {status.protection === 'password' && (
+            <div className="flex items-center gap-3">
+              <label className="text-sm text-zinc-700 dark:text-zinc-300">自动锁定</label>
+              <select
+                value={status.autoLockMinutes}
+                onChange={e => handleAutoLock(Number(e.target.value))}
+                className="h-9 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white"
+              >
+                {[0, 5, 15, 30, 60, 240].map(m => (
+                  <option key={m} value={m}>{m === 0 ? '不自动锁定' : `${m} 分钟无操作后`}</option>
+                ))}
+              </select>
+            </div>
+          )}
+
+          {!status.locked && (
+            <div className="pt-4 border-t border-zinc-200 dark:border-zinc-800 space-y-3">
+              <div className="flex items-center gap-3">
+                <label className="text-sm text-zinc-700 dark:text-zinc-300">更改保护方式</label>
+                <select
+                  value={protection}
+                  onChange={e => setProtection(e.target.value)}
+                  className="h-9 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white"
                 >
-                  {copied === 'public' ? <Check size={18} className="text-emerald-500" /> : <Copy size={18} />}
-                </button>
+                  <option value="password">{status.protection === 'password' ? '修改主密码' : protectionLabels.password}</option>
+                  {status.keyringAvailable && <option value="keyring">{protectionLabels.keyring}</option>}
+                  <option value="plain">{protectionLabels.plain} (不推荐)</option>
+                </select>
               </div>
+              {protection === 'password' && passwordFields}
+              <button
+                onClick={() => handleSetProtection(protection)}
+                disabled={savingProtection || (protection === status.protection && protection !== 'password')}
+                className={primaryButton}
+              >
+                <Check size={16} />
+                应用
+              </button>
             </div>
+          )}
+        </div>
+      )}
 
-            {/* 私钥 */}
+      {/* 新建密钥 */}
+      {isAdding && (
+        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-6">
+          <h2 className="text-sm font-medium text-zinc-900 dark:text-white mb-4">新建密钥</h2>
+          <div className="space-y-4">
+            <div className="grid grid-cols-2 gap-3">
+              <div>
+                <label className="block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-1.5">名称</label>
+                <input
+                  type="text"
+                  value={form.name}
+                  onChange={e => setForm({ ...form, name: e.target.value })}
+                  placeholder="例如：生产环境"
+                  className={inputClass}
+                />
+              </div>
+              <div>
+                <label className="block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-1.5">备注</label>
+                <input
+                  type="text"
+                  value={form.notes}
+                  onChange={e => setForm({ ...form, notes: e.target.value })}
+                  className={inputClass}
+                />
+              </div>
+            </div>
             <div>
-              <label className="block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-2">
-                私钥 <span className="font-normal text-zinc-500">(请妥善保管)</span>
-              </label>
-              <div className="flex items-center gap-2">
-                <code className="flex-1 px-4 py-3 rounded-lg bg-zinc-100 dark:bg-zinc-800 text-sm font-mono text-red-600 dark:text-red-400 break-all">
-                  {showPrivateKey ? keyPair.privateKey : maskKey(keyPair.privateKey)}
-                </code>
-                <button
-                  onClick={() => setShowPrivateKey(!showPrivateKey)}
-                  className="flex-shrink-0 p-2.5 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors"
-                  title={showPrivateKey ? '隐藏' : '显示'}
-                >
-                  {showPrivateKey ? <EyeOff size={18} /> : <Eye size={18} />}
+              <label className="block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-1.5">已有私钥 (可选)</label>
+              <input
+                type="password"
+                value={form.privateKey}
+                onChange={e => setForm({ ...form, privateKey: e.target.value })}
+                placeholder="留空则生成新的密钥对 (128位十六进制)"
+                className={inputClass}
+              />
+            </div>
+            <div className="flex gap-3 pt-2">
+              <button onClick={handleCreate} className={primaryButton}>
+                <Check size={16} />
+                保存
+              </button>
+              <button
+                onClick={() => { setIsAdding(false); setForm({ name: '', notes: '', privateKey: '' }); }}
+                className={secondaryButton}
+              >
+                <X size={16} />
+                取消
+              </button>
+            </div>
+          </div>
+        </div>
+      )}
+
+      {/* 密钥列表 */}
+      <div className="space-y-3">
+        {keys.map(key => (
+          <div
+            key={key.id}
+            className={`bg-white dark:bg-zinc-900 rounded-xl border p-5 ${
+              key.isDefault ? 'border-amber-300 dark:border-amber-700' : 'border-zinc-200 dark:border-zinc-800'
+            }`}
+          >
+            <div className="flex justify-between items-start gap-4">
+              <div className="flex-1 min-w-0 space-y-2">
+                <div className="flex items-center gap-2">
+                  <h3 className="text-sm font-medium text-zinc-900 dark:text-white">{key.name}</h3>
+                  {key.isDefault && (
+                    <span className="inline-flex items-center gap-1 px-2 py-0.5 text-xs font-medium rounded-full bg-amber-100 dark:bg-amber-900/30 text-amber-700 dark:text-amber-400">
+                      <Star size={10} />
+                      默认
+                    </span>
+                  )}
+                  {key.locked && <Lock size={12} className="text-zinc-400" />}
+                </div>
+                {key.notes && <p className="text-sm text-zinc-500 dark:text-zinc-400">{key.notes}</p>}
+                <p className="text-xs text-zinc-500 dark:text-zinc-400">
+                  指纹 <code className="font-mono">{key.fingerprint}</code>
+                  {key.createdAt && <> · 创建于 {new Date(key.createdAt).toLocaleString()}</>}
+                </p>
+                <div className="flex items-center gap-2">
+                  <code className="flex-1 px-3 py-2 rounded-lg bg-zinc-100 dark:bg-zinc-800 text-xs font-mono text-emerald-600 dark:text-emerald-400 break-all">
+                    {key.publicKey}
+                  </code>
+                  <button onClick={() => copyToClipboard(key.publicKey, key.id)} className={iconButton} title="复制公钥 (粘贴到服务器 config.json)">
+                    {copied === key.id ? <Check size={16} className="text-emerald-500" /> : <Copy size={16} />}
+                  </button>
+                </div>
+                {revealed?.id === key.id && (
+                  <div className="flex items-center gap-2">
+                    <code className="flex-1 px-3 py-2 rounded-lg bg-zinc-100 dark:bg-zinc-800 text-xs font-mono text-red-600 dark:text-red-400 break-all">
+                      {revealed.privateKey}
+                    </code>
+                    <button onClick={() => copyToClipboard(revealed.privateKey, key.id + ':private')} className={iconButton} title="复制私钥">
+                      {copied === key.id + ':private' ? <Check size={16} className="text-emerald-500" /> : <Copy size={16} />}
+                    </button>
+                  </div>
+                )}
+              </div>
+              <div className="flex gap-1">
+                <button onClick={() => handleReveal(key)} disabled={key.locked} className={iconButton + ' disabled:opacity-50'} title={revealed?.id === key.id ? '隐藏私钥' : '显示私钥'}>
+                  {revealed?.id === key.id ? <EyeOff size={16} /> : <Eye size={16} />}
+                </button>
+                <button onClick={() => handleExport(key)} disabled={key.locked} className={iconButton + ' disabled:opacity-50'} title="导出">
+                  <Download size={16} />
                 </button>
+                {!key.isDefault && (
+                  <button
+                    onClick={() => handleSetDefault(key.id)}
+                    className="p-2 text-zinc-400 hover:text-amber-500 hover:bg-amber-50 dark:hover:bg-amber-900/20 rounded-lg transition-colors"
+                    title="设为默认"
+                  >
+                    <Star size={16} />
+                  </button>
+                )}
                 <button
-                  onClick={() => copyToClipboard(keyPair.privateKey, 'private')}
-                  className="flex-shrink-0 p-2.5 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors"
-                  title="复制私钥"
+                  onClick={() => handleDelete(key)}
+                  className="p-2 text-zinc-400 hover:text-red-500 hover:bg-red-50 dark:hover:bg-red-900/20 rounded-lg transition-colors"
+                  title="删除"
                 >
-                  {copied === 'private' ? <Check size={18} className="text-emerald-500" /> : <Copy size={18} />}
+                  <Trash2 size={16} />
                 </button>
               </div>
             </div>
           </div>
-        </div>
-      ) : (
-        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-12 text-center">
-          <div className="w-16 h-16 rounded-full bg-zinc-100 dark:bg-zinc-800 flex items-center justify-center mx-auto mb-4">
-            <Key size={32} className="text-zinc-400 dark:text-zinc-500" />
-          </div>
-          <p className="text-sm text-zinc-600 dark:text-zinc-400">尚未配置密钥对</p>
-          <p className="text-sm text-zinc-500 dark:text-zinc-500 mt-1">生成或导入密钥对以启用安全上传</p>
-        </div>
-      )}
+        ))}
 
-      {/* 操作区 */}
-      <div className="grid grid-cols-2 gap-4">
-        {/* 生成新密钥 */}
-        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-6">
-          <h3 className="text-sm font-medium text-zinc-900 dark:text-white mb-2">生成新密钥对</h3>
-          <p className="text-sm text-zinc-500 dark:text-zinc-400 mb-4">
-            生成新的 Ed25519 密钥对，公钥需复制到服务器配置中。
-          </p>
-          <button
-            onClick={handleGenerate}
-            disabled={isGenerating}
-            className="inline-flex items-center gap-2 px-4 py-2.5 text-sm font-medium rounded-lg bg-zinc-900 dark:bg-white text-white dark:text-zinc-900 hover:bg-zinc-700 dark:hover:bg-zinc-200 disabled:opacity-50 transition-colors"
-          >
-            <RefreshCw size={16} className={isGenerating ? 'animate-spin' : ''} />
-            {isGenerating ? '生成中...' : '生成密钥对'}
-          </button>
-        </div>
+        {keys.length === 0 && !isAdding && (
+          <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-12 text-center">
+            <div className="w-16 h-16 rounded-full bg-zinc-100 dark:bg-zinc-800 flex items-center justify-center mx-auto mb-4">
+              <Key size={32} className="text-zinc-400 dark:text-zinc-500" />
+            </div>
+            <p className="text-sm text-zinc-600 dark:text-zinc-400">尚未配置密钥</p>
+            <p className="text-sm text-zinc-500 dark:text-zinc-500 mt-1">新建或导入密钥以启用安全上传</p>
+          </div>
+        )}
+      </div>
 
-        {/* 导入私钥 */}
+      {/* 导入密钥文件 */}
+      {!status?.locked && !status?.needsPassword && (
         <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-6">
-          <h3 className="text-sm font-medium text-zinc-900 dark:text-white mb-2">导入已有私钥</h3>
-          <p className="text-sm text-zinc-500 dark:text-zinc-400 mb-4">
-            如果您已有私钥，可以直接导入使用。
-          </p>
+          <h3 className="text-sm font-medium text-zinc-900 dark:text-white mb-2">导入密钥文件</h3>
+          <p className="text-sm text-zinc-500 dark:text-zinc-400 mb-4">导入其他设备导出的 .key.json 文件，加密导出的文件需要输入导出密码。</p>
           <div className="flex gap-2">
             <input
               type="password"
-              value={importKey}
-              onChange={e => setImportKey(e.target.value)}
-              placeholder="输入私钥 (128位十六进制)"
-              className="flex-1 h-10 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent"
+              value={importPassphrase}
+              onChange={e => setImportPassphrase(e.target.value)}
+              placeholder="导出密码 (明文导出的文件留空)"
+              className={inputClass + ' flex-1'}
             />
-            <button
-              onClick={handleImport}
-              disabled={isImporting || !importKey.trim()}
-              className="inline-flex items-center gap-2 px-4 py-2.5 text-sm font-medium rounded-lg bg-zinc-900 dark:bg-white text-white dark:text-zinc-900 hover:bg-zinc-700 dark:hover:bg-zinc-200 disabled:opacity-50 transition-colors"
-            >
-              <Save size={16} />
-              导入
+            <button onClick={handleImportFile} className={primaryButton}>
+              <Upload size={16} />
+              选择文件
             </button>
           </div>
         </div>
-      </div>
+      )}
 
       {/* 安全提示 */}
       <div className="bg-amber-50 dark:bg-amber-900/20 border border-amber-200 dark:border-amber-800 rounded-xl p-5">
@@ -219,10 +553,10 @@ export default function KeysPage() {
           <div>
             <h3 className="text-sm font-medium text-amber-800 dark:text-amber-200 mb-2">安全提示</h3>
             <ul className="text-sm text-amber-700 dark:text-amber-300 space-y-1">
-              <li>私钥仅保存在本机，请妥善保管</li>
+              <li>私钥仅保存在本机，建议使用主密码或系统钥匙串加密</li>
               <li>公钥可以安全地复制到服务器</li>
-              <li>如果私钥泄露，请立即生成新密钥对并更新服务器配置</li>
-              <li>建议定期更换密钥对</li>
+              <li>忘记主密码无法找回私钥，请先导出备份</li>
+              <li>如果私钥泄露，请立即生成新密钥并更新服务器配置</li>
             </ul>
           </div>
         </div>
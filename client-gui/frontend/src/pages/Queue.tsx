@@ -0,0 +1,251 @@
+import { useState, useEffect } from 'react';
+import { ListOrdered, Trash2, Check, X, RefreshCw, RotateCcw, ArrowRight, Clock } from 'lucide-react';
+import { GetQueue, GetServers, RetryQueueJob, DropQueueJob, ClearQueue } from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+import { database } from '../../wailsjs/go/models';
+
+const filters = [
+  { status: '', label: '全部' },
+  { status: 'pending', label: '等待' },
+  { status: 'running', label: '执行中' },
+  { status: 'failed', label: '失败' },
+  { status: 'done', label: '完成' },
+];
+
+const sourceLabels: Record<string, string> = {
+  manual: '手动',
+  watch: '监控',
+  schedule: '定时',
+  recipe: '配方',
+};
+
+export default function QueuePage() {
+  const [jobs, setJobs] = useState<database.QueueJob[]>([]);
+  const [serverNames, setServerNames] = useState<Record<string, string>>({});
+  const [filter, setFilter] = useState('');
+  const [loading, setLoading] = useState(true);
+
+  useEffect(() => {
+    loadQueue();
+  }, [filter]);
+
+  // 调度器每次改变任务状态时推送，按 ID 更新列表
+  useEffect(() => {
+    const offs = [
+      EventsOn('queue:update', (job: database.QueueJob) => {
+        setJobs(prev => {
+          const rest = prev.filter(j => j.id !== job.id);
+          if (filter && job.status !== filter) return rest;
+          return prev.some(j => j.id === job.id)
+            ? prev.map(j => (j.id === job.id ? job : j))
+            : [job, ...rest];
+        });
+      }),
+      EventsOn('queue:dropped', (data: { id: string }) => {
+        setJobs(prev => prev.filter(j => j.id !== data.id));
+      }),
+    ];
+    return () => offs.forEach(off => off());
+  }, [filter]);
+
+  const loadQueue = async () => {
+    setLoading(true);
+    try {
+      const [list, servers] = await Promise.all([GetQueue(filter), GetServers()]);
+      setJobs(list || []);
+      const names: Record<string, string> = {};
+      (servers || []).forEach(s => { names[s.id] = s.name; });
+      setServerNames(names);
+    } catch (err) {
+      console.error('加载队列失败:', err);
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleRetry = async (id: string) => {
+    try {
+      await RetryQueueJob(id);
+    } catch (err) {
+      alert('重试失败: ' + err);
+    }
+  };
+
+  const handleDrop = async (job: database.QueueJob) => {
+    if (job.status === 'running' && !confirm('任务正在执行，确定要中断并删除吗？')) return;
+    try {
+      await DropQueueJob(job.id);
+    } catch (err) {
+      alert('删除失败: ' + err);
+    }
+  };
+
+  const handleClear = async (status: string) => {
+    try {
+      await ClearQueue(status);
+      loadQueue();
+    } catch (err) {
+      alert('清除失败: ' + err);
+    }
+  };
+
+  const fileName = (job: database.QueueJob) =>
+    job.relPath || job.filePath.split(/[\\/]/).pop() || job.filePath;
+
+  const formatTime = (dateStr: string) => {
+    if (!dateStr) return '';
+    const date = new Date(dateStr);
+    const diff = date.getTime() - Date.now();
+    if (diff > 0 && diff < 3600000) return Math.ceil(diff / 60000) + ' 分钟后';
+    return date.toLocaleString([], { month: '2-digit', day: '2-digit', hour: '2-digit', minute: '2-digit', second: '2-digit' });
+  };
+
+  const pendingCount = jobs.filter(j => j.status === 'pending' || j.status === 'running').length;
+  const hasDone = jobs.some(j => j.status === 'done');
+  const hasFailed = jobs.some(j => j.status === 'failed');
+
+  return (
+    <div className="space-y-6">
+      {/* 头部 */}
+      <div className="flex justify-between items-center">
+        <div>
+          <h1 className="text-lg font-semibold text-zinc-900 dark:text-white">上传队列</h1>
+          <p className="text-sm text-zinc-500 dark:text-zinc-400 mt-1">
+            网络错误等可重试的失败会加入队列，按退避时间自动重试
+            {pendingCount > 0 && ` · ${pendingCount} 个任务待完成`}
+          </p>
+        </div>
+        <div className="flex gap-2">
+          <button
+            onClick={loadQueue}
+            className="p-2.5 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors"
+            title="刷新"
+          >
+            <RefreshCw size={18} />
+          </button>
+          {hasDone && (
+            <button
+              onClick={() => handleClear('done')}
+              className="inline-flex items-center gap-2 px-4 py-2 text-sm font-medium rounded-lg border border-zinc-300 dark:border-zinc-700 text-zinc-700 dark:text-zinc-300 hover:bg-zinc-50 dark:hover:bg-zinc-800 transition-colors"
+            >
+              <Trash2 size={16} />
+              清除已完成
+            </button>
+          )}
+          {hasFailed && (
+            <button
+              onClick={() => handleClear('failed')}
+              className="inline-flex items-center gap-2 px-4 py-2 text-sm font-medium rounded-lg border border-red-300 dark:border-red-800 text-red-600 dark:text-red-400 hover:bg-red-50 dark:hover:bg-red-900/20 transition-colors"
+            >
+              <Trash2 size={16} />
+              清除失败
+            </button>
+          )}
+        </div>
+      </div>
+
+      {/* 状态筛选 */}
+      <div className="flex gap-1 p-1 rounded-lg bg-zinc-100 dark:bg-zinc-800 w-fit">
+        {filters.map(f => (
+          <button
+            key={f.status}
+            onClick={() => setFilter(f.status)}
+            className={`px-3 py-1.5 text-sm font-medium rounded-md transition-colors ${
+              filter === f.status
+                ? 'bg-white dark:bg-zinc-900 text-zinc-900 dark:text-white shadow-sm'
+                : 'text-zinc-500 dark:text-zinc-400 hover:text-zinc-900 dark:hover:text-white'
+            }`}
+          >
+            {f.label}
+          </button>
+        ))}
+      </div>
+
+      {loading ? (
+        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-12 text-center">
+          <RefreshCw className="animate-spin mx-auto mb-4 text-zinc-400" size={32} />
+          <p className="text-sm text-zinc-500 dark:text-zinc-400">加载中...</p>
+        </div>
+      ) : jobs.length === 0 ? (
+        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-12 text-center">
+          <div className="w-16 h-16 rounded-full bg-zinc-100 dark:bg-zinc-800 flex items-center justify-center mx-auto mb-4">
+            <ListOrdered size={32} className="text-zinc-400 dark:text-zinc-500" />
+          </div>
+          <p className="text-sm text-zinc-500 dark:text-zinc-400">队列中没有任务</p>
+        </div>
+      ) : (
+        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 overflow-hidden">
+          <div className="divide-y divide-zinc-200 dark:divide-zinc-800">
+            {jobs.map(job => (
+              <div key={job.id} className="flex items-center gap-4 px-6 py-4">
+                <div className={`w-8 h-8 rounded-full flex items-center justify-center flex-shrink-0 ${
+                  job.status === 'done'
+                    ? 'bg-emerald-100 dark:bg-emerald-900/30'
+                    : job.status === 'failed'
+                    ? 'bg-red-100 dark:bg-red-900/30'
+                    : job.status === 'running'
+                    ? 'bg-blue-100 dark:bg-blue-900/30'
+                    : 'bg-amber-100 dark:bg-amber-900/30'
+                }`}>
+                  {job.status === 'done' ? (
+                    <Check className="text-emerald-600 dark:text-emerald-400" size={16} />
+                  ) : job.status === 'failed' ? (
+                    <X className="text-red-600 dark:text-red-400" size={16} />
+                  ) : job.status === 'running' ? (
+                    <RefreshCw className="animate-spin text-blue-600 dark:text-blue-400" size={16} />
+                  ) : (
+                    <Clock className="text-amber-600 dark:text-amber-400" size={16} />
+                  )}
+                </div>
+
+                <div className="flex-1 min-w-0">
+                  <div className="flex items-center gap-2 mb-1">
+                    <span className="text-sm font-medium text-zinc-900 dark:text-white truncate" title={job.filePath}>
+                      {fileName(job)}
+                    </span>
+                    <span className="text-xs text-zinc-500 dark:text-zinc-400">
+                      {sourceLabels[job.source] || job.source}
+                    </span>
+                  </div>
+                  <div className="flex items-center gap-2 text-xs text-zinc-500 dark:text-zinc-400">
+                    <span className="px-1.5 py-0.5 rounded bg-zinc-100 dark:bg-zinc-800">{serverNames[job.serverId] || job.serverId}</span>
+                    <ArrowRight size={12} />
+                    <span className="px-1.5 py-0.5 rounded bg-zinc-100 dark:bg-zinc-800">{job.pathKey}</span>
+                    <span>· 第 {job.attempts}/{job.maxAttempts} 次</span>
+                    {job.status === 'pending' && job.nextAttemptAt && (
+                      <span>· {formatTime(job.nextAttemptAt)} 重试</span>
+                    )}
+                  </div>
+                  {job.lastError && job.status !== 'done' && (
+                    <p className="text-sm text-red-600 dark:text-red-400 mt-1 truncate" title={job.lastError}>
+                      {job.lastError}
+                    </p>
+                  )}
+                </div>
+
+                <div className="flex items-center gap-1 flex-shrink-0">
+                  {(job.status === 'failed' || job.status === 'pending') && (
+                    <button
+                      onClick={() => handleRetry(job.id)}
+                      className="p-2 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors"
+                      title="立即重试"
+                    >
+                      <RotateCcw size={16} />
+                    </button>
+                  )}
+                  <button
+                    onClick={() => handleDrop(job)}
+                    className="p-2 text-zinc-400 hover:text-red-600 dark:hover:text-red-400 hover:bg-red-50 dark:hover:bg-red-900/20 rounded-lg transition-colors"
+                    title={job.status === 'running' ? '中断并删除' : '删除'}
+                  >
+                    <Trash2 size={16} />
+                  </button>
+                </div>
+              </div>
+            ))}
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
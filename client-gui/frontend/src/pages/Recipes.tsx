@@ -0,0 +1,685 @@
+import { useState, useEffect, useRef } from 'react';
+import { Workflow, Plus, Trash2, Edit2, Play, Square, Check, X, RefreshCw, ChevronUp, ChevronDown, Clock, Minus } from 'lucide-react';
+import {
+  GetRecipes, SaveRecipe, DeleteRecipe, RunRecipe, CancelRecipe, GetRunningRecipes,
+  GetRecipeRuns, GetRecipeRun, ClearRecipeRuns, GetServers, GetServerGroups,
+} from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+import { database } from '../../wailsjs/go/models';
+
+const stepTypes = [
+  { type: 'command', label: '运行命令' },
+  { type: 'package', label: '打包' },
+  { type: 'upload', label: '上传' },
+  { type: 'verify', label: '校验地址' },
+  { type: 'notify', label: '通知' },
+];
+
+const triggerLabels: Record<string, string> = {
+  manual: '手动',
+  watch: '监控',
+  schedule: '定时',
+};
+
+// 表单中的步骤，排除规则以文本编辑，上传目标用 target 区分服务器和服务器组
+interface StepForm {
+  type: string;
+  name: string;
+  always: boolean;
+  command: string;
+  dir: string;
+  source: string;
+  output: string;
+  excludes: string;
+  target: 'server' | 'group';
+  serverId: string;
+  groupId: string;
+  pathKey: string;
+  extract: boolean;
+  url: string;
+  expectStatus: number;
+  contains: string;
+  message: string;
+  timeoutSeconds: number;
+}
+
+interface RecipeForm {
+  id: string;
+  name: string;
+  createdAt: string;
+  steps: StepForm[];
+}
+
+// LiveRun 正在运行或刚结束的运行，由 recipe:* 事件填充
+interface LiveRun {
+  runId: number;
+  recipeId: string;
+  status: string;
+  errorMsg: string;
+  steps: Record<number, database.RecipeStepResult>;
+  lines: { step: number; line: string }[];
+}
+
+const maxLiveLines = 2000;
+
+const emptyStep = (type = 'command'): StepForm => ({
+  type,
+  name: '',
+  always: false,
+  command: '',
+  dir: '',
+  source: '',
+  output: '',
+  excludes: '',
+  target: 'server',
+  serverId: '',
+  groupId: '',
+  pathKey: '',
+  extract: false,
+  url: '',
+  expectStatus: 0,
+  contains: '',
+  message: '',
+  timeoutSeconds: 0,
+});
+
+const toStepForm = (s: database.RecipeStep): StepForm => ({
+  ...emptyStep(s.type),
+  name: s.name || '',
+  always: s.always,
+  command: s.command || '',
+  dir: s.dir || '',
+  source: s.source || '',
+  output: s.output || '',
+  excludes: (s.excludes || []).join('\n'),
+  target: s.groupId ? 'group' : 'server',
+  serverId: s.serverId || '',
+  groupId: s.groupId || '',
+  pathKey: s.pathKey || '',
+  extract: !!s.extract,
+  url: s.url || '',
+  expectStatus: s.expectStatus || 0,
+  contains: s.contains || '',
+  message: s.message || '',
+  timeoutSeconds: s.timeoutSeconds || 0,
+});
+
+// fromStepForm 只保留步骤类型用到的字段
+const fromStepForm = (f: StepForm): database.RecipeStep => {
+  const base = { type: f.type, name: f.name.trim(), always: f.always };
+  switch (f.type) {
+    case 'command':
+      return database.RecipeStep.createFrom({ ...base, command: f.command, dir: f.dir, timeoutSeconds: f.timeoutSeconds });
+    case 'package':
+      return database.RecipeStep.createFrom({
+        ...base, source: f.source, output: f.output,
+        excludes: f.excludes.split('\n').map(p => p.trim()).filter(Boolean),
+      });
+    case 'upload':
+      return database.RecipeStep.createFrom({
+        ...base, source: f.source, pathKey: f.pathKey, extract: f.extract,
+        serverId: f.target === 'server' ? f.serverId : '',
+        groupId: f.target === 'group' ? f.groupId : '',
+      });
+    case 'verify':
+      return database.RecipeStep.createFrom({ ...base, url: f.url, expectStatus: f.expectStatus, contains: f.contains, timeoutSeconds: f.timeoutSeconds });
+    default:
+      return database.RecipeStep.createFrom({ ...base, message: f.message });
+  }
+};
+
+const emptyForm = (): RecipeForm => ({ id: '', name: '', createdAt: '', steps: [emptyStep()] });
+
+const inputClass = 'w-full h-10 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent';
+const textareaClass = 'w-full px-3 py-2 text-sm font-mono rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent';
+const labelClass = 'block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-1.5';
+const iconButtonClass = 'p-2 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors disabled:opacity-30';
+
+function StepStatusIcon({ status }: { status?: string }) {
+  switch (status) {
+    case 'success':
+      return <Check className="text-emerald-600 dark:text-emerald-400" size={14} />;
+    case 'failed':
+      return <X className="text-red-600 dark:text-red-400" size={14} />;
+    case 'running':
+      return <RefreshCw className="animate-spin text-blue-600 dark:text-blue-400" size={14} />;
+    case 'canceled':
+    case 'skipped':
+      return <Minus className="text-zinc-400" size={14} />;
+    default:
+      return <Clock className="text-zinc-300 dark:text-zinc-600" size={14} />;
+  }
+}
+
+export default function RecipesPage() {
+  const [recipes, setRecipes] = useState<database.Recipe[]>([]);
+  const [servers, setServers] = useState<database.Server[]>([]);
+  const [groups, setGroups] = useState<database.ServerGroup[]>([]);
+  const [running, setRunning] = useState<string[]>([]);
+  const [form, setForm] = useState<RecipeForm | null>(null);
+  const [selectedId, setSelectedId] = useState<string | null>(null);
+  const [liveRuns, setLiveRuns] = useState<Record<string, LiveRun>>({});
+  const [runs, setRuns] = useState<database.RecipeRun[]>([]);
+  const [viewedRun, setViewedRun] = useState<database.RecipeRun | null>(null);
+  const outputRef = useRef<HTMLDivElement>(null);
+  const selectedRef = useRef<string | null>(null);
+
+  useEffect(() => {
+    loadData();
+  }, []);
+
+  // 步骤输出和状态通过事件实时推送，按配方保存最近一次运行
+  useEffect(() => {
+    const updateRun = (runId: number, update: (run: LiveRun) => LiveRun) =>
+      setLiveRuns(prev => {
+        const run = Object.values(prev).find(r => r.runId === runId);
+        return run ? { ...prev, [run.recipeId]: update(run) } : prev;
+      });
+
+    const offs = [
+      EventsOn('recipe:start', (data: { runId: number; recipeId: string }) => {
+        setRunning(prev => (prev.includes(data.recipeId) ? prev : [...prev, data.recipeId]));
+        setLiveRuns(prev => ({
+          ...prev,
+          [data.recipeId]: { runId: data.runId, recipeId: data.recipeId, status: 'running', errorMsg: '', steps: {}, lines: [] },
+        }));
+      }),
+      EventsOn('recipe:output', (data: { runId: number; step: number; line: string }) => {
+        updateRun(data.runId, run => ({ ...run, lines: [...run.lines, { step: data.step, line: data.line }].slice(-maxLiveLines) }));
+      }),
+      EventsOn('recipe:step', (data: { runId: number; step: number; result: database.RecipeStepResult }) => {
+        updateRun(data.runId, run => ({ ...run, steps: { ...run.steps, [data.step]: data.result } }));
+      }),
+      EventsOn('recipe:end', (run: database.RecipeRun) => {
+        setRunning(prev => prev.filter(id => id !== run.recipeId));
+        updateRun(run.id, live => {
+          const steps: Record<number, database.RecipeStepResult> = {};
+          (run.steps || []).forEach((s, i) => { steps[i] = s; });
+          return { ...live, status: run.status, errorMsg: run.errorMsg, steps };
+        });
+        if (selectedRef.current === run.recipeId) {
+          setRuns(prev => [run, ...prev.filter(r => r.id !== run.id)]);
+        }
+      }),
+    ];
+    return () => offs.forEach(off => off());
+  }, []);
+
+  useEffect(() => {
+    selectedRef.current = selectedId;
+    setViewedRun(null);
+    setRuns([]);
+    if (selectedId) loadRuns(selectedId);
+  }, [selectedId]);
+
+  // 新输出到达时滚动到底部
+  const live = selectedId ? liveRuns[selectedId] : undefined;
+  useEffect(() => {
+    if (outputRef.current) outputRef.current.scrollTop = outputRef.current.scrollHeight;
+  }, [live?.lines.length, viewedRun]);
+
+  const loadData = async () => {
+    try {
+      const [recipeList, serverList, groupList, runningIds] = await Promise.all([
+        GetRecipes(), GetServers(), GetServerGroups(), GetRunningRecipes(),
+      ]);
+      setRecipes(recipeList || []);
+      setServers(serverList || []);
+      setGroups(groupList || []);
+      setRunning(runningIds || []);
+    } catch (err) {
+      console.error('加载配方失败:', err);
+    }
+  };
+
+  const loadRuns = async (recipeId: string) => {
+    try {
+      const list = await GetRecipeRuns(recipeId, 20);
+      setRuns(list || []);
+    } catch (err) {
+      console.error('加载运行记录失败:', err);
+    }
+  };
+
+  const handleSave = async () => {
+    if (!form) return;
+    try {
+      await SaveRecipe(database.Recipe.createFrom({
+        id: form.id,
+        name: form.name.trim(),
+        createdAt: form.createdAt,
+        steps: form.steps.map(fromStepForm),
+      }));
+      setForm(null);
+      loadData();
+    } catch (err) {
+      alert('保存失败: ' + err);
+    }
+  };
+
+  const handleDelete = async (id: string) => {
+    if (!confirm('确定要删除这个配方吗？')) return;
+    try {
+      await DeleteRecipe(id);
+      if (selectedId === id) setSelectedId(null);
+      loadData();
+    } catch (err) {
+      alert('删除失败: ' + err);
+    }
+  };
+
+  // 运行结束前 RunRecipe 不返回，进度通过事件显示
+  const handleRun = (id: string) => {
+    setSelectedId(id);
+    setViewedRun(null);
+    RunRecipe(id).catch(err => alert('运行失败: ' + err));
+  };
+
+  const handleCancel = async (id: string) => {
+    try {
+      await CancelRecipe(id);
+    } catch (err) {
+      console.error('取消失败:', err);
+    }
+  };
+
+  const handleViewRun = async (id: number) => {
+    try {
+      setViewedRun(await GetRecipeRun(id));
+    } catch (err) {
+      console.error('加载运行记录失败:', err);
+    }
+  };
+
+  const handleClearRuns = async () => {
+    if (!confirm('确定要清空所有已结束的运行记录吗？')) return;
+    try {
+      await ClearRecipeRuns();
+      setViewedRun(null);
+      if (selectedId) loadRuns(selectedId);
+    } catch (err) {
+      alert('清空失败: ' + err);
+    }
+  };
+
+  const setStep = (i: number, patch: Partial<StepForm>) => {
+    if (!form) return;
+    setForm({ ...form, steps: form.steps.map((s, j) => (j === i ? { ...s, ...patch } : s)) });
+  };
+
+  const moveStep = (i: number, delta: number) => {
+    if (!form) return;
+    const steps = [...form.steps];
+    [steps[i], steps[i + delta]] = [steps[i + delta], steps[i]];
+    setForm({ ...form, steps });
+  };
+
+  const removeStep = (i: number) => {
+    if (!form) return;
+    setForm({ ...form, steps: form.steps.filter((_, j) => j !== i) });
+  };
+
+  const stepLabel = (type: string) => stepTypes.find(t => t.type === type)?.label || type;
+  const pathOptions = (step: StepForm) => servers.find(s => s.id === step.serverId)?.paths || [];
+
+  const renderStepFields = (step: StepForm, i: number) => {
+    switch (step.type) {
+      case 'command':
+        return (
+          <div className="grid grid-cols-2 gap-3">
+            <div className="col-span-2">
+              <label className={labelClass}>命令</label>
+              <input type="text" value={step.command} onChange={e => setStep(i, { command: e.target.value })}
+                placeholder="npm run build" className={`${inputClass} font-mono`} />
+            </div>
+            <div>
+              <label className={labelClass}>工作目录</label>
+              <input type="text" value={step.dir} onChange={e => setStep(i, { dir: e.target.value })}
+                placeholder="默认为程序所在目录" className={inputClass} />
+            </div>
+            <div>
+              <label className={labelClass}>超时 (秒)</label>
+              <input type="number" min={0} value={step.timeoutSeconds} onChange={e => setStep(i, { timeoutSeconds: Number(e.target.value) || 0 })}
+                placeholder="0 表示默认" className={inputClass} />
+            </div>
+          </div>
+        );
+      case 'package':
+        return (
+          <div className="grid grid-cols-2 gap-3">
+            <div>
+              <label className={labelClass}>打包目录</label>
+              <input type="text" value={step.source} onChange={e => setStep(i, { source: e.target.value })}
+                placeholder="C:\project\dist" className={inputClass} />
+            </div>
+            <div>
+              <label className={labelClass}>输出文件</label>
+              <input type="text" value={step.output} onChange={e => setStep(i, { output: e.target.value })}
+                placeholder="为空时使用临时文件" className={inputClass} />
+            </div>
+            <div className="col-span-2">
+              <label className={labelClass}>排除规则 (每行一条，追加在 .deployignore 之后)</label>
+              <textarea rows={2} value={step.excludes} onChange={e => setStep(i, { excludes: e.target.value })}
+                placeholder="*.map" className={textareaClass} />
+            </div>
+          </div>
+        );
+      case 'upload':
+        return (
+          <div className="grid grid-cols-2 gap-3">
+            <div className="col-span-2">
+              <label className={labelClass}>上传路径</label>
+              <input type="text" value={step.source} onChange={e => setStep(i, { source: e.target.value })}
+                placeholder="为空时上传前面打包步骤的产物" className={inputClass} />
+            </div>
+            <div>
+              <label className={labelClass}>目标</label>
+              <div className="flex gap-2">
+                <select value={step.target} onChange={e => setStep(i, { target: e.target.value as 'server' | 'group' })}
+                  className={`${inputClass} w-28`}>
+                  <option value="server">服务器</option>
+                  <option value="group">服务器组</option>
+                </select>
+                {step.target === 'server' ? (
+                  <select value={step.serverId} onChange={e => setStep(i, { serverId: e.target.value, pathKey: '' })} className={inputClass}>
+                    <option value="">选择服务器</option>
+                    {servers.map(s => <option key={s.id} value={s.id}>{s.name}</option>)}
+                  </select>
+                ) : (
+                  <select value={step.groupId} onChange={e => setStep(i, { groupId: e.target.value })} className={inputClass}>
+                    <option value="">选择服务器组</option>
+                    {groups.map(g => <option key={g.id} value={g.id}>{g.name}</option>)}
+                  </select>
+                )}
+              </div>
+            </div>
+            <div>
+              <label className={labelClass}>路径标识</label>
+              {step.target === 'server' && pathOptions(step).length > 0 ? (
+                <select value={step.pathKey} onChange={e => setStep(i, { pathKey: e.target.value })} className={inputClass}>
+                  <option value="">选择路径</option>
+                  {pathOptions(step).map(p => <option key={p} value={p}>{p}</option>)}
+                </select>
+              ) : (
+                <input type="text" value={step.pathKey} onChange={e => setStep(i, { pathKey: e.target.value })}
+                  placeholder="web" className={inputClass} />
+              )}
+            </div>
+            <label className="col-span-2 flex items-center gap-2 text-sm text-zinc-700 dark:text-zinc-300">
+              <input type="checkbox" checked={step.extract} onChange={e => setStep(i, { extract: e.target.checked })} />
+              上传 zip 后由接收端解压
+            </label>
+          </div>
+        );
+      case 'verify':
+        return (
+          <div className="grid grid-cols-2 gap-3">
+            <div className="col-span-2">
+              <label className={labelClass}>校验地址</label>
+              <input type="text" value={step.url} onChange={e => setStep(i, { url: e.target.value })}
+                placeholder="https://example.com/health" className={inputClass} />
+            </div>
+            <div>
+              <label className={labelClass}>期望状态码</label>
+              <input type="number" min={0} value={step.expectStatus} onChange={e => setStep(i, { expectStatus: Number(e.target.value) || 0 })}
+                placeholder="0 表示 200" className={inputClass} />
+            </div>
+            <div>
+              <label className={labelClass}>超时 (秒)</label>
+              <input type="number" min={0} value={step.timeoutSeconds} onChange={e => setStep(i, { timeoutSeconds: Number(e.target.value) || 0 })}
+                className={inputClass} />
+            </div>
+            <div className="col-span-2">
+              <label className={labelClass}>响应需包含</label>
+              <input type="text" value={step.contains} onChange={e => setStep(i, { contains: e.target.value })}
+                placeholder="可选" className={inputClass} />
+            </div>
+          </div>
+        );
+      default:
+        return (
+          <div>
+            <label className={labelClass}>通知内容 (可使用 {'{{recipe}}'} {'{{status}}'} {'{{error}}'})</label>
+            <input type="text" value={step.message} onChange={e => setStep(i, { message: e.target.value })}
+              placeholder="{{recipe}} 部署{{status}}" className={inputClass} />
+          </div>
+        );
+    }
+  };
+
+  const selected = recipes.find(r => r.id === selectedId);
+
+  return (
+    <div className="space-y-6">
+      {/* 头部 */}
+      <div className="flex justify-between items-center">
+        <div>
+          <h1 className="text-lg font-semibold text-zinc-900 dark:text-white">部署配方</h1>
+          <p className="text-sm text-zinc-500 dark:text-zinc-400 mt-1">按顺序执行构建、打包、上传、校验和通知</p>
+        </div>
+        {!form && (
+          <button
+            onClick={() => setForm(emptyForm())}
+            className="inline-flex items-center gap-2 px-4 py-2.5 text-sm font-medium rounded-lg bg-zinc-900 dark:bg-white text-white dark:text-zinc-900 hover:bg-zinc-700 dark:hover:bg-zinc-200 transition-colors shadow-sm"
+          >
+            <Plus size={16} />
+            新建配方
+          </button>
+        )}
+      </div>
+
+      {/* 编辑器 */}
+      {form && (
+        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-6 space-y-4">
+          <h2 className="text-base font-semibold text-zinc-900 dark:text-white">{form.id ? '编辑配方' : '新建配方'}</h2>
+          <div>
+            <label className={labelClass}>名称</label>
+            <input type="text" value={form.name} onChange={e => setForm({ ...form, name: e.target.value })}
+              placeholder="构建并部署前端" className={inputClass} />
+          </div>
+
+          <div className="space-y-3">
+            {form.steps.map((step, i) => (
+              <div key={i} className="rounded-lg border border-zinc-200 dark:border-zinc-800 p-4 space-y-3">
+                <div className="flex items-center gap-2">
+                  <span className="w-6 h-6 rounded-full bg-zinc-100 dark:bg-zinc-800 text-xs font-medium text-zinc-600 dark:text-zinc-400 flex items-center justify-center flex-shrink-0">
+                    {i + 1}
+                  </span>
+                  <select value={step.type} onChange={e => setStep(i, { type: e.target.value })} className={`${inputClass} w-32`}>
+                    {stepTypes.map(t => <option key={t.type} value={t.type}>{t.label}</option>)}
+                  </select>
+                  <input type="text" value={step.name} onChange={e => setStep(i, { name: e.target.value })}
+                    placeholder="步骤名称 (可选)" className={inputClass} />
+                  <label className="flex items-center gap-1.5 text-xs text-zinc-600 dark:text-zinc-400 whitespace-nowrap" title="前面的步骤失败后仍然执行">
+                    <input type="checkbox" checked={step.always} onChange={e => setStep(i, { always: e.target.checked })} />
+                    总是执行
+                  </label>
+                  <button onClick={() => moveStep(i, -1)} disabled={i === 0} className={iconButtonClass} title="上移">
+                    <ChevronUp size={16} />
+                  </button>
+                  <button onClick={() => moveStep(i, 1)} disabled={i === form.steps.length - 1} className={iconButtonClass} title="下移">
+                    <ChevronDown size={16} />
+                  </button>
+                  <button onClick={() => removeStep(i)} disabled={form.steps.length === 1} className={iconButtonClass} title="删除步骤">
+                    <Trash2 size={16} />
+                  </button>
+                </div>
+                {renderStepFields(step, i)}
+              </div>
+            ))}
+            <button
+              onClick={() => setForm({ ...form, steps: [...form.steps, emptyStep()] })}
+              className="inline-flex items-center gap-2 px-3 py-2 text-sm font-medium rounded-lg border border-dashed border-zinc-300 dark:border-zinc-700 text-zinc-600 dark:text-zinc-400 hover:bg-zinc-50 dark:hover:bg-zinc-800 transition-colors"
+            >
+              <Plus size={14} />
+              添加步骤
+            </button>
+          </div>
+
+          <div className="flex justify-end gap-2 pt-2">
+            <button
+              onClick={() => setForm(null)}
+              className="px-4 py-2 text-sm font-medium rounded-lg border border-zinc-300 dark:border-zinc-700 text-zinc-700 dark:text-zinc-300 hover:bg-zinc-50 dark:hover:bg-zinc-800 transition-colors"
+            >
+              取消
+            </button>
+            <button
+              onClick={handleSave}
+              className="px-4 py-2 text-sm font-medium rounded-lg bg-zinc-900 dark:bg-white text-white dark:text-zinc-900 hover:bg-zinc-700 dark:hover:bg-zinc-200 transition-colors"
+            >
+              保存
+            </button>
+          </div>
+        </div>
+      )}
+
+      {/* 配方列表 */}
+      {recipes.length === 0 && !form ? (
+        <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-12 text-center">
+          <div className="w-16 h-16 rounded-full bg-zinc-100 dark:bg-zinc-800 flex items-center justify-center mx-auto mb-4">
+            <Workflow size={32} className="text-zinc-400 dark:text-zinc-500" />
+          </div>
+          <p className="text-sm text-zinc-500 dark:text-zinc-400">还没有部署配方</p>
+        </div>
+      ) : (
+        <div className="grid gap-3">
+          {recipes.map(r => {
+            const isRunning = running.includes(r.id);
+            return (
+              <div
+                key={r.id}
+                onClick={() => setSelectedId(r.id)}
+                className={`bg-white dark:bg-zinc-900 rounded-xl border p-5 cursor-pointer transition-colors ${
+                  selectedId === r.id ? 'border-zinc-900 dark:border-white' : 'border-zinc-200 dark:border-zinc-800'
+                }`}
+              >
+                <div className="flex justify-between items-center">
+                  <div className="min-w-0">
+                    <div className="flex items-center gap-2 mb-1">
+                      <h3 className="text-sm font-medium text-zinc-900 dark:text-white">{r.name}</h3>
+                      {isRunning && (
+                        <span className="inline-flex items-center gap-1 px-2 py-0.5 text-xs font-medium rounded-full bg-blue-100 dark:bg-blue-900/30 text-blue-700 dark:text-blue-400">
+                          <RefreshCw size={10} className="animate-spin" />
+                          运行中
+                        </span>
+                      )}
+                    </div>
+                    <p className="text-xs text-zinc-500 dark:text-zinc-400 truncate">
+                      {(r.steps || []).map(s => s.name || stepLabel(s.type)).join(' → ')}
+                    </p>
+                  </div>
+                  <div className="flex gap-1 flex-shrink-0" onClick={e => e.stopPropagation()}>
+                    {isRunning ? (
+                      <button onClick={() => handleCancel(r.id)} className={iconButtonClass} title="取消运行">
+                        <Square size={16} />
+                      </button>
+                    ) : (
+                      <button onClick={() => handleRun(r.id)} className={iconButtonClass} title="运行">
+                        <Play size={16} />
+                      </button>
+                    )}
+                    <button
+                      onClick={() => setForm({ id: r.id, name: r.name, createdAt: r.createdAt, steps: (r.steps || []).map(toStepForm) })}
+                      className={iconButtonClass}
+                      title="编辑"
+                    >
+                      <Edit2 size={16} />
+                    </button>
+                    <button onClick={() => handleDelete(r.id)} className={iconButtonClass} title="删除">
+                      <Trash2 size={16} />
+                    </button>
+                  </div>
+                </div>
+              </div>
+            );
+          })}
+        </div>
+      )}
+
+      {/* 运行输出和历史 */}
+      {selected && (
+        <div className="grid grid-cols-3 gap-4">
+          <div className="col-span-2 bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 overflow-hidden">
+            <div className="flex justify-between items-center px-5 py-3 border-b border-zinc-200 dark:border-zinc-800">
+              <h2 className="text-sm font-semibold text-zinc-900 dark:text-white">
+                {viewedRun ? `运行记录 #${viewedRun.id}` : '实时输出'}
+              </h2>
+              {viewedRun && live && (
+                <button onClick={() => setViewedRun(null)} className="text-xs text-zinc-500 hover:text-zinc-900 dark:hover:text-white">
+                  返回实时输出
+                </button>
+              )}
+            </div>
+
+            {/* 步骤状态 */}
+            <div className="px-5 py-3 space-y-1.5 border-b border-zinc-200 dark:border-zinc-800">
+              {(selected.steps || []).map((s, i) => {
+                const result = viewedRun ? viewedRun.steps?.[i] : live?.steps[i];
+                return (
+                  <div key={i} className="flex items-center gap-2 text-sm">
+                    <StepStatusIcon status={result?.status} />
+                    <span className="text-zinc-900 dark:text-white">{s.name || stepLabel(s.type)}</span>
+                    {result?.summary && <span className="text-xs text-zinc-500 dark:text-zinc-400 truncate">{result.summary}</span>}
+                    {result?.error && <span className="text-xs text-red-600 dark:text-red-400 truncate">{result.error}</span>}
+                    {result && result.durationMs > 0 && (
+                      <span className="ml-auto text-xs text-zinc-400 flex-shrink-0">{(result.durationMs / 1000).toFixed(1)} s</span>
+                    )}
+                  </div>
+                );
+              })}
+            </div>
+
+            <div ref={outputRef} className="h-72 overflow-y-auto bg-zinc-950 px-4 py-3">
+              <pre className="text-xs font-mono text-zinc-200 whitespace-pre-wrap break-all">
+                {viewedRun
+                  ? viewedRun.log || '没有输出'
+                  : live
+                  ? live.lines.map(l => l.line).join('\n') || '等待输出...'
+                  : '点击运行后在这里显示每个步骤的输出'}
+              </pre>
+            </div>
+            {!viewedRun && live && live.status !== 'running' && (
+              <div className={`px-5 py-2 text-sm ${live.status === 'success' ? 'text-emerald-600 dark:text-emerald-400' : 'text-red-600 dark:text-red-400'}`}>
+                {live.status === 'success' ? '运行成功' : live.errorMsg || '运行失败'}
+              </div>
+            )}
+          </div>
+
+          <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 overflow-hidden">
+            <div className="flex justify-between items-center px-5 py-3 border-b border-zinc-200 dark:border-zinc-800">
+              <h2 className="text-sm font-semibold text-zinc-900 dark:text-white">运行记录</h2>
+              {runs.length > 0 && (
+                <button onClick={handleClearRuns} className="text-xs text-zinc-500 hover:text-red-600 dark:hover:text-red-400">
+                  清空
+                </button>
+              )}
+            </div>
+            <div className="divide-y divide-zinc-200 dark:divide-zinc-800 max-h-[26rem] overflow-y-auto">
+              {runs.length === 0 ? (
+                <p className="px-5 py-6 text-sm text-center text-zinc-500 dark:text-zinc-400">暂无运行记录</p>
+              ) : (
+                runs.map(run => (
+                  <button
+                    key={run.id}
+                    onClick={() => handleViewRun(run.id)}
+                    className={`w-full flex items-center gap-2 px-5 py-2.5 text-left hover:bg-zinc-50 dark:hover:bg-zinc-800/50 ${
+                      viewedRun?.id === run.id ? 'bg-zinc-50 dark:bg-zinc-800/50' : ''
+                    }`}
+                  >
+                    <StepStatusIcon status={run.status} />
+                    <div className="min-w-0">
+                      <p className="text-xs text-zinc-900 dark:text-white">
+                        {new Date(run.startedAt).toLocaleString()} · {triggerLabels[run.trigger] || run.trigger}
+                      </p>
+                      {run.errorMsg && <p className="text-xs text-red-600 dark:text-red-400 truncate">{run.errorMsg}</p>}
+                    </div>
+                  </button>
+                ))
+              )}
+            </div>
+          </div>
+        </div>
+      )}
+    </div>
+  );
+}
@@ -1,56 +1,147 @@
 import { useState, useEffect } from 'react';
-import { Plus, Trash2, Star, Check, X, RefreshCw, Edit2 } from 'lucide-react';
-import { GetServers, SaveServer, DeleteServer, SetDefaultServer, TestConnection } from '../../wailsjs/go/main/App';
+import { Plus, Trash2, Star, Check, X, RefreshCw, Edit2, ChevronDown, ChevronRight, Activity } from 'lucide-react';
+import { GetServers, GetKeys, SaveServer, DeleteServer, SetDefaultServer, TestServerConnection, GetServerHealth, CheckServerHealth } from '../../wailsjs/go/main/App';
+import { EventsOn } from '../../wailsjs/runtime/runtime';
+import { database, health } from '../../wailsjs/go/models';
+import { HealthBadge, LatencySparkline } from '../components/ServerHealth';
+
+interface ConnSettings {
+  rateLimitKB: number;
+  proxyUrl: string;
+  caCert: string;
+  skipVerify: boolean;
+  tlsServerName: string;
+  connectTimeout: number;
+  timeout: number;
+  headers: Record<string, string>;
+}
 
 interface Server {
   id: string;
   name: string;
   url: string;
   paths: string[];
+  keyId: string;
+  workers: number;
+  excludes: string[];
+  zipFolders: boolean;
+  conn: ConnSettings;
   isDefault: boolean;
   createdAt: string;
 }
 
+interface KeyOption {
+  id: string;
+  name: string;
+  isDefault: boolean;
+}
+
+// 表单中列表类字段以文本编辑，保存时再拆分
+interface ServerForm {
+  server: Server;
+  paths: string;
+  excludes: string;
+  headers: string;
+}
+
+const emptyConn: ConnSettings = {
+  rateLimitKB: 0,
+  proxyUrl: '',
+  caCert: '',
+  skipVerify: false,
+  tlsServerName: '',
+  connectTimeout: 0,
+  timeout: 0,
+  headers: {},
+};
+
+const emptyServer: Server = {
+  id: '',
+  name: '',
+  url: '',
+  paths: [],
+  keyId: '',
+  workers: 0,
+  excludes: [],
+  zipFolders: false,
+  conn: emptyConn,
+  isDefault: false,
+  createdAt: '',
+};
+
+const toForm = (server: Server): ServerForm => ({
+  server: { ...emptyServer, ...server, conn: { ...emptyConn, ...server.conn } },
+  paths: server.paths?.join(', ') || '',
+  excludes: server.excludes?.join('\n') || '',
+  headers: Object.entries(server.conn?.headers || {}).map(([k, v]) => `${k}: ${v}`).join('\n'),
+});
+
+// fromForm 把表单还原为完整的服务器配置，未在表单中显示的字段保持原值
+const fromForm = (form: ServerForm): database.Server => {
+  const headers: Record<string, string> = {};
+  form.headers.split('\n').forEach(line => {
+    const idx = line.indexOf(':');
+    if (idx > 0) headers[line.slice(0, idx).trim()] = line.slice(idx + 1).trim();
+  });
+  return database.Server.createFrom({
+    ...form.server,
+    url: form.server.url.trim().replace(/\/$/, ''),
+    paths: form.paths.split(',').map(p => p.trim()).filter(Boolean),
+    excludes: form.excludes.split('\n').map(p => p.trim()).filter(Boolean),
+    conn: { ...form.server.conn, headers },
+  });
+};
+
+const inputClass = 'w-full h-10 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent';
+const textareaClass = 'w-full px-3 py-2 text-sm font-mono rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent';
+const labelClass = 'block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-1.5';
+
 export default function ServersPage() {
   const [servers, setServers] = useState<Server[]>([]);
+  const [keys, setKeys] = useState<KeyOption[]>([]);
   const [isAdding, setIsAdding] = useState(false);
-  const [editingId, setEditingId] = useState<string | null>(null);
-  const [form, setForm] = useState({ name: '', url: '', paths: '' });
+  const [form, setForm] = useState<ServerForm>(toForm(emptyServer));
+  const [showAdvanced, setShowAdvanced] = useState(false);
   const [testing, setTesting] = useState<string | null>(null);
   const [testResult, setTestResult] = useState<{ id: string; success: boolean; message: string } | null>(null);
+  const [healthById, setHealthById] = useState<Record<string, health.Status>>({});
+  const [checking, setChecking] = useState<string | null>(null);
 
   useEffect(() => {
     loadServers();
   }, []);
 
+  // 后台定期检测各服务器，每次检测后推送 health:update
+  useEffect(() => {
+    const setStatus = (st: health.Status) => setHealthById(prev => ({ ...prev, [st.serverId]: st }));
+    GetServerHealth()
+      .then(list => (list || []).forEach(setStatus))
+      .catch(err => console.error('获取服务器状态失败:', err));
+    return EventsOn('health:update', setStatus);
+  }, []);
+
   const loadServers = async () => {
     try {
-      const list = await GetServers();
+      const [list, keyList] = await Promise.all([GetServers(), GetKeys()]);
       setServers(list || []);
+      setKeys(keyList || []);
     } catch (err) {
       console.error('加载服务器失败:', err);
     }
   };
 
+  const setServer = (patch: Partial<Server>) => setForm({ ...form, server: { ...form.server, ...patch } });
+  const setConn = (patch: Partial<ConnSettings>) => setServer({ conn: { ...form.server.conn, ...patch } });
+
   const handleSave = async () => {
-    if (!form.name || !form.url) {
+    if (!form.server.name || !form.server.url) {
       alert('请填写服务器名称和地址');
       return;
     }
 
     try {
-      const paths = form.paths.split(',').map(p => p.trim()).filter(Boolean);
-      await SaveServer({
-        id: editingId || '',
-        name: form.name,
-        url: form.url.replace(/\/$/, ''),
-        paths,
-        isDefault: false,
-        createdAt: '',
-      });
-      setIsAdding(false);
-      setEditingId(null);
-      setForm({ name: '', url: '', paths: '' });
+      await SaveServer(fromForm(form));
+      cancelEdit();
       loadServers();
     } catch (err) {
       console.error('保存失败:', err);
@@ -59,12 +150,8 @@ export default function ServersPage() {
   };
 
   const handleEdit = (server: Server) => {
-    setEditingId(server.id);
-    setForm({
-      name: server.name,
-      url: server.url,
-      paths: server.paths?.join(', ') || '',
-    });
+    setForm(toForm(server));
+    setShowAdvanced(false);
     setIsAdding(true);
   };
 
@@ -87,25 +174,40 @@ export default function ServersPage() {
     }
   };
 
-  const handleTest = async (server: Server) => {
-    setTesting(server.id);
+  // 使用服务器自己的连接设置 (代理、证书、请求头) 测试
+  const testConnection = async (id: string, server: database.Server) => {
+    setTesting(id);
     setTestResult(null);
     try {
-      await TestConnection(server.url);
-      setTestResult({ id: server.id, success: true, message: '连接成功' });
+      await TestServerConnection(server);
+      setTestResult({ id, success: true, message: '连接成功' });
     } catch (err: any) {
-      setTestResult({ id: server.id, success: false, message: err.message || '连接失败' });
+      setTestResult({ id, success: false, message: String(err?.message || err || '连接失败') });
     } finally {
       setTesting(null);
     }
   };
 
+  const checkHealth = async (id: string) => {
+    setChecking(id);
+    try {
+      const st = await CheckServerHealth(id);
+      setHealthById(prev => ({ ...prev, [id]: st }));
+    } catch (err) {
+      console.error('检测失败:', err);
+    } finally {
+      setChecking(null);
+    }
+  };
+
   const cancelEdit = () => {
     setIsAdding(false);
-    setEditingId(null);
-    setForm({ name: '', url: '', paths: '' });
+    setShowAdvanced(false);
+    setForm(toForm(emptyServer));
   };
 
+  const keyName = (id: string) => keys.find(k => k.id === id)?.name || id;
+
   return (
     <div className="space-y-6">
       {/* 头部 */}
@@ -129,40 +231,186 @@ export default function ServersPage() {
       {isAdding && (
         <div className="bg-white dark:bg-zinc-900 rounded-xl border border-zinc-200 dark:border-zinc-800 p-6">
           <h2 className="text-sm font-medium text-zinc-900 dark:text-white mb-4">
-            {editingId ? '编辑服务器' : '添加服务器'}
+            {form.server.id ? '编辑服务器' : '添加服务器'}
           </h2>
           <div className="space-y-4">
             <div>
-              <label className="block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-1.5">名称</label>
+              <label className={labelClass}>名称</label>
               <input
                 type="text"
-                value={form.name}
-                onChange={e => setForm({ ...form, name: e.target.value })}
+                value={form.server.name}
+                onChange={e => setServer({ name: e.target.value })}
                 placeholder="例如：生产服务器"
-                className="w-full h-10 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent"
+                className={inputClass}
               />
             </div>
             <div>
-              <label className="block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-1.5">地址</label>
+              <label className={labelClass}>地址</label>
               <input
                 type="text"
-                value={form.url}
-                onChange={e => setForm({ ...form, url: e.target.value })}
+                value={form.server.url}
+                onChange={e => setServer({ url: e.target.value })}
                 placeholder="例如：http://192.168.1.100:8022"
-                className="w-full h-10 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent"
+                className={inputClass}
               />
             </div>
             <div>
-              <label className="block text-sm font-medium text-zinc-700 dark:text-zinc-300 mb-1.5">路径标识</label>
+              <label className={labelClass}>路径标识</label>
               <input
                 type="text"
                 value={form.paths}
                 onChange={e => setForm({ ...form, paths: e.target.value })}
                 placeholder="例如：web, api, static (逗号分隔)"
-                className="w-full h-10 px-3 text-sm rounded-lg border border-zinc-300 dark:border-zinc-700 bg-white dark:bg-zinc-800 text-zinc-900 dark:text-white placeholder-zinc-400 dark:placeholder-zinc-500 focus:outline-none focus:ring-2 focus:ring-zinc-900 dark:focus:ring-white focus:border-transparent"
+                className={inputClass}
               />
               <p className="text-xs text-zinc-500 dark:text-zinc-400 mt-1.5">与服务器 config.json 中的 paths 配置对应</p>
             </div>
+            <div className="grid grid-cols-2 gap-4">
+              <div>
+                <label className={labelClass}>签名密钥</label>
+                <select
+                  value={form.server.keyId}
+                  onChange={e => setServer({ keyId: e.target.value })}
+                  className={inputClass}
+                >
+                  <option value="">默认密钥</option>
+                  {keys.map(k => (
+                    <option key={k.id} value={k.id}>{k.name}{k.isDefault ? ' (默认)' : ''}</option>
+                  ))}
+                </select>
+              </div>
+              <div>
+                <label className={labelClass}>文件夹上传并发数</label>
+                <input
+                  type="number"
+                  min={0}
+                  max={16}
+                  value={form.server.workers}
+                  onChange={e => setServer({ workers: Number(e.target.value) || 0 })}
+                  className={inputClass}
+                />
+                <p className="text-xs text-zinc-500 dark:text-zinc-400 mt-1.5">0 使用默认值</p>
+              </div>
+            </div>
+            <div>
+              <label className={labelClass}>排除规则</label>
+              <textarea
+                rows={3}
+                value={form.excludes}
+                onChange={e => setForm({ ...form, excludes: e.target.value })}
+                placeholder={'每行一条，.deployignore 语法，例如：\nnode_modules/\n*.log'}
+                className={textareaClass}
+              />
+            </div>
+            <label className="flex items-center gap-2 text-sm text-zinc-700 dark:text-zinc-300">
+              <input
+                type="checkbox"
+                checked={form.server.zipFolders}
+                onChange={e => setServer({ zipFolders: e.target.checked })}
+                className="rounded border-zinc-300 dark:border-zinc-700"
+              />
+              上传文件夹时打包为 zip，由服务器解压
+            </label>
+
+            {/* 连接设置 */}
+            <button
+              onClick={() => setShowAdvanced(!showAdvanced)}
+              className="inline-flex items-center gap-1 text-sm font-medium text-zinc-600 dark:text-zinc-400 hover:text-zinc-900 dark:hover:text-white"
+            >
+              {showAdvanced ? <ChevronDown size={16} /> : <ChevronRight size={16} />}
+              连接设置
+            </button>
+            {showAdvanced && (
+              <div className="space-y-4 pl-5 border-l-2 border-zinc-200 dark:border-zinc-800">
+                <div className="grid grid-cols-2 gap-4">
+                  <div>
+                    <label className={labelClass}>代理</label>
+                    <input
+                      type="text"
+                      value={form.server.conn.proxyUrl}
+                      onChange={e => setConn({ proxyUrl: e.target.value })}
+                      placeholder="留空使用系统代理，direct 不使用代理"
+                      className={inputClass}
+                    />
+                  </div>
+                  <div>
+                    <label className={labelClass}>上传限速 (KB/s)</label>
+                    <input
+                      type="number"
+                      min={0}
+                      value={form.server.conn.rateLimitKB}
+                      onChange={e => setConn({ rateLimitKB: Number(e.target.value) || 0 })}
+                      className={inputClass}
+                    />
+                  </div>
+                  <div>
+                    <label className={labelClass}>连接超时 (秒)</label>
+                    <input
+                      type="number"
+                      min={0}
+                      value={form.server.conn.connectTimeout}
+                      onChange={e => setConn({ connectTimeout: Number(e.target.value) || 0 })}
+                      className={inputClass}
+                    />
+                  </div>
+                  <div>
+                    <label className={labelClass}>请求超时 (秒)</label>
+                    <input
+                      type="number"
+                      min={0}
+                      value={form.server.conn.timeout}
+                      onChange={e => setConn({ timeout: Number(e.target.value) || 0 })}
+                      className={inputClass}
+                    />
+                  </div>
+                  <div>
+                    <label className={labelClass}>TLS 主机名</label>
+                    <input
+                      type="text"
+                      value={form.server.conn.tlsServerName}
+                      onChange={e => setConn({ tlsServerName: e.target.value })}
+                      placeholder="通过 IP 访问时验证证书使用的主机名"
+                      className={inputClass}
+                    />
+                  </div>
+                  <label className="flex items-center gap-2 text-sm text-zinc-700 dark:text-zinc-300 pt-7">
+                    <input
+                      type="checkbox"
+                      checked={form.server.conn.skipVerify}
+                      onChange={e => setConn({ skipVerify: e.target.checked })}
+                      className="rounded border-zinc-300 dark:border-zinc-700"
+                    />
+                    不验证服务器证书 (仅用于测试)
+                  </label>
+                </div>
+                <div>
+                  <label className={labelClass}>CA 证书 (PEM)</label>
+                  <textarea
+                    rows={3}
+                    value={form.server.conn.caCert}
+                    onChange={e => setConn({ caCert: e.target.value })}
+                    placeholder="-----BEGIN CERTIFICATE-----"
+                    className={textareaClass}
+                  />
+                </div>
+                <div>
+                  <label className={labelClass}>附加请求头</label>
+                  <textarea
+                    rows={2}
+                    value={form.headers}
+                    onChange={e => setForm({ ...form, headers: e.target.value })}
+                    placeholder="每行一个，例如：Authorization: Bearer xxx"
+                    className={textareaClass}
+                  />
+                </div>
+              </div>
+            )}
+
+            {testResult?.id === 'form' && (
+              <p className={`text-sm ${testResult.success ? 'text-emerald-600 dark:text-emerald-400' : 'text-red-600 dark:text-red-400'}`}>
+                {testResult.message}
+              </p>
+            )}
             <div className="flex gap-3 pt-2">
               <button
                 onClick={handleSave}
@@ -171,6 +419,14 @@ export default function ServersPage() {
                 <Check size={16} />
                 保存
               </button>
+              <button
+                onClick={() => testConnection('form', fromForm(form))}
+                disabled={!form.server.url || testing === 'form'}
+                className="inline-flex items-center gap-2 px-4 py-2.5 text-sm font-medium rounded-lg border border-zinc-300 dark:border-zinc-700 text-zinc-700 dark:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 disabled:opacity-50 transition-colors"
+              >
+                <RefreshCw size={16} className={testing === 'form' ? 'animate-spin' : ''} />
+                测试连接
+              </button>
               <button
                 onClick={cancelEdit}
                 className="inline-flex items-center gap-2 px-4 py-2.5 text-sm font-medium rounded-lg border border-zinc-300 dark:border-zinc-700 text-zinc-700 dark:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 transition-colors"
@@ -198,6 +454,7 @@ export default function ServersPage() {
               <div className="flex-1 min-w-0">
                 <div className="flex items-center gap-2 mb-1">
                   <h3 className="text-sm font-medium text-zinc-900 dark:text-white">{server.name}</h3>
+                  <HealthBadge status={healthById[server.id]} />
                   {server.isDefault && (
                     <span className="inline-flex items-center gap-1 px-2 py-0.5 text-xs font-medium rounded-full bg-amber-100 dark:bg-amber-900/30 text-amber-700 dark:text-amber-400">
                       <Star size={10} />
@@ -205,7 +462,13 @@ export default function ServersPage() {
                     </span>
                   )}
                 </div>
-                <p className="text-sm text-zinc-500 dark:text-zinc-400 mb-3">{server.url}</p>
+                <div className="flex items-center gap-3 mb-3">
+                  <p className="text-sm text-zinc-500 dark:text-zinc-400 truncate">{server.url}</p>
+                  <LatencySparkline history={healthById[server.id]?.history} />
+                  {healthById[server.id]?.reachable && healthById[server.id]?.version && (
+                    <span className="text-xs text-zinc-400 dark:text-zinc-500 flex-shrink-0">v{healthById[server.id].version}</span>
+                  )}
+                </div>
                 {server.paths?.length > 0 && (
                   <div className="flex gap-1.5 flex-wrap">
                     {server.paths.map(p => (
@@ -215,6 +478,14 @@ export default function ServersPage() {
                     ))}
                   </div>
                 )}
+                <p className="text-xs text-zinc-400 dark:text-zinc-500 mt-2">
+                  密钥: {server.keyId ? keyName(server.keyId) : '默认'}
+                  {server.workers > 0 && ` · 并发 ${server.workers}`}
+                  {server.zipFolders && ' · 打包上传'}
+                  {server.excludes?.length > 0 && ` · 排除 ${server.excludes.length} 条规则`}
+                  {server.conn?.proxyUrl && ` · 代理 ${server.conn.proxyUrl.replace(/\/\/[^@/]*@/, '//***@')}`}
+                  {server.conn?.rateLimitKB > 0 && ` · 限速 ${server.conn.rateLimitKB} KB/s`}
+                </p>
                 {testResult?.id === server.id && (
                   <p className={`text-sm mt-3 ${testResult.success ? 'text-emerald-600 dark:text-emerald-400' : 'text-red-600 dark:text-red-400'}`}>
                     {testResult.message}
@@ -223,7 +494,15 @@ export default function ServersPage() {
               </div>
               <div className="flex gap-1">
                 <button
-                  onClick={() => handleTest(server)}
+                  onClick={() => checkHealth(server.id)}
+                  disabled={checking === server.id}
+                  className="p-2 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors disabled:opacity-50"
+                  title="立即检测状态"
+                >
+                  <Activity size={16} className={checking === server.id ? 'animate-pulse' : ''} />
+                </button>
+                <button
+                  onClick={() => testConnection(server.id, database.Server.createFrom(server))}
                   disabled={testing === server.id}
                   className="p-2 text-zinc-400 hover:text-zinc-600 dark:hover:text-zinc-300 hover:bg-zinc-100 dark:hover:bg-zinc-800 rounded-lg transition-colors disabled:opacity-50"
                   title="测试连接"
@@ -1,7 +1,9 @@
 import { useState, useEffect, useCallback } from 'react';
 import { Upload as UploadIcon, File, Folder, X, Check, AlertCircle } from 'lucide-react';
-import { GetServers, SelectFile, SelectFolder, UploadFile, GetFileInfo } from '../../wailsjs/go/main/App';
+import { GetServers, SelectFile, SelectFolder, UploadFile, GetFileInfo, PreviewDeploy, DeploySelected } from '../../wailsjs/go/main/App';
 import { EventsOn, EventsOff } from '../../wailsjs/runtime/runtime';
+import { main } from '../../wailsjs/go/models';
+import DeployPreviewDialog from '../components/DeployPreviewDialog';
 
 interface Server {
   id: string;
@@ -30,6 +32,8 @@ export default function UploadPage() {
   const [extract, setExtract] = useState(false);
   const [tasks, setTasks] = useState<UploadTask[]>([]);
   const [isDragging, setIsDragging] = useState(false);
+  // 正在确认的文件夹差异预览，resolve 返回选中的相对路径，取消时为 null
+  const [preview, setPreview] = useState<{ data: main.DeployPreview; resolve: (relPaths: string[] | null) => void } | null>(null);
 
   useEffect(() => {
     loadServers();
@@ -131,12 +135,27 @@ export default function UploadPage() {
     const pendingTasks = tasks.filter(t => t.status === 'pending');
 
     for (const task of pendingTasks) {
+      // 逐个文件上传的文件夹先预览差异，确认后只上传选中的文件；打包上传时整个文件夹作为一个 zip
+      let relPaths: string[] | null = null;
+      if (task.isDir && !extract) {
+        try {
+          const data = await PreviewDeploy(selectedServer, pathKey, task.filePath);
+          relPaths = await new Promise<string[] | null>(resolve => setPreview({ data, resolve }));
+          setPreview(null);
+          if (relPaths === null) continue;
+        } catch (err) {
+          if (!confirm(`无法预览 ${task.filename} 的差异: ${err}\n\n是否直接上传整个文件夹？`)) continue;
+        }
+      }
+
       setTasks(prev => prev.map(t =>
         t.id === task.id ? { ...t, status: 'uploading' } : t
       ));
 
       try {
-        const result = await UploadFile(selectedServer, pathKey, task.filePath, extract);
+        const result = relPaths
+          ? await DeploySelected(selectedServer, pathKey, task.filePath, relPaths)
+          : await UploadFile(selectedServer, pathKey, task.filePath, extract);
         setTasks(prev => prev.map(t =>
           t.id === task.id
             ? { ...t, status: result.success ? 'success' : 'error', progress: 100, error: result.error }
@@ -327,6 +346,15 @@ export default function UploadPage() {
         </div>
       )}
 
+      {preview && (
+        <DeployPreviewDialog
+          preview={preview.data}
+          formatSize={formatSize}
+          onConfirm={relPaths => preview.resolve(relPaths)}
+          onCancel={() => preview.resolve(null)}
+        />
+      )}
+
       {servers.length === 0 && (
         <div className="bg-amber-50 dark:bg-amber-900/20 border border-amber-200 dark:border-amber-800 rounded-xl p-6 text-center">
           <p className="text-sm text-amber-800 dark:text-amber-200">尚未配置服务器</p>
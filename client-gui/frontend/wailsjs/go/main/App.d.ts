@@ -1,50 +1,177 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {health} from '../models';
 import {database} from '../models';
 import {main} from '../models';
+import {keystore} from '../models';
+import {bundle} from '../models';
+
+export function CancelRecipe(arg1:string):Promise<void>;
+
+export function CancelUpload(arg1:string):Promise<void>;
+
+export function CheckServerHealth(arg1:string):Promise<health.Status>;
 
 export function ClearHistory():Promise<void>;
 
+export function ClearQueue(arg1:string):Promise<void>;
+
+export function ClearRecipeRuns():Promise<void>;
+
+export function CreateKey(arg1:string,arg2:string,arg3:string):Promise<database.KeyPair>;
+
+export function DeleteKey(arg1:string):Promise<void>;
+
+export function DeleteRecipe(arg1:string):Promise<void>;
+
 export function DeleteSchedule(arg1:string):Promise<void>;
 
 export function DeleteServer(arg1:string):Promise<void>;
 
+export function DeleteServerGroup(arg1:string):Promise<void>;
+
 export function DeleteWatch(arg1:string):Promise<void>;
 
+export function DeploySelected(arg1:string,arg2:string,arg3:string,arg4:Array<string>):Promise<main.UploadResultWrapper>;
+
+export function DeployToGroup(arg1:string,arg2:string,arg3:string,arg4:boolean):Promise<main.DeployResult>;
+
+export function DropQueueJob(arg1:string):Promise<void>;
+
+export function ExportConfig(arg1:boolean,arg2:string):Promise<string>;
+
+export function ExportHistory(arg1:database.HistoryFilter,arg2:string):Promise<string>;
+
+export function ExportKey(arg1:string,arg2:string):Promise<string>;
+
 export function GenerateKeyPair():Promise<Record<string, string>>;
 
+export function GetActiveUploads():Promise<Array<main.UploadJobInfo>>;
+
 export function GetDataDir():Promise<string>;
 
+export function GetEngineStatus():Promise<main.EngineStatus>;
+
 export function GetFileInfo(arg1:string):Promise<Record<string, any>>;
 
+export function GetHealthInterval():Promise<number>;
+
 export function GetHistory(arg1:number):Promise<Array<database.HistoryEntry>>;
 
+export function GetHistoryFiles(arg1:number):Promise<Array<database.HistoryFile>>;
+
+export function GetKey(arg1:string):Promise<database.KeyPair>;
+
 export function GetKeyPair():Promise<database.KeyPair>;
 
+export function GetKeyStatus():Promise<keystore.Status>;
+
+export function GetKeys():Promise<Array<database.KeyPair>>;
+
+export function GetNotifySettings():Promise<main.NotifySettings>;
+
 export function GetPublicKeyFromPrivate(arg1:string):Promise<string>;
 
+export function GetQueue(arg1:string):Promise<Array<database.QueueJob>>;
+
+export function GetRecipeRun(arg1:number):Promise<database.RecipeRun>;
+
+export function GetRecipeRuns(arg1:string,arg2:number):Promise<Array<database.RecipeRun>>;
+
+export function GetRecipes():Promise<Array<database.Recipe>>;
+
+export function GetRunningRecipes():Promise<Array<string>>;
+
+export function GetRunningWatches():Promise<Array<string>>;
+
 export function GetSchedules():Promise<Array<database.Schedule>>;
 
+export function GetServerGroups():Promise<Array<database.ServerGroup>>;
+
+export function GetServerHealth():Promise<Array<health.Status>>;
+
 export function GetServerInfo(arg1:string):Promise<Record<string, any>>;
 
 export function GetServers():Promise<Array<database.Server>>;
 
+export function GetTraySettings():Promise<main.TraySettings>;
+
 export function GetWatches():Promise<Array<database.WatchConfig>>;
 
+export function GetWatchesPaused():Promise<boolean>;
+
+export function ImportConfig(arg1:string,arg2:string,arg3:string):Promise<bundle.ImportResult>;
+
+export function ImportConfigFile(arg1:string,arg2:string):Promise<bundle.ImportResult>;
+
+export function ImportKey(arg1:string,arg2:string,arg3:string):Promise<database.KeyPair>;
+
+export function ImportKeyFile(arg1:string,arg2:string):Promise<database.KeyPair>;
+
+export function LockKey():Promise<void>;
+
+export function PauseUpload(arg1:string):Promise<void>;
+
+export function PauseWatches():Promise<void>;
+
+export function PreviewCron(arg1:string,arg2:number):Promise<Array<string>>;
+
+export function PreviewDeploy(arg1:string,arg2:string,arg3:string):Promise<main.DeployPreview>;
+
+export function QueryHistory(arg1:database.HistoryFilter):Promise<database.HistoryPage>;
+
+export function ResumeUpload(arg1:string):Promise<void>;
+
+export function ResumeWatches():Promise<void>;
+
+export function RetryQueueJob(arg1:string):Promise<void>;
+
+export function RunRecipe(arg1:string):Promise<database.RecipeRun>;
+
+export function RunScheduleNow(arg1:string):Promise<void>;
+
 export function SaveKeyPair(arg1:string):Promise<void>;
 
+export function SaveRecipe(arg1:database.Recipe):Promise<void>;
+
 export function SaveSchedule(arg1:database.Schedule):Promise<void>;
 
 export function SaveServer(arg1:database.Server):Promise<void>;
 
+export function SaveServerGroup(arg1:database.ServerGroup):Promise<void>;
+
 export function SaveWatch(arg1:database.WatchConfig):Promise<void>;
 
 export function SelectFile():Promise<string>;
 
 export function SelectFolder():Promise<string>;
 
+export function SetAutoLock(arg1:number):Promise<void>;
+
+export function SetCloseToTray(arg1:boolean):Promise<void>;
+
+export function SetDefaultKey(arg1:string):Promise<void>;
+
 export function SetDefaultServer(arg1:string):Promise<void>;
 
+export function SetHealthInterval(arg1:number):Promise<void>;
+
+export function SetKeyProtection(arg1:string,arg2:string):Promise<void>;
+
+export function SetNotifySettings(arg1:main.NotifySettings):Promise<void>;
+
+export function StartEngines():Promise<void>;
+
+export function SyncWatch(arg1:string,arg2:boolean,arg3:boolean):Promise<main.SyncResult>;
+
 export function TestConnection(arg1:string):Promise<void>;
 
+export function TestNotification():Promise<void>;
+
+export function TestServerConnection(arg1:database.Server):Promise<void>;
+
+export function UnlockKey(arg1:string):Promise<void>;
+
+export function UpdateKey(arg1:string,arg2:string,arg3:string):Promise<void>;
+
 export function UploadFile(arg1:string,arg2:string,arg3:string,arg4:boolean):Promise<main.UploadResultWrapper>;
@@ -2,10 +2,42 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelRecipe(arg1) {
+  return window['go']['main']['App']['CancelRecipe'](arg1);
+}
+
+export function CancelUpload(arg1) {
+  return window['go']['main']['App']['CancelUpload'](arg1);
+}
+
+export function CheckServerHealth(arg1) {
+  return window['go']['main']['App']['CheckServerHealth'](arg1);
+}
+
 export function ClearHistory() {
   return window['go']['main']['App']['ClearHistory']();
 }
 
+export function ClearQueue(arg1) {
+  return window['go']['main']['App']['ClearQueue'](arg1);
+}
+
+export function ClearRecipeRuns() {
+  return window['go']['main']['App']['ClearRecipeRuns']();
+}
+
+export function CreateKey(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CreateKey'](arg1, arg2, arg3);
+}
+
+export function DeleteKey(arg1) {
+  return window['go']['main']['App']['DeleteKey'](arg1);
+}
+
+export function DeleteRecipe(arg1) {
+  return window['go']['main']['App']['DeleteRecipe'](arg1);
+}
+
 export function DeleteSchedule(arg1) {
   return window['go']['main']['App']['DeleteSchedule'](arg1);
 }
@@ -14,38 +46,130 @@ export function DeleteServer(arg1) {
   return window['go']['main']['App']['DeleteServer'](arg1);
 }
 
+export function DeleteServerGroup(arg1) {
+  return window['go']['main']['App']['DeleteServerGroup'](arg1);
+}
+
 export function DeleteWatch(arg1) {
   return window['go']['main']['App']['DeleteWatch'](arg1);
 }
 
+export function DeploySelected(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['DeploySelected'](arg1, arg2, arg3, arg4);
+}
+
+export function DeployToGroup(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['DeployToGroup'](arg1, arg2, arg3, arg4);
+}
+
+export function DropQueueJob(arg1) {
+  return window['go']['main']['App']['DropQueueJob'](arg1);
+}
+
+export function ExportConfig(arg1, arg2) {
+  return window['go']['main']['App']['ExportConfig'](arg1, arg2);
+}
+
+export function ExportHistory(arg1, arg2) {
+  return window['go']['main']['App']['ExportHistory'](arg1, arg2);
+}
+
+export function ExportKey(arg1, arg2) {
+  return window['go']['main']['App']['ExportKey'](arg1, arg2);
+}
+
 export function GenerateKeyPair() {
   return window['go']['main']['App']['GenerateKeyPair']();
 }
 
+export function GetActiveUploads() {
+  return window['go']['main']['App']['GetActiveUploads']();
+}
+
 export function GetDataDir() {
   return window['go']['main']['App']['GetDataDir']();
 }
 
+export function GetEngineStatus() {
+  return window['go']['main']['App']['GetEngineStatus']();
+}
+
 export function GetFileInfo(arg1) {
   return window['go']['main']['App']['GetFileInfo'](arg1);
 }
 
+export function GetHealthInterval() {
+  return window['go']['main']['App']['GetHealthInterval']();
+}
+
 export function GetHistory(arg1) {
   return window['go']['main']['App']['GetHistory'](arg1);
 }
 
+export function GetHistoryFiles(arg1) {
+  return window['go']['main']['App']['GetHistoryFiles'](arg1);
+}
+
+export function GetKey(arg1) {
+  return window['go']['main']['App']['GetKey'](arg1);
+}
+
 export function GetKeyPair() {
   return window['go']['main']['App']['GetKeyPair']();
 }
 
+export function GetKeyStatus() {
+  return window['go']['main']['App']['GetKeyStatus']();
+}
+
+export function GetKeys() {
+  return window['go']['main']['App']['GetKeys']();
+}
+
+export function GetNotifySettings() {
+  return window['go']['main']['App']['GetNotifySettings']();
+}
+
 export function GetPublicKeyFromPrivate(arg1) {
   return window['go']['main']['App']['GetPublicKeyFromPrivate'](arg1);
 }
 
+export function GetQueue(arg1) {
+  return window['go']['main']['App']['GetQueue'](arg1);
+}
+
+export function GetRecipeRun(arg1) {
+  return window['go']['main']['App']['GetRecipeRun'](arg1);
+}
+
+export function GetRecipeRuns(arg1, arg2) {
+  return window['go']['main']['App']['GetRecipeRuns'](arg1, arg2);
+}
+
+export function GetRecipes() {
+  return window['go']['main']['App']['GetRecipes']();
+}
+
+export function GetRunningRecipes() {
+  return window['go']['main']['App']['GetRunningRecipes']();
+}
+
+export function GetRunningWatches() {
+  return window['go']['main']['App']['GetRunningWatches']();
+}
+
 export function GetSchedules() {
   return window['go']['main']['App']['GetSchedules']();
 }
 
+export function GetServerGroups() {
+  return window['go']['main']['App']['GetServerGroups']();
+}
+
+export function GetServerHealth() {
+  return window['go']['main']['App']['GetServerHealth']();
+}
+
 export function GetServerInfo(arg1) {
   return window['go']['main']['App']['GetServerInfo'](arg1);
 }
@@ -54,14 +178,86 @@ export function GetServers() {
   return window['go']['main']['App']['GetServers']();
 }
 
+export function GetTraySettings() {
+  return window['go']['main']['App']['GetTraySettings']();
+}
+
 export function GetWatches() {
   return window['go']['main']['App']['GetWatches']();
 }
 
+export function GetWatchesPaused() {
+  return window['go']['main']['App']['GetWatchesPaused']();
+}
+
+export function ImportConfig(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportConfig'](arg1, arg2, arg3);
+}
+
+export function ImportConfigFile(arg1, arg2) {
+  return window['go']['main']['App']['ImportConfigFile'](arg1, arg2);
+}
+
+export function ImportKey(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ImportKey'](arg1, arg2, arg3);
+}
+
+export function ImportKeyFile(arg1, arg2) {
+  return window['go']['main']['App']['ImportKeyFile'](arg1, arg2);
+}
+
+export function LockKey() {
+  return window['go']['main']['App']['LockKey']();
+}
+
+export function PauseUpload(arg1) {
+  return window['go']['main']['App']['PauseUpload'](arg1);
+}
+
+export function PauseWatches() {
+  return window['go']['main']['App']['PauseWatches']();
+}
+
+export function PreviewCron(arg1, arg2) {
+  return window['go']['main']['App']['PreviewCron'](arg1, arg2);
+}
+
+export function PreviewDeploy(arg1, arg2, arg3) {
+  return window['go']['main']['App']['PreviewDeploy'](arg1, arg2, arg3);
+}
+
+export function QueryHistory(arg1) {
+  return window['go']['main']['App']['QueryHistory'](arg1);
+}
+
+export function ResumeUpload(arg1) {
+  return window['go']['main']['App']['ResumeUpload'](arg1);
+}
+
+export function ResumeWatches() {
+  return window['go']['main']['App']['ResumeWatches']();
+}
+
+export function RetryQueueJob(arg1) {
+  return window['go']['main']['App']['RetryQueueJob'](arg1);
+}
+
+export function RunRecipe(arg1) {
+  return window['go']['main']['App']['RunRecipe'](arg1);
+}
+
+export function RunScheduleNow(arg1) {
+  return window['go']['main']['App']['RunScheduleNow'](arg1);
+}
+
 export function SaveKeyPair(arg1) {
   return window['go']['main']['App']['SaveKeyPair'](arg1);
 }
 
+export function SaveRecipe(arg1) {
+  return window['go']['main']['App']['SaveRecipe'](arg1);
+}
+
 export function SaveSchedule(arg1) {
   return window['go']['main']['App']['SaveSchedule'](arg1);
 }
@@ -70,6 +266,10 @@ export function SaveServer(arg1) {
   return window['go']['main']['App']['SaveServer'](arg1);
 }
 
+export function SaveServerGroup(arg1) {
+  return window['go']['main']['App']['SaveServerGroup'](arg1);
+}
+
 export function SaveWatch(arg1) {
   return window['go']['main']['App']['SaveWatch'](arg1);
 }
@@ -82,14 +282,62 @@ export function SelectFolder() {
   return window['go']['main']['App']['SelectFolder']();
 }
 
+export function SetAutoLock(arg1) {
+  return window['go']['main']['App']['SetAutoLock'](arg1);
+}
+
+export function SetCloseToTray(arg1) {
+  return window['go']['main']['App']['SetCloseToTray'](arg1);
+}
+
+export function SetDefaultKey(arg1) {
+  return window['go']['main']['App']['SetDefaultKey'](arg1);
+}
+
 export function SetDefaultServer(arg1) {
   return window['go']['main']['App']['SetDefaultServer'](arg1);
 }
 
+export function SetHealthInterval(arg1) {
+  return window['go']['main']['App']['SetHealthInterval'](arg1);
+}
+
+export function SetKeyProtection(arg1, arg2) {
+  return window['go']['main']['App']['SetKeyProtection'](arg1, arg2);
+}
+
+export function SetNotifySettings(arg1) {
+  return window['go']['main']['App']['SetNotifySettings'](arg1);
+}
+
+export function StartEngines() {
+  return window['go']['main']['App']['StartEngines']();
+}
+
+export function SyncWatch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SyncWatch'](arg1, arg2, arg3);
+}
+
 export function TestConnection(arg1) {
   return window['go']['main']['App']['TestConnection'](arg1);
 }
 
+export function TestNotification() {
+  return window['go']['main']['App']['TestNotification']();
+}
+
+export function TestServerConnection(arg1) {
+  return window['go']['main']['App']['TestServerConnection'](arg1);
+}
+
+export function UnlockKey(arg1) {
+  return window['go']['main']['App']['UnlockKey'](arg1);
+}
+
+export function UpdateKey(arg1, arg2, arg3) {
+  return window['go']['main']['App']['UpdateKey'](arg1, arg2, arg3);
+}
+
 export function UploadFile(arg1, arg2, arg3, arg4) {
   return window['go']['main']['App']['UploadFile'](arg1, arg2, arg3, arg4);
 }
@@ -1,5 +1,72 @@
+export namespace bundle {
+	
+	export class ImportResult {
+	    servers: number;
+	    groups: number;
+	    recipes: number;
+	    watches: number;
+	    schedules: number;
+	    keys: number;
+	    renamed: number;
+	    warnings: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.servers = source["servers"];
+	        this.groups = source["groups"];
+	        this.recipes = source["recipes"];
+	        this.watches = source["watches"];
+	        this.schedules = source["schedules"];
+	        this.keys = source["keys"];
+	        this.renamed = source["renamed"];
+	        this.warnings = source["warnings"];
+	    }
+	}
+
+}
+
 export namespace database {
 	
+	export class HistoryFile {
+	    id: number;
+	    historyId: number;
+	    relPath: string;
+	    fileSize: number;
+	    sha256: string;
+	    durationMs: number;
+	    httpStatus: number;
+	    serverPath: string;
+	    status: string;
+	    errorMsg: string;
+	    serverId: string;
+	    serverName: string;
+	    serverSha256: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryFile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.historyId = source["historyId"];
+	        this.relPath = source["relPath"];
+	        this.fileSize = source["fileSize"];
+	        this.sha256 = source["sha256"];
+	        this.durationMs = source["durationMs"];
+	        this.httpStatus = source["httpStatus"];
+	        this.serverPath = source["serverPath"];
+	        this.status = source["status"];
+	        this.errorMsg = source["errorMsg"];
+	        this.serverId = source["serverId"];
+	        this.serverName = source["serverName"];
+	        this.serverSha256 = source["serverSha256"];
+	    }
+	}
 	export class HistoryEntry {
 	    id: number;
 	    serverId: string;
@@ -10,6 +77,10 @@ export namespace database {
 	    status: string;
 	    errorMsg: string;
 	    uploadedAt: string;
+	    fileCount: number;
+	    durationMs: number;
+	    groupId: string;
+	    files?: HistoryFile[];
 	
 	    static createFrom(source: any = {}) {
 	        return new HistoryEntry(source);
@@ -26,12 +97,101 @@ export namespace database {
 	        this.status = source["status"];
 	        this.errorMsg = source["errorMsg"];
 	        this.uploadedAt = source["uploadedAt"];
+	        this.fileCount = source["fileCount"];
+	        this.durationMs = source["durationMs"];
+	        this.groupId = source["groupId"];
+	        this.files = this.convertValues(source["files"], HistoryFile);
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class HistoryFilter {
+	    serverId: string;
+	    pathKey: string;
+	    status: string;
+	    from: string;
+	    to: string;
+	    search: string;
+	    offset: number;
+	    limit: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.serverId = source["serverId"];
+	        this.pathKey = source["pathKey"];
+	        this.status = source["status"];
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.search = source["search"];
+	        this.offset = source["offset"];
+	        this.limit = source["limit"];
+	    }
+	}
+	export class HistoryPage {
+	    entries: HistoryEntry[];
+	    total: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], HistoryEntry);
+	        this.total = source["total"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class KeyPair {
+	    id: string;
+	    name: string;
 	    privateKey: string;
 	    publicKey: string;
+	    fingerprint: string;
+	    notes: string;
+	    protection: string;
+	    isDefault: boolean;
+	    createdAt: string;
 	    updatedAt: string;
+	    locked: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new KeyPair(source);
@@ -39,11 +199,215 @@ export namespace database {
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
 	        this.privateKey = source["privateKey"];
 	        this.publicKey = source["publicKey"];
+	        this.fingerprint = source["fingerprint"];
+	        this.notes = source["notes"];
+	        this.protection = source["protection"];
+	        this.isDefault = source["isDefault"];
+	        this.createdAt = source["createdAt"];
 	        this.updatedAt = source["updatedAt"];
+	        this.locked = source["locked"];
 	    }
 	}
+	export class QueueJob {
+	    id: string;
+	    serverId: string;
+	    pathKey: string;
+	    filePath: string;
+	    relPath: string;
+	    extract: boolean;
+	    source: string;
+	    sourceId: string;
+	    status: string;
+	    attempts: number;
+	    maxAttempts: number;
+	    lastError: string;
+	    errorCode: string;
+	    nextAttemptAt: string;
+	    createdAt: string;
+	    updatedAt: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueJob(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.serverId = source["serverId"];
+	        this.pathKey = source["pathKey"];
+	        this.filePath = source["filePath"];
+	        this.relPath = source["relPath"];
+	        this.extract = source["extract"];
+	        this.source = source["source"];
+	        this.sourceId = source["sourceId"];
+	        this.status = source["status"];
+	        this.attempts = source["attempts"];
+	        this.maxAttempts = source["maxAttempts"];
+	        this.lastError = source["lastError"];
+	        this.errorCode = source["errorCode"];
+	        this.nextAttemptAt = source["nextAttemptAt"];
+	        this.createdAt = source["createdAt"];
+	        this.updatedAt = source["updatedAt"];
+	    }
+	}
+	export class RecipeStep {
+	    type: string;
+	    name: string;
+	    always: boolean;
+	    command?: string;
+	    dir?: string;
+	    source?: string;
+	    output?: string;
+	    excludes?: string[];
+	    serverId?: string;
+	    groupId?: string;
+	    pathKey?: string;
+	    extract?: boolean;
+	    url?: string;
+	    expectStatus?: number;
+	    contains?: string;
+	    message?: string;
+	    timeoutSeconds?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecipeStep(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.name = source["name"];
+	        this.always = source["always"];
+	        this.command = source["command"];
+	        this.dir = source["dir"];
+	        this.source = source["source"];
+	        this.output = source["output"];
+	        this.excludes = source["excludes"];
+	        this.serverId = source["serverId"];
+	        this.groupId = source["groupId"];
+	        this.pathKey = source["pathKey"];
+	        this.extract = source["extract"];
+	        this.url = source["url"];
+	        this.expectStatus = source["expectStatus"];
+	        this.contains = source["contains"];
+	        this.message = source["message"];
+	        this.timeoutSeconds = source["timeoutSeconds"];
+	    }
+	}
+	export class Recipe {
+	    id: string;
+	    name: string;
+	    steps: RecipeStep[];
+	    createdAt: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Recipe(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.steps = this.convertValues(source["steps"], RecipeStep);
+	        this.createdAt = source["createdAt"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecipeStepResult {
+	    name: string;
+	    type: string;
+	    status: string;
+	    summary: string;
+	    error: string;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecipeStepResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.status = source["status"];
+	        this.summary = source["summary"];
+	        this.error = source["error"];
+	        this.durationMs = source["durationMs"];
+	    }
+	}
+	export class RecipeRun {
+	    id: number;
+	    recipeId: string;
+	    recipeName: string;
+	    trigger: string;
+	    status: string;
+	    errorMsg: string;
+	    steps: RecipeStepResult[];
+	    log?: string;
+	    startedAt: string;
+	    finishedAt: string;
+	    durationMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecipeRun(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.recipeId = source["recipeId"];
+	        this.recipeName = source["recipeName"];
+	        this.trigger = source["trigger"];
+	        this.status = source["status"];
+	        this.errorMsg = source["errorMsg"];
+	        this.steps = this.convertValues(source["steps"], RecipeStepResult);
+	        this.log = source["log"];
+	        this.startedAt = source["startedAt"];
+	        this.finishedAt = source["finishedAt"];
+	        this.durationMs = source["durationMs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
 	export class Schedule {
 	    id: string;
 	    name: string;
@@ -53,6 +417,12 @@ export namespace database {
 	    pathKey: string;
 	    extract: boolean;
 	    enabled: boolean;
+	    overlap: string;
+	    catchUp: string;
+	    lastRun: string;
+	    nextRun: string;
+	    lastResult: string;
+	    recipeId: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new Schedule(source);
@@ -68,6 +438,12 @@ export namespace database {
 	        this.pathKey = source["pathKey"];
 	        this.extract = source["extract"];
 	        this.enabled = source["enabled"];
+	        this.overlap = source["overlap"];
+	        this.catchUp = source["catchUp"];
+	        this.lastRun = source["lastRun"];
+	        this.nextRun = source["nextRun"];
+	        this.lastResult = source["lastResult"];
+	        this.recipeId = source["recipeId"];
 	    }
 	}
 	export class Server {
@@ -75,6 +451,11 @@ export namespace database {
 	    name: string;
 	    url: string;
 	    paths: string[];
+	    keyId: string;
+	    workers: number;
+	    excludes: string[];
+	    zipFolders: boolean;
+	    conn: uploader.ConnSettings;
 	    isDefault: boolean;
 	    createdAt: string;
 	
@@ -88,9 +469,56 @@ export namespace database {
 	        this.name = source["name"];
 	        this.url = source["url"];
 	        this.paths = source["paths"];
+	        this.keyId = source["keyId"];
+	        this.workers = source["workers"];
+	        this.excludes = source["excludes"];
+	        this.zipFolders = source["zipFolders"];
+	        this.conn = this.convertValues(source["conn"], uploader.ConnSettings);
 	        this.isDefault = source["isDefault"];
 	        this.createdAt = source["createdAt"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ServerGroup {
+	    id: string;
+	    name: string;
+	    serverIds: string[];
+	    strategy: string;
+	    maxUnavailable: number;
+	    stopOnFailure: boolean;
+	    createdAt: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ServerGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.serverIds = source["serverIds"];
+	        this.strategy = source["strategy"];
+	        this.maxUnavailable = source["maxUnavailable"];
+	        this.stopOnFailure = source["stopOnFailure"];
+	        this.createdAt = source["createdAt"];
+	    }
 	}
 	export class WatchConfig {
 	    id: string;
@@ -98,8 +526,10 @@ export namespace database {
 	    serverId: string;
 	    pathKey: string;
 	    patterns: string[];
+	    excludes: string[];
 	    debounceMs: number;
 	    enabled: boolean;
+	    recipeId: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new WatchConfig(source);
@@ -112,8 +542,159 @@ export namespace database {
 	        this.serverId = source["serverId"];
 	        this.pathKey = source["pathKey"];
 	        this.patterns = source["patterns"];
+	        this.excludes = source["excludes"];
 	        this.debounceMs = source["debounceMs"];
 	        this.enabled = source["enabled"];
+	        this.recipeId = source["recipeId"];
+	    }
+	}
+
+}
+
+export namespace deploy {
+	
+	export class Result {
+	    serverId: string;
+	    serverName: string;
+	    status: string;
+	    error: string;
+	    durationMs: number;
+	    files?: database.HistoryFile[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.serverId = source["serverId"];
+	        this.serverName = source["serverName"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.durationMs = source["durationMs"];
+	        this.files = this.convertValues(source["files"], database.HistoryFile);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace health {
+	
+	export class Sample {
+	    at: string;
+	    reachable: boolean;
+	    latencyMs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Sample(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.at = source["at"];
+	        this.reachable = source["reachable"];
+	        this.latencyMs = source["latencyMs"];
+	    }
+	}
+	export class Status {
+	    serverId: string;
+	    name: string;
+	    url: string;
+	    checked: boolean;
+	    reachable: boolean;
+	    latencyMs: number;
+	    version: string;
+	    security: boolean;
+	    paths: string[];
+	    error: string;
+	    checkedAt: string;
+	    since: string;
+	    history: Sample[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.serverId = source["serverId"];
+	        this.name = source["name"];
+	        this.url = source["url"];
+	        this.checked = source["checked"];
+	        this.reachable = source["reachable"];
+	        this.latencyMs = source["latencyMs"];
+	        this.version = source["version"];
+	        this.security = source["security"];
+	        this.paths = source["paths"];
+	        this.error = source["error"];
+	        this.checkedAt = source["checkedAt"];
+	        this.since = source["since"];
+	        this.history = this.convertValues(source["history"], Sample);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace keystore {
+	
+	export class Status {
+	    hasKey: boolean;
+	    keyCount: number;
+	    protection: string;
+	    locked: boolean;
+	    autoLockMinutes: number;
+	    keyringAvailable: boolean;
+	    needsPassword: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.hasKey = source["hasKey"];
+	        this.keyCount = source["keyCount"];
+	        this.protection = source["protection"];
+	        this.locked = source["locked"];
+	        this.autoLockMinutes = source["autoLockMinutes"];
+	        this.keyringAvailable = source["keyringAvailable"];
+	        this.needsPassword = source["needsPassword"];
 	    }
 	}
 
@@ -121,6 +702,160 @@ export namespace database {
 
 export namespace main {
 	
+	export class DeployPreview {
+	    serverId: string;
+	    serverName: string;
+	    pathKey: string;
+	    folderPath: string;
+	    added: uploader.DiffEntry[];
+	    modified: uploader.DiffEntry[];
+	    deleted: uploader.DiffEntry[];
+	    unchanged: uploader.DiffEntry[];
+	    hidden: uploader.DiffEntry[];
+	    uploadSize: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DeployPreview(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.serverId = source["serverId"];
+	        this.serverName = source["serverName"];
+	        this.pathKey = source["pathKey"];
+	        this.folderPath = source["folderPath"];
+	        this.added = this.convertValues(source["added"], uploader.DiffEntry);
+	        this.modified = this.convertValues(source["modified"], uploader.DiffEntry);
+	        this.deleted = this.convertValues(source["deleted"], uploader.DiffEntry);
+	        this.unchanged = this.convertValues(source["unchanged"], uploader.DiffEntry);
+	        this.hidden = this.convertValues(source["hidden"], uploader.DiffEntry);
+	        this.uploadSize = source["uploadSize"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DeployResult {
+	    groupId: string;
+	    groupName: string;
+	    jobId: string;
+	    success: boolean;
+	    status: string;
+	    error: string;
+	    results: deploy.Result[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DeployResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.groupId = source["groupId"];
+	        this.groupName = source["groupName"];
+	        this.jobId = source["jobId"];
+	        this.success = source["success"];
+	        this.status = source["status"];
+	        this.error = source["error"];
+	        this.results = this.convertValues(source["results"], deploy.Result);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class EngineStatus {
+	    running: boolean;
+	    owner: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new EngineStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.running = source["running"];
+	        this.owner = source["owner"];
+	    }
+	}
+	export class NotifyPref {
+	    id: string;
+	    name: string;
+	    default: boolean;
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new NotifyPref(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.default = source["default"];
+	        this.enabled = source["enabled"];
+	    }
+	}
+	export class NotifySettings {
+	    native: boolean;
+	    events: NotifyPref[];
+	
+	    static createFrom(source: any = {}) {
+	        return new NotifySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.native = source["native"];
+	        this.events = this.convertValues(source["events"], NotifyPref);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class UploadResultWrapper {
 	    success: boolean;
 	    status: string;
@@ -131,7 +866,20 @@ export namespace main {
 	    extracted: boolean;
 	    extractDir: string;
 	    error: string;
+	    code: string;
+	    requestId: string;
+	    canceled: boolean;
+	    httpStatus: number;
+	    sha256: string;
+	    durationMs: number;
+	    serverSha256: string;
+	    verified: boolean;
 	    serverName: string;
+	    jobId: string;
+	    queued: number;
+	    completedFiles?: string[];
+	    failedFiles?: string[];
+	    skippedFiles?: string[];
 	
 	    static createFrom(source: any = {}) {
 	        return new UploadResultWrapper(source);
@@ -148,7 +896,127 @@ export namespace main {
 	        this.extracted = source["extracted"];
 	        this.extractDir = source["extractDir"];
 	        this.error = source["error"];
+	        this.code = source["code"];
+	        this.requestId = source["requestId"];
+	        this.canceled = source["canceled"];
+	        this.httpStatus = source["httpStatus"];
+	        this.sha256 = source["sha256"];
+	        this.durationMs = source["durationMs"];
+	        this.serverSha256 = source["serverSha256"];
+	        this.verified = source["verified"];
+	        this.serverName = source["serverName"];
+	        this.jobId = source["jobId"];
+	        this.queued = source["queued"];
+	        this.completedFiles = source["completedFiles"];
+	        this.failedFiles = source["failedFiles"];
+	        this.skippedFiles = source["skippedFiles"];
+	    }
+	}
+	export class SyncResult {
+	    watchId: string;
+	    folderPath: string;
+	    serverName: string;
+	    total: number;
+	    changed: string[];
+	    upload?: UploadResultWrapper;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.watchId = source["watchId"];
+	        this.folderPath = source["folderPath"];
+	        this.serverName = source["serverName"];
+	        this.total = source["total"];
+	        this.changed = source["changed"];
+	        this.upload = this.convertValues(source["upload"], UploadResultWrapper);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TraySettings {
+	    available: boolean;
+	    closeToTray: boolean;
+	    unsupported?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TraySettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.available = source["available"];
+	        this.closeToTray = source["closeToTray"];
+	        this.unsupported = source["unsupported"];
+	    }
+	}
+	export class UploadJobInfo {
+	    id: string;
+	    name: string;
+	    serverName: string;
+	    startedAt: string;
+	    paused: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new UploadJobInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
 	        this.serverName = source["serverName"];
+	        this.startedAt = source["startedAt"];
+	        this.paused = source["paused"];
+	    }
+	}
+
+}
+
+export namespace uploader {
+	
+	export class ConnSettings {
+	    rateLimitKB: number;
+	    proxyUrl: string;
+	    caCert: string;
+	    skipVerify: boolean;
+	    tlsServerName: string;
+	    connectTimeout: number;
+	    timeout: number;
+	    headers: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConnSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.rateLimitKB = source["rateLimitKB"];
+	        this.proxyUrl = source["proxyUrl"];
+	        this.caCert = source["caCert"];
+	        this.skipVerify = source["skipVerify"];
+	        this.tlsServerName = source["tlsServerName"];
+	        this.connectTimeout = source["connectTimeout"];
+	        this.timeout = source["timeout"];
+	        this.headers = source["headers"];
 	    }
 	}
 
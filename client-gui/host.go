@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"client-gui/internal/notify"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// host 程序的运行环境: 图形界面把事件推送给前端，后台服务 (daemon) 和命令行写入日志
+type host interface {
+	Emit(event string, data ...interface{})
+	Notify(title, message string) // 系统桌面通知
+	Info(msg string)
+	Warn(msg string)
+	Error(msg string)
+}
+
+// wailsHost 图形界面
+type wailsHost struct {
+	ctx context.Context
+}
+
+func (h *wailsHost) Emit(event string, data ...interface{}) {
+	runtime.EventsEmit(h.ctx, event, data...)
+}
+
+func (h *wailsHost) Notify(title, message string) {
+	go func() {
+		if err := notify.Send(title, message); err != nil {
+			runtime.LogWarning(h.ctx, fmt.Sprintf("显示系统通知失败: %v", err))
+		}
+	}()
+}
+
+func (h *wailsHost) Info(msg string)  { runtime.LogInfo(h.ctx, msg) }
+func (h *wailsHost) Warn(msg string)  { runtime.LogWarning(h.ctx, msg) }
+func (h *wailsHost) Error(msg string) { runtime.LogError(h.ctx, msg) }
+
+// loggedEvents 后台运行时写入日志的事件，进度等高频事件和已另外记录日志的事件不记录
+var loggedEvents = map[string]bool{
+	"watch:changes":   true,
+	"watch:upload":    true,
+	"schedule:update": true,
+	"queue:dropped":   true,
+	"recipe:start":    true,
+	"recipe:end":      true,
+	"key:locked":      true,
+	"key:unprotected": true,
+}
+
+// logHost 没有界面时使用，事件按名称筛选后写入日志
+type logHost struct {
+	logger *log.Logger
+}
+
+func (h *logHost) Emit(event string, data ...interface{}) {
+	if !loggedEvents[event] {
+		return
+	}
+	msg := event
+	for _, d := range data {
+		if b, err := json.Marshal(d); err == nil {
+			msg += " " + string(b)
+		} else {
+			msg += fmt.Sprintf(" %v", d)
+		}
+	}
+	h.logger.Print("[EVENT] " + msg)
+}
+
+func (h *logHost) Notify(title, message string) {
+	h.logger.Print("[NOTIFY] " + title + ": " + message)
+}
+
+func (h *logHost) Info(msg string)  { h.logger.Print("[INFO] " + msg) }
+func (h *logHost) Warn(msg string)  { h.logger.Print("[WARN] " + msg) }
+func (h *logHost) Error(msg string) { h.logger.Print("[ERROR] " + msg) }
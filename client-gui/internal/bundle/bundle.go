@@ -0,0 +1,538 @@
+// Package bundle 客户端配置的导出和导入
+//
+// 配置包是一个带版本号的 JSON 文件，包含服务器 (含路径列表)、服务器组、部署配方、监控、定时任务，
+// 可选包含密钥。GUI 和命令行客户端使用同一格式，便于团队共享基础配置。
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"client-gui/internal/crypto"
+	"client-gui/internal/database"
+	"client-gui/internal/deploy"
+	"client-gui/internal/keystore"
+	"client-gui/internal/recipe"
+	"client-gui/internal/scheduler"
+)
+
+const (
+	bundleType    = "deploy-receiver-config"
+	bundleVersion = 1
+)
+
+// 导入模式
+const (
+	ModeMerge   = "merge"   // 合并到现有配置，同一服务器 (相同 ID 或 URL) 被覆盖
+	ModeReplace = "replace" // 清空现有的服务器、服务器组、配方、监控和定时任务后导入，密钥只增不删
+)
+
+// Bundle 配置包
+//
+// 加密的配置包只有 Encrypted 字段有内容，解密后是一个未加密的 Bundle 的 JSON。
+type Bundle struct {
+	Type       string                 `json:"type"`
+	Version    int                    `json:"version"`
+	ExportedAt string                 `json:"exportedAt"`
+	Servers    []database.Server      `json:"servers,omitempty"`
+	Groups     []database.ServerGroup `json:"groups,omitempty"`
+	Recipes    []database.Recipe      `json:"recipes,omitempty"`
+	Watches    []database.WatchConfig `json:"watches,omitempty"`
+	Schedules  []database.Schedule    `json:"schedules,omitempty"`
+	Keys       []Key                  `json:"keys,omitempty"`
+	Encrypted  string                 `json:"encrypted,omitempty"` // Argon2id + XChaCha20-Poly1305
+}
+
+// Key 配置包中的密钥，私钥为明文，所以包含密钥的配置包必须加密
+type Key struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Notes      string `json:"notes,omitempty"`
+	PublicKey  string `json:"publicKey"`
+	PrivateKey string `json:"privateKey"`
+	IsDefault  bool   `json:"isDefault"`
+}
+
+// ExportOptions 导出选项
+type ExportOptions struct {
+	IncludeKeys bool   // 包含所有密钥的私钥，此时必须提供密码
+	Passphrase  string // 非空时加密整个配置包
+}
+
+// ImportResult 导入结果
+type ImportResult struct {
+	Servers   int      `json:"servers"`
+	Groups    int      `json:"groups"`
+	Recipes   int      `json:"recipes"`
+	Watches   int      `json:"watches"`
+	Schedules int      `json:"schedules"`
+	Keys      int      `json:"keys"`    // 新增的密钥数，已存在的密钥不计
+	Renamed   int      `json:"renamed"` // 因 ID 冲突分配了新 ID 的记录数
+	Warnings  []string `json:"warnings"`
+}
+
+// Export 导出当前配置
+func Export(db *database.DB, keys *keystore.Store, opts ExportOptions) ([]byte, error) {
+	if opts.IncludeKeys && opts.Passphrase == "" {
+		return nil, errors.New("导出密钥时必须设置密码")
+	}
+	if opts.Passphrase != "" && len(opts.Passphrase) < keystore.MinPasswordLen {
+		return nil, fmt.Errorf("导出密码至少 %d 个字符", keystore.MinPasswordLen)
+	}
+
+	b := Bundle{
+		Type:       bundleType,
+		Version:    bundleVersion,
+		ExportedAt: time.Now().Format(time.RFC3339),
+	}
+
+	var err error
+	if b.Servers, err = db.GetServers(); err != nil {
+		return nil, err
+	}
+	for i := range b.Servers {
+		b.Servers[i].CreatedAt = ""
+		// 未加密的配置包不包含凭据 (地址和代理中的密码、通常带有令牌的自定义请求头)，导入后需要重新填写
+		if opts.Passphrase == "" {
+			b.Servers[i].URL = stripPassword(b.Servers[i].URL)
+			b.Servers[i].Conn.ProxyURL = stripPassword(b.Servers[i].Conn.ProxyURL)
+			b.Servers[i].Conn.Headers = nil
+		}
+	}
+	if b.Groups, err = db.GetServerGroups(); err != nil {
+		return nil, err
+	}
+	for i := range b.Groups {
+		b.Groups[i].CreatedAt = ""
+	}
+	if b.Recipes, err = db.GetRecipes(); err != nil {
+		return nil, err
+	}
+	for i := range b.Recipes {
+		b.Recipes[i].CreatedAt = ""
+	}
+	if b.Watches, err = db.GetWatches(); err != nil {
+		return nil, err
+	}
+	if b.Schedules, err = db.GetSchedules(); err != nil {
+		return nil, err
+	}
+	// 运行状态只对本机有意义
+	for i := range b.Schedules {
+		b.Schedules[i].LastRun = ""
+		b.Schedules[i].NextRun = ""
+		b.Schedules[i].LastResult = ""
+	}
+
+	if opts.IncludeKeys {
+		list, err := keys.List()
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range list {
+			kp, err := keys.Get(k.ID)
+			if err != nil {
+				return nil, err
+			}
+			if kp.Locked {
+				return nil, keystore.ErrLocked
+			}
+			b.Keys = append(b.Keys, Key{
+				ID:         kp.ID,
+				Name:       kp.Name,
+				Notes:      kp.Notes,
+				PublicKey:  kp.PublicKey,
+				PrivateKey: kp.PrivateKey,
+				IsDefault:  kp.IsDefault,
+			})
+		}
+	}
+
+	if opts.Passphrase == "" {
+		return json.MarshalIndent(b, "", "  ")
+	}
+
+	plain, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := crypto.EncryptWithPassword(string(plain), opts.Passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(Bundle{
+		Type:       bundleType,
+		Version:    bundleVersion,
+		ExportedAt: b.ExportedAt,
+		Encrypted:  encrypted,
+	}, "", "  ")
+}
+
+// stripPassword 去掉地址中的密码，保留用户名
+func stripPassword(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	if _, ok := u.User.Password(); !ok {
+		return rawURL
+	}
+	u.User = url.User(u.User.Username())
+	return u.String()
+}
+
+// IsEncrypted 判断配置包是否加密，用于提示输入密码
+func IsEncrypted(data []byte) (bool, error) {
+	b, err := decode(data)
+	if err != nil {
+		return false, err
+	}
+	return b.Encrypted != "", nil
+}
+
+// Parse 解析配置包，加密时使用 passphrase 解密
+func Parse(data []byte, passphrase string) (*Bundle, error) {
+	b, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if b.Encrypted == "" {
+		return b, nil
+	}
+
+	if passphrase == "" {
+		return nil, errors.New("配置包已加密，请输入密码")
+	}
+	plain, err := crypto.DecryptWithPassword(b.Encrypted, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	inner, err := decode([]byte(plain))
+	if err != nil {
+		return nil, err
+	}
+	if inner.Encrypted != "" {
+		return nil, errors.New("无效的配置包: 重复加密")
+	}
+	return inner, nil
+}
+
+func decode(data []byte) (*Bundle, error) {
+	var b Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("无效的配置包: %v", err)
+	}
+	if b.Type != bundleType {
+		return nil, fmt.Errorf("不是配置包: %q", b.Type)
+	}
+	if b.Version > bundleVersion {
+		return nil, fmt.Errorf("不支持的配置包版本: %d", b.Version)
+	}
+	return &b, nil
+}
+
+// Import 导入配置包
+//
+// 密钥按公钥识别，已存在的密钥直接复用。合并模式下服务器按 ID 或 URL 识别，
+// 找到时覆盖本地记录；ID 已被另一台服务器占用时分配新 ID。监控和定时任务相同 ID
+// 时覆盖。所有引用 (服务器的密钥、监控和定时任务的服务器) 按新的 ID 重新对应。
+func Import(db *database.DB, keys *keystore.Store, b *Bundle, mode string) (*ImportResult, error) {
+	if mode == "" {
+		mode = ModeMerge
+	}
+	if mode != ModeMerge && mode != ModeReplace {
+		return nil, fmt.Errorf("不支持的导入模式: %s", mode)
+	}
+	if err := validate(b); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{Warnings: []string{}}
+	im := &importer{
+		db:      db,
+		keys:    keys,
+		replace: mode == ModeReplace,
+		result:  result,
+		keyIDs:  make(map[string]string),
+		servers: make(map[string]string),
+	}
+
+	// 新建的密钥在后续步骤失败时删除，默认密钥在配置写入后再切换，保证导入要么全部生效要么不生效
+	if err := im.importKeys(b.Keys); err != nil {
+		return nil, im.rollback(err)
+	}
+	set, err := im.buildConfig(b)
+	if err != nil {
+		return nil, im.rollback(err)
+	}
+	if err := db.ApplyConfig(set, im.replace); err != nil {
+		return nil, im.rollback(err)
+	}
+	if im.defaultKey != "" {
+		if err := keys.SetDefault(im.defaultKey); err != nil {
+			im.warn("设置默认密钥失败: %v", err)
+		}
+	}
+
+	result.Servers = len(set.Servers)
+	result.Groups = len(set.Groups)
+	result.Recipes = len(set.Recipes)
+	result.Watches = len(set.Watches)
+	result.Schedules = len(set.Schedules)
+	return result, nil
+}
+
+// validate 在修改任何数据之前检查配置包
+func validate(b *Bundle) error {
+	serverIDs := make(map[string]bool)
+	for _, s := range b.Servers {
+		if s.ID == "" || strings.TrimSpace(s.Name) == "" || strings.TrimSpace(s.URL) == "" {
+			return fmt.Errorf("无效的服务器配置: %q", s.Name)
+		}
+		if serverIDs[s.ID] {
+			return fmt.Errorf("服务器 ID 重复: %s", s.ID)
+		}
+		serverIDs[s.ID] = true
+	}
+	for _, g := range b.Groups {
+		if g.ID == "" {
+			return fmt.Errorf("无效的服务器组: %q", g.Name)
+		}
+		if err := deploy.Validate(g); err != nil {
+			return fmt.Errorf("服务器组 %s: %v", g.Name, err)
+		}
+	}
+	for _, r := range b.Recipes {
+		if r.ID == "" {
+			return fmt.Errorf("无效的配方: %q", r.Name)
+		}
+		if err := recipe.Validate(r); err != nil {
+			return fmt.Errorf("配方 %s: %v", r.Name, err)
+		}
+	}
+	for _, w := range b.Watches {
+		if w.ID == "" || w.FolderPath == "" {
+			return fmt.Errorf("无效的监控配置: %q", w.FolderPath)
+		}
+	}
+	for _, s := range b.Schedules {
+		if s.ID == "" {
+			return fmt.Errorf("无效的定时任务: %q", s.Name)
+		}
+		if _, err := scheduler.ParseCron(s.CronExpr); err != nil {
+			return fmt.Errorf("定时任务 %s: %v", s.Name, err)
+		}
+	}
+	for _, k := range b.Keys {
+		publicKey, err := crypto.GetPublicKeyFromPrivate(k.PrivateKey)
+		if err != nil {
+			return fmt.Errorf("密钥 %s: %v", k.Name, err)
+		}
+		if k.PublicKey != "" && publicKey != k.PublicKey {
+			return fmt.Errorf("密钥 %s: 私钥与公钥不匹配", k.Name)
+		}
+	}
+	return nil
+}
+
+type importer struct {
+	db      *database.DB
+	keys    *keystore.Store
+	replace bool
+	result  *ImportResult
+
+	keyIDs  map[string]string // 配置包中的密钥 ID -> 本地密钥 ID
+	servers map[string]string // 配置包中的服务器 ID -> 本地服务器 ID
+
+	createdKeys []string // 本次新建的密钥，失败时删除
+	defaultKey  string   // 替换模式下导入成功后设为默认的密钥
+}
+
+// importKeys 导入密钥，记录 ID 对应关系
+func (im *importer) importKeys(keys []Key) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	local, err := im.keys.List()
+	if err != nil {
+		return err
+	}
+	byPublicKey := make(map[string]string)
+	for _, k := range local {
+		byPublicKey[k.PublicKey] = k.ID
+	}
+
+	for _, k := range keys {
+		publicKey, _ := crypto.GetPublicKeyFromPrivate(k.PrivateKey)
+		id, ok := byPublicKey[publicKey]
+		if !ok {
+			name := k.Name
+			if strings.TrimSpace(name) == "" {
+				name = "导入的密钥"
+			}
+			kp, err := im.keys.Create(name, k.Notes, k.PrivateKey)
+			if err != nil {
+				return fmt.Errorf("导入密钥 %s 失败: %v", k.Name, err)
+			}
+			id = kp.ID
+			byPublicKey[publicKey] = id
+			im.createdKeys = append(im.createdKeys, id)
+			im.result.Keys++
+		}
+		im.keyIDs[k.ID] = id
+
+		if k.IsDefault && im.replace {
+			im.defaultKey = id
+		}
+	}
+	return nil
+}
+
+// rollback 删除本次新建的密钥，返回原来的错误
+func (im *importer) rollback(cause error) error {
+	for i := len(im.createdKeys) - 1; i >= 0; i-- {
+		if err := im.keys.Delete(im.createdKeys[i]); err != nil {
+			return fmt.Errorf("%v (删除已导入的密钥失败: %v)", cause, err)
+		}
+	}
+	im.createdKeys = nil
+	return cause
+}
+
+// buildConfig 把配置包中的记录对应到本地 ID
+func (im *importer) buildConfig(b *Bundle) (database.ConfigSet, error) {
+	var set database.ConfigSet
+
+	var local []database.Server
+	if !im.replace {
+		var err error
+		if local, err = im.db.GetServers(); err != nil {
+			return set, err
+		}
+	}
+	localByID := make(map[string]database.Server)
+	localByURL := make(map[string]string)
+	hasDefault := false
+	for _, s := range local {
+		localByID[s.ID] = s
+		localByURL[normalizeURL(s.URL)] = s.ID
+		hasDefault = hasDefault || s.IsDefault
+	}
+
+	localKeys, err := im.keys.List()
+	if err != nil {
+		return set, err
+	}
+	keyExists := make(map[string]bool)
+	for _, k := range localKeys {
+		keyExists[k.ID] = true
+	}
+
+	for _, s := range b.Servers {
+		bundleID := s.ID
+		if id, ok := localByURL[normalizeURL(s.URL)]; ok {
+			s.ID = id
+		} else if _, taken := localByID[s.ID]; taken {
+			for taken {
+				s.ID = newID("server")
+				_, taken = localByID[s.ID]
+			}
+			im.result.Renamed++
+		}
+		im.servers[bundleID] = s.ID
+
+		if s.KeyID != "" {
+			if id, ok := im.keyIDs[s.KeyID]; ok {
+				s.KeyID = id
+			} else if !keyExists[s.KeyID] {
+				im.warn("服务器 %s 使用的密钥不在配置包中，已改为默认密钥", s.Name)
+				s.KeyID = ""
+			}
+		}
+		// 合并时保留本地的默认服务器
+		if hasDefault && !im.replace {
+			s.IsDefault = localByID[s.ID].IsDefault
+		}
+		if s.Paths == nil {
+			s.Paths = []string{}
+		}
+		set.Servers = append(set.Servers, s)
+	}
+
+	for _, g := range b.Groups {
+		ids := make([]string, 0, len(g.ServerIDs))
+		for _, sid := range g.ServerIDs {
+			id, err := im.serverID(sid, localByID)
+			if err != nil {
+				return set, fmt.Errorf("服务器组 %s: %v", g.Name, err)
+			}
+			ids = append(ids, id)
+		}
+		g.ServerIDs = ids
+		set.Groups = append(set.Groups, g)
+	}
+	for _, r := range b.Recipes {
+		steps := make([]database.RecipeStep, len(r.Steps))
+		for i, step := range r.Steps {
+			if step.ServerID != "" {
+				id, err := im.serverID(step.ServerID, localByID)
+				if err != nil {
+					return set, fmt.Errorf("配方 %s: %v", r.Name, err)
+				}
+				step.ServerID = id
+			}
+			steps[i] = step
+		}
+		r.Steps = steps
+		set.Recipes = append(set.Recipes, r)
+	}
+	// 关联配方的监控和定时任务可以不指定服务器
+	for _, w := range b.Watches {
+		if w.RecipeID == "" || w.ServerID != "" {
+			id, err := im.serverID(w.ServerID, localByID)
+			if err != nil {
+				return set, fmt.Errorf("监控 %s: %v", w.FolderPath, err)
+			}
+			w.ServerID = id
+		}
+		set.Watches = append(set.Watches, w)
+	}
+	for _, s := range b.Schedules {
+		if s.RecipeID == "" || s.ServerID != "" {
+			id, err := im.serverID(s.ServerID, localByID)
+			if err != nil {
+				return set, fmt.Errorf("定时任务 %s: %v", s.Name, err)
+			}
+			s.ServerID = id
+		}
+		set.Schedules = append(set.Schedules, s)
+	}
+	return set, nil
+}
+
+// serverID 监控和定时任务引用的服务器: 配置包中的服务器或 (合并时) 本地已有的服务器
+func (im *importer) serverID(id string, local map[string]database.Server) (string, error) {
+	if mapped, ok := im.servers[id]; ok {
+		return mapped, nil
+	}
+	if _, ok := local[id]; ok {
+		return id, nil
+	}
+	return "", fmt.Errorf("引用的服务器不存在: %s", id)
+}
+
+func (im *importer) warn(format string, args ...interface{}) {
+	im.result.Warnings = append(im.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+func normalizeURL(u string) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(u), "/"))
+}
+
+func newID(prefix string) string {
+	return fmt.Sprintf("%s_%d", prefix, time.Now().UnixNano())
+}
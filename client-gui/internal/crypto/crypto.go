@@ -3,6 +3,8 @@ package crypto
 import (
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -105,3 +107,13 @@ func GetPublicKeyFromPrivate(privateKeyHex string) (string, error) {
 	publicKey := privateKey.Public().(ed25519.PublicKey)
 	return hex.EncodeToString(publicKey), nil
 }
+
+// Fingerprint 计算公钥指纹，格式与 ssh-keygen 一致: SHA256:<base64>
+func Fingerprint(publicKeyHex string) (string, error) {
+	publicKey, err := hex.DecodeString(publicKeyHex)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return "", errors.New("无效的公钥格式")
+	}
+	sum := sha256.Sum256(publicKey)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]), nil
+}
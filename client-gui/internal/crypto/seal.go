@@ -0,0 +1,156 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Argon2id 参数 (OWASP 推荐的桌面端取值)
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024 // KiB
+	argonThreads = 4
+	saltSize     = 16
+)
+
+// ErrWrongPassword 密码错误或数据被篡改
+var ErrWrongPassword = errors.New("密码错误或数据已损坏")
+
+var b64 = base64.RawStdEncoding
+
+// KDFParams 密钥派生参数
+type KDFParams struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	Salt    []byte
+}
+
+// NewKDFParams 生成带随机盐的默认参数
+func NewKDFParams() (KDFParams, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return KDFParams{}, err
+	}
+	return KDFParams{Time: argonTime, Memory: argonMemory, Threads: argonThreads, Salt: salt}, nil
+}
+
+// DeriveKey 使用 Argon2id 从密码派生 32 字节密钥
+func DeriveKey(password string, p KDFParams) []byte {
+	return argon2.IDKey([]byte(password), p.Salt, p.Time, p.Memory, p.Threads, chacha20poly1305.KeySize)
+}
+
+// NewDataKey 生成随机的 32 字节数据密钥
+func NewDataKey() ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Seal 使用 XChaCha20-Poly1305 加密，返回 nonce||ciphertext
+func Seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open 解密 Seal 的输出
+func Open(key, sealed []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrWrongPassword
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, nil
+}
+
+// EncodePasswordSealed 编码密码加密的数据:
+//
+//	argon2id$m=65536,t=3,p=4$<salt>$<nonce||ciphertext>
+func EncodePasswordSealed(p KDFParams, sealed []byte) string {
+	return fmt.Sprintf("argon2id$m=%d,t=%d,p=%d$%s$%s",
+		p.Memory, p.Time, p.Threads, b64.EncodeToString(p.Salt), b64.EncodeToString(sealed))
+}
+
+// DecodePasswordSealed 解析 EncodePasswordSealed 的输出
+func DecodePasswordSealed(s string) (KDFParams, []byte, error) {
+	parts := strings.Split(s, "$")
+	if len(parts) != 4 || parts[0] != "argon2id" {
+		return KDFParams{}, nil, errors.New("无效的加密数据格式")
+	}
+
+	var p KDFParams
+	if _, err := fmt.Sscanf(parts[1], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return KDFParams{}, nil, fmt.Errorf("无效的密钥派生参数: %v", err)
+	}
+	salt, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return KDFParams{}, nil, fmt.Errorf("无效的盐值: %v", err)
+	}
+	sealed, err := b64.DecodeString(parts[3])
+	if err != nil {
+		return KDFParams{}, nil, fmt.Errorf("无效的密文: %v", err)
+	}
+	p.Salt = salt
+	return p, sealed, nil
+}
+
+// EncryptWithPassword 使用主密码加密 (Argon2id + XChaCha20-Poly1305)
+func EncryptWithPassword(plaintext, password string) (string, error) {
+	p, err := NewKDFParams()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := Seal(DeriveKey(password, p), []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return EncodePasswordSealed(p, sealed), nil
+}
+
+// DecryptWithPassword 使用主密码解密
+func DecryptWithPassword(encoded, password string) (string, error) {
+	p, sealed, err := DecodePasswordSealed(encoded)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := Open(DeriveKey(password, p), sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// EncodeKeySealed 编码数据密钥加密的数据: key$<nonce||ciphertext>
+func EncodeKeySealed(sealed []byte) string {
+	return "key$" + b64.EncodeToString(sealed)
+}
+
+// DecodeKeySealed 解析 EncodeKeySealed 的输出
+func DecodeKeySealed(s string) ([]byte, error) {
+	if !strings.HasPrefix(s, "key$") {
+		return nil, errors.New("无效的加密数据格式")
+	}
+	return b64.DecodeString(strings.TrimPrefix(s, "key$"))
+}
@@ -0,0 +1,62 @@
+package database
+
+// ConfigSet 可整体导入的配置: 服务器 (含路径列表)、服务器组、部署配方、监控和定时任务
+type ConfigSet struct {
+	Servers   []Server
+	Groups    []ServerGroup
+	Recipes   []Recipe
+	Watches   []WatchConfig
+	Schedules []Schedule
+}
+
+// ApplyConfig 在一个事务中写入配置，replace 为 true 时先清空现有的服务器、服务器组、配方、监控和定时任务
+//
+// 同 ID 的记录被覆盖，定时任务的运行状态保持不变。配置中有默认服务器时取代原来的默认服务器。
+func (d *DB) ApplyConfig(c ConfigSet, replace bool) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if replace {
+		if err := execAll(tx, "DELETE FROM servers", "DELETE FROM server_groups", "DELETE FROM recipes", "DELETE FROM watches", "DELETE FROM schedules"); err != nil {
+			return err
+		}
+	}
+
+	for _, s := range c.Servers {
+		if s.IsDefault {
+			if _, err := tx.Exec("UPDATE servers SET is_default = 0"); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	for _, s := range c.Servers {
+		if err := saveServer(tx, s); err != nil {
+			return err
+		}
+	}
+	for _, g := range c.Groups {
+		if err := saveServerGroup(tx, g); err != nil {
+			return err
+		}
+	}
+	for _, r := range c.Recipes {
+		if err := saveRecipe(tx, r); err != nil {
+			return err
+		}
+	}
+	for _, w := range c.Watches {
+		if err := saveWatch(tx, w); err != nil {
+			return err
+		}
+	}
+	for _, s := range c.Schedules {
+		if err := saveSchedule(tx, s); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -2,11 +2,16 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 
+	"client-gui/internal/uploader"
+
 	_ "modernc.org/sqlite"
 )
 
@@ -14,21 +19,42 @@ type DB struct {
 	*sql.DB
 }
 
+// execer *sql.DB 和 *sql.Tx 的公共部分，保存函数可以在事务内外复用
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
 // Server 服务器配置
 type Server struct {
-	ID        string   `json:"id"`
-	Name      string   `json:"name"`
-	URL       string   `json:"url"`
-	Paths     []string `json:"paths"`
-	IsDefault bool     `json:"isDefault"`
-	CreatedAt string   `json:"createdAt"`
+	ID         string       `json:"id"`
+	Name       string       `json:"name"`
+	URL        string       `json:"url"`
+	Paths      []string     `json:"paths"`
+	KeyID      string       `json:"keyId"`      // 签名使用的密钥，空表示默认密钥
+	Workers    int          `json:"workers"`    // 文件夹上传的并发数，0 表示默认值
+	Excludes   []string     `json:"excludes"`   // 上传文件夹时排除的文件，.deployignore 语法
+	ZipFolders bool         `json:"zipFolders"` // 文件夹打包为一个 zip 边压缩边上传，由服务器解压
+	Conn       ConnSettings `json:"conn"`       // 连接设置，对发往该服务器的所有请求生效
+	IsDefault  bool         `json:"isDefault"`
+	CreatedAt  string       `json:"createdAt"`
 }
 
+// ConnSettings 与服务器通信的连接设置，定义在 uploader 中，独立命令行客户端不需要依赖数据库
+type ConnSettings = uploader.ConnSettings
+
 // KeyPair 密钥对
 type KeyPair struct {
-	PrivateKey string `json:"privateKey"`
-	PublicKey  string `json:"publicKey"`
-	UpdatedAt  string `json:"updatedAt"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	PrivateKey  string `json:"privateKey"` // 数据库中为加密后的值，交给前端前解密
+	PublicKey   string `json:"publicKey"`
+	Fingerprint string `json:"fingerprint"`
+	Notes       string `json:"notes"`
+	Protection  string `json:"protection"` // plain / password / keyring，空表示旧版本的明文
+	IsDefault   bool   `json:"isDefault"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+	Locked      bool   `json:"locked"`
 }
 
 // HistoryEntry 上传历史
@@ -42,6 +68,47 @@ type HistoryEntry struct {
 	Status     string `json:"status"`
 	ErrorMsg   string `json:"errorMsg"`
 	UploadedAt string `json:"uploadedAt"`
+	FileCount  int    `json:"fileCount"`  // 文件明细条数
+	DurationMs int64  `json:"durationMs"` // 整个操作的耗时
+	GroupID    string `json:"groupId"`    // 按服务器组部署时为组 ID，ServerName 为组名
+
+	Files []HistoryFile `json:"files,omitempty"` // 逐个文件的明细，列表查询时不加载
+}
+
+// HistoryFile 一次上传中单个文件的明细
+type HistoryFile struct {
+	ID         int64  `json:"id"`
+	HistoryID  int64  `json:"historyId"`
+	RelPath    string `json:"relPath"`
+	FileSize   int64  `json:"fileSize"`
+	SHA256     string `json:"sha256"`
+	DurationMs int64  `json:"durationMs"`
+	HTTPStatus int    `json:"httpStatus"`
+	ServerPath string `json:"serverPath"` // 服务器返回的保存路径
+	Status     string `json:"status"`
+	ErrorMsg   string `json:"errorMsg"`
+	ServerID   string `json:"serverId"` // 按服务器组部署时文件所属的服务器，否则为空
+	ServerName string `json:"serverName"`
+
+	ServerSHA256 string `json:"serverSha256"` // 服务器计算的 SHA-256，旧版服务器为空
+}
+
+// HistoryFilter 历史记录查询条件，空字段表示不限制
+type HistoryFilter struct {
+	ServerID string `json:"serverId"`
+	PathKey  string `json:"pathKey"`
+	Status   string `json:"status"`
+	From     string `json:"from"`   // 起始日期 (含)，YYYY-MM-DD，按本地时间
+	To       string `json:"to"`     // 结束日期 (含)
+	Search   string `json:"search"` // 文件名包含
+	Offset   int    `json:"offset"`
+	Limit    int    `json:"limit"` // <= 0 表示不分页
+}
+
+// HistoryPage 分页查询结果
+type HistoryPage struct {
+	Entries []HistoryEntry `json:"entries"`
+	Total   int            `json:"total"`
 }
 
 // WatchConfig 监控配置
@@ -51,20 +118,56 @@ type WatchConfig struct {
 	ServerID   string   `json:"serverId"`
 	PathKey    string   `json:"pathKey"`
 	Patterns   []string `json:"patterns"`
+	Excludes   []string `json:"excludes"` // 忽略的变化，.deployignore 语法
 	DebounceMs int      `json:"debounceMs"`
 	Enabled    bool     `json:"enabled"`
+	RecipeID   string   `json:"recipeId"` // 非空时变化后运行部署配方，而不是上传变化的文件
 }
 
 // Schedule 定时任务
 type Schedule struct {
-	ID       string `json:"id"`
-	Name     string `json:"name"`
-	CronExpr string `json:"cronExpr"`
-	FilePath string `json:"filePath"`
-	ServerID string `json:"serverId"`
-	PathKey  string `json:"pathKey"`
-	Extract  bool   `json:"extract"`
-	Enabled  bool   `json:"enabled"`
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	CronExpr   string `json:"cronExpr"`
+	FilePath   string `json:"filePath"`
+	ServerID   string `json:"serverId"`
+	PathKey    string `json:"pathKey"`
+	Extract    bool   `json:"extract"`
+	Enabled    bool   `json:"enabled"`
+	Overlap    string `json:"overlap"`    // 上次未结束时: skip / queue
+	CatchUp    string `json:"catchUp"`    // 错过的运行: skip / once
+	LastRun    string `json:"lastRun"`    // 由调度器维护
+	NextRun    string `json:"nextRun"`    // 由调度器维护
+	LastResult string `json:"lastResult"` // 由调度器维护
+	RecipeID   string `json:"recipeId"`   // 非空时运行部署配方，而不是上传 FilePath
+}
+
+// 上传队列任务状态
+const (
+	QueuePending = "pending" // 等待 (首次或等待重试)
+	QueueRunning = "running"
+	QueueFailed  = "failed" // 不可重试或重试次数用尽
+	QueueDone    = "done"
+)
+
+// QueueJob 上传队列中的任务
+type QueueJob struct {
+	ID            string `json:"id"`
+	ServerID      string `json:"serverId"`
+	PathKey       string `json:"pathKey"`
+	FilePath      string `json:"filePath"`
+	RelPath       string `json:"relPath"` // 服务器端相对路径，空表示使用文件名
+	Extract       bool   `json:"extract"`
+	Source        string `json:"source"`   // manual / watch / schedule
+	SourceID      string `json:"sourceId"` // 监控或定时任务 ID
+	Status        string `json:"status"`
+	Attempts      int    `json:"attempts"`
+	MaxAttempts   int    `json:"maxAttempts"`
+	LastError     string `json:"lastError"`
+	ErrorCode     string `json:"errorCode"`
+	NextAttemptAt string `json:"nextAttemptAt"`
+	CreatedAt     string `json:"createdAt"`
+	UpdatedAt     string `json:"updatedAt"`
 }
 
 // GetDataDir 获取数据目录
@@ -88,8 +191,13 @@ func New() (*DB, error) {
 		return nil, err
 	}
 
-	dbPath := filepath.Join(dataDir, "data.db")
-	db, err := sql.Open("sqlite", dbPath)
+	return Open(filepath.Join(dataDir, "data.db"))
+}
+
+// Open 打开指定路径的数据库并升级结构
+func Open(dbPath string) (*DB, error) {
+	// 图形界面和后台服务可能同时打开数据库，写入冲突时等待而不是立即失败
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)")
 	if err != nil {
 		return nil, err
 	}
@@ -102,80 +210,36 @@ func New() (*DB, error) {
 	return d, nil
 }
 
+// init 创建或升级数据库结构，见 migrations.go
 func (d *DB) init() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS servers (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		url TEXT NOT NULL,
-		paths TEXT DEFAULT '[]',
-		is_default INTEGER DEFAULT 0,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS keys (
-		id INTEGER PRIMARY KEY CHECK (id = 1),
-		encrypted_private_key TEXT,
-		public_key TEXT,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS history (
-		id INTEGER PRIMARY KEY AUTOINCREMENT,
-		server_id TEXT,
-		server_name TEXT,
-		path_key TEXT,
-		filename TEXT,
-		file_size INTEGER,
-		status TEXT,
-		error_msg TEXT,
-		uploaded_at DATETIME DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS watches (
-		id TEXT PRIMARY KEY,
-		folder_path TEXT,
-		server_id TEXT,
-		path_key TEXT,
-		patterns TEXT DEFAULT '[]',
-		debounce_ms INTEGER DEFAULT 1000,
-		enabled INTEGER DEFAULT 1
-	);
-
-	CREATE TABLE IF NOT EXISTS schedules (
-		id TEXT PRIMARY KEY,
-		name TEXT,
-		cron_expr TEXT,
-		file_path TEXT,
-		server_id TEXT,
-		path_key TEXT,
-		extract INTEGER DEFAULT 0,
-		enabled INTEGER DEFAULT 1
-	);
-	`
-	_, err := d.Exec(schema)
-	return err
+	return d.migrate()
 }
 
 // SaveServer 保存服务器配置
 func (d *DB) SaveServer(s Server) error {
-	pathsJSON := "[]"
-	if len(s.Paths) > 0 {
-		pathsJSON = toJSON(s.Paths)
+	return saveServer(d, s)
+}
+
+func saveServer(ex execer, s Server) error {
+	pathsJSON := encodeStrings(s.Paths)
+	excludesJSON := encodeStrings(s.Excludes)
+	connJSON, err := json.Marshal(s.Conn)
+	if err != nil {
+		return err
 	}
 
-	_, err := d.Exec(`
-		INSERT INTO servers (id, name, url, paths, is_default)
-		VALUES (?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET name=?, url=?, paths=?, is_default=?
-	`, s.ID, s.Name, s.URL, pathsJSON, boolToInt(s.IsDefault),
-		s.Name, s.URL, pathsJSON, boolToInt(s.IsDefault))
+	_, err = ex.Exec(`
+		INSERT INTO servers (id, name, url, paths, key_id, workers, excludes, zip_folders, conn, is_default)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name=?, url=?, paths=?, key_id=?, workers=?, excludes=?, zip_folders=?, conn=?, is_default=?
+	`, s.ID, s.Name, s.URL, pathsJSON, s.KeyID, s.Workers, excludesJSON, boolToInt(s.ZipFolders), string(connJSON), boolToInt(s.IsDefault),
+		s.Name, s.URL, pathsJSON, s.KeyID, s.Workers, excludesJSON, boolToInt(s.ZipFolders), string(connJSON), boolToInt(s.IsDefault))
 	return err
 }
 
 // GetServers 获取所有服务器
 func (d *DB) GetServers() ([]Server, error) {
-	rows, err := d.Query("SELECT id, name, url, paths, is_default, created_at FROM servers ORDER BY created_at")
+	rows, err := d.Query("SELECT id, name, url, paths, key_id, workers, excludes, zip_folders, conn, is_default, created_at FROM servers ORDER BY created_at")
 	if err != nil {
 		return nil, err
 	}
@@ -184,24 +248,52 @@ func (d *DB) GetServers() ([]Server, error) {
 	var servers []Server
 	for rows.Next() {
 		var s Server
-		var pathsJSON string
-		var isDefault int
-		if err := rows.Scan(&s.ID, &s.Name, &s.URL, &pathsJSON, &isDefault, &s.CreatedAt); err != nil {
+		var pathsJSON, excludesJSON, connJSON string
+		var zipFolders, isDefault int
+		if err := rows.Scan(&s.ID, &s.Name, &s.URL, &pathsJSON, &s.KeyID, &s.Workers, &excludesJSON, &zipFolders, &connJSON, &isDefault, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		if connJSON != "" {
+			if err := json.Unmarshal([]byte(connJSON), &s.Conn); err != nil {
+				return nil, fmt.Errorf("解析连接设置失败: %v", err)
+			}
+		}
+		if s.Paths, err = decodeStrings(pathsJSON); err != nil {
+			return nil, err
+		}
+		if s.Excludes, err = decodeStrings(excludesJSON); err != nil {
 			return nil, err
 		}
-		s.Paths = fromJSON(pathsJSON)
+		s.ZipFolders = zipFolders == 1
 		s.IsDefault = isDefault == 1
 		servers = append(servers, s)
 	}
 	return servers, nil
 }
 
-// DeleteServer 删除服务器
-func (d *DB) DeleteServer(id string) error {
-	_, err := d.Exec("DELETE FROM servers WHERE id = ?", id)
+// UpdateServerPaths 更新服务器的路径列表
+func (d *DB) UpdateServerPaths(id string, paths []string) error {
+	_, err := d.Exec("UPDATE servers SET paths = ? WHERE id = ?", encodeStrings(paths), id)
 	return err
 }
 
+// DeleteServer 删除服务器，同时从服务器组中移除
+func (d *DB) DeleteServer(id string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM servers WHERE id = ?", id); err != nil {
+		return err
+	}
+	if err := removeServerFromGroups(tx, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // SetDefaultServer 设置默认服务器
 func (d *DB) SetDefaultServer(id string) error {
 	tx, err := d.Begin()
@@ -219,83 +311,343 @@ func (d *DB) SetDefaultServer(id string) error {
 	return tx.Commit()
 }
 
-// SaveKeyPair 保存密钥对
-func (d *DB) SaveKeyPair(privateKey, publicKey string) error {
+const keyPairColumns = "id, name, encrypted_private_key, public_key, fingerprint, notes, protection, is_default, created_at, updated_at"
+
+// SaveKeyPair 保存密钥对，PrivateKey 为按 Protection 加密后的值 (不修改默认标记)
+func (d *DB) SaveKeyPair(kp KeyPair) error {
+	now := time.Now().Format(time.RFC3339)
+	if kp.CreatedAt == "" {
+		kp.CreatedAt = now
+	}
 	_, err := d.Exec(`
-		INSERT INTO keys (id, encrypted_private_key, public_key, updated_at)
-		VALUES (1, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET encrypted_private_key=?, public_key=?, updated_at=?
-	`, privateKey, publicKey, time.Now().Format(time.RFC3339),
-		privateKey, publicKey, time.Now().Format(time.RFC3339))
+		INSERT INTO key_pairs (id, name, encrypted_private_key, public_key, fingerprint, notes, protection, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name=?, encrypted_private_key=?, public_key=?, fingerprint=?, notes=?, protection=?, updated_at=?
+	`, kp.ID, kp.Name, kp.PrivateKey, kp.PublicKey, kp.Fingerprint, kp.Notes, kp.Protection, kp.CreatedAt, now,
+		kp.Name, kp.PrivateKey, kp.PublicKey, kp.Fingerprint, kp.Notes, kp.Protection, now)
 	return err
 }
 
-// GetKeyPair 获取密钥对
-func (d *DB) GetKeyPair() (*KeyPair, error) {
-	var kp KeyPair
-	err := d.QueryRow("SELECT encrypted_private_key, public_key, updated_at FROM keys WHERE id = 1").
-		Scan(&kp.PrivateKey, &kp.PublicKey, &kp.UpdatedAt)
+// GetKeyPairs 获取所有密钥对 (私钥为数据库中的原始值)
+func (d *DB) GetKeyPairs() ([]KeyPair, error) {
+	rows, err := d.Query("SELECT " + keyPairColumns + " FROM key_pairs ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []KeyPair
+	for rows.Next() {
+		kp, err := scanKeyPair(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *kp)
+	}
+	return keys, rows.Err()
+}
+
+// GetKeyPair 按 ID 获取密钥对，id 为空时返回默认密钥，不存在时返回 nil
+func (d *DB) GetKeyPair(id string) (*KeyPair, error) {
+	var row *sql.Row
+	if id == "" {
+		row = d.QueryRow("SELECT " + keyPairColumns + " FROM key_pairs ORDER BY is_default DESC, created_at LIMIT 1")
+	} else {
+		row = d.QueryRow("SELECT "+keyPairColumns+" FROM key_pairs WHERE id = ?", id)
+	}
+	kp, err := scanKeyPair(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
+	return kp, err
+}
+
+// DeleteKeyPair 删除密钥对，删除默认密钥时把最早创建的密钥设为默认
+func (d *DB) DeleteKeyPair(id string) error {
+	tx, err := d.Begin()
 	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM key_pairs WHERE id = ?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE key_pairs SET is_default = 1
+		WHERE id = (SELECT id FROM key_pairs ORDER BY created_at LIMIT 1)
+		AND NOT EXISTS (SELECT 1 FROM key_pairs WHERE is_default = 1)
+	`); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetDefaultKeyPair 设置默认密钥
+func (d *DB) SetDefaultKeyPair(id string) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE key_pairs SET is_default = 0"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE key_pairs SET is_default = 1 WHERE id = ?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetServersUsingKey 获取指定使用某个密钥的服务器名称
+func (d *DB) GetServersUsingKey(keyID string) ([]string, error) {
+	rows, err := d.Query("SELECT name FROM servers WHERE key_id = ? ORDER BY created_at", keyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func scanKeyPair(row interface{ Scan(...interface{}) error }) (*KeyPair, error) {
+	var kp KeyPair
+	var isDefault int
+	var privateKey, publicKey, createdAt, updatedAt sql.NullString
+	if err := row.Scan(&kp.ID, &kp.Name, &privateKey, &publicKey, &kp.Fingerprint, &kp.Notes,
+		&kp.Protection, &isDefault, &createdAt, &updatedAt); err != nil {
 		return nil, err
 	}
+	kp.PrivateKey = privateKey.String
+	kp.PublicKey = publicKey.String
+	kp.CreatedAt = createdAt.String
+	kp.UpdatedAt = updatedAt.String
+	kp.IsDefault = isDefault == 1
 	return &kp, nil
 }
 
-// AddHistory 添加历史记录
-func (d *DB) AddHistory(h HistoryEntry) error {
+// GetSetting 读取设置，不存在时返回空字符串
+func (d *DB) GetSetting(key string) (string, error) {
+	var value string
+	err := d.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return value, err
+}
+
+// SetSetting 保存设置
+func (d *DB) SetSetting(key, value string) error {
 	_, err := d.Exec(`
-		INSERT INTO history (server_id, server_name, path_key, filename, file_size, status, error_msg)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, h.ServerID, h.ServerName, h.PathKey, h.Filename, h.FileSize, h.Status, h.ErrorMsg)
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value=?
+	`, key, value, value)
 	return err
 }
 
-// GetHistory 获取历史记录
+// AddHistory 添加历史记录及其文件明细，返回记录 ID
+func (d *DB) AddHistory(h HistoryEntry) (int64, error) {
+	tx, err := d.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO history (server_id, server_name, path_key, filename, file_size, status, error_msg, file_count, duration_ms, group_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, h.ServerID, h.ServerName, h.PathKey, h.Filename, h.FileSize, h.Status, h.ErrorMsg, len(h.Files), h.DurationMs, h.GroupID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, f := range h.Files {
+		if _, err := tx.Exec(`
+			INSERT INTO history_files (history_id, rel_path, file_size, sha256, duration_ms, http_status, server_path, status, error_msg, server_id, server_name, server_sha256)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, id, f.RelPath, f.FileSize, f.SHA256, f.DurationMs, f.HTTPStatus, f.ServerPath, f.Status, f.ErrorMsg, f.ServerID, f.ServerName, f.ServerSHA256); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// GetHistory 获取最近的历史记录
 func (d *DB) GetHistory(limit int) ([]HistoryEntry, error) {
-	rows, err := d.Query(`
-		SELECT id, server_id, server_name, path_key, filename, file_size, status, error_msg, uploaded_at
-		FROM history ORDER BY uploaded_at DESC LIMIT ?
-	`, limit)
+	page, err := d.QueryHistory(HistoryFilter{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return page.Entries, nil
+}
+
+// QueryHistory 按条件分页查询历史记录 (不含文件明细)，按时间倒序
+func (d *DB) QueryHistory(f HistoryFilter) (*HistoryPage, error) {
+	var where []string
+	var args []interface{}
+	if f.ServerID != "" {
+		// 包含部署到该服务器的服务器组记录
+		where = append(where, "(server_id = ? OR id IN (SELECT history_id FROM history_files WHERE server_id = ?))")
+		args = append(args, f.ServerID, f.ServerID)
+	}
+	if f.PathKey != "" {
+		where = append(where, "path_key = ?")
+		args = append(args, f.PathKey)
+	}
+	if f.Status != "" {
+		where = append(where, "status = ?")
+		args = append(args, f.Status)
+	}
+	if f.From != "" {
+		where = append(where, "date(uploaded_at, 'localtime') >= date(?)")
+		args = append(args, f.From)
+	}
+	if f.To != "" {
+		where = append(where, "date(uploaded_at, 'localtime') <= date(?)")
+		args = append(args, f.To)
+	}
+	if f.Search != "" {
+		where = append(where, "filename LIKE ? ESCAPE '\\'")
+		args = append(args, "%"+escapeLike(f.Search)+"%")
+	}
+	cond := ""
+	if len(where) > 0 {
+		cond = " WHERE " + strings.Join(where, " AND ")
+	}
+
+	page := &HistoryPage{Entries: []HistoryEntry{}}
+	if err := d.QueryRow("SELECT COUNT(*) FROM history"+cond, args...).Scan(&page.Total); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, server_id, server_name, path_key, filename, file_size, status, error_msg, uploaded_at, file_count, duration_ms, group_id
+		FROM history` + cond + " ORDER BY uploaded_at DESC, id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+
+	rows, err := d.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var entries []HistoryEntry
 	for rows.Next() {
 		var h HistoryEntry
-		if err := rows.Scan(&h.ID, &h.ServerID, &h.ServerName, &h.PathKey, &h.Filename, &h.FileSize, &h.Status, &h.ErrorMsg, &h.UploadedAt); err != nil {
+		var errorMsg sql.NullString
+		if err := rows.Scan(&h.ID, &h.ServerID, &h.ServerName, &h.PathKey, &h.Filename, &h.FileSize, &h.Status, &errorMsg,
+			&h.UploadedAt, &h.FileCount, &h.DurationMs, &h.GroupID); err != nil {
 			return nil, err
 		}
-		entries = append(entries, h)
+		h.ErrorMsg = errorMsg.String
+		page.Entries = append(page.Entries, h)
 	}
-	return entries, nil
+	return page, rows.Err()
+}
+
+// GetHistoryFiles 获取一条历史记录的文件明细
+func (d *DB) GetHistoryFiles(historyID int64) ([]HistoryFile, error) {
+	rows, err := d.Query(`
+		SELECT id, history_id, rel_path, file_size, sha256, duration_ms, http_status, server_path, status, error_msg, server_id, server_name, server_sha256
+		FROM history_files WHERE history_id = ? ORDER BY id
+	`, historyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	files := []HistoryFile{}
+	for rows.Next() {
+		var f HistoryFile
+		if err := rows.Scan(&f.ID, &f.HistoryID, &f.RelPath, &f.FileSize, &f.SHA256, &f.DurationMs,
+			&f.HTTPStatus, &f.ServerPath, &f.Status, &f.ErrorMsg, &f.ServerID, &f.ServerName, &f.ServerSHA256); err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// UploadedHashes 每个相对路径最近一次成功上传到服务器该路径标识的 SHA-256，用于只同步变化的文件
+func (d *DB) UploadedHashes(serverID, pathKey string) (map[string]string, error) {
+	rows, err := d.Query(`
+		SELECT f.rel_path, f.sha256 FROM history_files f JOIN history h ON h.id = f.history_id
+		WHERE f.status = 'success' AND f.sha256 != '' AND h.path_key = ? AND (h.server_id = ? OR f.server_id = ?)
+		ORDER BY f.id
+	`, pathKey, serverID, serverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
+	for rows.Next() {
+		var relPath, sum string
+		if err := rows.Scan(&relPath, &sum); err != nil {
+			return nil, err
+		}
+		hashes[relPath] = sum
+	}
+	return hashes, rows.Err()
 }
 
 // ClearHistory 清空历史记录
 func (d *DB) ClearHistory() error {
-	_, err := d.Exec("DELETE FROM history")
-	return err
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM history_files"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM history"); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// escapeLike 转义 LIKE 通配符
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 // SaveWatch 保存监控配置
 func (d *DB) SaveWatch(w WatchConfig) error {
-	patternsJSON := toJSON(w.Patterns)
-	_, err := d.Exec(`
-		INSERT INTO watches (id, folder_path, server_id, path_key, patterns, debounce_ms, enabled)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET folder_path=?, server_id=?, path_key=?, patterns=?, debounce_ms=?, enabled=?
-	`, w.ID, w.FolderPath, w.ServerID, w.PathKey, patternsJSON, w.DebounceMs, boolToInt(w.Enabled),
-		w.FolderPath, w.ServerID, w.PathKey, patternsJSON, w.DebounceMs, boolToInt(w.Enabled))
+	return saveWatch(d, w)
+}
+
+func saveWatch(ex execer, w WatchConfig) error {
+	patternsJSON := encodeStrings(w.Patterns)
+	excludesJSON := encodeStrings(w.Excludes)
+	_, err := ex.Exec(`
+		INSERT INTO watches (id, folder_path, server_id, path_key, patterns, excludes, debounce_ms, enabled, recipe_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET folder_path=?, server_id=?, path_key=?, patterns=?, excludes=?, debounce_ms=?, enabled=?, recipe_id=?
+	`, w.ID, w.FolderPath, w.ServerID, w.PathKey, patternsJSON, excludesJSON, w.DebounceMs, boolToInt(w.Enabled), w.RecipeID,
+		w.FolderPath, w.ServerID, w.PathKey, patternsJSON, excludesJSON, w.DebounceMs, boolToInt(w.Enabled), w.RecipeID)
 	return err
 }
 
 // GetWatches 获取所有监控配置
 func (d *DB) GetWatches() ([]WatchConfig, error) {
-	rows, err := d.Query("SELECT id, folder_path, server_id, path_key, patterns, debounce_ms, enabled FROM watches")
+	rows, err := d.Query("SELECT id, folder_path, server_id, path_key, patterns, excludes, debounce_ms, enabled, recipe_id FROM watches")
 	if err != nil {
 		return nil, err
 	}
@@ -304,12 +656,17 @@ func (d *DB) GetWatches() ([]WatchConfig, error) {
 	var watches []WatchConfig
 	for rows.Next() {
 		var w WatchConfig
-		var patternsJSON string
+		var patternsJSON, excludesJSON string
 		var enabled int
-		if err := rows.Scan(&w.ID, &w.FolderPath, &w.ServerID, &w.PathKey, &patternsJSON, &w.DebounceMs, &enabled); err != nil {
+		if err := rows.Scan(&w.ID, &w.FolderPath, &w.ServerID, &w.PathKey, &patternsJSON, &excludesJSON, &w.DebounceMs, &enabled, &w.RecipeID); err != nil {
+			return nil, err
+		}
+		if w.Patterns, err = decodeStrings(patternsJSON); err != nil {
+			return nil, err
+		}
+		if w.Excludes, err = decodeStrings(excludesJSON); err != nil {
 			return nil, err
 		}
-		w.Patterns = fromJSON(patternsJSON)
 		w.Enabled = enabled == 1
 		watches = append(watches, w)
 	}
@@ -322,20 +679,28 @@ func (d *DB) DeleteWatch(id string) error {
 	return err
 }
 
-// SaveSchedule 保存定时任务
+// SaveSchedule 保存定时任务 (不修改调度器维护的运行状态)
 func (d *DB) SaveSchedule(s Schedule) error {
-	_, err := d.Exec(`
-		INSERT INTO schedules (id, name, cron_expr, file_path, server_id, path_key, extract, enabled)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(id) DO UPDATE SET name=?, cron_expr=?, file_path=?, server_id=?, path_key=?, extract=?, enabled=?
-	`, s.ID, s.Name, s.CronExpr, s.FilePath, s.ServerID, s.PathKey, boolToInt(s.Extract), boolToInt(s.Enabled),
-		s.Name, s.CronExpr, s.FilePath, s.ServerID, s.PathKey, boolToInt(s.Extract), boolToInt(s.Enabled))
+	return saveSchedule(d, s)
+}
+
+func saveSchedule(ex execer, s Schedule) error {
+	_, err := ex.Exec(`
+		INSERT INTO schedules (id, name, cron_expr, file_path, server_id, path_key, extract, enabled, overlap, catch_up, recipe_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name=?, cron_expr=?, file_path=?, server_id=?, path_key=?, extract=?, enabled=?, overlap=?, catch_up=?, recipe_id=?
+	`, s.ID, s.Name, s.CronExpr, s.FilePath, s.ServerID, s.PathKey, boolToInt(s.Extract), boolToInt(s.Enabled), s.Overlap, s.CatchUp, s.RecipeID,
+		s.Name, s.CronExpr, s.FilePath, s.ServerID, s.PathKey, boolToInt(s.Extract), boolToInt(s.Enabled), s.Overlap, s.CatchUp, s.RecipeID)
 	return err
 }
 
 // GetSchedules 获取所有定时任务
 func (d *DB) GetSchedules() ([]Schedule, error) {
-	rows, err := d.Query("SELECT id, name, cron_expr, file_path, server_id, path_key, extract, enabled FROM schedules")
+	rows, err := d.Query(`
+		SELECT id, name, cron_expr, file_path, server_id, path_key, extract, enabled,
+			overlap, catch_up, last_run, next_run, last_result, recipe_id
+		FROM schedules
+	`)
 	if err != nil {
 		return nil, err
 	}
@@ -345,7 +710,8 @@ func (d *DB) GetSchedules() ([]Schedule, error) {
 	for rows.Next() {
 		var s Schedule
 		var extract, enabled int
-		if err := rows.Scan(&s.ID, &s.Name, &s.CronExpr, &s.FilePath, &s.ServerID, &s.PathKey, &extract, &enabled); err != nil {
+		if err := rows.Scan(&s.ID, &s.Name, &s.CronExpr, &s.FilePath, &s.ServerID, &s.PathKey, &extract, &enabled,
+			&s.Overlap, &s.CatchUp, &s.LastRun, &s.NextRun, &s.LastResult, &s.RecipeID); err != nil {
 			return nil, err
 		}
 		s.Extract = extract == 1
@@ -355,12 +721,142 @@ func (d *DB) GetSchedules() ([]Schedule, error) {
 	return schedules, nil
 }
 
+// UpdateScheduleRun 记录定时任务的运行结果
+func (d *DB) UpdateScheduleRun(id, lastRun, nextRun, lastResult string) error {
+	_, err := d.Exec("UPDATE schedules SET last_run = ?, next_run = ?, last_result = ? WHERE id = ?",
+		lastRun, nextRun, lastResult, id)
+	return err
+}
+
+// UpdateScheduleNextRun 更新定时任务的下一次运行时间
+func (d *DB) UpdateScheduleNextRun(id, nextRun string) error {
+	_, err := d.Exec("UPDATE schedules SET next_run = ? WHERE id = ?", nextRun, id)
+	return err
+}
+
 // DeleteSchedule 删除定时任务
 func (d *DB) DeleteSchedule(id string) error {
 	_, err := d.Exec("DELETE FROM schedules WHERE id = ?", id)
 	return err
 }
 
+const queueColumns = "id, server_id, path_key, file_path, rel_path, extract, source, source_id, status, attempts, max_attempts, last_error, error_code, next_attempt_at, created_at, updated_at"
+
+// AddQueueJob 加入上传队列
+func (d *DB) AddQueueJob(j QueueJob) error {
+	_, err := d.Exec(`
+		INSERT INTO upload_queue (`+queueColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, j.ID, j.ServerID, j.PathKey, j.FilePath, j.RelPath, boolToInt(j.Extract), j.Source, j.SourceID,
+		j.Status, j.Attempts, j.MaxAttempts, j.LastError, j.ErrorCode, j.NextAttemptAt, j.CreatedAt, j.UpdatedAt)
+	return err
+}
+
+// UpdateQueueJob 更新队列任务的状态和重试信息
+func (d *DB) UpdateQueueJob(j QueueJob) error {
+	_, err := d.Exec(`
+		UPDATE upload_queue SET status=?, attempts=?, last_error=?, error_code=?, next_attempt_at=?, updated_at=?
+		WHERE id = ?
+	`, j.Status, j.Attempts, j.LastError, j.ErrorCode, j.NextAttemptAt, j.UpdatedAt, j.ID)
+	return err
+}
+
+// GetQueueJob 获取队列任务，不存在时返回 nil
+func (d *DB) GetQueueJob(id string) (*QueueJob, error) {
+	j, err := scanQueueJob(d.QueryRow("SELECT "+queueColumns+" FROM upload_queue WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return j, err
+}
+
+// FindPendingQueueJob 查找同一文件、同一目标尚未执行的任务，用于去重
+func (d *DB) FindPendingQueueJob(serverID, pathKey, filePath, relPath string) (*QueueJob, error) {
+	j, err := scanQueueJob(d.QueryRow(`
+		SELECT `+queueColumns+` FROM upload_queue
+		WHERE status = ? AND server_id = ? AND path_key = ? AND file_path = ? AND rel_path = ?
+		LIMIT 1
+	`, QueuePending, serverID, pathKey, filePath, relPath))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return j, err
+}
+
+// GetQueueJobs 获取队列任务，status 为空时返回全部
+func (d *DB) GetQueueJobs(status string) ([]QueueJob, error) {
+	query := "SELECT " + queueColumns + " FROM upload_queue"
+	var args []interface{}
+	if status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	return d.queryQueueJobs(query+" ORDER BY created_at", args...)
+}
+
+// DueQueueJobs 获取已到执行时间的等待任务
+func (d *DB) DueQueueJobs(now string, limit int) ([]QueueJob, error) {
+	return d.queryQueueJobs(`
+		SELECT `+queueColumns+` FROM upload_queue
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at, created_at LIMIT ?
+	`, QueuePending, now, limit)
+}
+
+// NextQueueAttempt 最早的等待任务执行时间，没有等待任务时返回空字符串
+func (d *DB) NextQueueAttempt() (string, error) {
+	var next sql.NullString
+	err := d.QueryRow("SELECT MIN(next_attempt_at) FROM upload_queue WHERE status = ?", QueuePending).Scan(&next)
+	return next.String, err
+}
+
+// ResetRunningQueueJobs 把上次退出时仍在执行的任务恢复为等待
+func (d *DB) ResetRunningQueueJobs() error {
+	_, err := d.Exec("UPDATE upload_queue SET status = ? WHERE status = ?", QueuePending, QueueRunning)
+	return err
+}
+
+// DeleteQueueJob 删除队列任务
+func (d *DB) DeleteQueueJob(id string) error {
+	_, err := d.Exec("DELETE FROM upload_queue WHERE id = ?", id)
+	return err
+}
+
+// ClearQueueJobs 删除指定状态的队列任务
+func (d *DB) ClearQueueJobs(status string) error {
+	_, err := d.Exec("DELETE FROM upload_queue WHERE status = ?", status)
+	return err
+}
+
+func (d *DB) queryQueueJobs(query string, args ...interface{}) ([]QueueJob, error) {
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []QueueJob
+	for rows.Next() {
+		j, err := scanQueueJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, *j)
+	}
+	return jobs, rows.Err()
+}
+
+func scanQueueJob(row interface{ Scan(...interface{}) error }) (*QueueJob, error) {
+	var j QueueJob
+	var extract int
+	if err := row.Scan(&j.ID, &j.ServerID, &j.PathKey, &j.FilePath, &j.RelPath, &extract, &j.Source, &j.SourceID,
+		&j.Status, &j.Attempts, &j.MaxAttempts, &j.LastError, &j.ErrorCode, &j.NextAttemptAt, &j.CreatedAt, &j.UpdatedAt); err != nil {
+		return nil, err
+	}
+	j.Extract = extract == 1
+	return &j, nil
+}
+
 // Helper functions
 func boolToInt(b bool) int {
 	if b {
@@ -369,52 +865,26 @@ func boolToInt(b bool) int {
 	return 0
 }
 
-func toJSON(arr []string) string {
-	if len(arr) == 0 {
-		return "[]"
-	}
-	result := "["
-	for i, s := range arr {
-		if i > 0 {
-			result += ","
-		}
-		result += `"` + s + `"`
+// encodeStrings 数组列编码为 JSON，nil 编码为 []
+func encodeStrings(arr []string) string {
+	if arr == nil {
+		arr = []string{}
 	}
-	result += "]"
-	return result
+	data, _ := json.Marshal(arr)
+	return string(data)
 }
 
-func fromJSON(s string) []string {
-	if s == "" || s == "[]" {
-		return []string{}
-	}
-	// Simple JSON array parsing
-	s = s[1 : len(s)-1] // Remove brackets
+// decodeStrings 解析 JSON 数组列
+func decodeStrings(s string) ([]string, error) {
+	result := []string{}
 	if s == "" {
-		return []string{}
-	}
-	var result []string
-	inQuote := false
-	current := ""
-	for _, c := range s {
-		switch c {
-		case '"':
-			inQuote = !inQuote
-		case ',':
-			if !inQuote {
-				result = append(result, current)
-				current = ""
-			} else {
-				current += string(c)
-			}
-		default:
-			if inQuote {
-				current += string(c)
-			}
-		}
+		return result, nil
+	}
+	if err := json.Unmarshal([]byte(s), &result); err != nil {
+		return nil, fmt.Errorf("解析数组失败: %v", err)
 	}
-	if current != "" {
-		result = append(result, current)
+	if result == nil {
+		result = []string{}
 	}
-	return result
+	return result, nil
 }
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// openTestDB 在临时目录创建数据库
+func openTestDB(t *testing.T) *DB {
+	t.Helper()
+	d, err := Open(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+// getServer 按 ID 读出服务器
+func getServer(t *testing.T, d *DB, id string) Server {
+	t.Helper()
+	servers, err := d.GetServers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range servers {
+		if s.ID == id {
+			return s
+		}
+	}
+	t.Fatalf("server %s not found", id)
+	return Server{}
+}
+
+func TestSaveServerTwiceKeepsSettings(t *testing.T) {
+	d := openTestDB(t)
+	if err := d.SaveKeyPair(KeyPair{ID: "k1", Name: "deploy", PrivateKey: "priv", PublicKey: "pub"}); err != nil {
+		t.Fatal(err)
+	}
+
+	orig := Server{
+		ID:         "s1",
+		Name:       "prod",
+		URL:        "https://example.com",
+		Paths:      []string{"web", "api"},
+		KeyID:      "k1",
+		Workers:    8,
+		Excludes:   []string{"node_modules/", "*.log"},
+		ZipFolders: true,
+		Conn: ConnSettings{
+			RateLimitKB:    512,
+			ProxyURL:       "http://proxy:3128",
+			SkipVerify:     true,
+			TLSServerName:  "deploy.internal",
+			ConnectTimeout: 5,
+			Timeout:        60,
+			Headers:        map[string]string{"X-Team": "ops"},
+		},
+	}
+	if err := d.SaveServer(orig); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.SetDefaultServer("s1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 编辑表单读出整行，只改名称和路径后再保存
+	edited := getServer(t, d, "s1")
+	edited.Name = "production"
+	edited.Paths = []string{"web"}
+	if err := d.SaveServer(edited); err != nil {
+		t.Fatal(err)
+	}
+
+	got := getServer(t, d, "s1")
+	if got.Name != "production" || !reflect.DeepEqual(got.Paths, []string{"web"}) {
+		t.Errorf("name/paths = %q %q, want production [web]", got.Name, got.Paths)
+	}
+	if got.KeyID != orig.KeyID {
+		t.Errorf("KeyID = %q, want %q", got.KeyID, orig.KeyID)
+	}
+	if !reflect.DeepEqual(got.Conn, orig.Conn) {
+		t.Errorf("Conn = %+v, want %+v", got.Conn, orig.Conn)
+	}
+	if got.Workers != orig.Workers || got.ZipFolders != orig.ZipFolders || !reflect.DeepEqual(got.Excludes, orig.Excludes) {
+		t.Errorf("workers/zip/excludes = %d %v %q, want %d %v %q", got.Workers, got.ZipFolders, got.Excludes, orig.Workers, orig.ZipFolders, orig.Excludes)
+	}
+	if !got.IsDefault {
+		t.Error("IsDefault lost after second save")
+	}
+	if servers, _ := d.GetServersUsingKey("k1"); len(servers) != 1 {
+		t.Errorf("GetServersUsingKey = %q, want [production]", servers)
+	}
+}
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// 历史记录导出格式
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// ExportHistory 导出符合条件的全部历史记录 (忽略分页)，包含文件明细
+//
+// CSV 每个文件一行 (没有明细的记录占一行)，并带 UTF-8 BOM 便于 Excel 打开；
+// JSON 为记录数组，每条记录带 files 明细。
+func (d *DB) ExportHistory(w io.Writer, f HistoryFilter, format string) (int, error) {
+	f.Offset, f.Limit = 0, 0
+	page, err := d.QueryHistory(f)
+	if err != nil {
+		return 0, err
+	}
+	for i := range page.Entries {
+		files, err := d.GetHistoryFiles(page.Entries[i].ID)
+		if err != nil {
+			return 0, err
+		}
+		page.Entries[i].Files = files
+	}
+
+	switch format {
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return len(page.Entries), enc.Encode(page.Entries)
+	case ExportCSV:
+		return len(page.Entries), writeHistoryCSV(w, page.Entries)
+	default:
+		return 0, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+}
+
+func writeHistoryCSV(w io.Writer, entries []HistoryEntry) error {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"id", "uploaded_at", "server_id", "server_name", "path_key", "name", "status", "error",
+		"file", "file_size", "sha256", "duration_ms", "http_status", "server_path", "file_status", "file_error", "file_server", "server_sha256",
+	})
+	for _, h := range entries {
+		base := []string{
+			strconv.FormatInt(h.ID, 10), h.UploadedAt, h.ServerID, h.ServerName, h.PathKey, h.Filename, h.Status, h.ErrorMsg,
+		}
+		if len(h.Files) == 0 {
+			cw.Write(append(base, "", strconv.FormatInt(h.FileSize, 10), "", strconv.FormatInt(h.DurationMs, 10), "", "", "", "", "", ""))
+			continue
+		}
+		for _, f := range h.Files {
+			row := append(append([]string{}, base...),
+				f.RelPath,
+				strconv.FormatInt(f.FileSize, 10),
+				f.SHA256,
+				strconv.FormatInt(f.DurationMs, 10),
+				strconv.Itoa(f.HTTPStatus),
+				f.ServerPath,
+				f.Status,
+				f.ErrorMsg,
+				f.ServerName,
+				f.ServerSHA256,
+			)
+			cw.Write(row)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,131 @@
+package database
+
+import "database/sql"
+
+// 服务器组的部署策略
+const (
+	StrategyParallel   = "parallel"   // 同时部署到所有服务器
+	StrategySequential = "sequential" // 逐台部署
+	StrategyRolling    = "rolling"    // 同时最多 MaxUnavailable 台，完成一台再开始下一台
+)
+
+// ServerGroup 服务器组
+type ServerGroup struct {
+	ID             string   `json:"id"`
+	Name           string   `json:"name"`
+	ServerIDs      []string `json:"serverIds"` // 按部署顺序
+	Strategy       string   `json:"strategy"`
+	MaxUnavailable int      `json:"maxUnavailable"` // 滚动部署时同时进行的服务器数
+	StopOnFailure  bool     `json:"stopOnFailure"`  // 出现失败后不再部署其余服务器
+	CreatedAt      string   `json:"createdAt"`
+}
+
+const serverGroupColumns = "id, name, server_ids, strategy, max_unavailable, stop_on_failure, created_at"
+
+// SaveServerGroup 保存服务器组
+func (d *DB) SaveServerGroup(g ServerGroup) error {
+	return saveServerGroup(d, g)
+}
+
+func saveServerGroup(ex execer, g ServerGroup) error {
+	serverIDs := encodeStrings(g.ServerIDs)
+	_, err := ex.Exec(`
+		INSERT INTO server_groups (id, name, server_ids, strategy, max_unavailable, stop_on_failure)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name=?, server_ids=?, strategy=?, max_unavailable=?, stop_on_failure=?
+	`, g.ID, g.Name, serverIDs, g.Strategy, g.MaxUnavailable, boolToInt(g.StopOnFailure),
+		g.Name, serverIDs, g.Strategy, g.MaxUnavailable, boolToInt(g.StopOnFailure))
+	return err
+}
+
+// GetServerGroups 获取所有服务器组
+func (d *DB) GetServerGroups() ([]ServerGroup, error) {
+	rows, err := d.Query("SELECT " + serverGroupColumns + " FROM server_groups ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []ServerGroup
+	for rows.Next() {
+		g, err := scanServerGroup(rows)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *g)
+	}
+	return groups, rows.Err()
+}
+
+// GetServerGroup 按 ID 获取服务器组，不存在时返回 nil
+func (d *DB) GetServerGroup(id string) (*ServerGroup, error) {
+	g, err := scanServerGroup(d.QueryRow("SELECT "+serverGroupColumns+" FROM server_groups WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return g, err
+}
+
+// DeleteServerGroup 删除服务器组
+func (d *DB) DeleteServerGroup(id string) error {
+	_, err := d.Exec("DELETE FROM server_groups WHERE id = ?", id)
+	return err
+}
+
+// removeServerFromGroups 从所有服务器组中移除服务器
+func removeServerFromGroups(tx *sql.Tx, serverID string) error {
+	rows, err := tx.Query("SELECT id, server_ids FROM server_groups")
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]string)
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+		ids, err := decodeStrings(raw)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		kept := ids[:0]
+		for _, sid := range ids {
+			if sid != serverID {
+				kept = append(kept, sid)
+			}
+		}
+		if len(kept) != len(ids) {
+			updates[id] = encodeStrings(kept)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for id, serverIDs := range updates {
+		if _, err := tx.Exec("UPDATE server_groups SET server_ids = ? WHERE id = ?", serverIDs, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanServerGroup(row interface{ Scan(...interface{}) error }) (*ServerGroup, error) {
+	var g ServerGroup
+	var serverIDs string
+	var stopOnFailure int
+	if err := row.Scan(&g.ID, &g.Name, &serverIDs, &g.Strategy, &g.MaxUnavailable, &stopOnFailure, &g.CreatedAt); err != nil {
+		return nil, err
+	}
+	var err error
+	if g.ServerIDs, err = decodeStrings(serverIDs); err != nil {
+		return nil, err
+	}
+	g.StopOnFailure = stopOnFailure == 1
+	return &g, nil
+}
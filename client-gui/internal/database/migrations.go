@@ -0,0 +1,504 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// migration 一次数据库结构变更
+//
+// 迁移按版本号顺序在各自的事务中执行，成功后写入 schema_version。
+// 引入版本号之前的数据库可能已经部分包含这些变更，所以每个迁移都必须可重复执行
+// (CREATE ... IF NOT EXISTS、addColumnIfMissing 等)。
+type migration struct {
+	version int
+	name    string
+	up      func(tx *sql.Tx) error
+}
+
+// migrations 所有迁移，只能在末尾追加，不能修改已发布的迁移
+var migrations = []migration{
+	{1, "initial schema", migrateInitial},
+	{2, "schedule run state", migrateScheduleRunState},
+	{3, "key protection and settings", migrateKeyProtection},
+	{4, "named key pairs", migrateNamedKeyPairs},
+	{5, "server upload workers", migrateServerWorkers},
+	{6, "upload queue", migrateUploadQueue},
+	{7, "history file details", migrateHistoryFiles},
+	{8, "json array columns", migrateJSONArrays},
+	{9, "server groups", migrateServerGroups},
+	{10, "deploy recipes", migrateRecipes},
+	{11, "upload excludes and folder archives", migrateUploadExcludes},
+	{12, "server connection settings", migrateServerConn},
+	{13, "history server hashes", migrateHistoryServerHash},
+}
+
+// SchemaVersion 当前程序支持的数据库版本
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// migrate 执行尚未应用的迁移
+func (d *DB) migrate() error {
+	if _, err := d.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			version INTEGER PRIMARY KEY,
+			name TEXT,
+			applied_at TEXT
+		)
+	`); err != nil {
+		return err
+	}
+
+	current, err := d.Version()
+	if err != nil {
+		return err
+	}
+	if current > SchemaVersion() {
+		return fmt.Errorf("数据库版本 (%d) 高于程序支持的版本 (%d)，请升级客户端", current, SchemaVersion())
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := d.applyMigration(m); err != nil {
+			return fmt.Errorf("数据库迁移 %d (%s) 失败: %v", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+func (d *DB) applyMigration(m migration) error {
+	tx, err := d.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.up(tx); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(
+		"INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)",
+		m.version, m.name, time.Now().Format(time.RFC3339),
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Version 当前数据库版本，0 表示尚未执行任何迁移
+func (d *DB) Version() (int, error) {
+	var version sql.NullInt64
+	err := d.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version)
+	return int(version.Int64), err
+}
+
+// execAll 依次执行多条语句
+func execAll(tx *sql.Tx, statements ...string) error {
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumnIfMissing 列不存在时添加
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+func tableExists(tx *sql.Tx, table string) (bool, error) {
+	var n int
+	err := tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&n)
+	return n > 0, err
+}
+
+// migrateInitial 最初版本的表结构
+func migrateInitial(tx *sql.Tx) error {
+	return execAll(tx, `
+		CREATE TABLE IF NOT EXISTS servers (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			url TEXT NOT NULL,
+			paths TEXT DEFAULT '[]',
+			is_default INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`, `
+		CREATE TABLE IF NOT EXISTS keys (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			encrypted_private_key TEXT,
+			public_key TEXT,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`, `
+		CREATE TABLE IF NOT EXISTS history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			server_id TEXT,
+			server_name TEXT,
+			path_key TEXT,
+			filename TEXT,
+			file_size INTEGER,
+			status TEXT,
+			error_msg TEXT,
+			uploaded_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`, `
+		CREATE TABLE IF NOT EXISTS watches (
+			id TEXT PRIMARY KEY,
+			folder_path TEXT,
+			server_id TEXT,
+			path_key TEXT,
+			patterns TEXT DEFAULT '[]',
+			debounce_ms INTEGER DEFAULT 1000,
+			enabled INTEGER DEFAULT 1
+		)`, `
+		CREATE TABLE IF NOT EXISTS schedules (
+			id TEXT PRIMARY KEY,
+			name TEXT,
+			cron_expr TEXT,
+			file_path TEXT,
+			server_id TEXT,
+			path_key TEXT,
+			extract INTEGER DEFAULT 0,
+			enabled INTEGER DEFAULT 1
+		)`)
+}
+
+// migrateScheduleRunState 定时任务的重叠/补跑策略和运行状态
+func migrateScheduleRunState(tx *sql.Tx) error {
+	columns := []struct{ column, definition string }{
+		{"overlap", "TEXT DEFAULT 'skip'"},
+		{"catch_up", "TEXT DEFAULT 'skip'"},
+		{"last_run", "TEXT DEFAULT ''"},
+		{"next_run", "TEXT DEFAULT ''"},
+		{"last_result", "TEXT DEFAULT ''"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(tx, "schedules", c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateKeyProtection 私钥保护方式和通用设置表
+func migrateKeyProtection(tx *sql.Tx) error {
+	if exists, err := tableExists(tx, "keys"); err != nil || !exists {
+		// keys 表已在多密钥迁移中删除
+		if err != nil {
+			return err
+		}
+	} else if err := addColumnIfMissing(tx, "keys", "protection", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return execAll(tx, `
+		CREATE TABLE IF NOT EXISTS settings (
+			key TEXT PRIMARY KEY,
+			value TEXT
+		)`)
+}
+
+// migrateNamedKeyPairs 多个命名密钥: 旧 keys 表中的唯一密钥成为默认密钥
+func migrateNamedKeyPairs(tx *sql.Tx) error {
+	if err := execAll(tx, `
+		CREATE TABLE IF NOT EXISTS key_pairs (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			encrypted_private_key TEXT,
+			public_key TEXT,
+			fingerprint TEXT DEFAULT '',
+			notes TEXT DEFAULT '',
+			protection TEXT DEFAULT '',
+			is_default INTEGER DEFAULT 0,
+			created_at TEXT,
+			updated_at TEXT
+		)`); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "servers", "key_id", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+
+	exists, err := tableExists(tx, "keys")
+	if err != nil || !exists {
+		return err
+	}
+
+	var count int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM key_pairs").Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		var privateKey, publicKey, protection, updatedAt sql.NullString
+		err := tx.QueryRow("SELECT encrypted_private_key, public_key, protection, updated_at FROM keys WHERE id = 1").
+			Scan(&privateKey, &publicKey, &protection, &updatedAt)
+		switch {
+		case err == sql.ErrNoRows:
+		case err != nil:
+			return err
+		case privateKey.String != "":
+			if _, err := tx.Exec(`
+				INSERT INTO key_pairs (id, name, encrypted_private_key, public_key, protection, is_default, created_at, updated_at)
+				VALUES ('default', '默认密钥', ?, ?, ?, 1, ?, ?)
+			`, privateKey.String, publicKey.String, protection.String, updatedAt.String, updatedAt.String); err != nil {
+				return err
+			}
+		}
+	}
+	return execAll(tx, "DROP TABLE keys")
+}
+
+// migrateServerWorkers 文件夹上传并发数
+func migrateServerWorkers(tx *sql.Tx) error {
+	return addColumnIfMissing(tx, "servers", "workers", "INTEGER DEFAULT 0")
+}
+
+// migrateUploadQueue 持久化上传队列
+func migrateUploadQueue(tx *sql.Tx) error {
+	return execAll(tx, `
+		CREATE TABLE IF NOT EXISTS upload_queue (
+			id TEXT PRIMARY KEY,
+			server_id TEXT,
+			path_key TEXT,
+			file_path TEXT,
+			rel_path TEXT DEFAULT '',
+			extract INTEGER DEFAULT 0,
+			source TEXT DEFAULT '',
+			source_id TEXT DEFAULT '',
+			status TEXT DEFAULT 'pending',
+			attempts INTEGER DEFAULT 0,
+			max_attempts INTEGER DEFAULT 0,
+			last_error TEXT DEFAULT '',
+			error_code TEXT DEFAULT '',
+			next_attempt_at TEXT DEFAULT '',
+			created_at TEXT,
+			updated_at TEXT
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_upload_queue_due ON upload_queue (status, next_attempt_at)",
+	)
+}
+
+// migrateHistoryFiles 历史记录的文件明细
+func migrateHistoryFiles(tx *sql.Tx) error {
+	if err := addColumnIfMissing(tx, "history", "file_count", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "history", "duration_ms", "INTEGER DEFAULT 0"); err != nil {
+		return err
+	}
+	return execAll(tx, `
+		CREATE TABLE IF NOT EXISTS history_files (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			history_id INTEGER NOT NULL REFERENCES history(id) ON DELETE CASCADE,
+			rel_path TEXT,
+			file_size INTEGER DEFAULT 0,
+			sha256 TEXT DEFAULT '',
+			duration_ms INTEGER DEFAULT 0,
+			http_status INTEGER DEFAULT 0,
+			server_path TEXT DEFAULT '',
+			status TEXT,
+			error_msg TEXT DEFAULT ''
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_history_files_history ON history_files (history_id)",
+	)
+}
+
+// migrateServerGroups 服务器组，以及按组部署时历史记录中每个文件所属的服务器
+func migrateServerGroups(tx *sql.Tx) error {
+	if err := execAll(tx, `
+		CREATE TABLE IF NOT EXISTS server_groups (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			server_ids TEXT DEFAULT '[]',
+			strategy TEXT DEFAULT 'parallel',
+			max_unavailable INTEGER DEFAULT 1,
+			stop_on_failure INTEGER DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`); err != nil {
+		return err
+	}
+	columns := []struct{ table, column, definition string }{
+		{"history", "group_id", "TEXT DEFAULT ''"},
+		{"history_files", "server_id", "TEXT DEFAULT ''"},
+		{"history_files", "server_name", "TEXT DEFAULT ''"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return execAll(tx, "CREATE INDEX IF NOT EXISTS idx_history_files_server ON history_files (server_id)")
+}
+
+// migrateRecipes 部署配方、运行记录，以及监控和定时任务触发配方
+func migrateRecipes(tx *sql.Tx) error {
+	if err := execAll(tx, `
+		CREATE TABLE IF NOT EXISTS recipes (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			steps TEXT DEFAULT '[]',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`, `
+		CREATE TABLE IF NOT EXISTS recipe_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			recipe_id TEXT,
+			recipe_name TEXT,
+			trigger TEXT DEFAULT '',
+			status TEXT,
+			error_msg TEXT DEFAULT '',
+			steps TEXT DEFAULT '[]',
+			log TEXT DEFAULT '',
+			started_at TEXT,
+			finished_at TEXT DEFAULT '',
+			duration_ms INTEGER DEFAULT 0
+		)`,
+		"CREATE INDEX IF NOT EXISTS idx_recipe_runs_recipe ON recipe_runs (recipe_id, id)",
+	); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "watches", "recipe_id", "TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	return addColumnIfMissing(tx, "schedules", "recipe_id", "TEXT DEFAULT ''")
+}
+
+// migrateUploadExcludes 服务器和监控的排除模式，服务器的文件夹打包上传选项
+func migrateUploadExcludes(tx *sql.Tx) error {
+	columns := []struct{ table, column, definition string }{
+		{"servers", "excludes", "TEXT DEFAULT '[]'"},
+		{"servers", "zip_folders", "INTEGER DEFAULT 0"},
+		{"watches", "excludes", "TEXT DEFAULT '[]'"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateJSONArrays 把旧版手写拼接的数组列改写为标准 JSON
+//
+// 旧格式不转义引号和反斜杠，Windows 路径 (C:\new) 按标准 JSON 解析会被误读，
+// 所以一律按旧格式解析后重新编码。
+func migrateJSONArrays(tx *sql.Tx) error {
+	columns := []struct{ table, column string }{
+		{"servers", "paths"},
+		{"watches", "patterns"},
+	}
+	for _, c := range columns {
+		if err := rewriteArrayColumn(tx, c.table, c.column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func rewriteArrayColumn(tx *sql.Tx, table, column string) error {
+	rows, err := tx.Query(fmt.Sprintf("SELECT id, %s FROM %s", column, table))
+	if err != nil {
+		return err
+	}
+
+	updates := make(map[string]string)
+	for rows.Next() {
+		var id string
+		var raw sql.NullString
+		if err := rows.Scan(&id, &raw); err != nil {
+			rows.Close()
+			return err
+		}
+		data, err := json.Marshal(legacyFromJSON(raw.String))
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		if string(data) != raw.String {
+			updates[id] = string(data)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	rows.Close()
+
+	for id, value := range updates {
+		if _, err := tx.Exec(fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", table, column), value, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// legacyFromJSON 旧版本的数组解析 (只用于迁移): 引号内的字符原样保留，不处理转义
+func legacyFromJSON(s string) []string {
+	result := []string{}
+	if len(s) < 2 || s == "[]" {
+		return result
+	}
+	s = s[1 : len(s)-1]
+
+	inQuote := false
+	current := ""
+	for _, c := range s {
+		switch c {
+		case '"':
+			inQuote = !inQuote
+		case ',':
+			if !inQuote {
+				result = append(result, current)
+				current = ""
+			} else {
+				current += string(c)
+			}
+		default:
+			if inQuote {
+				current += string(c)
+			}
+		}
+	}
+	if current != "" {
+		result = append(result, current)
+	}
+	return result
+}
+
+// migrateServerConn 服务器的连接设置 (限速、代理、TLS、超时、请求头)，JSON
+func migrateServerConn(tx *sql.Tx) error {
+	return addColumnIfMissing(tx, "servers", "conn", "TEXT DEFAULT '{}'")
+}
+
+// migrateHistoryServerHash 服务器计算的文件 SHA-256，用于核对上传内容
+func migrateHistoryServerHash(tx *sql.Tx) error {
+	return addColumnIfMissing(tx, "history_files", "server_sha256", "TEXT DEFAULT ''")
+}
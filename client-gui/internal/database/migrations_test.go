@@ -0,0 +1,196 @@
+package database
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// baselineSchema 引入 schema_version 之前发布的表结构，keys 表已带有后来加入的 protection 列
+var baselineSchema = []string{`
+	CREATE TABLE servers (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		paths TEXT DEFAULT '[]',
+		is_default INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`, `
+	CREATE TABLE keys (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		encrypted_private_key TEXT,
+		public_key TEXT,
+		protection TEXT DEFAULT '',
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`, `
+	CREATE TABLE history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		server_id TEXT,
+		server_name TEXT,
+		path_key TEXT,
+		filename TEXT,
+		file_size INTEGER,
+		status TEXT,
+		error_msg TEXT,
+		uploaded_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`, `
+	CREATE TABLE watches (
+		id TEXT PRIMARY KEY,
+		folder_path TEXT,
+		server_id TEXT,
+		path_key TEXT,
+		patterns TEXT DEFAULT '[]',
+		debounce_ms INTEGER DEFAULT 1000,
+		enabled INTEGER DEFAULT 1
+	)`, `
+	CREATE TABLE schedules (
+		id TEXT PRIMARY KEY,
+		name TEXT,
+		cron_expr TEXT,
+		file_path TEXT,
+		server_id TEXT,
+		path_key TEXT,
+		extract INTEGER DEFAULT 0,
+		enabled INTEGER DEFAULT 1
+	)`,
+}
+
+// baselineData 旧版本写入的数据，数组列是不转义的手写格式
+var baselineData = []string{
+	`INSERT INTO servers (id, name, url, paths, is_default) VALUES ('s1', 'prod', 'https://prod.example.com', '["web","C:\new"]', 1)`,
+	`INSERT INTO servers (id, name, url, paths) VALUES ('s2', 'staging', 'https://staging.example.com', '[]')`,
+	`INSERT INTO keys (id, encrypted_private_key, public_key, protection, updated_at) VALUES (1, 'enc-priv', 'pub', 'password', '2024-01-01T00:00:00Z')`,
+	`INSERT INTO history (server_id, server_name, path_key, filename, file_size, status, error_msg, uploaded_at)
+		VALUES ('s1', 'prod', 'web', 'app.zip', 1024, 'success', '', '2024-01-01T00:00:00Z')`,
+	`INSERT INTO watches (id, folder_path, server_id, path_key, patterns, debounce_ms, enabled) VALUES ('w1', 'C:\src\dist', 's1', 'web', '["*.js","*.css"]', 500, 1)`,
+	`INSERT INTO schedules (id, name, cron_expr, file_path, server_id, path_key, extract, enabled) VALUES ('c1', 'nightly', '0 3 * * *', '/src/dist.zip', 's1', 'web', 1, 1)`,
+}
+
+// createBaseline 在临时目录创建旧版本的数据库
+func createBaseline(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.db")
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for _, stmt := range append(baselineSchema, baselineData...) {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	return path
+}
+
+// appliedVersions schema_version 中记录的版本
+func appliedVersions(t *testing.T, d *DB) []int {
+	t.Helper()
+	rows, err := d.Query("SELECT version FROM schema_version ORDER BY version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var versions []int
+	for rows.Next() {
+		var v int
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+	return versions
+}
+
+// checkMigrated 检查旧数据在迁移后的内容
+func checkMigrated(t *testing.T, d *DB) {
+	t.Helper()
+	if v, err := d.Version(); err != nil || v != SchemaVersion() {
+		t.Errorf("Version = %d, %v, want %d", v, err, SchemaVersion())
+	}
+	var want []int
+	for _, m := range migrations {
+		want = append(want, m.version)
+	}
+	if got := appliedVersions(t, d); !reflect.DeepEqual(got, want) {
+		t.Errorf("schema_version = %v, want %v", got, want)
+	}
+
+	// 手写数组按旧格式解析，Windows 路径中的 \n 不被当作转义
+	prod := getServer(t, d, "s1")
+	if !reflect.DeepEqual(prod.Paths, []string{"web", `C:\new`}) || !prod.IsDefault || prod.KeyID != "" || prod.Workers != 0 {
+		t.Errorf("prod = %+v", prod)
+	}
+	if staging := getServer(t, d, "s2"); len(staging.Paths) != 0 || staging.IsDefault {
+		t.Errorf("staging = %+v", staging)
+	}
+
+	// 旧 keys 表中的唯一密钥成为默认密钥，保留保护方式
+	keys, err := d.GetKeyPairs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].ID != "default" || !keys[0].IsDefault || keys[0].PublicKey != "pub" || keys[0].Protection != "password" {
+		t.Errorf("key pairs = %+v", keys)
+	}
+	var n int
+	if err := d.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'keys'").Scan(&n); err != nil || n != 0 {
+		t.Errorf("keys table still exists (%d, %v)", n, err)
+	}
+
+	history, err := d.GetHistory(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Filename != "app.zip" || history[0].FileSize != 1024 || history[0].Status != "success" {
+		t.Errorf("history = %+v", history)
+	}
+
+	watches, err := d.GetWatches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(watches) != 1 || !reflect.DeepEqual(watches[0].Patterns, []string{"*.js", "*.css"}) || watches[0].FolderPath != `C:\src\dist` || watches[0].DebounceMs != 500 {
+		t.Errorf("watches = %+v", watches)
+	}
+
+	schedules, err := d.GetSchedules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schedules) != 1 || !schedules[0].Extract || schedules[0].Overlap != "skip" || schedules[0].CatchUp != "skip" || schedules[0].RecipeID != "" {
+		t.Errorf("schedules = %+v", schedules)
+	}
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	path := createBaseline(t)
+
+	// 第二次打开时没有待执行的迁移，数据和版本记录保持不变
+	for i := 0; i < 2; i++ {
+		d, err := Open(path)
+		if err != nil {
+			t.Fatalf("open %d: %v", i+1, err)
+		}
+		checkMigrated(t, d)
+		d.Close()
+	}
+}
+
+func TestMigrateRejectsNewerVersion(t *testing.T) {
+	path := createBaseline(t)
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Exec("INSERT INTO schema_version (version, name) VALUES (?, 'future')", SchemaVersion()+1); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+
+	if d, err := Open(path); err == nil {
+		d.Close()
+		t.Error("Open should refuse a database from a newer client")
+	}
+}
@@ -0,0 +1,276 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// 部署配方的步骤类型
+const (
+	StepCommand = "command" // 运行本地命令
+	StepPackage = "package" // 把文件夹打包为 zip
+	StepUpload  = "upload"  // 上传文件或文件夹到服务器或服务器组
+	StepVerify  = "verify"  // 请求校验地址
+	StepNotify  = "notify"  // 发送通知
+)
+
+// 配方运行状态，步骤状态另有 skipped
+const (
+	RunRunning  = "running"
+	RunSuccess  = "success"
+	RunFailed   = "failed"
+	RunCanceled = "canceled"
+)
+
+// Recipe 部署配方: 按顺序执行的构建、打包、上传和校验步骤
+type Recipe struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Steps     []RecipeStep `json:"steps"`
+	CreatedAt string       `json:"createdAt"`
+}
+
+// RecipeStep 配方中的一个步骤，按 Type 使用对应的字段
+type RecipeStep struct {
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+	Always bool   `json:"always"` // 前面的步骤失败后仍然执行 (如失败通知)
+
+	// command
+	Command string `json:"command,omitempty"`
+	Dir     string `json:"dir,omitempty"` // 工作目录
+
+	// package: 把 Source 打包到 Output (为空时使用临时文件，运行结束后删除)
+	// upload: 上传 Source，为空时上传上一个打包步骤的产物
+	Source   string   `json:"source,omitempty"`
+	Output   string   `json:"output,omitempty"`
+	Excludes []string `json:"excludes,omitempty"` // 追加到源目录 .deployignore 的排除规则
+
+	// upload: ServerID 和 GroupID 二选一
+	ServerID string `json:"serverId,omitempty"`
+	GroupID  string `json:"groupId,omitempty"`
+	PathKey  string `json:"pathKey,omitempty"`
+	Extract  bool   `json:"extract,omitempty"`
+
+	// verify: 在超时前反复请求 URL，直到返回 ExpectStatus 且包含 Contains
+	URL          string `json:"url,omitempty"`
+	ExpectStatus int    `json:"expectStatus,omitempty"` // 默认 200
+	Contains     string `json:"contains,omitempty"`
+
+	// notify: 可使用 {{recipe}} {{status}} {{error}}
+	Message string `json:"message,omitempty"`
+
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"` // command 和 verify 的超时，0 表示默认值
+}
+
+// RecipeRun 配方的一次运行记录
+type RecipeRun struct {
+	ID         int64              `json:"id"`
+	RecipeID   string             `json:"recipeId"`
+	RecipeName string             `json:"recipeName"`
+	Trigger    string             `json:"trigger"` // manual / watch / schedule
+	Status     string             `json:"status"`
+	ErrorMsg   string             `json:"errorMsg"`
+	Steps      []RecipeStepResult `json:"steps"`
+	Log        string             `json:"log,omitempty"` // 列表查询时不加载
+	StartedAt  string             `json:"startedAt"`
+	FinishedAt string             `json:"finishedAt"`
+	DurationMs int64              `json:"durationMs"`
+}
+
+// RecipeStepResult 单个步骤的结果
+type RecipeStepResult struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Status     string `json:"status"` // success / failed / canceled / skipped
+	Summary    string `json:"summary"`
+	Error      string `json:"error"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// SaveRecipe 保存配方
+func (d *DB) SaveRecipe(r Recipe) error {
+	return saveRecipe(d, r)
+}
+
+func saveRecipe(ex execer, r Recipe) error {
+	steps, err := json.Marshal(r.Steps)
+	if err != nil {
+		return err
+	}
+	_, err = ex.Exec(`
+		INSERT INTO recipes (id, name, steps) VALUES (?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name=?, steps=?
+	`, r.ID, r.Name, string(steps), r.Name, string(steps))
+	return err
+}
+
+// GetRecipes 获取所有配方
+func (d *DB) GetRecipes() ([]Recipe, error) {
+	rows, err := d.Query("SELECT id, name, steps, created_at FROM recipes ORDER BY created_at")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipes []Recipe
+	for rows.Next() {
+		r, err := scanRecipe(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, *r)
+	}
+	return recipes, rows.Err()
+}
+
+// GetRecipe 按 ID 获取配方，不存在时返回 nil
+func (d *DB) GetRecipe(id string) (*Recipe, error) {
+	r, err := scanRecipe(d.QueryRow("SELECT id, name, steps, created_at FROM recipes WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+// DeleteRecipe 删除配方，保留运行记录
+func (d *DB) DeleteRecipe(id string) error {
+	_, err := d.Exec("DELETE FROM recipes WHERE id = ?", id)
+	return err
+}
+
+// GetRecipeUsers 引用配方的监控和定时任务 (用于删除前提示)
+func (d *DB) GetRecipeUsers(id string) ([]string, error) {
+	rows, err := d.Query(`
+		SELECT '监控: ' || folder_path FROM watches WHERE recipe_id = ?
+		UNION ALL
+		SELECT '定时任务: ' || name FROM schedules WHERE recipe_id = ?
+	`, id, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		users = append(users, name)
+	}
+	return users, rows.Err()
+}
+
+func scanRecipe(row interface{ Scan(...interface{}) error }) (*Recipe, error) {
+	var r Recipe
+	var steps string
+	if err := row.Scan(&r.ID, &r.Name, &steps, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(steps), &r.Steps); err != nil {
+		return nil, fmt.Errorf("解析配方步骤失败: %v", err)
+	}
+	if r.Steps == nil {
+		r.Steps = []RecipeStep{}
+	}
+	return &r, nil
+}
+
+// AddRecipeRun 记录开始运行，返回记录 ID
+func (d *DB) AddRecipeRun(r RecipeRun) (int64, error) {
+	res, err := d.Exec(`
+		INSERT INTO recipe_runs (recipe_id, recipe_name, trigger, status, started_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, r.RecipeID, r.RecipeName, r.Trigger, r.Status, r.StartedAt)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// FinishRecipeRun 保存运行结果和日志
+func (d *DB) FinishRecipeRun(r RecipeRun) error {
+	steps, err := json.Marshal(r.Steps)
+	if err != nil {
+		return err
+	}
+	_, err = d.Exec(`
+		UPDATE recipe_runs SET status = ?, error_msg = ?, steps = ?, log = ?, finished_at = ?, duration_ms = ?
+		WHERE id = ?
+	`, r.Status, r.ErrorMsg, string(steps), r.Log, r.FinishedAt, r.DurationMs, r.ID)
+	return err
+}
+
+// AbortRunningRecipeRuns 把上次退出时未结束的运行标记为失败
+func (d *DB) AbortRunningRecipeRuns() error {
+	_, err := d.Exec("UPDATE recipe_runs SET status = ?, error_msg = ? WHERE status = ?",
+		RunFailed, "程序退出时中断", RunRunning)
+	return err
+}
+
+// GetRecipeRuns 获取最近的运行记录 (不含日志)，recipeID 为空时返回所有配方的记录
+func (d *DB) GetRecipeRuns(recipeID string, limit int) ([]RecipeRun, error) {
+	query := `SELECT id, recipe_id, recipe_name, trigger, status, error_msg, steps, '', started_at, finished_at, duration_ms FROM recipe_runs`
+	var args []interface{}
+	if recipeID != "" {
+		query += " WHERE recipe_id = ?"
+		args = append(args, recipeID)
+	}
+	query += " ORDER BY id DESC"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := d.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []RecipeRun{}
+	for rows.Next() {
+		r, err := scanRecipeRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *r)
+	}
+	return runs, rows.Err()
+}
+
+// GetRecipeRun 获取一次运行的完整记录 (含日志)，不存在时返回 nil
+func (d *DB) GetRecipeRun(id int64) (*RecipeRun, error) {
+	r, err := scanRecipeRun(d.QueryRow(`
+		SELECT id, recipe_id, recipe_name, trigger, status, error_msg, steps, log, started_at, finished_at, duration_ms
+		FROM recipe_runs WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return r, err
+}
+
+// ClearRecipeRuns 清空运行记录
+func (d *DB) ClearRecipeRuns() error {
+	_, err := d.Exec("DELETE FROM recipe_runs WHERE status != ?", RunRunning)
+	return err
+}
+
+func scanRecipeRun(row interface{ Scan(...interface{}) error }) (*RecipeRun, error) {
+	var r RecipeRun
+	var steps string
+	if err := row.Scan(&r.ID, &r.RecipeID, &r.RecipeName, &r.Trigger, &r.Status, &r.ErrorMsg, &steps, &r.Log,
+		&r.StartedAt, &r.FinishedAt, &r.DurationMs); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(steps), &r.Steps); err != nil {
+		return nil, fmt.Errorf("解析运行记录失败: %v", err)
+	}
+	if r.Steps == nil {
+		r.Steps = []RecipeStepResult{}
+	}
+	return &r, nil
+}
@@ -0,0 +1,215 @@
+// Package deploy 按服务器组的部署策略把同一次上传分发到多台服务器
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"client-gui/internal/database"
+)
+
+// 单台服务器的部署状态
+const (
+	StatusSuccess  = "success"
+	StatusPartial  = "partial" // 文件夹部分文件失败
+	StatusFailed   = "failed"
+	StatusCanceled = "canceled"
+	StatusSkipped  = "skipped" // 前面的服务器失败后未部署
+)
+
+// Result 单台服务器的部署结果
+type Result struct {
+	ServerID   string                 `json:"serverId"`
+	ServerName string                 `json:"serverName"`
+	Status     string                 `json:"status"`
+	Error      string                 `json:"error"`
+	DurationMs int64                  `json:"durationMs"`
+	Files      []database.HistoryFile `json:"files,omitempty"`
+}
+
+// Failed 是否计为失败 (触发 StopOnFailure)
+func (r Result) Failed() bool {
+	return r.Status == StatusFailed || r.Status == StatusPartial
+}
+
+// StepFunc 部署到单台服务器，需要遵循 ctx 的取消
+type StepFunc func(ctx context.Context, server database.Server) Result
+
+// Options 部署选项，回调可能在多个协程中并发调用
+type Options struct {
+	Strategy       string
+	MaxUnavailable int
+	StopOnFailure  bool
+	OnStart        func(server database.Server)
+	OnDone         func(r Result)
+}
+
+// Validate 检查服务器组的策略配置
+func Validate(g database.ServerGroup) error {
+	if strings.TrimSpace(g.Name) == "" {
+		return errors.New("服务器组名称不能为空")
+	}
+	if len(g.ServerIDs) == 0 {
+		return errors.New("服务器组至少包含一台服务器")
+	}
+	seen := make(map[string]bool, len(g.ServerIDs))
+	for _, id := range g.ServerIDs {
+		if seen[id] {
+			return fmt.Errorf("服务器重复: %s", id)
+		}
+		seen[id] = true
+	}
+	switch g.Strategy {
+	case database.StrategyParallel, database.StrategySequential:
+	case database.StrategyRolling:
+		if g.MaxUnavailable < 1 {
+			return errors.New("滚动部署的同时部署数至少为 1")
+		}
+	default:
+		return fmt.Errorf("不支持的部署策略: %s", g.Strategy)
+	}
+	return nil
+}
+
+// concurrency 同时部署的服务器数
+func concurrency(strategy string, maxUnavailable, n int) int {
+	switch strategy {
+	case database.StrategySequential:
+		return 1
+	case database.StrategyRolling:
+		if maxUnavailable < 1 {
+			return 1
+		}
+		if maxUnavailable > n {
+			return n
+		}
+		return maxUnavailable
+	default:
+		return n
+	}
+}
+
+// Run 按策略部署到所有服务器，结果顺序与 servers 一致
+//
+// 并行策略同时开始所有服务器；顺序策略逐台进行；滚动策略同时最多 MaxUnavailable 台，
+// 一台完成后立即开始下一台。StopOnFailure 时出现失败后不再开始新的服务器
+// (标记为已跳过)，并行策略下还会取消进行中的部署。ctx 取消后未开始的服务器标记为已取消。
+func Run(ctx context.Context, servers []database.Server, opts Options, step StepFunc) []Result {
+	results := make([]Result, len(servers))
+	if len(servers) == 0 {
+		return results
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	stopped := false
+	isStopped := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stopped
+	}
+
+	slots := make(chan struct{}, concurrency(opts.Strategy, opts.MaxUnavailable, len(servers)))
+	var wg sync.WaitGroup
+	for i, s := range servers {
+		slots <- struct{}{}
+
+		switch {
+		case isStopped():
+			<-slots
+			results[i] = Result{ServerID: s.ID, ServerName: s.Name, Status: StatusSkipped, Error: "前面的服务器部署失败，已跳过"}
+			continue
+		case parent.Err() != nil:
+			<-slots
+			results[i] = Result{ServerID: s.ID, ServerName: s.Name, Status: StatusCanceled, Error: "已取消"}
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, s database.Server) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if opts.OnStart != nil {
+				opts.OnStart(s)
+			}
+			started := time.Now()
+			r := step(ctx, s)
+			r.ServerID = s.ID
+			r.ServerName = s.Name
+			if r.DurationMs == 0 {
+				r.DurationMs = time.Since(started).Milliseconds()
+			}
+			results[i] = r
+
+			if r.Failed() && opts.StopOnFailure {
+				mu.Lock()
+				stopped = true
+				mu.Unlock()
+				if opts.Strategy == database.StrategyParallel {
+					cancel()
+				}
+			}
+			if opts.OnDone != nil {
+				opts.OnDone(r)
+			}
+		}(i, s)
+	}
+	wg.Wait()
+	return results
+}
+
+// Summary 部署结果统计
+type Summary struct {
+	Success  int
+	Failed   int
+	Canceled int
+	Skipped  int
+	Status   string // 整体状态: success / partial / failed / canceled
+	Error    string // 失败服务器的错误汇总
+}
+
+// Summarize 统计部署结果
+func Summarize(results []Result) Summary {
+	var sum Summary
+	var problems []string
+	for _, r := range results {
+		switch r.Status {
+		case StatusSuccess:
+			sum.Success++
+		case StatusCanceled:
+			sum.Canceled++
+		case StatusSkipped:
+			sum.Skipped++
+		default:
+			sum.Failed++
+			problems = append(problems, fmt.Sprintf("%s: %s", r.ServerName, r.Error))
+		}
+	}
+	if sum.Skipped > 0 {
+		problems = append(problems, fmt.Sprintf("%d 台服务器已跳过", sum.Skipped))
+	}
+	if sum.Canceled > 0 {
+		problems = append(problems, fmt.Sprintf("已取消，%d 台服务器未完成", sum.Canceled))
+	}
+	sum.Error = strings.Join(problems, "; ")
+
+	switch {
+	case sum.Success == len(results):
+		sum.Status = StatusSuccess
+	case sum.Canceled > 0 && sum.Failed == 0:
+		sum.Status = StatusCanceled
+	case sum.Success == 0:
+		sum.Status = StatusFailed
+	default:
+		sum.Status = StatusPartial
+	}
+	return sum
+}
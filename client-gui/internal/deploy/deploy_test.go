@@ -0,0 +1,162 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"client-gui/internal/database"
+)
+
+func testServers(n int) []database.Server {
+	servers := make([]database.Server, n)
+	for i := range servers {
+		servers[i] = database.Server{ID: fmt.Sprintf("s%d", i), Name: fmt.Sprintf("server%d", i)}
+	}
+	return servers
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   database.ServerGroup
+		wantErr bool
+	}{
+		{"parallel", database.ServerGroup{Name: "g", ServerIDs: []string{"a", "b"}, Strategy: database.StrategyParallel}, false},
+		{"rolling", database.ServerGroup{Name: "g", ServerIDs: []string{"a"}, Strategy: database.StrategyRolling, MaxUnavailable: 1}, false},
+		{"empty name", database.ServerGroup{Name: " ", ServerIDs: []string{"a"}, Strategy: database.StrategyParallel}, true},
+		{"no servers", database.ServerGroup{Name: "g", Strategy: database.StrategyParallel}, true},
+		{"duplicate server", database.ServerGroup{Name: "g", ServerIDs: []string{"a", "a"}, Strategy: database.StrategySequential}, true},
+		{"rolling without batch size", database.ServerGroup{Name: "g", ServerIDs: []string{"a"}, Strategy: database.StrategyRolling}, true},
+		{"unknown strategy", database.ServerGroup{Name: "g", ServerIDs: []string{"a"}, Strategy: "canary"}, true},
+	}
+	for _, tt := range tests {
+		if err := Validate(tt.group); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRunConcurrency(t *testing.T) {
+	tests := []struct {
+		strategy       string
+		maxUnavailable int
+		want           int
+	}{
+		{database.StrategyParallel, 0, 5},
+		{database.StrategySequential, 3, 1},
+		{database.StrategyRolling, 2, 2},
+		{database.StrategyRolling, 10, 5},
+	}
+	for _, tt := range tests {
+		var mu sync.Mutex
+		active, maxActive := 0, 0
+		var order []string
+		results := Run(context.Background(), testServers(5), Options{Strategy: tt.strategy, MaxUnavailable: tt.maxUnavailable},
+			func(ctx context.Context, s database.Server) Result {
+				mu.Lock()
+				active++
+				if active > maxActive {
+					maxActive = active
+				}
+				order = append(order, s.ID)
+				mu.Unlock()
+				time.Sleep(20 * time.Millisecond)
+				mu.Lock()
+				active--
+				mu.Unlock()
+				return Result{Status: StatusSuccess}
+			})
+
+		if maxActive != tt.want {
+			t.Errorf("%s/%d: max concurrent = %d, want %d", tt.strategy, tt.maxUnavailable, maxActive, tt.want)
+		}
+		if tt.strategy == database.StrategySequential && fmt.Sprint(order) != "[s0 s1 s2 s3 s4]" {
+			t.Errorf("sequential order = %v", order)
+		}
+		for i, r := range results {
+			if r.ServerID != fmt.Sprintf("s%d", i) || r.ServerName == "" || r.Status != StatusSuccess {
+				t.Errorf("%s: results[%d] = %+v", tt.strategy, i, r)
+			}
+		}
+	}
+}
+
+func TestRunStopOnFailure(t *testing.T) {
+	// 顺序部署: 第二台失败后其余跳过
+	results := Run(context.Background(), testServers(4), Options{Strategy: database.StrategySequential, StopOnFailure: true},
+		func(ctx context.Context, s database.Server) Result {
+			if s.ID == "s1" {
+				return Result{Status: StatusFailed, Error: "boom"}
+			}
+			return Result{Status: StatusSuccess}
+		})
+	want := []string{StatusSuccess, StatusFailed, StatusSkipped, StatusSkipped}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("sequential results[%d] = %s, want %s", i, r.Status, want[i])
+		}
+	}
+
+	// 并行部署: 失败后取消进行中的服务器 (尚未开始的计为跳过)
+	results = Run(context.Background(), testServers(3), Options{Strategy: database.StrategyParallel, StopOnFailure: true},
+		func(ctx context.Context, s database.Server) Result {
+			if s.ID == "s0" {
+				return Result{Status: StatusFailed, Error: "boom"}
+			}
+			select {
+			case <-ctx.Done():
+				return Result{Status: StatusCanceled}
+			case <-time.After(5 * time.Second):
+				return Result{Status: StatusSuccess}
+			}
+		})
+	if sum := Summarize(results); sum.Failed != 1 || sum.Success != 0 || sum.Canceled+sum.Skipped != 2 {
+		t.Errorf("parallel summary = %+v, want 1 failed and the rest canceled", sum)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	results := Run(ctx, testServers(3), Options{Strategy: database.StrategySequential},
+		func(ctx context.Context, s database.Server) Result {
+			cancel()
+			return Result{Status: StatusSuccess}
+		})
+	want := []string{StatusSuccess, StatusCanceled, StatusCanceled}
+	for i, r := range results {
+		if r.Status != want[i] {
+			t.Errorf("results[%d] = %s, want %s", i, r.Status, want[i])
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	r := func(statuses ...string) []Result {
+		var out []Result
+		for i, s := range statuses {
+			out = append(out, Result{ServerName: fmt.Sprintf("server%d", i), Status: s, Error: "err"})
+		}
+		return out
+	}
+	tests := []struct {
+		name    string
+		results []Result
+		status  string
+		error   string
+	}{
+		{"all success", r(StatusSuccess, StatusSuccess), StatusSuccess, ""},
+		{"one failed", r(StatusSuccess, StatusFailed), StatusPartial, "server1: err"},
+		{"partial counts as failed", r(StatusPartial), StatusFailed, "server0: err"},
+		{"failed and skipped", r(StatusFailed, StatusSkipped), StatusFailed, "server0: err; 1 台服务器已跳过"},
+		{"canceled", r(StatusSuccess, StatusCanceled), StatusCanceled, "已取消，1 台服务器未完成"},
+	}
+	for _, tt := range tests {
+		sum := Summarize(tt.results)
+		if sum.Status != tt.status || sum.Error != tt.error {
+			t.Errorf("%s: Summarize = %q %q, want %q %q", tt.name, sum.Status, sum.Error, tt.status, tt.error)
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Package health 后台轮询所有服务器的可用性
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"client-gui/internal/database"
+	"client-gui/internal/uploader"
+)
+
+// 轮询参数
+const (
+	DefaultInterval = 60 * time.Second
+	MinInterval     = 10 * time.Second
+	historySize     = 60 // 每台服务器保留的最近采样数，用于趋势图
+)
+
+// Sample 一次检测的采样
+type Sample struct {
+	At        string `json:"at"`
+	Reachable bool   `json:"reachable"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// Status 服务器的最新状态
+type Status struct {
+	ServerID  string   `json:"serverId"`
+	Name      string   `json:"name"`
+	URL       string   `json:"url"`
+	Checked   bool     `json:"checked"` // 是否已完成过检测
+	Reachable bool     `json:"reachable"`
+	LatencyMs int64    `json:"latencyMs"`
+	Version   string   `json:"version"`
+	Security  bool     `json:"security"` // 接收端是否启用了签名验证
+	Paths     []string `json:"paths"`    // 接收端报告的路径标识
+	Error     string   `json:"error"`
+	CheckedAt string   `json:"checkedAt"`
+	Since     string   `json:"since"` // 进入当前可达/不可达状态的时间
+	History   []Sample `json:"history"`
+}
+
+// UpdateFunc 每次检测后回调，changed 表示可达状态发生了变化 (首次检测不算)
+type UpdateFunc func(st Status, changed bool)
+
+// PathsFunc 服务器报告的路径标识与本地配置不同时回调，server 为已更新的配置
+type PathsFunc func(server database.Server)
+
+// Monitor 按固定间隔并发检测所有已配置的服务器
+type Monitor struct {
+	db       *database.DB
+	onUpdate UpdateFunc
+	onPaths  PathsFunc
+
+	mu       sync.Mutex
+	interval time.Duration
+	status   map[string]*Status
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New 创建监控，interval <= 0 时使用默认间隔
+func New(db *database.DB, interval time.Duration, onUpdate UpdateFunc, onPaths PathsFunc) *Monitor {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Monitor{
+		db:       db,
+		onUpdate: onUpdate,
+		onPaths:  onPaths,
+		interval: normalizeInterval(interval),
+		status:   make(map[string]*Status),
+		ctx:      ctx,
+		cancel:   cancel,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Start 启动轮询，立即进行第一次检测
+func (m *Monitor) Start() {
+	m.wg.Add(1)
+	go m.loop()
+}
+
+// Stop 停止轮询并等待进行中的检测结束
+func (m *Monitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// SetInterval 修改轮询间隔，下一轮起生效
+func (m *Monitor) SetInterval(d time.Duration) {
+	m.mu.Lock()
+	m.interval = normalizeInterval(d)
+	m.mu.Unlock()
+	m.Refresh()
+}
+
+// Interval 当前轮询间隔
+func (m *Monitor) Interval() time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.interval
+}
+
+// Refresh 立即检测所有服务器
+func (m *Monitor) Refresh() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Check 立即检测单台服务器并返回结果
+func (m *Monitor) Check(server database.Server) Status {
+	return m.check(m.ctx, server)
+}
+
+// Statuses 所有服务器的最新状态，按服务器名称排序
+func (m *Monitor) Statuses() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := make([]Status, 0, len(m.status))
+	for _, st := range m.status {
+		list = append(list, copyStatus(st))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Get 单台服务器的最新状态
+func (m *Monitor) Get(serverID string) (Status, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	st, ok := m.status[serverID]
+	if !ok {
+		return Status{}, false
+	}
+	return copyStatus(st), true
+}
+
+func (m *Monitor) loop() {
+	defer m.wg.Done()
+
+	for {
+		m.checkAll()
+
+		timer := time.NewTimer(m.Interval())
+		select {
+		case <-m.ctx.Done():
+			timer.Stop()
+			return
+		case <-m.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// checkAll 并发检测所有服务器，并移除已删除服务器的状态
+func (m *Monitor) checkAll() {
+	servers, err := m.db.GetServers()
+	if err != nil {
+		return
+	}
+
+	ids := make(map[string]bool, len(servers))
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		ids[s.ID] = true
+		wg.Add(1)
+		go func(s database.Server) {
+			defer wg.Done()
+			m.check(m.ctx, s)
+		}(s)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	for id := range m.status {
+		if !ids[id] {
+			delete(m.status, id)
+		}
+	}
+	m.mu.Unlock()
+}
+
+// check 请求服务器信息接口，记录延迟、版本和安全模式
+func (m *Monitor) check(ctx context.Context, server database.Server) Status {
+	started := time.Now()
+	info, err := uploader.GetServerInfo(ctx, uploader.ClientFor(server.URL, server.Conn))
+	latency := time.Since(started).Milliseconds()
+	if ctx.Err() != nil {
+		st, _ := m.Get(server.ID)
+		return st
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	sample := Sample{At: now, Reachable: err == nil, LatencyMs: latency}
+
+	m.mu.Lock()
+	st, ok := m.status[server.ID]
+	if !ok {
+		st = &Status{ServerID: server.ID}
+		m.status[server.ID] = st
+	}
+	changed := st.Checked && st.Reachable != sample.Reachable
+	if !st.Checked || changed {
+		st.Since = now
+	}
+
+	st.Name = server.Name
+	st.URL = server.URL
+	st.Checked = true
+	st.Reachable = sample.Reachable
+	st.LatencyMs = latency
+	st.CheckedAt = now
+	st.Error = ""
+	if err != nil {
+		st.Error = err.Error()
+	} else {
+		st.Version, _ = info["version"].(string)
+		st.Security, _ = info["security"].(bool)
+		st.Paths = stringList(info["paths"])
+	}
+	st.History = append(st.History, sample)
+	if len(st.History) > historySize {
+		st.History = st.History[len(st.History)-historySize:]
+	}
+	result := copyStatus(st)
+	m.mu.Unlock()
+
+	if err == nil {
+		m.syncPaths(server, result.Paths)
+	}
+	if m.onUpdate != nil {
+		m.onUpdate(result, changed)
+	}
+	return result
+}
+
+// syncPaths 用接收端报告的路径标识更新本地配置 (旧版本接收端不报告时保持不变)
+func (m *Monitor) syncPaths(server database.Server, paths []string) {
+	if paths == nil || sameSet(server.Paths, paths) {
+		return
+	}
+	if err := m.db.UpdateServerPaths(server.ID, paths); err != nil {
+		return
+	}
+	server.Paths = paths
+	if m.onPaths != nil {
+		m.onPaths(server)
+	}
+}
+
+// stringList 解析 JSON 字符串数组，不是数组时返回 nil，结果排序以便比较
+func stringList(v interface{}) []string {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	list := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[string]int, len(a))
+	for _, s := range a {
+		seen[s]++
+	}
+	for _, s := range b {
+		if seen[s] == 0 {
+			return false
+		}
+		seen[s]--
+	}
+	return true
+}
+
+func copyStatus(st *Status) Status {
+	c := *st
+	c.Paths = append([]string(nil), st.Paths...)
+	c.History = append([]Sample(nil), st.History...)
+	return c
+}
+
+func normalizeInterval(d time.Duration) time.Duration {
+	if d <= 0 {
+		return DefaultInterval
+	}
+	if d < MinInterval {
+		return MinInterval
+	}
+	return d
+}
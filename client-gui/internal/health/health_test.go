@@ -0,0 +1,150 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"client-gui/internal/database"
+)
+
+// newTestMonitor 使用临时数据库创建监控，记录回调
+func newTestMonitor(t *testing.T) (*Monitor, *database.DB, *callbacks) {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	cb := &callbacks{}
+	return New(db, time.Hour, cb.onUpdate, cb.onPaths), db, cb
+}
+
+type callbacks struct {
+	mu      sync.Mutex
+	changed []bool
+	paths   []database.Server
+}
+
+func (c *callbacks) onUpdate(st Status, changed bool) {
+	c.mu.Lock()
+	c.changed = append(c.changed, changed)
+	c.mu.Unlock()
+}
+
+func (c *callbacks) onPaths(server database.Server) {
+	c.mu.Lock()
+	c.paths = append(c.paths, server)
+	c.mu.Unlock()
+}
+
+// fakeInfo 模拟接收端的服务器信息接口
+func fakeInfo(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"version": "2.1.0", "security": true, "paths": ["web", "api"]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCheck(t *testing.T) {
+	m, db, cb := newTestMonitor(t)
+	srv := fakeInfo(t)
+	server := database.Server{ID: "s1", Name: "prod", URL: srv.URL, Paths: []string{"web"}}
+	if err := db.SaveServer(server); err != nil {
+		t.Fatal(err)
+	}
+
+	st := m.Check(server)
+	if !st.Checked || !st.Reachable || st.Version != "2.1.0" || !st.Security || st.Error != "" {
+		t.Errorf("status = %+v", st)
+	}
+	if len(st.History) != 1 || !st.History[0].Reachable || st.Since == "" {
+		t.Errorf("history = %+v since = %q", st.History, st.Since)
+	}
+
+	// 接收端报告的路径标识写回本地配置
+	servers, _ := db.GetServers()
+	if got := servers[0].Paths; !sameSet(got, []string{"api", "web"}) {
+		t.Errorf("server paths = %q, want [api web]", got)
+	}
+	if len(cb.paths) != 1 || !sameSet(cb.paths[0].Paths, []string{"api", "web"}) {
+		t.Errorf("onPaths calls = %+v", cb.paths)
+	}
+
+	// 服务器停止后变为不可达，报告状态变化
+	srv.Close()
+	st = m.Check(server)
+	if st.Reachable || st.Error == "" || len(st.History) != 2 || st.History[1].Reachable {
+		t.Errorf("status after stop = %+v", st)
+	}
+	if len(cb.changed) != 2 || cb.changed[0] || !cb.changed[1] {
+		t.Errorf("changed flags = %v, want [false true]", cb.changed)
+	}
+	if got, ok := m.Get("s1"); !ok || got.Reachable {
+		t.Errorf("Get = %+v, %v", got, ok)
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	m, _, _ := newTestMonitor(t)
+	server := database.Server{ID: "s1", Name: "prod", URL: fakeInfo(t).URL, Paths: []string{"api", "web"}}
+	for i := 0; i < historySize+5; i++ {
+		m.Check(server)
+	}
+	st, _ := m.Get("s1")
+	if len(st.History) != historySize {
+		t.Errorf("history length = %d, want %d", len(st.History), historySize)
+	}
+
+	// 返回的是副本，修改不影响监控内部状态
+	st.History[0].LatencyMs = -1
+	if again, _ := m.Get("s1"); again.History[0].LatencyMs == -1 {
+		t.Error("Get returned shared history slice")
+	}
+}
+
+func TestCheckAllDropsDeletedServers(t *testing.T) {
+	m, db, _ := newTestMonitor(t)
+	url := fakeInfo(t).URL
+	for _, s := range []database.Server{
+		{ID: "s1", Name: "b", URL: url, Paths: []string{"api", "web"}},
+		{ID: "s2", Name: "a", URL: url, Paths: []string{"api", "web"}},
+	} {
+		if err := db.SaveServer(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.checkAll()
+	list := m.Statuses()
+	if len(list) != 2 || list[0].Name != "a" || list[1].Name != "b" {
+		t.Fatalf("Statuses = %+v, want sorted by name", list)
+	}
+
+	if err := db.DeleteServer("s1"); err != nil {
+		t.Fatal(err)
+	}
+	m.checkAll()
+	if _, ok := m.Get("s1"); ok || len(m.Statuses()) != 1 {
+		t.Errorf("deleted server still reported: %+v", m.Statuses())
+	}
+}
+
+func TestNormalizeInterval(t *testing.T) {
+	tests := []struct{ in, want time.Duration }{
+		{0, DefaultInterval},
+		{-time.Second, DefaultInterval},
+		{time.Second, MinInterval},
+		{5 * time.Minute, 5 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := normalizeInterval(tt.in); got != tt.want {
+			t.Errorf("normalizeInterval(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+// Package ignore 解析 .deployignore，按 gitignore 语法排除不需要部署的文件
+//
+// 支持的语法与 gitignore 相同: # 注释、! 取反、末尾 / 只匹配目录、
+// 含 / 的模式相对根目录匹配 (否则匹配任意层级的名称)、* ? [] 和 **。
+// 目录被排除后其中的文件不能再用 ! 重新包含。
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FileName 文件夹根目录下的排除规则文件
+const FileName = ".deployignore"
+
+// rule 一条排除规则
+type rule struct {
+	pattern string
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// Matcher 一组排除规则，nil 表示不排除任何文件
+type Matcher struct {
+	rules []rule
+}
+
+// New 由规则行创建 Matcher，后面的规则优先
+func New(lines []string) (*Matcher, error) {
+	m := &Matcher{}
+	if err := m.add(lines); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Load 读取 dir 下的 .deployignore (不存在时忽略)，再追加 extra 中的规则
+//
+// .deployignore 文件本身默认被排除，可以用 !.deployignore 重新包含。
+func Load(dir string, extra []string) (*Matcher, error) {
+	m := &Matcher{}
+	lines := []string{"/" + FileName}
+
+	data, err := os.ReadFile(filepath.Join(dir, FileName))
+	switch {
+	case err == nil:
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			lines = append(lines, sc.Text())
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("读取 %s 失败: %v", FileName, err)
+	}
+
+	if err := m.add(lines); err != nil {
+		return nil, fmt.Errorf("%s: %v", FileName, err)
+	}
+	if err := m.add(extra); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *Matcher) add(lines []string) error {
+	for _, line := range lines {
+		r, ok, err := parse(line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			m.rules = append(m.rules, r)
+		}
+	}
+	return nil
+}
+
+// Match 判断相对路径 (正斜杠分隔) 是否被排除，包括所在目录被排除的情况
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	if m == nil || len(m.rules) == 0 {
+		return false
+	}
+	relPath = strings.Trim(filepath.ToSlash(relPath), "/")
+	if relPath == "" || relPath == "." {
+		return false
+	}
+
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if m.match(strings.Join(parts[:i], "/"), true) {
+			return true
+		}
+	}
+	return m.match(relPath, isDir)
+}
+
+// MatchEntry 只判断路径本身，用于遍历目录时 (被排除的目录已整体跳过)
+func (m *Matcher) MatchEntry(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	return m.match(strings.Trim(filepath.ToSlash(relPath), "/"), isDir)
+}
+
+// match 最后一条匹配的规则决定结果
+func (m *Matcher) match(relPath string, isDir bool) bool {
+	for i := len(m.rules) - 1; i >= 0; i-- {
+		r := m.rules[i]
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if r.re.MatchString(relPath) {
+			return !r.negate
+		}
+	}
+	return false
+}
+
+// parse 解析一行规则，空行和注释返回 false
+func parse(line string) (rule, bool, error) {
+	line = strings.TrimSuffix(line, "\r")
+	// 末尾空格被忽略，除非用反斜杠转义
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false, nil
+	}
+
+	r := rule{pattern: line}
+	switch {
+	case strings.HasPrefix(line, "!"):
+		r.negate = true
+		line = line[1:]
+	case strings.HasPrefix(line, "\\!"), strings.HasPrefix(line, "\\#"):
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false, nil
+	}
+
+	// 含有 / 的模式相对根目录，否则匹配任意层级
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if !anchored {
+		line = "**/" + line
+	}
+
+	re, err := regexp.Compile("^" + translate(line) + "$")
+	if err != nil {
+		return rule{}, false, fmt.Errorf("无效的排除规则 %q: %v", r.pattern, err)
+	}
+	r.re = re
+	return r, true, nil
+}
+
+// translate 把 glob 模式转换为正则表达式
+func translate(pattern string) string {
+	var sb strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				atStart := i == 0 || pattern[i-1] == '/'
+				atEnd := i+2 == len(pattern)
+				switch {
+				case atStart && i+2 < len(pattern) && pattern[i+2] == '/':
+					// **/ 匹配零或多层目录
+					sb.WriteString("(?:.*/)?")
+					i += 2
+					continue
+				case atStart && atEnd:
+					// 末尾的 /** 匹配其中的所有内容
+					sb.WriteString(".*")
+					i++
+					continue
+				}
+				// 其他位置的 ** 与 * 相同
+				i++
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			// 紧跟在 [ 或 [! 之后的 ] 是集合中的字符
+			start := i + 1
+			if start < len(pattern) && pattern[start] == '!' {
+				start++
+			}
+			if start < len(pattern) && pattern[start] == ']' {
+				start++
+			}
+			end := strings.IndexByte(pattern[start:], ']')
+			if end < 0 {
+				sb.WriteString(`\[`)
+				continue
+			}
+			end += start - i - 1
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,102 @@
+package ignore
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"a.txt", `a\.txt`},
+		{"*.log", `[^/]*\.log`},
+		{"file?.js", `file[^/]\.js`},
+		{"**/node_modules", `(?:.*/)?node_modules`},
+		{"logs/**", `logs/.*`},
+		{"a/**/b", `a/(?:.*/)?b`},
+		{"a**b", `a[^/]*b`},
+		{"[abc].txt", `[abc]\.txt`},
+		{"[!abc].txt", `[^abc]\.txt`},
+		{"[a-z]*", `[a-z][^/]*`},
+		{"[unclosed", `\[unclosed`},
+		{"[]a]", `[]a]`},
+		{"[!]a]x", `[^]a]x`},
+		{"[]", `\[\]`},
+		{`\*.txt`, `\*\.txt`},
+		{`a\`, `a`},
+		{"(x)+", `\(x\)\+`},
+	}
+	for _, tt := range tests {
+		got := translate(tt.pattern)
+		if got != tt.want {
+			t.Errorf("translate(%q) = %q, want %q", tt.pattern, got, tt.want)
+			continue
+		}
+		if _, err := regexp.Compile("^" + got + "$"); err != nil {
+			t.Errorf("translate(%q) = %q does not compile: %v", tt.pattern, got, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		rules []string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		// 不含 / 的模式匹配任意层级
+		{[]string{"*.log"}, "a.log", false, true},
+		{[]string{"*.log"}, "sub/dir/a.log", false, true},
+		{[]string{"*.log"}, "a.log.txt", false, false},
+		// 含 / 的模式相对根目录
+		{[]string{"/build"}, "build", true, true},
+		{[]string{"/build"}, "src/build", true, false},
+		{[]string{"docs/*.md"}, "docs/a.md", false, true},
+		{[]string{"docs/*.md"}, "docs/sub/a.md", false, false},
+		{[]string{"docs/*.md"}, "x/docs/a.md", false, false},
+		// 末尾 / 只匹配目录，目录下的文件随目录排除
+		{[]string{"tmp/"}, "tmp", false, false},
+		{[]string{"tmp/"}, "tmp", true, true},
+		{[]string{"tmp/"}, "a/tmp/file", false, true},
+		// ** 匹配零或多层目录
+		{[]string{"a/**/b"}, "a/b", false, true},
+		{[]string{"a/**/b"}, "a/x/y/b", false, true},
+		{[]string{"logs/**"}, "logs/x/y.txt", false, true},
+		{[]string{"logs/**"}, "logs", true, false},
+		{[]string{"**/cache"}, "x/y/cache", true, true},
+		// 后面的规则优先，! 重新包含
+		{[]string{"*.log", "!keep.log"}, "keep.log", false, false},
+		{[]string{"!keep.log", "*.log"}, "keep.log", false, true},
+		// 所在目录被排除时不能重新包含
+		{[]string{"dist/", "!dist/keep.txt"}, "dist/keep.txt", false, true},
+		// 注释、转义和末尾空格
+		{[]string{"# comment"}, "# comment", false, false},
+		{[]string{`\#hash`}, "#hash", false, true},
+		{[]string{`\!bang`}, "!bang", false, true},
+		{[]string{"trailing   "}, "trailing", false, true},
+		{[]string{`space\ `}, "space ", false, true},
+		{[]string{"[!a]*.js"}, "b.js", false, true},
+		{[]string{"[!a]*.js"}, "a.js", false, false},
+		{[]string{"[]]"}, "]", false, true},
+		{[]string{"*.log"}, "", false, false},
+	}
+	for _, tt := range tests {
+		m, err := New(tt.rules)
+		if err != nil {
+			t.Fatalf("New(%q): %v", tt.rules, err)
+		}
+		if got := m.Match(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("New(%q).Match(%q, %v) = %v, want %v", tt.rules, tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestNilMatcher(t *testing.T) {
+	var m *Matcher
+	if m.Match("a.log", false) || m.MatchEntry("a.log", false) {
+		t.Error("nil Matcher should not exclude anything")
+	}
+}
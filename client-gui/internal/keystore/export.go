@@ -0,0 +1,136 @@
+package keystore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"client-gui/internal/crypto"
+	"client-gui/internal/database"
+)
+
+const (
+	exportType    = "deploy-receiver-key"
+	exportVersion = 1
+)
+
+// ExportedKey 导出的密钥文件
+//
+// 提供导出密码时私钥以 EncryptedPrivateKey 保存 (Argon2id + XChaCha20-Poly1305)，
+// 否则以明文 PrivateKey 保存。
+type ExportedKey struct {
+	Type                string `json:"type"`
+	Version             int    `json:"version"`
+	Name                string `json:"name"`
+	Notes               string `json:"notes,omitempty"`
+	PublicKey           string `json:"publicKey"`
+	Fingerprint         string `json:"fingerprint"`
+	CreatedAt           string `json:"createdAt,omitempty"`
+	ExportedAt          string `json:"exportedAt"`
+	PrivateKey          string `json:"privateKey,omitempty"`
+	EncryptedPrivateKey string `json:"encryptedPrivateKey,omitempty"`
+}
+
+// Export 导出密钥为 JSON，passphrase 为空时私钥以明文导出
+func (s *Store) Export(id, passphrase string) (string, error) {
+	kp, err := s.Get(id)
+	if err != nil {
+		return "", err
+	}
+	if kp == nil {
+		return "", fmt.Errorf("密钥不存在: %s", id)
+	}
+	if kp.Locked {
+		return "", ErrLocked
+	}
+
+	out := ExportedKey{
+		Type:        exportType,
+		Version:     exportVersion,
+		Name:        kp.Name,
+		Notes:       kp.Notes,
+		PublicKey:   kp.PublicKey,
+		Fingerprint: kp.Fingerprint,
+		CreatedAt:   kp.CreatedAt,
+		ExportedAt:  time.Now().Format(time.RFC3339),
+	}
+	if passphrase == "" {
+		out.PrivateKey = kp.PrivateKey
+	} else {
+		if len(passphrase) < MinPasswordLen {
+			return "", fmt.Errorf("导出密码至少 %d 个字符", MinPasswordLen)
+		}
+		if out.EncryptedPrivateKey, err = crypto.EncryptWithPassword(kp.PrivateKey, passphrase); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Import 导入密钥，data 可以是 Export 的输出或十六进制私钥
+//
+// name 为空时使用导出文件中的名称。
+func (s *Store) Import(data, passphrase, name string) (*database.KeyPair, error) {
+	data = strings.TrimSpace(data)
+	if data == "" {
+		return nil, errors.New("导入内容为空")
+	}
+
+	var privateKey, notes string
+	if strings.HasPrefix(data, "{") {
+		var in ExportedKey
+		if err := json.Unmarshal([]byte(data), &in); err != nil {
+			return nil, fmt.Errorf("无效的密钥文件: %v", err)
+		}
+		if in.Type != exportType {
+			return nil, fmt.Errorf("不是密钥导出文件: %q", in.Type)
+		}
+		if in.Version > exportVersion {
+			return nil, fmt.Errorf("不支持的密钥文件版本: %d", in.Version)
+		}
+
+		switch {
+		case in.EncryptedPrivateKey != "":
+			if passphrase == "" {
+				return nil, errors.New("密钥文件已加密，请输入导出密码")
+			}
+			var err error
+			if privateKey, err = crypto.DecryptWithPassword(in.EncryptedPrivateKey, passphrase); err != nil {
+				return nil, err
+			}
+		case in.PrivateKey != "":
+			privateKey = in.PrivateKey
+		default:
+			return nil, errors.New("密钥文件中没有私钥")
+		}
+
+		// 校验私钥与文件中的公钥一致，防止文件被篡改
+		if in.PublicKey != "" {
+			publicKey, err := crypto.GetPublicKeyFromPrivate(privateKey)
+			if err != nil {
+				return nil, err
+			}
+			if publicKey != in.PublicKey {
+				return nil, errors.New("私钥与公钥不匹配")
+			}
+		}
+		if name == "" {
+			name = in.Name
+		}
+		notes = in.Notes
+	} else {
+		privateKey = data
+	}
+
+	if name == "" {
+		name = "导入的密钥"
+	}
+	return s.Create(name, notes, privateKey)
+}
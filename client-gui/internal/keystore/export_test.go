@@ -0,0 +1,66 @@
+package keystore
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	src, _ := newTestStore(t, nil)
+	kp, err := src.Create("生产", "备注", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, _ := src.PrivateKey(kp.ID)
+
+	plain, err := src.Export(kp.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := src.Export(kp.ID, "export-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(encrypted, priv) {
+		t.Error("encrypted export contains the plaintext private key")
+	}
+	if _, err := src.Export(kp.ID, "short"); err == nil {
+		t.Error("Export should reject a short passphrase")
+	}
+
+	tests := []struct {
+		name       string
+		data       string
+		passphrase string
+		ok         bool
+	}{
+		{"plain export", plain, "", true},
+		{"encrypted export", encrypted, "export-pass", true},
+		{"missing passphrase", encrypted, "", false},
+		{"wrong passphrase", encrypted, "wrong-pass", false},
+		{"hex private key", priv, "", true},
+		{"tampered public key", strings.Replace(plain, kp.PublicKey, strings.Repeat("0", len(kp.PublicKey)), 1), "", false},
+		{"wrong type", `{"type": "other", "privateKey": "` + priv + `"}`, "", false},
+		{"empty", "  ", "", false},
+	}
+	for _, tt := range tests {
+		dst, _ := newTestStore(t, nil)
+		got, err := dst.Import(tt.data, tt.passphrase, "")
+		if !tt.ok {
+			if err == nil {
+				t.Errorf("%s: Import should fail", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: Import: %v", tt.name, err)
+			continue
+		}
+		if got.PublicKey != kp.PublicKey || !got.IsDefault {
+			t.Errorf("%s: imported %+v, want public key %s as default", tt.name, got, kp.PublicKey)
+		}
+		if p, _ := dst.PrivateKey(got.ID); p != priv {
+			t.Errorf("%s: imported private key does not match", tt.name)
+		}
+	}
+}
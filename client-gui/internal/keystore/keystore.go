@@ -0,0 +1,654 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"client-gui/internal/crypto"
+	"client-gui/internal/database"
+
+	"github.com/zalando/go-keyring"
+)
+
+// 私钥的保护方式
+const (
+	ProtectionPlain    = "plain"    // 明文 (不推荐)
+	ProtectionPassword = "password" // 主密码加密 (Argon2id + XChaCha20-Poly1305)
+	ProtectionKeyring  = "keyring"  // 数据密钥存放在系统钥匙串 (Windows 凭据管理器 / macOS 钥匙串 / Secret Service)
+)
+
+const (
+	keyringService = "DeployReceiverClient"
+	keyringUser    = "key-encryption-key"
+
+	settingAutoLock = "key.auto_lock_minutes"
+
+	// 旧版本的明文私钥无法自动加密时设置，在用户选择保护方式之前拒绝使用私钥
+	settingPlainPending = "key.plain_pending"
+)
+
+// MinPasswordLen 主密码和导出密码的最小长度
+const MinPasswordLen = 8
+
+var (
+	// ErrLocked 私钥已锁定
+	ErrLocked = errors.New("私钥已锁定，请先输入主密码解锁")
+	// ErrNoKey 尚未配置私钥
+	ErrNoKey = errors.New("尚未配置私钥")
+	// ErrUnprotected 旧版本的明文私钥尚未加密
+	ErrUnprotected = errors.New("私钥仍以明文保存，请先在密钥管理中设置主密码")
+	// ErrNotPasswordProtected 没有使用主密码保护的密钥，不需要解锁
+	ErrNotPasswordProtected = errors.New("没有使用主密码保护的密钥，无需解锁")
+)
+
+// Status 私钥状态
+type Status struct {
+	HasKey           bool   `json:"hasKey"`
+	KeyCount         int    `json:"keyCount"`
+	Protection       string `json:"protection"`
+	Locked           bool   `json:"locked"`
+	AutoLockMinutes  int    `json:"autoLockMinutes"`
+	KeyringAvailable bool   `json:"keyringAvailable"`
+	NeedsPassword    bool   `json:"needsPassword"` // 旧版本的明文私钥等待设置主密码，期间不能上传
+}
+
+// Store 管理多个命名密钥的加密存储、解锁和自动锁定
+//
+// 所有密钥使用同一种保护方式，主密码模式下共用一个主密码。
+type Store struct {
+	db     *database.DB
+	onLock func()
+
+	mu        sync.Mutex
+	unlocked  bool
+	masterKey []byte // 主密码派生的密钥，解锁后用于解密和加密
+	kdf       crypto.KDFParams
+	autoLock  time.Duration
+	timer     *time.Timer
+}
+
+// New 创建私钥存储，onLock 在自动锁定时调用
+func New(db *database.DB, onLock func()) *Store {
+	s := &Store{db: db, onLock: onLock}
+	if v, err := db.GetSetting(settingAutoLock); err == nil && v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil && minutes > 0 {
+			s.autoLock = time.Duration(minutes) * time.Minute
+		}
+	}
+	return s
+}
+
+// Migrate 迁移旧版本的密钥: 补充公钥指纹，明文私钥在系统钥匙串可用时自动加密
+//
+// 返回当前的保护方式，没有密钥时返回空字符串。无法自动加密的明文私钥在设置保护方式
+// (SetProtection) 之前不能使用，Status 中 NeedsPassword 为 true；系统钥匙串加密失败的
+// 原因和保护方式一起返回。
+func (s *Store) Migrate() (string, error) {
+	keys, err := s.db.GetKeyPairs()
+	if err != nil || len(keys) == 0 {
+		return "", err
+	}
+
+	pending := false
+	var keyringErr error
+	for _, kp := range keys {
+		changed := false
+		if kp.Fingerprint == "" {
+			if fp, err := crypto.Fingerprint(kp.PublicKey); err == nil {
+				kp.Fingerprint, changed = fp, true
+			}
+		}
+		if kp.Protection == "" {
+			kp.Protection, changed = ProtectionPlain, true
+			if keyringAvailable() {
+				encrypted, err := sealWithKeyring(kp.PrivateKey)
+				if err == nil {
+					kp.PrivateKey, kp.Protection = encrypted, ProtectionKeyring
+				} else if keyringErr == nil {
+					keyringErr = err
+				}
+			}
+			if kp.Protection == ProtectionPlain {
+				pending = true
+			}
+		}
+		if changed {
+			if err := s.db.SaveKeyPair(kp); err != nil {
+				return "", err
+			}
+		}
+	}
+	if pending {
+		if err := s.db.SetSetting(settingPlainPending, "1"); err != nil {
+			return "", err
+		}
+	}
+
+	protection, err := s.protection()
+	if err == nil && keyringErr != nil {
+		err = fmt.Errorf("系统钥匙串加密失败，私钥仍为明文: %v", keyringErr)
+	}
+	return protection, err
+}
+
+// plainPending 判断是否有等待设置保护方式的旧版本明文私钥
+func (s *Store) plainPending() bool {
+	v, err := s.db.GetSetting(settingPlainPending)
+	return err == nil && v == "1"
+}
+
+// Status 获取私钥状态
+func (s *Store) Status() (Status, error) {
+	st := Status{KeyringAvailable: keyringAvailable()}
+
+	keys, err := s.db.GetKeyPairs()
+	if err != nil {
+		return st, err
+	}
+	protection, err := s.protection()
+	if err != nil {
+		return st, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st.AutoLockMinutes = int(s.autoLock / time.Minute)
+	if len(keys) == 0 {
+		return st, nil
+	}
+	st.HasKey = true
+	st.KeyCount = len(keys)
+	st.Protection = protection
+	st.Locked = protection == ProtectionPassword && !s.unlocked
+	st.NeedsPassword = protection == ProtectionPlain && s.plainPending()
+	return st, nil
+}
+
+// List 获取所有密钥，不包含私钥
+func (s *Store) List() ([]database.KeyPair, error) {
+	keys, err := s.db.GetKeyPairs()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	unlocked := s.unlocked
+	s.mu.Unlock()
+
+	for i := range keys {
+		keys[i].PrivateKey = ""
+		keys[i].Locked = keys[i].Protection == ProtectionPassword && !unlocked
+	}
+	return keys, nil
+}
+
+// Get 按 ID 获取密钥并解密私钥，id 为空时返回默认密钥，不存在时返回 nil
+//
+// 锁定状态下返回的私钥为空并标记 Locked。
+func (s *Store) Get(id string) (*database.KeyPair, error) {
+	kp, err := s.db.GetKeyPair(id)
+	if err != nil || kp == nil {
+		return kp, err
+	}
+
+	privateKey, err := s.decrypt(kp)
+	switch {
+	case err == ErrLocked:
+		kp.PrivateKey = ""
+		kp.Locked = true
+	case err != nil:
+		return nil, err
+	default:
+		kp.PrivateKey = privateKey
+	}
+	return kp, nil
+}
+
+// PrivateKey 获取用于签名的明文私钥，id 为空时使用默认密钥
+//
+// 未配置任何密钥时返回空字符串 (服务器未开启验证时仍可上传)，旧版本的明文私钥
+// 尚未设置保护方式时返回 ErrUnprotected。
+func (s *Store) PrivateKey(id string) (string, error) {
+	kp, err := s.db.GetKeyPair(id)
+	if err != nil {
+		return "", err
+	}
+	if kp == nil {
+		if id != "" {
+			return "", fmt.Errorf("密钥不存在: %s", id)
+		}
+		return "", nil
+	}
+	if kp.Protection == ProtectionPlain && s.plainPending() {
+		return "", ErrUnprotected
+	}
+	return s.decrypt(kp)
+}
+
+// Unlock 使用主密码解锁，没有使用主密码保护的密钥时返回 ErrNotPasswordProtected
+func (s *Store) Unlock(password string) error {
+	keys, err := s.db.GetKeyPairs()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return ErrNoKey
+	}
+
+	for _, kp := range keys {
+		if kp.Protection != ProtectionPassword {
+			continue
+		}
+		params, sealed, err := crypto.DecodePasswordSealed(kp.PrivateKey)
+		if err != nil {
+			return err
+		}
+		key := crypto.DeriveKey(password, params)
+		if _, err := crypto.Open(key, sealed); err != nil {
+			return err
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.masterKey = key
+		s.kdf = params
+		s.unlocked = true
+		s.touchLocked()
+		return nil
+	}
+	return ErrNotPasswordProtected
+}
+
+// Lock 立即锁定，清除内存中的主密钥
+func (s *Store) Lock() {
+	s.mu.Lock()
+	s.lockLocked()
+	s.mu.Unlock()
+}
+
+// Create 新建密钥，privateKey 为空时生成新的 Ed25519 密钥对
+//
+// 第一个密钥自动成为默认密钥。
+func (s *Store) Create(name, notes, privateKey string) (*database.KeyPair, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("密钥名称不能为空")
+	}
+
+	var publicKey string
+	var err error
+	if privateKey == "" {
+		privateKey, publicKey, err = crypto.GenerateKeyPair()
+	} else {
+		privateKey = strings.TrimSpace(privateKey)
+		publicKey, err = crypto.GetPublicKeyFromPrivate(privateKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	fingerprint, err := crypto.Fingerprint(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.db.GetKeyPairs()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if k.PublicKey == publicKey {
+			return nil, fmt.Errorf("该密钥已存在: %s", k.Name)
+		}
+	}
+
+	protection, err := s.protection()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.sealCurrent(privateKey, protection)
+	if err != nil {
+		return nil, err
+	}
+
+	kp := database.KeyPair{
+		ID:          fmt.Sprintf("key_%d", time.Now().UnixNano()),
+		Name:        name,
+		PrivateKey:  encrypted,
+		PublicKey:   publicKey,
+		Fingerprint: fingerprint,
+		Notes:       notes,
+		Protection:  protection,
+	}
+	if err := s.db.SaveKeyPair(kp); err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		if err := s.db.SetDefaultKeyPair(kp.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.db.GetKeyPair(kp.ID)
+}
+
+// Replace 替换密钥的私钥，保留名称和备注，id 为空时替换默认密钥 (没有密钥时新建)
+func (s *Store) Replace(id, privateKey string) error {
+	kp, err := s.db.GetKeyPair(id)
+	if err != nil {
+		return err
+	}
+	if kp == nil {
+		if id != "" {
+			return fmt.Errorf("密钥不存在: %s", id)
+		}
+		_, err := s.Create("默认密钥", "", privateKey)
+		return err
+	}
+
+	privateKey = strings.TrimSpace(privateKey)
+	publicKey, err := crypto.GetPublicKeyFromPrivate(privateKey)
+	if err != nil {
+		return err
+	}
+	fingerprint, err := crypto.Fingerprint(publicKey)
+	if err != nil {
+		return err
+	}
+	encrypted, err := s.sealCurrent(privateKey, kp.Protection)
+	if err != nil {
+		return err
+	}
+
+	kp.PrivateKey, kp.PublicKey, kp.Fingerprint = encrypted, publicKey, fingerprint
+	return s.db.SaveKeyPair(*kp)
+}
+
+// Update 修改密钥名称和备注
+func (s *Store) Update(id, name, notes string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("密钥名称不能为空")
+	}
+	kp, err := s.db.GetKeyPair(id)
+	if err != nil {
+		return err
+	}
+	if kp == nil {
+		return fmt.Errorf("密钥不存在: %s", id)
+	}
+	kp.Name, kp.Notes = name, notes
+	return s.db.SaveKeyPair(*kp)
+}
+
+// Delete 删除密钥，仍被服务器使用时拒绝删除
+func (s *Store) Delete(id string) error {
+	servers, err := s.db.GetServersUsingKey(id)
+	if err != nil {
+		return err
+	}
+	if len(servers) > 0 {
+		return fmt.Errorf("密钥正在被服务器使用: %s", strings.Join(servers, ", "))
+	}
+	return s.db.DeleteKeyPair(id)
+}
+
+// SetDefault 设置默认密钥 (服务器未指定密钥时使用)
+func (s *Store) SetDefault(id string) error {
+	kp, err := s.db.GetKeyPair(id)
+	if err != nil {
+		return err
+	}
+	if kp == nil {
+		return fmt.Errorf("密钥不存在: %s", id)
+	}
+	return s.db.SetDefaultKeyPair(id)
+}
+
+// SetProtection 切换所有密钥的保护方式，password 模式需要提供新主密码
+//
+// 当前为主密码保护时必须先解锁。选择 plain 视为确认以明文保存，不再阻止使用旧版本的明文私钥。
+func (s *Store) SetProtection(protection, password string) error {
+	keys, err := s.db.GetKeyPairs()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return ErrNoKey
+	}
+
+	// 先全部解密，任何一个失败都不做修改
+	plaintexts := make([]string, len(keys))
+	for i := range keys {
+		if plaintexts[i], err = s.decrypt(&keys[i]); err != nil {
+			return err
+		}
+	}
+
+	var key []byte
+	var params crypto.KDFParams
+	switch protection {
+	case ProtectionPlain, ProtectionKeyring:
+	case ProtectionPassword:
+		if password != "" {
+			if len(password) < MinPasswordLen {
+				return fmt.Errorf("主密码至少 %d 个字符", MinPasswordLen)
+			}
+			if params, err = crypto.NewKDFParams(); err != nil {
+				return err
+			}
+			key = crypto.DeriveKey(password, params)
+		} else {
+			s.mu.Lock()
+			key, params = s.masterKey, s.kdf
+			s.mu.Unlock()
+			if key == nil {
+				return ErrLocked
+			}
+		}
+	default:
+		return fmt.Errorf("未知的保护方式: %s", protection)
+	}
+
+	for i, kp := range keys {
+		encrypted, err := seal(plaintexts[i], protection, key, params)
+		if err != nil {
+			return err
+		}
+		kp.PrivateKey, kp.Protection = encrypted, protection
+		if err := s.db.SaveKeyPair(kp); err != nil {
+			return err
+		}
+	}
+	if err := s.db.SetSetting(settingPlainPending, ""); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if protection == ProtectionPassword {
+		s.masterKey, s.kdf, s.unlocked = key, params, true
+		s.touchLocked()
+	} else {
+		s.lockLocked()
+	}
+	return nil
+}
+
+// SetAutoLock 设置自动锁定时间 (分钟)，0 表示不自动锁定
+func (s *Store) SetAutoLock(minutes int) error {
+	if minutes < 0 {
+		return fmt.Errorf("无效的自动锁定时间: %d", minutes)
+	}
+	if err := s.db.SetSetting(settingAutoLock, strconv.Itoa(minutes)); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.autoLock = time.Duration(minutes) * time.Minute
+	if s.unlocked {
+		s.touchLocked()
+	}
+	return nil
+}
+
+// protection 当前的保护方式: 以默认密钥为准，没有密钥时优先使用系统钥匙串
+func (s *Store) protection() (string, error) {
+	kp, err := s.db.GetKeyPair("")
+	if err != nil {
+		return "", err
+	}
+	if kp != nil && kp.Protection != "" {
+		return kp.Protection, nil
+	}
+	if keyringAvailable() {
+		return ProtectionKeyring, nil
+	}
+	return ProtectionPlain, nil
+}
+
+// decrypt 解密数据库中的私钥
+func (s *Store) decrypt(kp *database.KeyPair) (string, error) {
+	switch kp.Protection {
+	case ProtectionPassword:
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.unlocked {
+			return "", ErrLocked
+		}
+		_, sealed, err := crypto.DecodePasswordSealed(kp.PrivateKey)
+		if err != nil {
+			return "", err
+		}
+		plaintext, err := crypto.Open(s.masterKey, sealed)
+		if err != nil {
+			return "", fmt.Errorf("解密密钥 %s 失败: %v", kp.Name, err)
+		}
+		s.touchLocked()
+		return string(plaintext), nil
+	case ProtectionKeyring:
+		return openWithKeyring(kp.PrivateKey)
+	default:
+		return kp.PrivateKey, nil
+	}
+}
+
+// sealCurrent 按保护方式加密，主密码模式使用已解锁的主密钥
+func (s *Store) sealCurrent(privateKey, protection string) (string, error) {
+	s.mu.Lock()
+	key, params, unlocked := s.masterKey, s.kdf, s.unlocked
+	s.mu.Unlock()
+
+	if protection == ProtectionPassword && !unlocked {
+		return "", ErrLocked
+	}
+	return seal(privateKey, protection, key, params)
+}
+
+// seal 按保护方式加密私钥，password 模式使用给定的主密钥
+func seal(privateKey, protection string, key []byte, params crypto.KDFParams) (string, error) {
+	switch protection {
+	case ProtectionPlain:
+		return privateKey, nil
+	case ProtectionKeyring:
+		return sealWithKeyring(privateKey)
+	case ProtectionPassword:
+		sealed, err := crypto.Seal(key, []byte(privateKey))
+		if err != nil {
+			return "", err
+		}
+		return crypto.EncodePasswordSealed(params, sealed), nil
+	default:
+		return "", fmt.Errorf("未知的保护方式: %s", protection)
+	}
+}
+
+// touchLocked 重置自动锁定计时 (调用方持有锁)
+func (s *Store) touchLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.autoLock <= 0 {
+		return
+	}
+	s.timer = time.AfterFunc(s.autoLock, func() {
+		s.Lock()
+		if s.onLock != nil {
+			s.onLock()
+		}
+	})
+}
+
+// lockLocked 清除解锁状态 (调用方持有锁)
+func (s *Store) lockLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.masterKey = nil
+	s.unlocked = false
+}
+
+// keyringAvailable 判断系统钥匙串是否可用
+func keyringAvailable() bool {
+	_, err := keyring.Get(keyringService, keyringUser)
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+// keyringDataKey 读取系统钥匙串中的数据密钥，不存在时创建
+func keyringDataKey() ([]byte, error) {
+	v, err := keyring.Get(keyringService, keyringUser)
+	if err == nil {
+		return hex.DecodeString(v)
+	}
+	if !errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("读取系统钥匙串失败: %v", err)
+	}
+
+	key, err := crypto.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := keyring.Set(keyringService, keyringUser, hex.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("写入系统钥匙串失败: %v", err)
+	}
+	return key, nil
+}
+
+func sealWithKeyring(plaintext string) (string, error) {
+	key, err := keyringDataKey()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := crypto.Seal(key, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return crypto.EncodeKeySealed(sealed), nil
+}
+
+func openWithKeyring(encoded string) (string, error) {
+	sealed, err := crypto.DecodeKeySealed(encoded)
+	if err != nil {
+		return "", err
+	}
+	v, err := keyring.Get(keyringService, keyringUser)
+	if err != nil {
+		return "", fmt.Errorf("读取系统钥匙串失败: %v", err)
+	}
+	key, err := hex.DecodeString(v)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := crypto.Open(key, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
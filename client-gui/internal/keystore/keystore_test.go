@@ -0,0 +1,164 @@
+package keystore
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"client-gui/internal/crypto"
+	"client-gui/internal/database"
+
+	"github.com/zalando/go-keyring"
+)
+
+// newTestStore 使用临时数据库创建私钥存储，keyringErr 非空时模拟系统钥匙串不可用
+func newTestStore(t *testing.T, keyringErr error) (*Store, *database.DB) {
+	t.Helper()
+	if keyringErr != nil {
+		keyring.MockInitWithError(keyringErr)
+	} else {
+		keyring.MockInit()
+	}
+	db, err := database.Open(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return New(db, nil), db
+}
+
+// saveLegacyKey 写入旧版本的明文私钥 (protection 为空)
+func saveLegacyKey(t *testing.T, db *database.DB) string {
+	t.Helper()
+	priv, pub, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveKeyPair(database.KeyPair{ID: "legacy", Name: "默认密钥", PrivateKey: priv, PublicKey: pub}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetDefaultKeyPair("legacy"); err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func TestMigrateWithoutKeyring(t *testing.T) {
+	s, db := newTestStore(t, errors.New("no secret service"))
+	priv := saveLegacyKey(t, db)
+
+	protection, err := s.Migrate()
+	if err != nil || protection != ProtectionPlain {
+		t.Fatalf("Migrate = %q, %v, want %q", protection, err, ProtectionPlain)
+	}
+	st, _ := s.Status()
+	if !st.NeedsPassword || st.KeyringAvailable {
+		t.Errorf("Status = %+v, want NeedsPassword without keyring", st)
+	}
+	if _, err := s.PrivateKey(""); err != ErrUnprotected {
+		t.Errorf("PrivateKey before protecting = %v, want ErrUnprotected", err)
+	}
+	if err := s.Unlock("whatever-password"); err != ErrNotPasswordProtected {
+		t.Errorf("Unlock on plain key = %v, want ErrNotPasswordProtected", err)
+	}
+
+	// 设置主密码后恢复使用
+	if err := s.SetProtection(ProtectionPassword, "short"); err == nil {
+		t.Error("SetProtection should reject a short password")
+	}
+	if err := s.SetProtection(ProtectionPassword, "correct horse"); err != nil {
+		t.Fatalf("SetProtection: %v", err)
+	}
+	st, _ = s.Status()
+	if st.NeedsPassword || st.Protection != ProtectionPassword || st.Locked {
+		t.Errorf("Status after SetProtection = %+v", st)
+	}
+	if got, err := s.PrivateKey(""); err != nil || got != priv {
+		t.Errorf("PrivateKey after SetProtection = %q, %v", got, err)
+	}
+}
+
+func TestMigrateWithKeyring(t *testing.T) {
+	s, db := newTestStore(t, nil)
+	priv := saveLegacyKey(t, db)
+
+	protection, err := s.Migrate()
+	if err != nil || protection != ProtectionKeyring {
+		t.Fatalf("Migrate = %q, %v, want %q", protection, err, ProtectionKeyring)
+	}
+	kp, _ := db.GetKeyPair("legacy")
+	if kp.PrivateKey == priv || kp.Fingerprint == "" {
+		t.Errorf("migrated key still plaintext or missing fingerprint: %+v", kp)
+	}
+	if got, err := s.PrivateKey(""); err != nil || got != priv {
+		t.Errorf("PrivateKey = %q, %v", got, err)
+	}
+	if err := s.Unlock("whatever-password"); err != ErrNotPasswordProtected {
+		t.Errorf("Unlock on keyring key = %v, want ErrNotPasswordProtected", err)
+	}
+}
+
+func TestUnlockAndLock(t *testing.T) {
+	s, _ := newTestStore(t, nil)
+	if err := s.Unlock("correct horse"); err != ErrNoKey {
+		t.Errorf("Unlock without keys = %v, want ErrNoKey", err)
+	}
+
+	kp, err := s.Create("生产", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetProtection(ProtectionPassword, "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	priv, err := s.PrivateKey(kp.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Lock()
+	if _, err := s.PrivateKey(kp.ID); err != ErrLocked {
+		t.Errorf("PrivateKey while locked = %v, want ErrLocked", err)
+	}
+	if got, _ := s.Get(kp.ID); got == nil || !got.Locked || got.PrivateKey != "" {
+		t.Errorf("Get while locked = %+v, want Locked without private key", got)
+	}
+	if err := s.Unlock("wrong password"); err != crypto.ErrWrongPassword {
+		t.Errorf("Unlock with wrong password = %v, want ErrWrongPassword", err)
+	}
+	if err := s.Unlock("correct horse"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if got, err := s.PrivateKey(kp.ID); err != nil || got != priv {
+		t.Errorf("PrivateKey after unlock = %q, %v", got, err)
+	}
+
+	// 新建的密钥沿用主密码保护
+	second, err := s.Create("测试", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Protection != ProtectionPassword || second.IsDefault {
+		t.Errorf("second key = %+v, want password protection and not default", second)
+	}
+}
+
+func TestDeleteKeyInUse(t *testing.T) {
+	s, db := newTestStore(t, nil)
+	kp, err := s.Create("生产", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SaveServer(database.Server{ID: "srv", Name: "web", URL: "http://localhost", KeyID: kp.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(kp.ID); err == nil {
+		t.Error("Delete should refuse a key used by a server")
+	}
+	if err := db.DeleteServer("srv"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(kp.ID); err != nil {
+		t.Errorf("Delete unused key: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Package lock 进程间互斥锁，保证监控、定时任务和上传队列只在一个进程中运行
+//
+// 锁由操作系统在进程退出 (包括崩溃) 时自动释放，不会留下需要手动清理的锁。
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrLocked 锁已被另一个进程持有
+var ErrLocked = errors.New("已被另一个进程占用")
+
+// Lock 已取得的锁
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// Acquire 取得锁并写入持有者信息 (进程号和运行模式)，已被占用时返回 ErrLocked
+func Acquire(path, owner string) (*Lock, error) {
+	f, err := lockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	info := fmt.Sprintf("pid=%d mode=%s since=%s\n", os.Getpid(), owner, time.Now().Format(time.RFC3339))
+	writeOwner(path, info)
+	return &Lock{path: path, file: f}, nil
+}
+
+// Release 释放锁
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	os.Remove(ownerPath(l.path))
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// Owner 读取当前持有者的信息，读取失败时返回空字符串
+func Owner(path string) string {
+	data, err := os.ReadFile(ownerPath(path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// 持有者信息写在单独的文件中 (Windows 下锁文件本身不能被其他进程读取)
+func ownerPath(path string) string {
+	return path + ".owner"
+}
+
+func writeOwner(path, info string) {
+	os.WriteFile(ownerPath(path), []byte(info), 0644)
+}
@@ -0,0 +1,43 @@
+package lock
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAcquireRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "service.lock")
+
+	l, err := Acquire(path, "gui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner := Owner(path); !strings.Contains(owner, "mode=gui") || !strings.Contains(owner, "pid=") {
+		t.Errorf("Owner = %q", owner)
+	}
+
+	// 锁按打开的文件区分，同一进程再次获取也会失败
+	if _, err := Acquire(path, "cli"); err != ErrLocked {
+		t.Errorf("second Acquire = %v, want ErrLocked", err)
+	}
+	if owner := Owner(path); !strings.Contains(owner, "mode=gui") {
+		t.Errorf("failed Acquire overwrote owner: %q", owner)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Release(); err != nil {
+		t.Errorf("second Release = %v", err)
+	}
+	if owner := Owner(path); owner != "" {
+		t.Errorf("Owner after release = %q", owner)
+	}
+
+	l2, err := Acquire(path, "cli")
+	if err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+	l2.Release()
+}
@@ -0,0 +1,35 @@
+//go:build windows
+
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation 文件已被其他进程以独占方式打开
+const errorSharingViolation syscall.Errno = 32
+
+// lockFile 以不共享的方式打开锁文件，进程持有句柄期间其他进程无法打开
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name,
+		syscall.GENERIC_READ|syscall.GENERIC_WRITE,
+		0, // 不共享
+		nil,
+		syscall.OPEN_ALWAYS,
+		syscall.FILE_ATTRIBUTE_NORMAL,
+		0)
+	if err != nil {
+		if errors.Is(err, errorSharingViolation) {
+			return nil, ErrLocked
+		}
+		return nil, fmt.Errorf("打开锁文件失败: %v", err)
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
@@ -0,0 +1,114 @@
+// Package notify 显示系统桌面通知，并定义可以单独开关的通知事件
+//
+// 各平台使用系统自带的命令显示通知 (Windows: PowerShell 的 Toast 通知，
+// macOS: osascript，Linux: notify-send)，不依赖额外的库。
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// AppName 通知中显示的程序名称
+const AppName = "Deploy Receiver Client"
+
+// 通知事件类型
+const (
+	UploadSuccess   = "upload.success"   // 手动上传或部署到服务器组成功
+	UploadFailed    = "upload.failed"    // 手动上传或部署到服务器组失败
+	WatchSuccess    = "watch.success"    // 监控到的变化上传成功
+	WatchFailed     = "watch.failed"     // 监控到的变化重试后仍上传失败
+	ScheduleSuccess = "schedule.success" // 定时任务运行成功
+	ScheduleFailed  = "schedule.failed"  // 定时任务运行失败
+	RecipeSuccess   = "recipe.success"   // 部署配方运行成功
+	RecipeFailed    = "recipe.failed"    // 部署配方运行失败或被取消
+	QueueFailed     = "queue.failed"     // 上传队列中的任务重试次数用尽
+	ServerDown      = "server.down"      // 服务器变为不可达
+	ServerUp        = "server.up"        // 服务器恢复
+)
+
+// Event 通知事件及默认是否通知 (失败默认通知，成功默认不通知)
+type Event struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+}
+
+// Events 所有通知事件，按设置界面中的显示顺序
+var Events = []Event{
+	{UploadSuccess, "手动上传成功", false},
+	{UploadFailed, "手动上传失败", true},
+	{WatchSuccess, "监控上传成功", false},
+	{WatchFailed, "监控上传失败", true},
+	{ScheduleSuccess, "定时任务成功", false},
+	{ScheduleFailed, "定时任务失败", true},
+	{RecipeSuccess, "部署配方成功", true},
+	{RecipeFailed, "部署配方失败", true},
+	{QueueFailed, "重试队列任务失败", true},
+	{ServerDown, "服务器不可达", true},
+	{ServerUp, "服务器恢复", false},
+}
+
+// Known 是否为已定义的事件类型
+func Known(id string) bool {
+	for _, e := range Events {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Default 事件默认是否通知，未知事件 (例如配方中的通知步骤) 总是通知
+func Default(id string) bool {
+	for _, e := range Events {
+		if e.ID == id {
+			return e.Default
+		}
+	}
+	return true
+}
+
+// sendTimeout 等待通知命令的最长时间
+const sendTimeout = 15 * time.Second
+
+// maxMessage 通知正文的最大字符数，过长的错误信息被截断
+const maxMessage = 240
+
+// Send 显示一条桌面通知，系统不支持或命令失败时返回错误
+func Send(title, message string) error {
+	if utf8.RuneCountInString(message) > maxMessage {
+		message = string([]rune(message)[:maxMessage]) + "…"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+	cmd := command(ctx, title, message)
+	if cmd == nil {
+		return fmt.Errorf("当前系统不支持桌面通知")
+	}
+
+	// 脚本从环境变量读取标题和正文，不需要转义
+	cmd.Env = append(os.Environ(), "DR_NOTIFY_TITLE="+title, "DR_NOTIFY_MESSAGE="+message)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// lookPath 命令不存在时返回空字符串
+func lookPath(name string) string {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,11 @@
+package notify
+
+import (
+	"context"
+	"os/exec"
+)
+
+func command(ctx context.Context, title, message string) *exec.Cmd {
+	return exec.CommandContext(ctx, "osascript",
+		"-e", `display notification (system attribute "DR_NOTIFY_MESSAGE") with title (system attribute "DR_NOTIFY_TITLE")`)
+}
@@ -0,0 +1,17 @@
+//go:build !windows && !darwin
+
+package notify
+
+import (
+	"context"
+	"os/exec"
+)
+
+// command 使用 notify-send (libnotify)
+func command(ctx context.Context, title, message string) *exec.Cmd {
+	path := lookPath("notify-send")
+	if path == "" {
+		return nil
+	}
+	return exec.CommandContext(ctx, path, "--app-name", AppName, "--", title, message)
+}
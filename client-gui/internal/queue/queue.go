@@ -0,0 +1,330 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"client-gui/internal/database"
+	"client-gui/internal/uploader"
+)
+
+// 重试参数
+const (
+	DefaultMaxAttempts = 8
+	baseDelay          = 10 * time.Second
+	maxDelay           = 30 * time.Minute
+
+	workers = 4 // 同时执行的任务数
+
+	// 最长等待间隔: 其他进程 (图形界面、命令行) 可能直接向数据库加入任务
+	pollInterval = 30 * time.Second
+)
+
+// timeLayout 队列时间统一使用 UTC，保证数据库中按字符串比较即按时间比较
+const timeLayout = time.RFC3339
+
+// Executor 执行一次上传，返回的结果用于判断成功与否以及是否重试
+type Executor func(ctx context.Context, job database.QueueJob) *uploader.UploadResult
+
+// UpdateFunc 任务状态变化回调 (开始执行、成功、等待重试、失败、删除)
+type UpdateFunc func(job database.QueueJob, result *uploader.UploadResult)
+
+// Retryable 附加的可重试判断，返回 true 时视为可重试 (例如私钥锁定，解锁后即可成功)
+type Retryable func(err error) bool
+
+// Dispatcher 持久化上传队列的调度器: 到期任务并发执行，失败后按指数退避加抖动重试
+type Dispatcher struct {
+	db        *database.DB
+	exec      Executor
+	onUpdate  UpdateFunc
+	retryable Retryable
+
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	wg     sync.WaitGroup
+	now    func() time.Time
+}
+
+// New 创建调度器，retryable 可为 nil
+func New(db *database.DB, exec Executor, onUpdate UpdateFunc, retryable Retryable) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		db:        db,
+		exec:      exec,
+		onUpdate:  onUpdate,
+		retryable: retryable,
+		running:   make(map[string]context.CancelFunc),
+		ctx:       ctx,
+		cancel:    cancel,
+		wake:      make(chan struct{}, 1),
+		now:       time.Now,
+	}
+}
+
+// Start 恢复上次退出时中断的任务并启动调度循环
+func (d *Dispatcher) Start() error {
+	if err := d.db.ResetRunningQueueJobs(); err != nil {
+		return err
+	}
+	d.wg.Add(1)
+	go d.loop()
+	return nil
+}
+
+// Stop 停止调度，中断正在执行的任务 (下次启动时重新执行)
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Enqueue 加入队列并尽快执行，同一文件已有等待中的任务时合并为一个
+func (d *Dispatcher) Enqueue(job database.QueueJob) (*database.QueueJob, error) {
+	existing, err := d.db.FindPendingQueueJob(job.ServerID, job.PathKey, job.FilePath, job.RelPath)
+	if err != nil {
+		return nil, err
+	}
+	now := d.timestamp(d.now())
+
+	if existing != nil {
+		existing.NextAttemptAt = now
+		existing.UpdatedAt = now
+		if err := d.db.UpdateQueueJob(*existing); err != nil {
+			return nil, err
+		}
+		d.notify()
+		return existing, nil
+	}
+
+	if job.ID == "" {
+		job.ID = fmt.Sprintf("queue_%d", time.Now().UnixNano())
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = DefaultMaxAttempts
+	}
+	job.Status = database.QueuePending
+	job.NextAttemptAt = now
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	if err := d.db.AddQueueJob(job); err != nil {
+		return nil, err
+	}
+	d.update(job, nil)
+	d.notify()
+	return &job, nil
+}
+
+// Retry 立即重试任务，重新计算重试次数
+func (d *Dispatcher) Retry(id string) error {
+	job, err := d.db.GetQueueJob(id)
+	if err != nil {
+		return err
+	}
+	if job == nil {
+		return fmt.Errorf("队列任务不存在: %s", id)
+	}
+	if job.Status == database.QueueRunning {
+		return errors.New("任务正在执行")
+	}
+
+	now := d.timestamp(d.now())
+	job.Status = database.QueuePending
+	job.Attempts = 0
+	job.NextAttemptAt = now
+	job.UpdatedAt = now
+	if err := d.db.UpdateQueueJob(*job); err != nil {
+		return err
+	}
+	d.update(*job, nil)
+	d.notify()
+	return nil
+}
+
+// Drop 删除任务，正在执行时先中断
+func (d *Dispatcher) Drop(id string) error {
+	d.mu.Lock()
+	cancel, running := d.running[id]
+	d.mu.Unlock()
+	if running {
+		cancel()
+	}
+	return d.db.DeleteQueueJob(id)
+}
+
+// IsRunning 判断任务是否正在执行
+func (d *Dispatcher) IsRunning(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.running[id]
+	return ok
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) loop() {
+	defer d.wg.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		d.dispatchDue()
+
+		wait := pollInterval
+		if next, err := d.db.NextQueueAttempt(); err == nil && next != "" {
+			if t, err := time.Parse(timeLayout, next); err == nil {
+				if until := t.Sub(d.now()); until < wait {
+					wait = until
+				}
+			}
+		}
+		if wait < time.Second {
+			// 并发已满或刚刚调度过，等任务结束时再被唤醒
+			wait = time.Second
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-d.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatchDue 启动到期的任务，不超过并发上限
+func (d *Dispatcher) dispatchDue() {
+	d.mu.Lock()
+	free := workers - len(d.running)
+	d.mu.Unlock()
+	if free <= 0 {
+		return
+	}
+
+	jobs, err := d.db.DueQueueJobs(d.timestamp(d.now()), free)
+	if err != nil {
+		return
+	}
+	for _, job := range jobs {
+		job.Status = database.QueueRunning
+		job.Attempts++
+		job.UpdatedAt = d.timestamp(d.now())
+		if err := d.db.UpdateQueueJob(job); err != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(d.ctx)
+		d.mu.Lock()
+		d.running[job.ID] = cancel
+		d.mu.Unlock()
+
+		d.update(job, nil)
+
+		d.wg.Add(1)
+		go d.run(ctx, cancel, job)
+	}
+}
+
+// run 执行一次任务并按结果更新状态
+func (d *Dispatcher) run(ctx context.Context, cancel context.CancelFunc, job database.QueueJob) {
+	defer d.wg.Done()
+	defer d.notify()
+
+	result := d.exec(ctx, job)
+	if result == nil {
+		result = &uploader.UploadResult{Success: false, Error: "没有上传结果"}
+	}
+
+	d.mu.Lock()
+	delete(d.running, job.ID)
+	d.mu.Unlock()
+	cancel()
+
+	// 程序退出中断的任务保持等待状态，下次启动时继续
+	if d.ctx.Err() != nil {
+		job.Status = database.QueuePending
+		job.Attempts--
+		job.UpdatedAt = d.timestamp(d.now())
+		d.db.UpdateQueueJob(job)
+		return
+	}
+	// 执行中被删除
+	if ctx.Err() != nil {
+		if existing, _ := d.db.GetQueueJob(job.ID); existing == nil {
+			return
+		}
+	}
+
+	now := d.now()
+	job.UpdatedAt = d.timestamp(now)
+	job.LastError = result.Error
+	job.ErrorCode = result.Code
+
+	switch {
+	case result.Success:
+		job.Status = database.QueueDone
+		job.LastError = ""
+		job.ErrorCode = ""
+	case d.shouldRetry(result.Err()) && job.Attempts < job.MaxAttempts:
+		job.Status = database.QueuePending
+		job.NextAttemptAt = d.timestamp(now.Add(Backoff(job.Attempts)))
+	default:
+		job.Status = database.QueueFailed
+	}
+
+	d.db.UpdateQueueJob(job)
+	d.update(job, result)
+}
+
+func (d *Dispatcher) shouldRetry(err error) bool {
+	if d.retryable != nil && d.retryable(err) {
+		return true
+	}
+	return uploader.IsRetryable(err)
+}
+
+func (d *Dispatcher) update(job database.QueueJob, result *uploader.UploadResult) {
+	if d.onUpdate != nil {
+		d.onUpdate(job, result)
+	}
+}
+
+func (d *Dispatcher) timestamp(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// Backoff 第 attempt 次失败后的等待时间: 指数增长并加入抖动 (取上限的 50%~100%)，
+// 避免大量任务在网络恢复时同时重试
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := maxDelay
+	if attempt <= 20 {
+		if d := baseDelay << uint(attempt-1); d < maxDelay {
+			delay = d
+		}
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
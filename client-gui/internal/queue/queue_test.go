@@ -0,0 +1,282 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"client-gui/internal/database"
+	"client-gui/internal/uploader"
+)
+
+// testQueue 使用临时数据库和可控时钟的调度器，executor 的结果由测试逐个提供
+type testQueue struct {
+	*Dispatcher
+	db      *database.DB
+	results chan *uploader.UploadResult // 每次执行取一个结果，nil 表示等待 ctx 结束
+	updates chan database.QueueJob
+
+	mu    sync.Mutex
+	clock time.Time
+}
+
+func newTestQueue(t *testing.T, retryable Retryable) *testQueue {
+	t.Helper()
+	db, err := database.Open(filepath.Join(t.TempDir(), "data.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	q := &testQueue{
+		db:      db,
+		results: make(chan *uploader.UploadResult, 10),
+		updates: make(chan database.QueueJob, 100),
+		clock:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	exec := func(ctx context.Context, job database.QueueJob) *uploader.UploadResult {
+		select {
+		case r := <-q.results:
+			if r != nil {
+				return r
+			}
+		case <-ctx.Done():
+			return &uploader.UploadResult{Canceled: true, Error: uploader.ErrCanceled.Error()}
+		}
+		<-ctx.Done()
+		return &uploader.UploadResult{Canceled: true, Error: uploader.ErrCanceled.Error()}
+	}
+	q.Dispatcher = New(db, exec, func(job database.QueueJob, _ *uploader.UploadResult) { q.updates <- job }, retryable)
+	q.now = q.Now
+	return q
+}
+
+func (q *testQueue) Now() time.Time {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.clock
+}
+
+// advance 拨快时钟并唤醒调度循环
+func (q *testQueue) advance(d time.Duration) {
+	q.mu.Lock()
+	q.clock = q.clock.Add(d)
+	q.mu.Unlock()
+	q.notify()
+}
+
+// waitStatus 等待任务进入指定状态
+func (q *testQueue) waitStatus(t *testing.T, status string) database.QueueJob {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case job := <-q.updates:
+			if job.Status == status {
+				return job
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for status %s", status)
+		}
+	}
+}
+
+func networkError() *uploader.UploadResult {
+	return uploader.FailedResult(&uploader.NetworkError{Err: errors.New("connection refused")})
+}
+
+func TestDispatcherRetriesUntilSuccess(t *testing.T) {
+	q := newTestQueue(t, nil)
+	if err := q.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer q.Stop()
+
+	q.results <- networkError()
+	job, err := q.Enqueue(database.QueueJob{ServerID: "s1", PathKey: "web", FilePath: "/tmp/a.zip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.MaxAttempts != DefaultMaxAttempts || job.Status != database.QueuePending {
+		t.Errorf("enqueued job = %+v", job)
+	}
+
+	q.waitStatus(t, database.QueueRunning)
+	retry := q.waitStatus(t, database.QueuePending)
+	if retry.Attempts != 1 || retry.LastError == "" {
+		t.Errorf("after network error: attempts = %d, lastError = %q", retry.Attempts, retry.LastError)
+	}
+	next, _ := time.Parse(timeLayout, retry.NextAttemptAt)
+	if wait := next.Sub(q.Now()); wait < baseDelay/2 || wait > baseDelay {
+		t.Errorf("next attempt in %v, want between %v and %v", wait, baseDelay/2, baseDelay)
+	}
+
+	// 未到重试时间不执行
+	q.notify()
+	select {
+	case job := <-q.updates:
+		t.Fatalf("job ran before its retry time: %+v", job)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	q.results <- &uploader.UploadResult{Success: true}
+	q.advance(baseDelay)
+	done := q.waitStatus(t, database.QueueDone)
+	if done.Attempts != 2 || done.LastError != "" {
+		t.Errorf("done job: attempts = %d, lastError = %q", done.Attempts, done.LastError)
+	}
+}
+
+func TestDispatcherFailure(t *testing.T) {
+	errLocked := errors.New("locked")
+	tests := []struct {
+		name        string
+		result      *uploader.UploadResult
+		maxAttempts int
+		want        string
+	}{
+		{"not retryable", uploader.FailedResult(uploader.ErrAuthBadSignature), 0, database.QueueFailed},
+		{"attempts used up", networkError(), 1, database.QueueFailed},
+		{"retryable hook", uploader.FailedResult(errLocked), 0, database.QueuePending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t, func(err error) bool { return errors.Is(err, errLocked) })
+			if err := q.Start(); err != nil {
+				t.Fatal(err)
+			}
+			defer q.Stop()
+
+			q.results <- tt.result
+			if _, err := q.Enqueue(database.QueueJob{ServerID: "s1", PathKey: "web", FilePath: "/tmp/a.zip", MaxAttempts: tt.maxAttempts}); err != nil {
+				t.Fatal(err)
+			}
+			q.waitStatus(t, database.QueueRunning)
+			job := q.waitStatus(t, tt.want)
+			if job.LastError != tt.result.Error {
+				t.Errorf("LastError = %q, want %q", job.LastError, tt.result.Error)
+			}
+		})
+	}
+}
+
+func TestEnqueueMergesPending(t *testing.T) {
+	q := newTestQueue(t, nil)
+
+	job := database.QueueJob{ServerID: "s1", PathKey: "web", FilePath: "/tmp/a.zip", Source: "watch"}
+	first, err := q.Enqueue(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := q.Enqueue(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.RelPath = "sub/a.zip"
+	other, err := q.Enqueue(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if second.ID != first.ID || other.ID == first.ID {
+		t.Errorf("ids = %s %s %s, want first two merged", first.ID, second.ID, other.ID)
+	}
+	if jobs, _ := q.db.GetQueueJobs(""); len(jobs) != 2 {
+		t.Errorf("queue has %d jobs, want 2", len(jobs))
+	}
+}
+
+func TestDispatcherDropAndRetry(t *testing.T) {
+	q := newTestQueue(t, nil)
+	if err := q.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer q.Stop()
+
+	// 执行中删除: executor 被中断，任务不再出现
+	q.results <- nil
+	job, err := q.Enqueue(database.QueueJob{ServerID: "s1", PathKey: "web", FilePath: "/tmp/a.zip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.waitStatus(t, database.QueueRunning)
+	if !q.IsRunning(job.ID) {
+		t.Fatal("job should be running")
+	}
+	if err := q.Drop(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for q.IsRunning(job.ID) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got, _ := q.db.GetQueueJob(job.ID); got != nil || q.IsRunning(job.ID) {
+		t.Errorf("dropped job still present: %+v", got)
+	}
+
+	// 失败的任务手动重试时重新计数
+	q.results <- uploader.FailedResult(uploader.ErrPathReadOnly)
+	job, err = q.Enqueue(database.QueueJob{ServerID: "s1", PathKey: "web", FilePath: "/tmp/b.zip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.waitStatus(t, database.QueueFailed)
+	q.results <- &uploader.UploadResult{Success: true}
+	if err := q.Retry(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if done := q.waitStatus(t, database.QueueDone); done.Attempts != 1 {
+		t.Errorf("attempts after manual retry = %d, want 1", done.Attempts)
+	}
+	if err := q.Retry("missing"); err == nil {
+		t.Error("Retry of missing job should fail")
+	}
+}
+
+func TestStopKeepsJobPending(t *testing.T) {
+	q := newTestQueue(t, nil)
+	if err := q.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	q.results <- nil
+	job, err := q.Enqueue(database.QueueJob{ServerID: "s1", PathKey: "web", FilePath: "/tmp/a.zip"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	q.waitStatus(t, database.QueueRunning)
+	q.Stop()
+
+	got, err := q.db.GetQueueJob(job.ID)
+	if err != nil || got == nil {
+		t.Fatalf("job = %+v, err = %v", got, err)
+	}
+	if got.Status != database.QueuePending || got.Attempts != 0 {
+		t.Errorf("after stop: status = %s attempts = %d, want pending 0", got.Status, got.Attempts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, baseDelay},
+		{1, baseDelay},
+		{2, 2 * baseDelay},
+		{4, 8 * baseDelay},
+		{10, maxDelay},
+		{100, maxDelay},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			if got := Backoff(tt.attempt); got < tt.max/2 || got > tt.max {
+				t.Errorf("Backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.max/2, tt.max)
+				break
+			}
+		}
+	}
+}
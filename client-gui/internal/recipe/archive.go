@@ -0,0 +1,58 @@
+package recipe
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"client-gui/internal/ignore"
+	"client-gui/internal/uploader"
+)
+
+// ZipFolder 把目录中的文件打包为 zip，条目使用相对路径 (正斜杠)
+//
+// 遵循目录中的 .deployignore，excludes 为追加的排除规则 (相同语法)。
+// 输出文件位于目录内时不会把自己打包进去。返回文件数和原始总大小。
+func ZipFolder(ctx context.Context, src, dst string, excludes []string) (int, int64, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return 0, 0, fmt.Errorf("无法访问打包目录: %v", err)
+	}
+	if !info.IsDir() {
+		return 0, 0, fmt.Errorf("打包路径不是文件夹: %s", src)
+	}
+
+	exclude, err := ignore.Load(src, excludes)
+	if err != nil {
+		return 0, 0, err
+	}
+	listed, err := uploader.ListFilesInDir(src, exclude)
+	if err != nil {
+		return 0, 0, fmt.Errorf("列出文件失败: %v", err)
+	}
+	absDst, _ := filepath.Abs(dst)
+	files := listed[:0]
+	var size int64
+	for _, f := range listed {
+		if abs, _ := filepath.Abs(f.AbsPath); abs == absDst {
+			continue
+		}
+		files = append(files, f)
+		size += f.Size
+	}
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, 0, fmt.Errorf("创建压缩包失败: %v", err)
+	}
+	err = uploader.WriteZip(ctx, out, files, nil)
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(dst)
+		return 0, 0, fmt.Errorf("打包失败: %v", err)
+	}
+	return len(files), size, nil
+}
@@ -0,0 +1,419 @@
+// Package recipe 部署配方: 按顺序执行本地构建命令、打包、上传、校验和通知
+package recipe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"client-gui/internal/database"
+)
+
+// 步骤的默认参数
+const (
+	defaultCommandTimeout = 30 * time.Minute
+	defaultVerifyTimeout  = 60 * time.Second
+	verifyInterval        = 2 * time.Second
+	verifyRequestTimeout  = 10 * time.Second
+
+	maxLogSize = 1 << 20 // 运行记录中保存的日志上限
+)
+
+// 步骤状态 (运行状态见 database.Run*)
+const (
+	StepRunning = "running"
+	StepSkipped = "skipped"
+)
+
+// Env 运行环境，上传和通知由调用方实现，回调均可为 nil
+type Env struct {
+	// Upload 上传文件或文件夹到步骤指定的服务器或服务器组，返回结果摘要
+	Upload func(ctx context.Context, step database.RecipeStep, path string) (string, error)
+	// Notify 发送通知
+	Notify func(title, message string)
+	// Output 实时输出，step 为步骤序号 (从 0 开始)
+	Output func(step int, line string)
+	// OnStep 步骤开始 (状态为 running) 和结束时调用
+	OnStep func(step int, result database.RecipeStepResult)
+}
+
+// Validate 检查配方
+func Validate(r database.Recipe) error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("配方名称不能为空")
+	}
+	if len(r.Steps) == 0 {
+		return errors.New("配方至少包含一个步骤")
+	}
+
+	packaged := false
+	for i, s := range r.Steps {
+		var err error
+		switch s.Type {
+		case database.StepCommand:
+			if strings.TrimSpace(s.Command) == "" {
+				err = errors.New("命令不能为空")
+			}
+		case database.StepPackage:
+			if s.Source == "" {
+				err = errors.New("打包目录不能为空")
+			}
+			packaged = true
+		case database.StepUpload:
+			switch {
+			case (s.ServerID == "") == (s.GroupID == ""):
+				err = errors.New("需要选择一台服务器或一个服务器组")
+			case s.PathKey == "":
+				err = errors.New("路径标识不能为空")
+			case s.Source == "" && !packaged:
+				err = errors.New("没有指定上传路径，且前面没有打包步骤")
+			}
+		case database.StepVerify:
+			if !strings.HasPrefix(s.URL, "http://") && !strings.HasPrefix(s.URL, "https://") {
+				err = errors.New("校验地址必须以 http:// 或 https:// 开头")
+			}
+		case database.StepNotify:
+			if strings.TrimSpace(s.Message) == "" {
+				err = errors.New("通知内容不能为空")
+			}
+		default:
+			err = fmt.Errorf("不支持的步骤类型: %q", s.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("步骤 %d (%s): %v", i+1, stepName(s), err)
+		}
+	}
+	return nil
+}
+
+// Run 执行配方，返回的记录不含 ID 和 Trigger
+//
+// 某个步骤失败后，后续步骤跳过，除非标记了 Always (例如失败通知)。ctx 取消后
+// 正在执行的命令被终止，其余步骤全部跳过。
+func Run(ctx context.Context, r database.Recipe, env Env) database.RecipeRun {
+	started := time.Now()
+	run := &runner{
+		ctx:    ctx,
+		recipe: r,
+		env:    env,
+		result: database.RecipeRun{
+			RecipeID:   r.ID,
+			RecipeName: r.Name,
+			Status:     database.RunSuccess,
+			Steps:      make([]database.RecipeStepResult, len(r.Steps)),
+			StartedAt:  started.Format(time.RFC3339),
+		},
+	}
+	defer run.cleanup()
+
+	for i, step := range r.Steps {
+		res := database.RecipeStepResult{Name: stepName(step), Type: step.Type}
+
+		switch {
+		case ctx.Err() != nil:
+			run.markCanceled()
+			res.Status = StepSkipped
+		case run.result.Status != database.RunSuccess && !step.Always:
+			res.Status = StepSkipped
+		}
+		if res.Status == StepSkipped {
+			run.result.Steps[i] = res
+			run.stepDone(i, res)
+			continue
+		}
+
+		run.logf(i, "==> [%d/%d] %s", i+1, len(r.Steps), res.Name)
+		res.Status = StepRunning
+		if env.OnStep != nil {
+			env.OnStep(i, res)
+		}
+
+		stepStarted := time.Now()
+		summary, err := run.step(i, step)
+		res.DurationMs = time.Since(stepStarted).Milliseconds()
+		res.Summary = summary
+
+		switch {
+		case err == nil:
+			res.Status = database.RunSuccess
+			if summary != "" {
+				run.logf(i, "%s", summary)
+			}
+		case ctx.Err() != nil:
+			res.Status = database.RunCanceled
+			res.Error = "已取消"
+			run.markCanceled()
+		default:
+			res.Status = database.RunFailed
+			res.Error = err.Error()
+			run.logf(i, "失败: %v", err)
+			if run.result.Status == database.RunSuccess {
+				run.result.Status = database.RunFailed
+				run.result.ErrorMsg = fmt.Sprintf("%s: %v", res.Name, err)
+			}
+		}
+		run.result.Steps[i] = res
+		run.stepDone(i, res)
+	}
+
+	finished := time.Now()
+	run.result.FinishedAt = finished.Format(time.RFC3339)
+	run.result.DurationMs = finished.Sub(started).Milliseconds()
+	run.result.Log = run.log.String()
+	return run.result
+}
+
+type runner struct {
+	ctx    context.Context
+	recipe database.Recipe
+	env    Env
+	result database.RecipeRun
+
+	artifact string   // 最近一个打包步骤的产物
+	temp     []string // 运行结束后删除的临时文件
+
+	logMu     sync.Mutex
+	log       strings.Builder
+	truncated bool
+}
+
+func (run *runner) step(i int, s database.RecipeStep) (string, error) {
+	switch s.Type {
+	case database.StepCommand:
+		return run.command(i, s)
+	case database.StepPackage:
+		return run.pack(i, s)
+	case database.StepUpload:
+		path := s.Source
+		if path == "" {
+			path = run.artifact
+		}
+		if path == "" {
+			return "", errors.New("没有可上传的文件")
+		}
+		if run.env.Upload == nil {
+			return "", errors.New("当前环境不支持上传")
+		}
+		run.logf(i, "上传 %s", path)
+		return run.env.Upload(run.ctx, s, path)
+	case database.StepVerify:
+		return run.verify(i, s)
+	case database.StepNotify:
+		message := strings.NewReplacer(
+			"{{recipe}}", run.recipe.Name,
+			"{{status}}", run.result.Status,
+			"{{error}}", run.result.ErrorMsg,
+		).Replace(s.Message)
+		if run.env.Notify != nil {
+			run.env.Notify(run.recipe.Name, message)
+		}
+		return message, nil
+	default:
+		return "", fmt.Errorf("不支持的步骤类型: %q", s.Type)
+	}
+}
+
+// command 通过系统 shell 运行命令，逐行转发标准输出和标准错误
+func (run *runner) command(i int, s database.RecipeStep) (string, error) {
+	ctx, cancel := context.WithTimeout(run.ctx, timeout(s.TimeoutSeconds, defaultCommandTimeout))
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", s.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", s.Command)
+	}
+	cmd.Dir = s.Dir
+	// 命令被终止后子进程可能仍持有输出管道，不再等待
+	cmd.WaitDelay = 5 * time.Second
+
+	// 输出交给 exec 复制，命令被终止后 WaitDelay 才能关闭管道
+	stdout := &lineWriter{emit: func(line string) { run.logf(i, "%s", line) }}
+	stderr := &lineWriter{emit: func(line string) { run.logf(i, "%s", line) }}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	run.logf(i, "$ %s", s.Command)
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("启动命令失败: %v", err)
+	}
+
+	err := cmd.Wait()
+	stdout.flush()
+	stderr.flush()
+	if ctx.Err() == context.DeadlineExceeded && run.ctx.Err() == nil {
+		return "", fmt.Errorf("命令超时 (%v)", timeout(s.TimeoutSeconds, defaultCommandTimeout))
+	}
+	if err != nil {
+		return "", fmt.Errorf("命令执行失败: %v", err)
+	}
+	return "命令执行成功", nil
+}
+
+// lineWriter 按行转发命令输出，不限制单行长度
+type lineWriter struct {
+	emit func(line string)
+	buf  []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		n := bytes.IndexByte(w.buf, '\n')
+		if n < 0 {
+			break
+		}
+		w.send(w.buf[:n])
+		w.buf = w.buf[n+1:]
+	}
+	return len(p), nil
+}
+
+// flush 转发最后一行没有换行符的输出
+func (w *lineWriter) flush() {
+	w.send(w.buf)
+	w.buf = nil
+}
+
+func (w *lineWriter) send(line []byte) {
+	if s := strings.TrimRight(string(line), "\r"); s != "" {
+		w.emit(s)
+	}
+}
+
+// pack 把目录打包为 zip，未指定输出路径时写入临时文件
+func (run *runner) pack(i int, s database.RecipeStep) (string, error) {
+	output := s.Output
+	if output == "" {
+		f, err := os.CreateTemp("", "recipe-*.zip")
+		if err != nil {
+			return "", err
+		}
+		f.Close()
+		output = f.Name()
+		run.temp = append(run.temp, output)
+	}
+
+	run.logf(i, "打包 %s -> %s", s.Source, output)
+	count, size, err := ZipFolder(run.ctx, s.Source, output, s.Excludes)
+	if err != nil {
+		return "", err
+	}
+	run.artifact = output
+	return fmt.Sprintf("已打包 %d 个文件 (%d 字节)", count, size), nil
+}
+
+// verify 在超时前反复请求校验地址，直到状态码和内容符合要求
+func (run *runner) verify(i int, s database.RecipeStep) (string, error) {
+	expect := s.ExpectStatus
+	if expect == 0 {
+		expect = http.StatusOK
+	}
+	limit := timeout(s.TimeoutSeconds, defaultVerifyTimeout)
+	deadline := time.Now().Add(limit)
+	client := &http.Client{Timeout: verifyRequestTimeout}
+
+	for attempt := 1; ; attempt++ {
+		err := checkURL(run.ctx, client, s.URL, expect, s.Contains)
+		if err == nil {
+			return fmt.Sprintf("校验通过: %s (第 %d 次请求)", s.URL, attempt), nil
+		}
+		if run.ctx.Err() != nil {
+			return "", run.ctx.Err()
+		}
+		if time.Now().Add(verifyInterval).After(deadline) {
+			return "", fmt.Errorf("校验失败 (%v 内共请求 %d 次): %v", limit, attempt, err)
+		}
+		run.logf(i, "第 %d 次请求未通过: %v", attempt, err)
+
+		select {
+		case <-run.ctx.Done():
+			return "", run.ctx.Err()
+		case <-time.After(verifyInterval):
+		}
+	}
+}
+
+func checkURL(ctx context.Context, client *http.Client, url string, expect int, contains string) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != expect {
+		return fmt.Errorf("状态码 %d，期望 %d", resp.StatusCode, expect)
+	}
+	if contains != "" && !strings.Contains(string(body), contains) {
+		return fmt.Errorf("响应中不包含 %q", contains)
+	}
+	return nil
+}
+
+// logf 写入运行日志并实时输出
+func (run *runner) logf(i int, format string, args ...interface{}) {
+	line := fmt.Sprintf(format, args...)
+
+	run.logMu.Lock()
+	if run.log.Len()+len(line) < maxLogSize {
+		run.log.WriteString(time.Now().Format("15:04:05 "))
+		run.log.WriteString(line)
+		run.log.WriteByte('\n')
+	} else if !run.truncated {
+		run.truncated = true
+		run.log.WriteString("... 日志过长，后续输出未保存\n")
+	}
+	// 标准输出和标准错误在不同协程中转发，回调保持串行
+	if run.env.Output != nil {
+		run.env.Output(i, line)
+	}
+	run.logMu.Unlock()
+}
+
+func (run *runner) stepDone(i int, res database.RecipeStepResult) {
+	if run.env.OnStep != nil {
+		run.env.OnStep(i, res)
+	}
+}
+
+func (run *runner) markCanceled() {
+	if run.result.Status != database.RunCanceled {
+		run.result.Status = database.RunCanceled
+		run.result.ErrorMsg = "已取消"
+	}
+}
+
+func (run *runner) cleanup() {
+	for _, path := range run.temp {
+		os.Remove(path)
+	}
+}
+
+func stepName(s database.RecipeStep) string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.Type
+}
+
+func timeout(seconds int, fallback time.Duration) time.Duration {
+	if seconds <= 0 {
+		return fallback
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,116 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1,,2 * * * *",
+		"* * * FOO *",
+		"@never",
+	}
+	for _, expr := range tests {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) succeeded, want error", expr)
+		}
+	}
+}
+
+func TestParseCronStar(t *testing.T) {
+	tests := []struct {
+		expr             string
+		domStar, dowStar bool
+	}{
+		{"0 0 * * *", true, true},
+		{"0 0 ? * 1", true, false},
+		{"0 0 */2 * 1", true, false},
+		{"0 0 1 * */2", false, true},
+		{"0 0 1-15 * MON", false, false},
+		{"0 0 1 * 1", false, false},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if c.domStar != tt.domStar || c.dowStar != tt.dowStar {
+			t.Errorf("ParseCron(%q) domStar=%v dowStar=%v, want %v %v", tt.expr, c.domStar, c.dowStar, tt.domStar, tt.dowStar)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// 2024-01-01 是周一
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"* * * * *", "2024-01-01 10:00", "2024-01-01 10:01"},
+		{"*/15 * * * *", "2024-01-01 10:07", "2024-01-01 10:15"},
+		{"0 * * * *", "2024-01-01 10:00", "2024-01-01 11:00"},
+		{"30 2 * * *", "2024-01-01 03:00", "2024-01-02 02:30"},
+		{"5/20 * * * *", "2024-01-01 10:26", "2024-01-01 10:45"},
+		{"0 9-17/4 * * *", "2024-01-01 10:00", "2024-01-01 13:00"},
+		{"0 0 1,15 * *", "2024-01-02 00:00", "2024-01-15 00:00"},
+		{"0 0 31 * *", "2024-04-01 00:00", "2024-05-31 00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 1 JAN-MAR *", "2024-03-02 00:00", "2025-01-01 00:00"},
+		{"@daily", "2024-01-01 00:00", "2024-01-02 00:00"},
+		{"@weekly", "2024-01-01 00:00", "2024-01-07 00:00"},
+		{"@monthly", "2024-01-15 12:00", "2024-02-01 00:00"},
+		// 周字段 7 与 0 都表示周日
+		{"0 0 * * 7", "2024-01-01 00:00", "2024-01-07 00:00"},
+		{"0 0 * * SAT", "2024-01-01 00:00", "2024-01-06 00:00"},
+		{"0 0 * * MON-FRI", "2024-01-05 12:00", "2024-01-08 00:00"},
+		// 日和周都被限制时满足其一即可
+		{"0 0 13 * 5", "2024-01-01 00:00", "2024-01-05 00:00"},
+		{"0 0 13 * 5", "2024-01-06 00:00", "2024-01-12 00:00"},
+		{"0 0 13 * 5", "2024-01-12 00:00", "2024-01-13 00:00"},
+		// 以 * 开头的日字段视为未限制，与周字段取交集 (1 月 3 日是周三)
+		{"0 0 */2 * 3", "2024-01-01 00:00", "2024-01-03 00:00"},
+		{"0 0 */2 * 1", "2024-01-01 00:00", "2024-01-15 00:00"},
+		{"0 0 1 * */3", "2024-01-02 00:00", "2024-05-01 00:00"},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+		}
+		if got := c.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestCronNextNoMatch(t *testing.T) {
+	c, err := ParseCron("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); !got.IsZero() {
+		t.Errorf("Next = %v, want zero time", got)
+	}
+}
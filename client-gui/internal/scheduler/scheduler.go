@@ -0,0 +1,257 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// 重叠策略: 上一次运行尚未结束时又到了触发时间
+const (
+	OverlapSkip  = "skip"  // 跳过本次 (默认)
+	OverlapQueue = "queue" // 排队，上一次结束后立即再运行一次
+)
+
+// 补跑策略: 程序关闭期间错过的运行
+const (
+	CatchUpSkip = "skip" // 不补跑，等待下一次触发 (默认)
+	CatchUpOnce = "once" // 启动后立即补跑一次
+)
+
+// Job 定时任务
+type Job struct {
+	ID      string
+	Expr    string
+	Overlap string
+	CatchUp string
+	NextRun time.Time // 上次持久化的下一次运行时间，用于判断是否错过
+}
+
+// RunFunc 执行任务，返回的结果描述会被记录
+type RunFunc func(id string) (string, error)
+
+// RunInfo 一次运行的信息
+type RunInfo struct {
+	ID      string
+	Started time.Time
+	NextRun time.Time
+	Result  string
+	Err     error
+	Skipped bool // 因重叠被跳过
+}
+
+// UpdateFunc 运行状态变化回调 (开始、结束、跳过、下一次时间变化)
+type UpdateFunc func(info RunInfo)
+
+type entry struct {
+	job     Job
+	cron    *Cron
+	next    time.Time
+	running bool
+	queued  bool
+}
+
+// Scheduler cron 调度器
+type Scheduler struct {
+	mu       sync.Mutex
+	entries  map[string]*entry
+	run      RunFunc
+	onUpdate UpdateFunc
+	wake     chan struct{}
+	stop     chan struct{}
+	wg       sync.WaitGroup
+	now      func() time.Time
+}
+
+// New 创建调度器
+func New(run RunFunc, onUpdate UpdateFunc) *Scheduler {
+	return &Scheduler{
+		entries:  make(map[string]*entry),
+		run:      run,
+		onUpdate: onUpdate,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// Start 启动调度循环
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop 停止调度并等待正在运行的任务结束
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// Add 添加或更新任务，返回下一次运行时间
+func (s *Scheduler) Add(job Job) (time.Time, error) {
+	c, err := ParseCron(job.Expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if job.Overlap == "" {
+		job.Overlap = OverlapSkip
+	}
+	if job.CatchUp == "" {
+		job.CatchUp = CatchUpSkip
+	}
+
+	now := s.now()
+	next := c.Next(now)
+
+	s.mu.Lock()
+	e, exists := s.entries[job.ID]
+	if exists {
+		e.job, e.cron, e.next = job, c, next
+	} else {
+		e = &entry{job: job, cron: c, next: next}
+		s.entries[job.ID] = e
+	}
+	missed := !exists && job.CatchUp == CatchUpOnce && !job.NextRun.IsZero() && job.NextRun.Before(now)
+	s.mu.Unlock()
+
+	if missed {
+		s.fire(e)
+	}
+	s.notify()
+	return next, nil
+}
+
+// Remove 移除任务 (正在运行的不会被中断)
+func (s *Scheduler) Remove(id string) {
+	s.mu.Lock()
+	delete(s.entries, id)
+	s.mu.Unlock()
+	s.notify()
+}
+
+// RunNow 立即运行一次，遵循重叠策略
+func (s *Scheduler) RunNow(id string) bool {
+	s.mu.Lock()
+	e, ok := s.entries[id]
+	s.mu.Unlock()
+	if ok {
+		s.fire(e)
+	}
+	return ok
+}
+
+// NextRun 获取任务的下一次运行时间
+func (s *Scheduler) NextRun(id string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[id]
+	if !ok {
+		return time.Time{}, false
+	}
+	return e.next, true
+}
+
+// IsRunning 判断任务是否正在运行
+func (s *Scheduler) IsRunning(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[id]
+	return ok && e.running
+}
+
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		now := s.now()
+		var due []*entry
+		earliest := now.Add(time.Hour)
+
+		s.mu.Lock()
+		for _, e := range s.entries {
+			if e.next.IsZero() {
+				continue
+			}
+			if !e.next.After(now) {
+				due = append(due, e)
+				e.next = e.cron.Next(now)
+			}
+			if e.next.Before(earliest) {
+				earliest = e.next
+			}
+		}
+		s.mu.Unlock()
+
+		for _, e := range due {
+			s.fire(e)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(earliest.Sub(now))
+
+		select {
+		case <-s.stop:
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// fire 按重叠策略启动一次运行
+func (s *Scheduler) fire(e *entry) {
+	s.mu.Lock()
+	if e.running {
+		queue := e.job.Overlap == OverlapQueue
+		if queue {
+			e.queued = true
+		}
+		info := RunInfo{ID: e.job.ID, Started: s.now(), NextRun: e.next, Skipped: !queue}
+		s.mu.Unlock()
+		if info.Skipped && s.onUpdate != nil {
+			s.onUpdate(info)
+		}
+		return
+	}
+	e.running = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			started := s.now()
+			result, err := s.run(e.job.ID)
+
+			s.mu.Lock()
+			again := e.queued
+			e.queued = false
+			if !again {
+				e.running = false
+			}
+			info := RunInfo{ID: e.job.ID, Started: started, NextRun: e.next, Result: result, Err: err}
+			s.mu.Unlock()
+
+			if s.onUpdate != nil {
+				s.onUpdate(info)
+			}
+			if !again {
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,161 @@
+package uploader
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// WriteZip 把文件按相对路径写入 zip，onRead 报告已读取的原始字节数 (可为 nil)
+func WriteZip(ctx context.Context, w io.Writer, files []FileToUpload, onRead func(n int64)) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := addZipEntry(zw, f, onRead); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func addZipEntry(zw *zip.Writer, f FileToUpload, onRead func(n int64)) error {
+	file, err := os.Open(f.AbsPath)
+	if err != nil {
+		return fmt.Errorf("无法打开文件: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("无法获取文件信息: %v", err)
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+	header.Name = f.RelPath
+	header.Method = zip.Deflate
+
+	w, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = file
+	if onRead != nil {
+		r = &countingReader{reader: file, onRead: onRead}
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return fmt.Errorf("%s: %v", f.RelPath, err)
+	}
+	return nil
+}
+
+// countingReader 报告读取的字节数
+type countingReader struct {
+	reader io.Reader
+	onRead func(n int64)
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.reader.Read(p)
+	if n > 0 {
+		cr.onRead(int64(n))
+	}
+	return n, err
+}
+
+// UploadArchive 把文件夹中的文件打包为 name (以 .zip 结尾) 边压缩边上传，由服务器解压
+//
+// 不写临时文件，压缩包大小未知，使用分块传输。服务器解压到路径根目录 (与逐个上传文件的
+// 位置相同) 后删除压缩包。进度按已压缩的原始字节数计算，ctx 中的 Gate 暂停时停止发送。
+func UploadArchive(ctx context.Context, c *Client, pathKey, name string, files []FileToUpload, privateKey string, onProgress func(sent, total int64)) (*UploadResult, error) {
+	if r := c.configError(); r != nil {
+		return r, nil
+	}
+	if err := gateFrom(ctx).Wait(ctx); err != nil {
+		return canceledResult(), nil
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".zip") {
+		name += ".zip"
+	}
+
+	urlPath := fmt.Sprintf("/upload/%s/%s", pathKey, name)
+	// 压缩包中的条目带有各自的修改时间，压缩包本身的 mtime 为打包时间
+	fullURL := c.URL + urlPath + "?extract=true&target=.&keep_archive=delete&mtime=" + mtimeParam(time.Now())
+
+	var total int64
+	for _, f := range files {
+		total += f.Size
+	}
+
+	// 压缩在单独的协程中进行，通过管道交给请求体；请求结束后关闭管道让压缩协程退出
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+	zipDone := make(chan error, 1)
+	go func() {
+		var read int64
+		var last time.Time
+		err := WriteZip(ctx, pipeWriter, files, func(n int64) {
+			read += n
+			if onProgress != nil && (read == total || time.Since(last) >= progressInterval) {
+				last = time.Now()
+				onProgress(read, total)
+			}
+		})
+		pipeWriter.CloseWithError(err)
+		zipDone <- err
+	}()
+
+	pr := &progressReader{
+		ctx:    ctx,
+		gate:   gateFrom(ctx),
+		reader: c.limit(ctx, pipeReader),
+		hash:   sha256.New(),
+		total:  -1,
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, pr)
+	if err != nil {
+		return &UploadResult{Success: false, Error: fmt.Sprintf("创建请求失败: %v", err)}, nil
+	}
+	req.Header.Set("Content-Type", "application/zip")
+	req.ContentLength = -1
+	// 压缩包边压缩边上传，哈希在发送完后才知道，放在 trailer 中让服务器校验
+	req.Trailer = http.Header{}
+	req.Trailer.Set(headerContentSHA256, "")
+	pr.trailer = req.Trailer
+	if err := signRequest(req, privateKey, urlPath); err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, nil
+	}
+
+	started := time.Now()
+	resp, err := c.do(req)
+	pipeReader.Close()
+	zipErr := <-zipDone
+	if err != nil {
+		if ctx.Err() != nil {
+			return canceledResult(), nil
+		}
+		if zipErr != nil && zipErr != io.ErrClosedPipe {
+			return &UploadResult{Success: false, Error: fmt.Sprintf("打包失败: %v", zipErr)}, nil
+		}
+		ne := &NetworkError{Err: err}
+		return &UploadResult{Success: false, Error: ne.Error(), err: ne}, nil
+	}
+	defer resp.Body.Close()
+
+	result := parseUploadResponse(resp)
+	result.DurationMs = time.Since(started).Milliseconds()
+	if zipErr == nil && result.Success {
+		result.SHA256 = hex.EncodeToString(pr.hash.Sum(nil))
+	}
+	return result.verifyHash(), nil
+}
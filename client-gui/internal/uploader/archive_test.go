@@ -0,0 +1,110 @@
+package uploader
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+// readZip 读出 zip 中的文件
+func readZip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestUploadArchive(t *testing.T) {
+	fr, srv, priv := newFakeReceiver(t)
+	files := localFiles(t, 5)
+
+	var sent, total int64
+	result, err := UploadArchive(context.Background(), DefaultClient(srv.URL), "web", "site", files, priv, func(s, tot int64) {
+		sent, total = s, tot
+	})
+	if err != nil || !result.Success {
+		t.Fatalf("result = %+v, err = %v", result, err)
+	}
+
+	// 分块上传时请求头中没有哈希，fakeReceiver 用 trailer 中的哈希校验通过才会保存
+	req := fr.lastRequest(t)
+	if h := req.Header.Get(headerContentSHA256); h != "" {
+		t.Errorf("header %s = %q, want empty (sent as trailer)", headerContentSHA256, h)
+	}
+	if req.ContentLength != -1 {
+		t.Errorf("ContentLength = %d, want -1 (chunked)", req.ContentLength)
+	}
+	q := req.URL.Query()
+	if q.Get("extract") != "true" || q.Get("target") != "." || q.Get("keep_archive") != "delete" || q.Get("mtime") == "" {
+		t.Errorf("query = %v", q)
+	}
+
+	body := []byte(fr.file("site.zip"))
+	sum := sha256.Sum256(body)
+	if !result.Verified || result.SHA256 != hex.EncodeToString(sum[:]) {
+		t.Errorf("Verified = %v SHA256 = %s, want %x", result.Verified, result.SHA256, sum)
+	}
+	got := readZip(t, body)
+	for _, f := range files {
+		want, err := os.ReadFile(f.AbsPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got[f.RelPath] != string(want) {
+			t.Errorf("zip entry %s = %q", f.RelPath, got[f.RelPath])
+		}
+	}
+	if sent != total || total == 0 {
+		t.Errorf("final progress = %d/%d, want complete", sent, total)
+	}
+}
+
+func TestUploadArchiveChecksumMismatch(t *testing.T) {
+	fr, srv, priv := newFakeReceiver(t)
+	fr.mu.Lock()
+	fr.wrongHash = true
+	fr.mu.Unlock()
+
+	result, err := UploadArchive(context.Background(), DefaultClient(srv.URL), "web", "site.zip", localFiles(t, 2), priv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success || !errors.Is(result.Err(), ErrChecksumMismatch) {
+		t.Errorf("result = %+v, want checksum mismatch", result)
+	}
+}
+
+func TestUploadArchiveMissingFile(t *testing.T) {
+	_, srv, priv := newFakeReceiver(t)
+	files := localFiles(t, 2)
+	files = append(files, FileToUpload{AbsPath: files[0].AbsPath + ".missing", RelPath: "missing.txt", Size: 1})
+
+	result, err := UploadArchive(context.Background(), DefaultClient(srv.URL), "web", "site.zip", files, priv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Success {
+		t.Errorf("result = %+v, want failure for unreadable file", result)
+	}
+}
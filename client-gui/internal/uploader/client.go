@@ -0,0 +1,291 @@
+package uploader
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 连接设置的默认值
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultUploadTimeout  = 30 * time.Minute // 单个上传请求的总超时
+	infoTimeout           = 10 * time.Second // 健康检查、服务器信息等轻量请求的超时
+)
+
+// ProxyDirect 不使用代理 (包括系统代理)
+const ProxyDirect = "direct"
+
+// ConnSettings 与服务器通信的连接设置，零值表示默认 (系统代理和证书、不限速)
+type ConnSettings struct {
+	RateLimitKB    int               `json:"rateLimitKB"`    // 上传限速 (KB/s)，同一服务器的并发上传共享，0 表示不限速
+	ProxyURL       string            `json:"proxyUrl"`       // http://、https:// 或 socks5://，可包含 user:password@；空表示使用系统代理，direct 表示不使用代理
+	CACert         string            `json:"caCert"`         // 额外信任的 CA 证书 (PEM)，用于自签名证书
+	SkipVerify     bool              `json:"skipVerify"`     // 不验证服务器证书，仅用于测试
+	TLSServerName  string            `json:"tlsServerName"`  // 验证证书时使用的主机名，通过 IP 或别名访问时使用
+	ConnectTimeout int               `json:"connectTimeout"` // 建立连接 (含代理和 TLS 握手) 的超时秒数，0 表示默认
+	Timeout        int               `json:"timeout"`        // 单个请求的总超时秒数，0 表示默认
+	Headers        map[string]string `json:"headers"`        // 附加到每个请求的请求头
+}
+
+// reservedHeaders 由上传逻辑设置的请求头，不能自定义
+var reservedHeaders = map[string]bool{
+	"Content-Type":      true,
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"X-Timestamp":       true,
+	"X-Nonce":           true,
+	"X-Signature":       true,
+	"X-Content-Sha256":  true,
+}
+
+// Client 发往一台服务器的请求使用的客户端，按服务器的连接设置创建
+//
+// 同一服务器的请求共用连接池和限速器，文件夹并发上传时限速对所有文件合计生效。
+// 连接设置无效时 (例如导入了无法解析的证书) 每个请求都返回该错误。
+type Client struct {
+	URL string
+
+	http    *http.Client
+	headers http.Header
+	host    string
+	limiter *Limiter
+	timeout time.Duration
+	err     error
+}
+
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[string]*Client)
+)
+
+// maxClients 缓存的客户端数，超过后清空 (连接设置修改后旧的客户端不再使用)
+const maxClients = 64
+
+// ClientFor 获取服务器使用的客户端，相同地址和连接设置的服务器共用一个
+func ClientFor(serverURL string, conn ConnSettings) *Client {
+	fingerprint, _ := json.Marshal(conn)
+	key := serverURL + "\x00" + string(fingerprint)
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if c, ok := clients[key]; ok {
+		return c
+	}
+	if len(clients) >= maxClients {
+		for k, c := range clients {
+			c.http.CloseIdleConnections()
+			delete(clients, k)
+		}
+	}
+	c := newClient(serverURL, conn)
+	clients[key] = c
+	return c
+}
+
+// DefaultClient 使用默认连接设置的客户端 (例如保存服务器前测试地址)
+func DefaultClient(serverURL string) *Client {
+	return ClientFor(serverURL, ConnSettings{})
+}
+
+func newClient(serverURL string, conn ConnSettings) *Client {
+	c := &Client{URL: strings.TrimRight(serverURL, "/"), headers: make(http.Header)}
+
+	transport, err := newTransport(conn)
+	if err != nil {
+		c.err = err
+		transport = &http.Transport{}
+	}
+	c.timeout = DefaultUploadTimeout
+	if conn.Timeout > 0 {
+		c.timeout = time.Duration(conn.Timeout) * time.Second
+	}
+	c.http = &http.Client{Timeout: c.timeout, Transport: transport}
+
+	for name, value := range conn.Headers {
+		name = textproto.CanonicalMIMEHeaderKey(strings.TrimSpace(name))
+		switch {
+		case name == "Host":
+			c.host = value
+		case !reservedHeaders[name]:
+			c.headers.Set(name, value)
+		}
+	}
+	if conn.RateLimitKB > 0 {
+		c.limiter = NewLimiter(int64(conn.RateLimitKB) * 1024)
+	}
+	return c
+}
+
+// newTransport 按连接设置创建 Transport，保持长连接以便并发上传大量小文件时复用连接
+func newTransport(conn ConnSettings) (*http.Transport, error) {
+	connectTimeout := DefaultConnectTimeout
+	if conn.ConnectTimeout > 0 {
+		connectTimeout = time.Duration(conn.ConnectTimeout) * time.Second
+	}
+
+	proxy, err := proxyFunc(conn.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := tlsConfig(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dialer.DialContext,
+		TLSClientConfig:     tlsConfig,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        64,
+		MaxIdleConnsPerHost: MaxWorkers,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: connectTimeout,
+	}, nil
+}
+
+// proxyFunc 解析代理地址，SOCKS5 和 HTTP 代理的认证信息写在地址中
+func proxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	proxyURL = strings.TrimSpace(proxyURL)
+	switch proxyURL {
+	case "":
+		return http.ProxyFromEnvironment, nil
+	case ProxyDirect:
+		return nil, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("代理地址无效: %v", err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("不支持的代理类型: %q (支持 http、https、socks5)", u.Scheme)
+	}
+	if u.Host == "" {
+		return nil, errors.New("代理地址缺少主机")
+	}
+	return http.ProxyURL(u), nil
+}
+
+// tlsConfig 在系统证书之外信任自定义 CA
+func tlsConfig(conn ConnSettings) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         strings.TrimSpace(conn.TLSServerName),
+		InsecureSkipVerify: conn.SkipVerify,
+	}
+	if strings.TrimSpace(conn.CACert) == "" {
+		return cfg, nil
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM([]byte(conn.CACert)) {
+		return nil, errors.New("CA 证书无效: 未找到 PEM 格式的证书")
+	}
+	cfg.RootCAs = pool
+	return cfg, nil
+}
+
+// ValidateConn 检查连接设置，保存服务器前调用
+func ValidateConn(conn ConnSettings) error {
+	if conn.RateLimitKB < 0 || conn.ConnectTimeout < 0 || conn.Timeout < 0 {
+		return errors.New("限速和超时不能为负数")
+	}
+	if _, err := proxyFunc(conn.ProxyURL); err != nil {
+		return err
+	}
+	if _, err := tlsConfig(conn); err != nil {
+		return err
+	}
+	for name, value := range conn.Headers {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, " \t\r\n:") {
+			return fmt.Errorf("请求头名称无效: %q", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("请求头 %s 的值不能包含换行", name)
+		}
+		if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			return fmt.Errorf("请求头 %s 由上传程序设置，不能自定义", name)
+		}
+	}
+	return nil
+}
+
+// do 附加自定义请求头后发送请求
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
+	if c.host != "" {
+		req.Host = c.host
+	}
+	return c.http.Do(req)
+}
+
+// get 发送轻量 GET 请求，超时为 infoTimeout 和连接设置中较短的一个
+func (c *Client) get(ctx context.Context, urlPath string) (*http.Response, error) {
+	timeout := infoTimeout
+	if c.timeout < timeout {
+		timeout = c.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.URL+urlPath, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody 关闭响应体时释放请求的超时 context
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// limit 按限速包装请求体
+func (c *Client) limit(ctx context.Context, r io.Reader) io.Reader {
+	if c.limiter == nil {
+		return r
+	}
+	return &rateReader{ctx: ctx, limiter: c.limiter, reader: r}
+}
+
+// configError 连接设置无效，重试也不会成功
+func (c *Client) configError() *UploadResult {
+	if c.err == nil {
+		return nil
+	}
+	return &UploadResult{Success: false, Error: fmt.Sprintf("连接设置无效: %v", c.err)}
+}
@@ -0,0 +1,91 @@
+package uploader
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrCanceled 上传已取消
+var ErrCanceled = errors.New("上传已取消")
+
+// Gate 暂停控制: 暂停后正在上传的文件停止读取，批量上传不再开始新文件
+type Gate struct {
+	mu     sync.Mutex
+	paused bool
+	resume chan struct{}
+}
+
+// NewGate 创建未暂停的 Gate
+func NewGate() *Gate {
+	return &Gate{}
+}
+
+// Pause 暂停，已暂停时返回 false
+func (g *Gate) Pause() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.paused {
+		return false
+	}
+	g.paused = true
+	g.resume = make(chan struct{})
+	return true
+}
+
+// Resume 恢复，未暂停时返回 false
+func (g *Gate) Resume() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return false
+	}
+	g.paused = false
+	close(g.resume)
+	return true
+}
+
+// Paused 是否处于暂停状态
+func (g *Gate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// Wait 暂停时阻塞直到恢复，ctx 结束时返回 ErrCanceled
+func (g *Gate) Wait(ctx context.Context) error {
+	if g != nil {
+		g.mu.Lock()
+		paused, resume := g.paused, g.resume
+		g.mu.Unlock()
+
+		if paused {
+			select {
+			case <-resume:
+			case <-ctx.Done():
+			}
+		}
+	}
+	if ctx.Err() != nil {
+		return ErrCanceled
+	}
+	return nil
+}
+
+type gateKey struct{}
+
+// WithGate 把暂停控制附加到 ctx，上传函数会在读取文件时遵循它
+func WithGate(ctx context.Context, g *Gate) context.Context {
+	return context.WithValue(ctx, gateKey{}, g)
+}
+
+// gateFrom 取出 ctx 中的暂停控制，没有时返回 nil (不暂停)
+func gateFrom(ctx context.Context) *Gate {
+	g, _ := ctx.Value(gateKey{}).(*Gate)
+	return g
+}
+
+// canceledResult 取消时的上传结果
+func canceledResult() *UploadResult {
+	return &UploadResult{Success: false, Canceled: true, Error: ErrCanceled.Error(), err: ErrCanceled}
+}
@@ -0,0 +1,46 @@
+package uploader
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestGate(t *testing.T) {
+	g := NewGate()
+	if !g.Pause() || g.Pause() || !g.Paused() {
+		t.Fatal("Pause should succeed once and report paused")
+	}
+
+	waited := make(chan error, 1)
+	go func() { waited <- g.Wait(context.Background()) }()
+	select {
+	case <-waited:
+		t.Fatal("Wait returned while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if !g.Resume() || g.Resume() || g.Paused() {
+		t.Fatal("Resume should succeed once and report not paused")
+	}
+	if err := <-waited; err != nil {
+		t.Errorf("Wait after resume = %v", err)
+	}
+
+	// 暂停中取消返回 ErrCanceled
+	g.Pause()
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := g.Wait(ctx); err != ErrCanceled {
+		t.Errorf("Wait after cancel = %v, want ErrCanceled", err)
+	}
+
+	// 没有 Gate 时只检查 ctx
+	var none *Gate
+	if err := none.Wait(context.Background()); err != nil {
+		t.Errorf("nil gate Wait = %v", err)
+	}
+	if err := none.Wait(ctx); err != ErrCanceled {
+		t.Errorf("nil gate Wait with canceled ctx = %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package uploader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DiffEntry 本地与服务器对比的一个文件
+type DiffEntry struct {
+	RelPath    string `json:"relPath"`
+	LocalSize  int64  `json:"localSize"`
+	RemoteSize int64  `json:"remoteSize"`
+	RemoteTime string `json:"remoteTime,omitempty"` // 服务器上文件的修改时间
+
+	File *FileToUpload `json:"-"` // 本地文件，只在服务器上存在的文件为 nil
+}
+
+// Diff 本地文件与服务器文件列表的差异
+type Diff struct {
+	Added      []DiffEntry `json:"added"`      // 服务器上没有的文件
+	Modified   []DiffEntry `json:"modified"`   // 内容不同的文件
+	Deleted    []DiffEntry `json:"deleted"`    // 只在服务器上存在的文件 (上传不会删除)
+	Unchanged  []DiffEntry `json:"unchanged"`  // 内容相同的文件
+	Hidden     []DiffEntry `json:"hidden"`     // 本地的隐藏文件，接收端不接受上传也不列出，不参与对比
+	UploadSize int64       `json:"uploadSize"` // 新增和修改的文件合计大小
+}
+
+// Changed 需要上传的文件 (新增和修改)
+func (d *Diff) Changed() []FileToUpload {
+	files := make([]FileToUpload, 0, len(d.Added)+len(d.Modified))
+	for _, list := range [][]DiffEntry{d.Added, d.Modified} {
+		for _, e := range list {
+			files = append(files, *e.File)
+		}
+	}
+	return files
+}
+
+// Compare 对比本地文件和服务器文件列表，大小相同的文件按 SHA-256 比较
+//
+// 路径中有以 . 开头的文件或目录 (.htaccess、.well-known/ 等) 时放入 Hidden。
+func Compare(local []FileToUpload, remote []RemoteFile) (*Diff, error) {
+	remoteFiles := make(map[string]RemoteFile, len(remote))
+	for _, f := range remote {
+		remoteFiles[f.Path] = f
+	}
+
+	diff := &Diff{
+		Added:     []DiffEntry{},
+		Modified:  []DiffEntry{},
+		Deleted:   []DiffEntry{},
+		Unchanged: []DiffEntry{},
+		Hidden:    []DiffEntry{},
+	}
+	for i := range local {
+		f := &local[i]
+		if IsHiddenPath(f.RelPath) {
+			diff.Hidden = append(diff.Hidden, DiffEntry{RelPath: f.RelPath, LocalSize: f.Size, File: f})
+			continue
+		}
+		rf, ok := remoteFiles[f.RelPath]
+		if !ok {
+			diff.Added = append(diff.Added, DiffEntry{RelPath: f.RelPath, LocalSize: f.Size, File: f})
+			diff.UploadSize += f.Size
+			continue
+		}
+		delete(remoteFiles, f.RelPath)
+
+		entry := DiffEntry{
+			RelPath:    f.RelPath,
+			LocalSize:  f.Size,
+			RemoteSize: rf.Size,
+			RemoteTime: remoteTime(rf),
+			File:       f,
+		}
+		same := f.Size == rf.Size
+		if same {
+			sum, err := FileSHA256(f.AbsPath)
+			if err != nil {
+				return nil, fmt.Errorf("读取文件失败: %v", err)
+			}
+			same = strings.EqualFold(sum, rf.SHA256)
+		}
+		if same {
+			diff.Unchanged = append(diff.Unchanged, entry)
+		} else {
+			diff.Modified = append(diff.Modified, entry)
+			diff.UploadSize += f.Size
+		}
+	}
+	for _, rf := range remote {
+		if _, ok := remoteFiles[rf.Path]; ok {
+			diff.Deleted = append(diff.Deleted, DiffEntry{
+				RelPath:    rf.Path,
+				RemoteSize: rf.Size,
+				RemoteTime: remoteTime(rf),
+			})
+		}
+	}
+
+	for _, list := range [][]DiffEntry{diff.Added, diff.Modified, diff.Deleted, diff.Unchanged, diff.Hidden} {
+		sort.Slice(list, func(i, j int) bool { return list[i].RelPath < list[j].RelPath })
+	}
+	return diff, nil
+}
+
+// IsHiddenPath 判断相对路径中是否有以 . 开头的文件或目录，接收端拒绝上传这样的路径
+func IsHiddenPath(relPath string) bool {
+	for _, part := range strings.FieldsFunc(relPath, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if strings.HasPrefix(part, ".") && part != "." {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteTime 服务器文件修改时间的 RFC 3339 表示
+func remoteTime(f RemoteFile) string {
+	if f.ModTime <= 0 {
+		return ""
+	}
+	return time.Unix(f.ModTime, 0).Format(time.RFC3339)
+}
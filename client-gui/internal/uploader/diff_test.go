@@ -0,0 +1,92 @@
+package uploader
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"client-gui/internal/ignore"
+)
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	local := []FileToUpload{
+		{AbsPath: writeLocalFile(t, dir, "same.txt", "same", now), RelPath: "same.txt", Size: 4},
+		{AbsPath: writeLocalFile(t, dir, "edited.txt", "new!", now), RelPath: "edited.txt", Size: 4},
+		{AbsPath: writeLocalFile(t, dir, "grown.txt", "longer", now), RelPath: "grown.txt", Size: 6},
+		{AbsPath: writeLocalFile(t, dir, "new/a.txt", "a", now), RelPath: "new/a.txt", Size: 1},
+		{AbsPath: writeLocalFile(t, dir, ".well-known/x", "x", now), RelPath: ".well-known/x", Size: 1},
+	}
+	sameHash, err := FileSHA256(local[0].AbsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := []RemoteFile{
+		{Path: "same.txt", Size: 4, SHA256: sameHash},
+		{Path: "edited.txt", Size: 4, SHA256: sameHash},
+		{Path: "grown.txt", Size: 3},
+		{Path: "old.txt", Size: 9, ModTime: 1700000000},
+	}
+
+	diff, err := Compare(local, remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := func(list []DiffEntry) []string {
+		out := []string{}
+		for _, e := range list {
+			out = append(out, e.RelPath)
+		}
+		return out
+	}
+	checks := []struct {
+		name string
+		got  []DiffEntry
+		want []string
+	}{
+		{"Added", diff.Added, []string{"new/a.txt"}},
+		{"Modified", diff.Modified, []string{"edited.txt", "grown.txt"}},
+		{"Deleted", diff.Deleted, []string{"old.txt"}},
+		{"Unchanged", diff.Unchanged, []string{"same.txt"}},
+		{"Hidden", diff.Hidden, []string{".well-known/x"}},
+	}
+	for _, c := range checks {
+		if got := paths(c.got); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s = %q, want %q", c.name, got, c.want)
+		}
+	}
+	if diff.UploadSize != 11 {
+		t.Errorf("UploadSize = %d, want 11", diff.UploadSize)
+	}
+	if diff.Deleted[0].RemoteTime == "" || diff.Deleted[0].File != nil {
+		t.Errorf("Deleted entry = %+v", diff.Deleted[0])
+	}
+	if got := len(diff.Changed()); got != 3 {
+		t.Errorf("Changed() = %d files, want 3", got)
+	}
+}
+
+func TestFilterRemote(t *testing.T) {
+	remote := []RemoteFile{
+		{Path: "index.html"},
+		{Path: "app.js.map"},
+		{Path: "node_modules/dep/index.js"},
+		{Path: "assets/node_modules.txt"},
+	}
+	if got := FilterRemote(remote, nil); len(got) != len(remote) {
+		t.Errorf("nil matcher kept %d files, want %d", len(got), len(remote))
+	}
+
+	exclude, err := ignore.New([]string{"*.map", "node_modules/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, f := range FilterRemote(remote, exclude) {
+		got = append(got, f.Path)
+	}
+	if want := []string{"index.html", "assets/node_modules.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterRemote = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,159 @@
+package uploader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// 服务器错误码 (与接收端 errors.go 保持一致)
+const (
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeInvalidURL       = "INVALID_URL"
+	CodeInvalidParameter = "INVALID_PARAMETER"
+
+	CodeAuthMissing      = "AUTH_MISSING"
+	CodeAuthBadTimestamp = "AUTH_BAD_TIMESTAMP"
+	CodeAuthExpired      = "AUTH_EXPIRED"
+	CodeAuthBadSignature = "AUTH_BAD_SIGNATURE"
+	CodeAuthIPDenied     = "AUTH_IP_DENIED"
+
+	CodeUnknownPathKey  = "UNKNOWN_PATH_KEY"
+	CodeInvalidFilename = "INVALID_FILENAME"
+	CodePathTraversal   = "PATH_TRAVERSAL"
+	CodePathDisabled    = "PATH_DISABLED"
+	CodePathReadOnly    = "PATH_READ_ONLY"
+	CodeFileTypeDenied  = "FILE_TYPE_DENIED"
+	CodeFileExists      = "FILE_EXISTS"
+	CodeFileNotNewer    = "FILE_NOT_NEWER"
+	CodeTooLarge        = "TOO_LARGE"
+
+	CodeChecksumMismatch = "CHECKSUM_MISMATCH"
+
+	CodeExtractFailed = "EXTRACT_FAILED"
+	CodeExtractLimit  = "EXTRACT_LIMIT"
+
+	CodeIOError  = "IO_ERROR"
+	CodeInternal = "INTERNAL_ERROR"
+)
+
+// ServerError 服务器返回的错误
+//
+// 可用 errors.Is 按错误码判断，例如 errors.Is(err, uploader.ErrAuthExpired)。
+type ServerError struct {
+	StatusCode int    // HTTP 状态码
+	Code       string // 稳定的错误码，旧版服务器返回纯文本时为空
+	Message    string
+	RequestID  string
+}
+
+func (e *ServerError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("服务器错误 (%d): %s", e.StatusCode, e.Message)
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("%s: %s (请求ID: %s)", e.Code, e.Message, e.RequestID)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// Is 按错误码比较
+func (e *ServerError) Is(target error) bool {
+	t, ok := target.(*ServerError)
+	return ok && t.Code != "" && t.Code == e.Code
+}
+
+// 常用错误，配合 errors.Is 使用
+var (
+	ErrAuthMissing      = &ServerError{Code: CodeAuthMissing}
+	ErrAuthExpired      = &ServerError{Code: CodeAuthExpired}
+	ErrAuthBadSignature = &ServerError{Code: CodeAuthBadSignature}
+	ErrAuthIPDenied     = &ServerError{Code: CodeAuthIPDenied}
+	ErrUnknownPathKey   = &ServerError{Code: CodeUnknownPathKey}
+	ErrPathTraversal    = &ServerError{Code: CodePathTraversal}
+	ErrPathDisabled     = &ServerError{Code: CodePathDisabled}
+	ErrPathReadOnly     = &ServerError{Code: CodePathReadOnly}
+	ErrFileTypeDenied   = &ServerError{Code: CodeFileTypeDenied}
+	ErrFileExists       = &ServerError{Code: CodeFileExists}
+	ErrFileNotNewer     = &ServerError{Code: CodeFileNotNewer}
+	ErrTooLarge         = &ServerError{Code: CodeTooLarge}
+	ErrExtractFailed    = &ServerError{Code: CodeExtractFailed}
+	ErrExtractLimit     = &ServerError{Code: CodeExtractLimit}
+	ErrChecksumMismatch = &ServerError{Code: CodeChecksumMismatch}
+)
+
+// IsAuthError 判断是否为认证类错误
+func IsAuthError(err error) bool {
+	var se *ServerError
+	if !errors.As(err, &se) {
+		return false
+	}
+	switch se.Code {
+	case CodeAuthMissing, CodeAuthBadTimestamp, CodeAuthExpired, CodeAuthBadSignature, CodeAuthIPDenied:
+		return true
+	}
+	return se.Code == "" && (se.StatusCode == 401 || se.StatusCode == 403)
+}
+
+// errorBody 服务器错误响应体
+type errorBody struct {
+	Status    string `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+// decodeServerError 解析错误响应，兼容旧版服务器的纯文本错误
+func decodeServerError(statusCode int, body []byte) *ServerError {
+	var eb errorBody
+	if err := json.Unmarshal(body, &eb); err == nil && eb.Code != "" {
+		return &ServerError{
+			StatusCode: statusCode,
+			Code:       eb.Code,
+			Message:    eb.Message,
+			RequestID:  eb.RequestID,
+		}
+	}
+	return &ServerError{StatusCode: statusCode, Message: string(body)}
+}
+
+// NetworkError 请求未能完成 (连接失败、超时、响应中断等)，通常值得重试
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("请求失败: %v", e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// IsRetryable 判断失败是否值得重试
+//
+// 网络错误、服务器内部错误、内容校验失败 (传输中损坏) 和没有错误码的 5xx 可以重试；
+// 认证、路径策略、参数错误和本地文件错误重试也不会成功，取消的上传不重试。
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, ErrCanceled) {
+		return false
+	}
+
+	var ne *NetworkError
+	if errors.As(err, &ne) {
+		return true
+	}
+
+	var se *ServerError
+	if !errors.As(err, &se) {
+		return false
+	}
+	switch se.Code {
+	case CodeIOError, CodeInternal, CodeChecksumMismatch:
+		return true
+	case "":
+		return se.StatusCode >= 500 || se.StatusCode == 408 || se.StatusCode == 429
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package uploader
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// Limiter 令牌桶限速器，多个并发的读取者共享同一速率
+//
+// 读取后按实际字节数预留额度，额度不足时等待，保证合计速率不超过设置值。
+type Limiter struct {
+	mu     sync.Mutex
+	rate   float64 // 字节/秒
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter 创建每秒 bytesPerSec 字节的限速器
+func NewLimiter(bytesPerSec int64) *Limiter {
+	return &Limiter{rate: float64(bytesPerSec), last: time.Now()}
+}
+
+// chunk 每次读取的最大字节数，约 1/10 秒的额度，使发送速率平稳
+func (l *Limiter) chunk() int {
+	n := int(l.rate / 10)
+	switch {
+	case n < 1024:
+		return 1024
+	case n > 64*1024:
+		return 64 * 1024
+	}
+	return n
+}
+
+// WaitN 预留 n 个字节的额度，不足时等待，ctx 取消时返回错误
+func (l *Limiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	// 最多积累 1 秒的额度，空闲后不会突然发送大量数据
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// rateReader 限速的 Reader
+type rateReader struct {
+	ctx     context.Context
+	limiter *Limiter
+	reader  io.Reader
+}
+
+func (rr *rateReader) Read(p []byte) (int, error) {
+	if max := rr.limiter.chunk(); len(p) > max {
+		p = p[:max]
+	}
+	n, err := rr.reader.Read(p)
+	if n > 0 {
+		if werr := rr.limiter.WaitN(rr.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
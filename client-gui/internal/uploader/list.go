@@ -0,0 +1,97 @@
+package uploader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"client-gui/internal/ignore"
+)
+
+// RemoteFile 服务器上的文件
+type RemoteFile struct {
+	Path    string `json:"path"` // 相对路径，使用 "/" 分隔
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"` // Unix 秒
+	SHA256  string `json:"sha256"`
+}
+
+// FilterRemote 去掉被 exclude 排除的服务器文件 (exclude 可为 nil)
+//
+// 本地列出文件时跳过的路径在服务器上仍可能存在，对比前用同一套规则过滤，
+// 否则这些文件会被误报为只在服务器上存在。
+func FilterRemote(remote []RemoteFile, exclude *ignore.Matcher) []RemoteFile {
+	if exclude == nil {
+		return remote
+	}
+	kept := make([]RemoteFile, 0, len(remote))
+	for _, f := range remote {
+		if !exclude.Match(f.Path, false) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// listResponse 列出文件接口的响应体
+type listResponse struct {
+	Status string       `json:"status"`
+	Files  []RemoteFile `json:"files"`
+}
+
+// ErrListUnsupported 服务器版本过旧，没有列出文件的接口
+var ErrListUnsupported = errors.New("服务器不支持列出文件，请升级接收端")
+
+// ListRemote 列出服务器上路径标识下 (dir 为空时为根目录) 的文件及其 SHA-256
+//
+// 服务器需要逐个计算哈希，文件多时较慢，使用上传请求的超时而不是轻量请求的超时。
+func ListRemote(ctx context.Context, c *Client, pathKey, dir, privateKey string) ([]RemoteFile, error) {
+	if c.err != nil {
+		return nil, fmt.Errorf("连接设置无效: %v", c.err)
+	}
+
+	urlPath := "/list/" + pathKey
+	if dir = strings.Trim(dir, "/"); dir != "" {
+		urlPath += "/" + dir
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.URL+urlPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %v", err)
+	}
+	if err := signRequest(req, privateKey, urlPath); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, &NetworkError{Err: err}
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, &NetworkError{Err: err}
+	}
+	if resp.StatusCode >= 400 {
+		se := decodeServerError(resp.StatusCode, body)
+		// 旧版服务器把未知路径交给根路径处理，返回 NOT_FOUND
+		if resp.StatusCode == http.StatusNotFound && (se.Code == CodeNotFound || se.Code == "") {
+			return nil, ErrListUnsupported
+		}
+		return nil, se
+	}
+
+	var lr listResponse
+	if err := json.Unmarshal(body, &lr); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %v", err)
+	}
+	if lr.Status != "ok" {
+		return nil, fmt.Errorf("服务器状态异常: %s", lr.Status)
+	}
+	return lr.Files, nil
+}
@@ -0,0 +1,205 @@
+package uploader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 并发上传的工作协程数
+const (
+	DefaultWorkers = 4
+	MaxWorkers     = 16
+)
+
+// 聚合进度回调的最小间隔，避免数千个小文件时事件过多
+const progressInterval = 100 * time.Millisecond
+
+// BatchOptions 批量上传选项，回调可能在多个协程中并发调用
+type BatchOptions struct {
+	Workers     int                                              // 工作协程数，<= 0 时使用 DefaultWorkers
+	OnFileStart func(index, started int, f FileToUpload)         // started 为已开始的文件数 (含本文件)
+	OnFileDone  func(index int, f FileToUpload, r *UploadResult) // 成功或失败都会调用
+	OnProgress  func(sent, total int64)                          // 所有文件汇总后的进度
+}
+
+// BatchResult 单个文件的上传结果，与输入的文件列表一一对应
+type BatchResult struct {
+	File   FileToUpload
+	Result *UploadResult
+}
+
+// NormalizeWorkers 把配置的工作协程数限制在 1 ~ MaxWorkers
+func NormalizeWorkers(n int) int {
+	if n <= 0 {
+		return DefaultWorkers
+	}
+	if n > MaxWorkers {
+		return MaxWorkers
+	}
+	return n
+}
+
+// UploadBatch 使用固定数量的工作协程并发上传文件，保持各自的相对路径
+//
+// 返回结果的顺序与 files 一致，不受完成顺序影响。ctx 取消后正在上传的文件被中断，
+// 尚未开始的文件标记为已取消；ctx 中的 Gate 暂停时不再开始新文件。
+func UploadBatch(ctx context.Context, c *Client, pathKey string, files []FileToUpload, privateKey string, opts BatchOptions) []BatchResult {
+	results := make([]BatchResult, len(files))
+	if len(files) == 0 {
+		return results
+	}
+
+	workers := NormalizeWorkers(opts.Workers)
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	progress := newBatchProgress(files, opts.OnProgress)
+	gate := gateFrom(ctx)
+
+	var mu sync.Mutex
+	started := 0
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				f := files[i]
+
+				if err := gate.Wait(ctx); err != nil {
+					results[i] = BatchResult{File: f, Result: canceledResult()}
+					continue
+				}
+
+				if opts.OnFileStart != nil {
+					mu.Lock()
+					started++
+					n := started
+					mu.Unlock()
+					opts.OnFileStart(i, n, f)
+				}
+
+				result, err := UploadSingleFile(ctx, c, pathKey, f.AbsPath, f.RelPath, privateKey, func(sent, total int64) {
+					progress.update(i, sent)
+				})
+				if err != nil {
+					result = &UploadResult{Success: false, Error: err.Error(), err: err}
+				}
+				// 失败的文件也计为已处理，保证总进度最终到达 100%
+				progress.finish(i)
+
+				results[i] = BatchResult{File: f, Result: result}
+				if opts.OnFileDone != nil {
+					opts.OnFileDone(i, f, result)
+				}
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	progress.flush()
+	return results
+}
+
+// BatchSummary 批量上传结果统计，文件列表均为相对路径并保持输入顺序
+type BatchSummary struct {
+	Success    int
+	Failed     int
+	Canceled   int
+	FirstError string   // 按文件顺序的第一个失败原因 (不含取消)
+	Completed  []string // 已成功上传的文件
+	FailedList []string // 上传失败的文件
+	Skipped    []string // 因取消未上传或被中断的文件
+}
+
+// SummarizeBatch 统计批量上传结果
+func SummarizeBatch(results []BatchResult) BatchSummary {
+	var sum BatchSummary
+	for _, r := range results {
+		switch {
+		case r.Result == nil || r.Result.Canceled:
+			sum.Canceled++
+			sum.Skipped = append(sum.Skipped, r.File.RelPath)
+		case r.Result.Success:
+			sum.Success++
+			sum.Completed = append(sum.Completed, r.File.RelPath)
+		default:
+			sum.Failed++
+			sum.FailedList = append(sum.FailedList, r.File.RelPath)
+			if sum.FirstError == "" {
+				sum.FirstError = fmt.Sprintf("%s: %s", r.File.RelPath, r.Result.Error)
+			}
+		}
+	}
+	return sum
+}
+
+// batchProgress 汇总多个工作协程的进度
+type batchProgress struct {
+	mu       sync.Mutex
+	files    []FileToUpload
+	sent     []int64 // 每个文件已发送的字节数
+	total    int64
+	done     int64 // 所有文件已发送字节数之和
+	last     time.Time
+	callback func(sent, total int64)
+}
+
+func newBatchProgress(files []FileToUpload, callback func(sent, total int64)) *batchProgress {
+	p := &batchProgress{
+		files:    files,
+		sent:     make([]int64, len(files)),
+		callback: callback,
+	}
+	for _, f := range files {
+		p.total += f.Size
+	}
+	return p
+}
+
+// update 记录单个文件的进度，sent 为该文件累计已发送字节数
+func (p *batchProgress) update(i int, sent int64) {
+	if sent > p.files[i].Size {
+		sent = p.files[i].Size
+	}
+
+	p.mu.Lock()
+	p.done += sent - p.sent[i]
+	p.sent[i] = sent
+	p.emitLocked(false)
+	p.mu.Unlock()
+}
+
+// finish 标记文件已处理完
+func (p *batchProgress) finish(i int) {
+	p.update(i, p.files[i].Size)
+}
+
+// flush 发送最终进度
+func (p *batchProgress) flush() {
+	p.mu.Lock()
+	p.emitLocked(true)
+	p.mu.Unlock()
+}
+
+func (p *batchProgress) emitLocked(force bool) {
+	if p.callback == nil {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(p.last) < progressInterval {
+		return
+	}
+	p.last = now
+	p.callback(p.done, p.total)
+}
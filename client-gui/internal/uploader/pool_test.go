@@ -0,0 +1,163 @@
+package uploader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// localFiles 在临时目录创建 n 个文件
+func localFiles(t *testing.T, n int) []FileToUpload {
+	t.Helper()
+	dir := t.TempDir()
+	files := make([]FileToUpload, n)
+	for i := range files {
+		rel := fmt.Sprintf("dir%d/file%d.txt", i%3, i)
+		content := fmt.Sprintf("content of file %d", i)
+		files[i] = FileToUpload{AbsPath: writeLocalFile(t, dir, rel, content, time.Now()), RelPath: rel, Size: int64(len(content))}
+	}
+	return files
+}
+
+func TestNormalizeWorkers(t *testing.T) {
+	tests := []struct{ in, want int }{
+		{-1, DefaultWorkers}, {0, DefaultWorkers}, {1, 1}, {8, 8}, {MaxWorkers, MaxWorkers}, {MaxWorkers + 1, MaxWorkers},
+	}
+	for _, tt := range tests {
+		if got := NormalizeWorkers(tt.in); got != tt.want {
+			t.Errorf("NormalizeWorkers(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestUploadBatch(t *testing.T) {
+	fr, srv, priv := newFakeReceiver(t)
+	release := fr.hold()
+	files := localFiles(t, 10)
+
+	// 所有工作协程都进入请求后再放行，确认并发数达到且不超过设置值
+	go func() {
+		for fr.maxActiveCount() < 3 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		close(release)
+	}()
+
+	var mu sync.Mutex
+	var sent, total int64
+	done := make(map[int]bool)
+	results := UploadBatch(context.Background(), DefaultClient(srv.URL), "web", files, priv, BatchOptions{
+		Workers: 3,
+		OnFileDone: func(index int, f FileToUpload, r *UploadResult) {
+			mu.Lock()
+			done[index] = r.Success
+			mu.Unlock()
+		},
+		OnProgress: func(s, tot int64) {
+			mu.Lock()
+			sent, total = s, tot
+			mu.Unlock()
+		},
+	})
+
+	if got := fr.maxActiveCount(); got != 3 {
+		t.Errorf("max concurrent requests = %d, want 3", got)
+	}
+	for i, r := range results {
+		if r.File.RelPath != files[i].RelPath {
+			t.Errorf("results[%d] = %s, want %s (input order)", i, r.File.RelPath, files[i].RelPath)
+		}
+		if !r.Result.Success || !done[i] {
+			t.Errorf("%s: result = %+v, OnFileDone success = %v", r.File.RelPath, r.Result, done[i])
+		}
+		if got := fr.file(r.File.RelPath); got != fmt.Sprintf("content of file %d", i) {
+			t.Errorf("%s: server content = %q", r.File.RelPath, got)
+		}
+	}
+	if sent != total || total == 0 {
+		t.Errorf("final progress = %d/%d, want complete", sent, total)
+	}
+	if sum := SummarizeBatch(results); sum.Success != len(files) || sum.Failed != 0 || sum.Canceled != 0 {
+		t.Errorf("summary = %+v", sum)
+	}
+}
+
+func TestUploadBatchCancel(t *testing.T) {
+	fr, srv, priv := newFakeReceiver(t)
+	// 测试结束前不放行，正在上传的文件只能被取消中断
+	release := fr.hold()
+	t.Cleanup(func() { close(release) })
+	files := localFiles(t, 6)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for fr.maxActiveCount() < 2 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		cancel()
+	}()
+
+	results := UploadBatch(ctx, DefaultClient(srv.URL), "web", files, priv, BatchOptions{Workers: 2})
+	sum := SummarizeBatch(results)
+	if sum.Canceled != len(files) || sum.Success != 0 || sum.Failed != 0 {
+		t.Errorf("summary = %+v, want all canceled", sum)
+	}
+	if n := fr.requestCount(); n != 2 {
+		t.Errorf("server received %d requests, want 2 (files not started must not be sent)", n)
+	}
+}
+
+func TestUploadBatchPauseResume(t *testing.T) {
+	fr, srv, priv := newFakeReceiver(t)
+	files := localFiles(t, 4)
+
+	gate := NewGate()
+	gate.Pause()
+	ctx := WithGate(context.Background(), gate)
+
+	done := make(chan []BatchResult)
+	go func() {
+		done <- UploadBatch(ctx, DefaultClient(srv.URL), "web", files, priv, BatchOptions{Workers: 2})
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("batch finished while paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+	if n := fr.requestCount(); n != 0 {
+		t.Fatalf("server received %d requests while paused", n)
+	}
+
+	gate.Resume()
+	select {
+	case results := <-done:
+		if sum := SummarizeBatch(results); sum.Success != len(files) {
+			t.Errorf("summary after resume = %+v", sum)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch did not finish after resume")
+	}
+}
+
+func TestSummarizeBatch(t *testing.T) {
+	results := []BatchResult{
+		{File: FileToUpload{RelPath: "a"}, Result: &UploadResult{Success: true}},
+		{File: FileToUpload{RelPath: "b"}, Result: &UploadResult{Error: "boom"}},
+		{File: FileToUpload{RelPath: "c"}, Result: canceledResult()},
+		{File: FileToUpload{RelPath: "d"}, Result: &UploadResult{Error: "second"}},
+		{File: FileToUpload{RelPath: "e"}},
+	}
+	sum := SummarizeBatch(results)
+	if sum.Success != 1 || sum.Failed != 2 || sum.Canceled != 2 {
+		t.Errorf("counts = %d/%d/%d, want 1/2/2", sum.Success, sum.Failed, sum.Canceled)
+	}
+	if sum.FirstError != "b: boom" {
+		t.Errorf("FirstError = %q", sum.FirstError)
+	}
+	if fmt.Sprint(sum.Completed, sum.FailedList, sum.Skipped) != "[a] [b d] [c e]" {
+		t.Errorf("lists = %v %v %v", sum.Completed, sum.FailedList, sum.Skipped)
+	}
+}
@@ -1,16 +1,25 @@
 package uploader
 
 import (
-	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
+	"io/fs"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"client-gui/internal/crypto"
+	"client-gui/internal/ignore"
 )
 
 // UploadResult 上传结果
@@ -24,6 +33,118 @@ type UploadResult struct {
 	Extracted  bool   `json:"extracted"`
 	ExtractDir string `json:"extractDir"`
 	Error      string `json:"error"`
+	Code       string `json:"code"`      // 服务器错误码
+	RequestID  string `json:"requestId"` // 服务器请求 ID，便于对照服务端日志
+	Canceled   bool   `json:"canceled"`  // 被用户取消或程序退出中断
+	HTTPStatus int    `json:"httpStatus"`
+	SHA256     string `json:"sha256"`     // 本地计算的文件 SHA-256，文件未完整发送时为空
+	DurationMs int64  `json:"durationMs"` // 从发送请求到收到响应的耗时
+
+	// 服务器接收时计算的 SHA-256 (旧版服务器为空)，与 SHA256 一致时 Verified 为 true
+	ServerSHA256 string `json:"serverSha256"`
+	Verified     bool   `json:"verified"`
+
+	err error
+}
+
+// headerContentSHA256 声明上传内容的 SHA-256，服务器不一致时拒绝写入
+const headerContentSHA256 = "X-Content-SHA256"
+
+// Err 返回失败原因对应的错误，服务器错误为 *ServerError，成功时为 nil
+func (r *UploadResult) Err() error {
+	if r.Success {
+		return nil
+	}
+	if r.err != nil {
+		return r.err
+	}
+	return errors.New(r.Error)
+}
+
+// FailedResult 由错误构造失败结果，Err() 返回原错误
+func FailedResult(err error) *UploadResult {
+	return &UploadResult{Success: false, Error: err.Error(), err: err}
+}
+
+// serverResponse 服务器成功响应体
+type serverResponse struct {
+	Status     string `json:"status"`
+	RequestID  string `json:"request_id"`
+	Path       string `json:"path"`
+	Size       int64  `json:"size"`
+	PathKey    string `json:"path_key"`
+	Filename   string `json:"filename"`
+	Extracted  bool   `json:"extracted"`
+	ExtractDir string `json:"extract_dir"`
+	SHA256     string `json:"sha256"`
+}
+
+// parseUploadResponse 解析上传响应，错误响应解码为 *ServerError
+func parseUploadResponse(resp *http.Response) *UploadResult {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &UploadResult{Success: false, Error: fmt.Sprintf("读取响应失败: %v", err), HTTPStatus: resp.StatusCode, err: &NetworkError{Err: err}}
+	}
+
+	if resp.StatusCode >= 400 {
+		se := decodeServerError(resp.StatusCode, body)
+		return &UploadResult{
+			Success:    false,
+			Error:      se.Error(),
+			Code:       se.Code,
+			RequestID:  se.RequestID,
+			HTTPStatus: resp.StatusCode,
+			err:        se,
+		}
+	}
+
+	var sr serverResponse
+	if err := json.Unmarshal(body, &sr); err != nil {
+		return &UploadResult{Success: false, Error: fmt.Sprintf("解析响应失败: %v, 原始响应: %s", err, string(body)), HTTPStatus: resp.StatusCode}
+	}
+
+	result := &UploadResult{
+		Success:    resp.StatusCode == 200 && sr.Status == "ok",
+		Status:     sr.Status,
+		Path:       sr.Path,
+		Size:       sr.Size,
+		PathKey:    sr.PathKey,
+		Filename:   sr.Filename,
+		Extracted:  sr.Extracted,
+		ExtractDir: sr.ExtractDir,
+		RequestID:  sr.RequestID,
+		HTTPStatus: resp.StatusCode,
+
+		ServerSHA256: sr.SHA256,
+	}
+	if !result.Success {
+		result.Error = fmt.Sprintf("上传失败: %s", sr.Status)
+	}
+	return result
+}
+
+// verifyHash 比较本地计算的哈希和服务器计算的哈希，不一致时标记为失败
+//
+// 服务器没有返回哈希 (旧版) 或本地没有完整发送时不校验。
+func (r *UploadResult) verifyHash() *UploadResult {
+	if !r.Success || r.SHA256 == "" || r.ServerSHA256 == "" {
+		return r
+	}
+	if strings.EqualFold(r.SHA256, r.ServerSHA256) {
+		r.Verified = true
+		return r
+	}
+	se := &ServerError{
+		StatusCode: r.HTTPStatus,
+		Code:       CodeChecksumMismatch,
+		Message:    fmt.Sprintf("内容校验失败: 服务器收到的数据 SHA-256 为 %s，本地为 %s", r.ServerSHA256, r.SHA256),
+		RequestID:  r.RequestID,
+	}
+	r.Success = false
+	r.Error = se.Error()
+	r.Code = se.Code
+	r.err = se
+	return r
 }
 
 // UploadProgress 上传进度
@@ -34,25 +155,89 @@ type UploadProgress struct {
 	Percent    float64 `json:"percent"`
 }
 
-// progressReader 带进度的 Reader
+// progressReader 带进度的 Reader，暂停时阻塞读取，同时计算 SHA-256
 type progressReader struct {
+	ctx        context.Context
+	gate       *Gate
 	reader     io.Reader
+	hash       hash.Hash
 	total      int64
 	sent       int64
 	onProgress func(sent, total int64)
+	trailer    http.Header // 不为 nil 时读完后把哈希写入 trailer (分块上传)
 }
 
 func (pr *progressReader) Read(p []byte) (int, error) {
+	if err := pr.gate.Wait(pr.ctx); err != nil {
+		return 0, err
+	}
 	n, err := pr.reader.Read(p)
+	if pr.hash != nil {
+		pr.hash.Write(p[:n])
+	}
 	pr.sent += int64(n)
 	if pr.onProgress != nil {
 		pr.onProgress(pr.sent, pr.total)
 	}
+	if err == io.EOF && pr.trailer != nil && pr.hash != nil {
+		pr.trailer.Set(headerContentSHA256, hex.EncodeToString(pr.hash.Sum(nil)))
+	}
 	return n, err
 }
 
-// UploadFile 上传文件
-func UploadFile(serverURL, pathKey, filePath, privateKey string, extract bool, onProgress func(sent, total int64)) (*UploadResult, error) {
+// annotate 记录耗时和文件哈希 (仅在文件完整发送时)，并与服务器计算的哈希比较
+func (pr *progressReader) annotate(result *UploadResult, started time.Time) *UploadResult {
+	result.DurationMs = time.Since(started).Milliseconds()
+	if pr.hash != nil && pr.sent == pr.total {
+		result.SHA256 = hex.EncodeToString(pr.hash.Sum(nil))
+	}
+	return result.verifyHash()
+}
+
+// hashFile 计算已打开文件的 SHA-256 后回到文件开头，用于上传前声明内容哈希
+func hashFile(f *os.File) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// mtimeParam 上传时附带的 mtime 参数 (Unix 秒)，接收端按路径标识的 if-newer 策略比较并保留该时间
+//
+// mtime 不在签名范围内，持有私钥的客户端可以任意指定，所以 if-newer 只用于防止误覆盖。
+func mtimeParam(t time.Time) string {
+	return strconv.FormatInt(t.Unix(), 10)
+}
+
+// UploadFile 上传文件到路径标识根目录，ctx 取消时中断上传
+func UploadFile(ctx context.Context, c *Client, pathKey, filePath, privateKey string, extract bool, onProgress func(sent, total int64)) (*UploadResult, error) {
+	query := url.Values{}
+	if extract {
+		query.Set("extract", "true")
+	}
+	return uploadFile(ctx, c, pathKey, filePath, filepath.Base(filePath), query, privateKey, onProgress)
+}
+
+// UploadSingleFile 上传单个文件（支持指定服务器端相对路径），ctx 取消时中断上传
+func UploadSingleFile(ctx context.Context, c *Client, pathKey, filePath, relPath, privateKey string, onProgress func(sent, total int64)) (*UploadResult, error) {
+	return uploadFile(ctx, c, pathKey, filePath, relPath, url.Values{}, privateKey, onProgress)
+}
+
+// uploadFile 把本地文件上传为服务器上的 remotePath，query 为附加的查询参数
+//
+// 文件先完整计算一次哈希放在 X-Content-SHA256 中，再流式发送，服务器不一致时拒绝写入。
+func uploadFile(ctx context.Context, c *Client, pathKey, filePath, remotePath string, query url.Values, privateKey string, onProgress func(sent, total int64)) (*UploadResult, error) {
+	if r := c.configError(); r != nil {
+		return r, nil
+	}
+	if err := gateFrom(ctx).Wait(ctx); err != nil {
+		return canceledResult(), nil
+	}
+
 	// 读取文件
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -64,88 +249,72 @@ func UploadFile(serverURL, pathKey, filePath, privateKey string, extract bool, o
 	if err != nil {
 		return &UploadResult{Success: false, Error: fmt.Sprintf("无法获取文件信息: %v", err)}, nil
 	}
-
-	filename := filepath.Base(filePath)
-	urlPath := fmt.Sprintf("/upload/%s/%s", pathKey, filename)
-
-	// 构建完整 URL（签名只用路径部分，不包含查询参数）
-	fullURL := serverURL + urlPath
-	if extract {
-		fullURL += "?extract=true"
-	}
-
-	// 创建签名头（签名只用路径部分，与服务器端一致）
-	var timestamp, nonce, signature string
-	if privateKey != "" {
-		timestamp, nonce, signature, err = crypto.CreateSignedHeaders(privateKey, urlPath)
-		if err != nil {
-			return &UploadResult{Success: false, Error: fmt.Sprintf("签名失败: %v", err)}, nil
-		}
-	}
-
-	// 读取文件内容
-	fileContent, err := io.ReadAll(file)
+	contentHash, err := hashFile(file)
 	if err != nil {
 		return &UploadResult{Success: false, Error: fmt.Sprintf("读取文件失败: %v", err)}, nil
 	}
 
-	// 创建带进度的 Reader
+	// 构建完整 URL（签名只用路径部分，不包含查询参数）
+	urlPath := fmt.Sprintf("/upload/%s/%s", pathKey, remotePath)
+	query.Set("mtime", mtimeParam(fileInfo.ModTime()))
+	fullURL := c.URL + urlPath + "?" + query.Encode()
+
+	// 直接从文件流式读取，避免并发上传时把所有文件读入内存
 	pr := &progressReader{
-		reader:     bytes.NewReader(fileContent),
+		ctx:        ctx,
+		gate:       gateFrom(ctx),
+		reader:     c.limit(ctx, file),
+		hash:       sha256.New(),
 		total:      fileInfo.Size(),
 		onProgress: onProgress,
 	}
 
 	// 创建请求
-	req, err := http.NewRequest("POST", fullURL, pr)
+	req, err := http.NewRequestWithContext(ctx, "POST", fullURL, pr)
 	if err != nil {
 		return &UploadResult{Success: false, Error: fmt.Sprintf("创建请求失败: %v", err)}, nil
 	}
 
 	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set(headerContentSHA256, contentHash)
 	req.ContentLength = fileInfo.Size()
-
-	if privateKey != "" {
-		req.Header.Set("X-Timestamp", timestamp)
-		req.Header.Set("X-Nonce", nonce)
-		req.Header.Set("X-Signature", signature)
+	if err := signRequest(req, privateKey, urlPath); err != nil {
+		return &UploadResult{Success: false, Error: err.Error()}, nil
 	}
 
 	// 发送请求
-	client := &http.Client{Timeout: 30 * time.Minute}
-	resp, err := client.Do(req)
+	started := time.Now()
+	resp, err := c.do(req)
 	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("请求失败: %v", err)}, nil
+		if ctx.Err() != nil {
+			return canceledResult(), nil
+		}
+		ne := &NetworkError{Err: err}
+		return &UploadResult{Success: false, Error: ne.Error(), err: ne}, nil
 	}
 	defer resp.Body.Close()
 
-	// 解析响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("读取响应失败: %v", err)}, nil
-	}
+	return pr.annotate(parseUploadResponse(resp), started), nil
+}
 
-	var result UploadResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		// 非 JSON 响应
-		if resp.StatusCode >= 400 {
-			return &UploadResult{Success: false, Error: fmt.Sprintf("服务器错误 (%d): %s", resp.StatusCode, string(body))}, nil
-		}
-		return &UploadResult{Success: false, Error: fmt.Sprintf("解析响应失败: %v, 原始响应: %s", err, string(body))}, nil
+// signRequest 用私钥为请求路径签名 (与服务器端一致，不含查询参数)，privateKey 为空时不签名
+func signRequest(req *http.Request, privateKey, urlPath string) error {
+	if privateKey == "" {
+		return nil
 	}
-
-	result.Success = resp.StatusCode == 200 && result.Status == "ok"
-	if !result.Success && result.Error == "" {
-		result.Error = fmt.Sprintf("上传失败: %s", result.Status)
+	timestamp, nonce, signature, err := crypto.CreateSignedHeaders(privateKey, urlPath)
+	if err != nil {
+		return fmt.Errorf("签名失败: %v", err)
 	}
-
-	return &result, nil
+	req.Header.Set("X-Timestamp", timestamp)
+	req.Header.Set("X-Nonce", nonce)
+	req.Header.Set("X-Signature", signature)
+	return nil
 }
 
 // TestConnection 测试服务器连接
-func TestConnection(serverURL string) error {
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(serverURL + "/health")
+func TestConnection(ctx context.Context, c *Client) error {
+	resp, err := c.get(ctx, "/health")
 	if err != nil {
 		return fmt.Errorf("连接失败: %v", err)
 	}
@@ -169,14 +338,17 @@ func TestConnection(serverURL string) error {
 }
 
 // GetServerInfo 获取服务器信息
-func GetServerInfo(serverURL string) (map[string]interface{}, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(serverURL + "/")
+func GetServerInfo(ctx context.Context, c *Client) (map[string]interface{}, error) {
+	resp, err := c.get(ctx, "/")
 	if err != nil {
 		return nil, fmt.Errorf("连接失败: %v", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("服务器响应异常: %d", resp.StatusCode)
+	}
+
 	body, _ := io.ReadAll(resp.Body)
 	var result map[string]interface{}
 	if err := json.Unmarshal(body, &result); err != nil {
@@ -188,34 +360,64 @@ func GetServerInfo(serverURL string) (map[string]interface{}, error) {
 
 // FileToUpload 待上传的文件信息
 type FileToUpload struct {
-	AbsPath  string // 文件绝对路径
-	RelPath  string // 相对路径（用于服务器端目录结构）
-	Size     int64  // 文件大小
+	AbsPath string // 文件绝对路径
+	RelPath string // 相对路径（用于服务器端目录结构）
+	Size    int64  // 文件大小
+}
+
+// FileSHA256 计算文件的 SHA-256 (十六进制)，与上传结果中的 SHA256 一致
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
-// ListFilesInDir 列出目录中的所有文件
-func ListFilesInDir(dirPath string) ([]FileToUpload, error) {
+// ListFilesInDir 列出目录中的所有文件，跳过 exclude 排除的文件和目录 (exclude 可为 nil)
+func ListFilesInDir(dirPath string, exclude *ignore.Matcher) ([]FileToUpload, error) {
 	var files []FileToUpload
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	err := filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// 跳过目录本身
-		if info.IsDir() {
+		// 获取相对路径
+		relPath, err := filepath.Rel(dirPath, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		relPath = filepath.ToSlash(relPath) // 使用正斜杠
+
+		// 被排除的目录整体跳过，不再遍历其中的文件
+		if exclude.MatchEntry(relPath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
 			return nil
 		}
 
-		// 获取相对路径
-		relPath, err := filepath.Rel(dirPath, path)
+		// 符号链接按指向的文件上传
+		info, err := os.Stat(path)
 		if err != nil {
 			return err
 		}
-
+		if info.IsDir() {
+			return nil
+		}
 		files = append(files, FileToUpload{
 			AbsPath: path,
-			RelPath: filepath.ToSlash(relPath), // 使用正斜杠
+			RelPath: relPath,
 			Size:    info.Size(),
 		})
 
@@ -224,88 +426,3 @@ func ListFilesInDir(dirPath string) ([]FileToUpload, error) {
 
 	return files, err
 }
-
-// UploadSingleFile 上传单个文件（支持指定服务器端相对路径）
-func UploadSingleFile(serverURL, pathKey, filePath, relPath, privateKey string, onProgress func(sent, total int64)) (*UploadResult, error) {
-	// 读取文件
-	file, err := os.Open(filePath)
-	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("无法打开文件: %v", err)}, nil
-	}
-	defer file.Close()
-
-	fileInfo, err := file.Stat()
-	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("无法获取文件信息: %v", err)}, nil
-	}
-
-	// 使用相对路径构建 URL
-	urlPath := fmt.Sprintf("/upload/%s/%s", pathKey, relPath)
-	fullURL := serverURL + urlPath
-
-	// 创建签名头
-	var timestamp, nonce, signature string
-	if privateKey != "" {
-		timestamp, nonce, signature, err = crypto.CreateSignedHeaders(privateKey, urlPath)
-		if err != nil {
-			return &UploadResult{Success: false, Error: fmt.Sprintf("签名失败: %v", err)}, nil
-		}
-	}
-
-	// 读取文件内容
-	fileContent, err := io.ReadAll(file)
-	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("读取文件失败: %v", err)}, nil
-	}
-
-	// 创建带进度的 Reader
-	pr := &progressReader{
-		reader:     bytes.NewReader(fileContent),
-		total:      fileInfo.Size(),
-		onProgress: onProgress,
-	}
-
-	// 创建请求
-	req, err := http.NewRequest("POST", fullURL, pr)
-	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("创建请求失败: %v", err)}, nil
-	}
-
-	req.Header.Set("Content-Type", "application/octet-stream")
-	req.ContentLength = fileInfo.Size()
-
-	if privateKey != "" {
-		req.Header.Set("X-Timestamp", timestamp)
-		req.Header.Set("X-Nonce", nonce)
-		req.Header.Set("X-Signature", signature)
-	}
-
-	// 发送请求
-	client := &http.Client{Timeout: 30 * time.Minute}
-	resp, err := client.Do(req)
-	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("请求失败: %v", err)}, nil
-	}
-	defer resp.Body.Close()
-
-	// 解析响应
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return &UploadResult{Success: false, Error: fmt.Sprintf("读取响应失败: %v", err)}, nil
-	}
-
-	var result UploadResult
-	if err := json.Unmarshal(body, &result); err != nil {
-		if resp.StatusCode >= 400 {
-			return &UploadResult{Success: false, Error: fmt.Sprintf("服务器错误 (%d): %s", resp.StatusCode, string(body))}, nil
-		}
-		return &UploadResult{Success: false, Error: fmt.Sprintf("解析响应失败: %v", err, string(body))}, nil
-	}
-
-	result.Success = resp.StatusCode == 200 && result.Status == "ok"
-	if !result.Success && result.Error == "" {
-		result.Error = fmt.Sprintf("上传失败: %s", result.Status)
-	}
-
-	return &result, nil
-}
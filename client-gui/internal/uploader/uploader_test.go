@@ -0,0 +1,333 @@
+package uploader
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"client-gui/internal/crypto"
+)
+
+// fakeReceiver 模拟接收端的上传接口: 校验签名和 X-Content-SHA256 (请求头或 trailer)，记录收到的文件
+type fakeReceiver struct {
+	publicKey string
+	wrongHash bool          // 响应中返回错误的哈希，模拟传输中损坏
+	release   chan struct{} // 不为 nil 时读取请求体前等待，用于测试并发和取消
+
+	mu        sync.Mutex
+	files     map[string][]byte // 服务器上的相对路径 -> 内容
+	requests  []*http.Request
+	active    int
+	maxActive int
+}
+
+// newFakeReceiver 启动模拟接收端，返回服务器和签名用的私钥
+func newFakeReceiver(t *testing.T) (*fakeReceiver, *httptest.Server, string) {
+	t.Helper()
+	priv, pub, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fr := &fakeReceiver{publicKey: pub, files: make(map[string][]byte)}
+	srv := httptest.NewServer(fr)
+	t.Cleanup(srv.Close)
+	return fr, srv, priv
+}
+
+func (fr *fakeReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fr.mu.Lock()
+	fr.requests = append(fr.requests, r)
+	release := fr.release
+	fr.active++
+	if fr.active > fr.maxActive {
+		fr.maxActive = fr.active
+	}
+	fr.mu.Unlock()
+	defer func() {
+		fr.mu.Lock()
+		fr.active--
+		fr.mu.Unlock()
+	}()
+
+	message := r.Header.Get("X-Timestamp") + r.Header.Get("X-Nonce") + r.URL.Path
+	if !crypto.Verify(fr.publicKey, message, r.Header.Get("X-Signature")) {
+		writeFakeError(w, http.StatusForbidden, CodeAuthBadSignature)
+		return
+	}
+	rel := strings.TrimPrefix(r.URL.Path, "/upload/web/")
+	if rel == r.URL.Path {
+		writeFakeError(w, http.StatusNotFound, CodeUnknownPathKey)
+		return
+	}
+
+	if release != nil {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return
+	}
+	sum := sha256.Sum256(body)
+	got := hex.EncodeToString(sum[:])
+	// 分块上传的哈希在读完请求体后才出现在 trailer 中
+	want := r.Header.Get(headerContentSHA256)
+	if want == "" {
+		want = r.Trailer.Get(headerContentSHA256)
+	}
+	if want != got {
+		writeFakeError(w, http.StatusBadRequest, CodeChecksumMismatch)
+		return
+	}
+
+	fr.mu.Lock()
+	fr.files[rel] = body
+	wrongHash := fr.wrongHash
+	fr.mu.Unlock()
+	if wrongHash {
+		got = strings.Repeat("0", 64)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok", "path_key": "web", "filename": rel, "size": len(body), "sha256": got,
+	})
+}
+
+func writeFakeError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"status": "error", "code": code, "message": code, "request_id": "req-1"})
+}
+
+// lastRequest 最近收到的请求
+func (fr *fakeReceiver) lastRequest(t *testing.T) *http.Request {
+	t.Helper()
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	if len(fr.requests) == 0 {
+		t.Fatal("no request received")
+	}
+	return fr.requests[len(fr.requests)-1]
+}
+
+// file 服务器上收到的文件内容
+func (fr *fakeReceiver) file(rel string) string {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return string(fr.files[rel])
+}
+
+// hold 之后的请求在读取请求体前等待，关闭返回的 channel 后放行
+func (fr *fakeReceiver) hold() chan struct{} {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	fr.release = make(chan struct{})
+	return fr.release
+}
+
+// maxActiveCount 同时处理中的最大请求数
+func (fr *fakeReceiver) maxActiveCount() int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return fr.maxActive
+}
+
+func (fr *fakeReceiver) requestCount() int {
+	fr.mu.Lock()
+	defer fr.mu.Unlock()
+	return len(fr.requests)
+}
+
+// writeLocalFile 在临时目录写入文件并设置修改时间
+func writeLocalFile(t *testing.T, dir, rel, content string, mtime time.Time) string {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestUploadFile(t *testing.T) {
+	fr, srv, priv := newFakeReceiver(t)
+	dir := t.TempDir()
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	local := writeLocalFile(t, dir, "dist.zip", "archive content", mtime)
+
+	tests := []struct {
+		name        string
+		upload      func() (*UploadResult, error)
+		remote      string
+		wantExtract string
+	}{
+		{
+			name: "UploadFile",
+			upload: func() (*UploadResult, error) {
+				return UploadFile(context.Background(), DefaultClient(srv.URL), "web", local, priv, false, nil)
+			},
+			remote: "dist.zip",
+		},
+		{
+			name: "UploadFile extract",
+			upload: func() (*UploadResult, error) {
+				return UploadFile(context.Background(), DefaultClient(srv.URL), "web", local, priv, true, nil)
+			},
+			remote:      "dist.zip",
+			wantExtract: "true",
+		},
+		{
+			name: "UploadSingleFile",
+			upload: func() (*UploadResult, error) {
+				return UploadSingleFile(context.Background(), DefaultClient(srv.URL), "web", local, "assets/js/dist.zip", priv, nil)
+			},
+			remote: "assets/js/dist.zip",
+		},
+	}
+	wantHash, err := FileSHA256(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		result, err := tt.upload()
+		if err != nil || !result.Success {
+			t.Errorf("%s: result = %+v, err = %v", tt.name, result, err)
+			continue
+		}
+		if !result.Verified || result.SHA256 != wantHash {
+			t.Errorf("%s: Verified = %v SHA256 = %s, want verified %s", tt.name, result.Verified, result.SHA256, wantHash)
+		}
+		if got := fr.file(tt.remote); got != "archive content" {
+			t.Errorf("%s: server file %s = %q", tt.name, tt.remote, got)
+		}
+
+		// 两个入口发送相同的请求头和 mtime 参数
+		req := fr.lastRequest(t)
+		if ct := req.Header.Get("Content-Type"); ct != "application/octet-stream" {
+			t.Errorf("%s: Content-Type = %q", tt.name, ct)
+		}
+		if h := req.Header.Get(headerContentSHA256); h != wantHash {
+			t.Errorf("%s: %s = %q, want %q", tt.name, headerContentSHA256, h, wantHash)
+		}
+		q := req.URL.Query()
+		if q.Get("mtime") != strconv.FormatInt(mtime.Unix(), 10) {
+			t.Errorf("%s: mtime = %q, want %d", tt.name, q.Get("mtime"), mtime.Unix())
+		}
+		if q.Get("extract") != tt.wantExtract {
+			t.Errorf("%s: extract = %q, want %q", tt.name, q.Get("extract"), tt.wantExtract)
+		}
+	}
+}
+
+func TestUploadFileErrors(t *testing.T) {
+	fr, srv, priv := newFakeReceiver(t)
+	local := writeLocalFile(t, t.TempDir(), "a.txt", "hello", time.Now())
+	otherPriv, _, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		client    *Client
+		file      string
+		key       string
+		wrongHash bool
+		wantErr   error
+		retryable bool
+	}{
+		{name: "bad signature", client: DefaultClient(srv.URL), file: local, key: otherPriv, wantErr: ErrAuthBadSignature},
+		{name: "checksum mismatch", client: DefaultClient(srv.URL), file: local, key: priv, wrongHash: true, wantErr: ErrChecksumMismatch, retryable: true},
+		{name: "missing file", client: DefaultClient(srv.URL), file: local + ".missing", key: priv},
+		{name: "invalid conn", client: ClientFor(srv.URL, ConnSettings{ProxyURL: "ftp://proxy"}), file: local, key: priv},
+		{name: "server down", client: DefaultClient("http://127.0.0.1:1"), file: local, key: priv, retryable: true},
+	}
+	for _, tt := range tests {
+		fr.mu.Lock()
+		fr.wrongHash = tt.wrongHash
+		fr.mu.Unlock()
+		result, err := UploadSingleFile(context.Background(), tt.client, "web", tt.file, "a.txt", tt.key, nil)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if result.Success || result.Error == "" {
+			t.Errorf("%s: result = %+v, want failure", tt.name, result)
+			continue
+		}
+		if tt.wantErr != nil && !errors.Is(result.Err(), tt.wantErr) {
+			t.Errorf("%s: Err() = %v, want %v", tt.name, result.Err(), tt.wantErr)
+		}
+		if got := IsRetryable(result.Err()); got != tt.retryable {
+			t.Errorf("%s: IsRetryable = %v, want %v", tt.name, got, tt.retryable)
+		}
+	}
+}
+
+func TestUploadFileCanceledWhilePaused(t *testing.T) {
+	fr, srv, priv := newFakeReceiver(t)
+	local := writeLocalFile(t, t.TempDir(), "a.txt", "hello", time.Now())
+
+	gate := NewGate()
+	gate.Pause()
+	ctx, cancel := context.WithCancel(WithGate(context.Background(), gate))
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	result, err := UploadFile(ctx, DefaultClient(srv.URL), "web", local, priv, false, nil)
+	if err != nil || !result.Canceled || !errors.Is(result.Err(), ErrCanceled) {
+		t.Fatalf("result = %+v, err = %v, want canceled", result, err)
+	}
+	if n := fr.requestCount(); n != 0 {
+		t.Errorf("server received %d requests while paused", n)
+	}
+}
+
+func TestListRemote(t *testing.T) {
+	priv, pub, err := crypto.GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		message := r.Header.Get("X-Timestamp") + r.Header.Get("X-Nonce") + r.URL.Path
+		switch {
+		case r.URL.Path == "/list/old":
+			http.Error(w, "404 page not found", http.StatusNotFound)
+		case !crypto.Verify(pub, message, r.Header.Get("X-Signature")):
+			writeFakeError(w, http.StatusForbidden, CodeAuthBadSignature)
+		default:
+			json.NewEncoder(w).Encode(listResponse{Status: "ok", Files: []RemoteFile{{Path: strings.TrimPrefix(r.URL.Path, "/list/web/") + "/a.txt", Size: 1}}})
+		}
+	}))
+	defer srv.Close()
+	c := DefaultClient(srv.URL)
+
+	files, err := ListRemote(context.Background(), c, "web", "/static/", priv)
+	if err != nil || len(files) != 1 || files[0].Path != "static/a.txt" {
+		t.Errorf("ListRemote = %+v, %v", files, err)
+	}
+	if _, err := ListRemote(context.Background(), c, "old", "", priv); err != ErrListUnsupported {
+		t.Errorf("old server error = %v, want ErrListUnsupported", err)
+	}
+	if _, err := ListRemote(context.Background(), c, "web", "", ""); !IsAuthError(err) {
+		t.Errorf("unsigned error = %v, want auth error", err)
+	}
+}
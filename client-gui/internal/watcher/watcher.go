@@ -0,0 +1,345 @@
+package watcher
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"client-gui/internal/ignore"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Config 单个监控任务的配置
+type Config struct {
+	ID         string
+	FolderPath string
+	Patterns   []string // 文件名 glob，空表示所有文件
+	Excludes   []string // 追加到目录中 .deployignore 的排除规则
+	DebounceMs int      // 最后一次变化后等待多久再上传
+}
+
+// ChangedFile 发生变化的文件
+type ChangedFile struct {
+	AbsPath string // 文件绝对路径
+	RelPath string // 相对监控目录的路径 (正斜杠)
+}
+
+// Handler 处理一批去抖后的变化，在每个监控任务自己的协程中顺序调用
+type Handler func(cfg Config, files []ChangedFile)
+
+// ErrorHandler 监控过程中的错误回调
+type ErrorHandler func(cfg Config, err error)
+
+// Manager 管理所有监控任务
+type Manager struct {
+	mu       sync.Mutex
+	watchers map[string]*folderWatcher
+	handler  Handler
+	onError  ErrorHandler
+}
+
+// NewManager 创建监控管理器
+func NewManager(handler Handler, onError ErrorHandler) *Manager {
+	return &Manager{
+		watchers: make(map[string]*folderWatcher),
+		handler:  handler,
+		onError:  onError,
+	}
+}
+
+// Start 启动监控任务，同 ID 的任务已在运行时先停止再按新配置启动
+func (m *Manager) Start(cfg Config) error {
+	info, err := os.Stat(cfg.FolderPath)
+	if err != nil {
+		return fmt.Errorf("无法访问监控目录: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("监控路径不是文件夹: %s", cfg.FolderPath)
+	}
+	if cfg.DebounceMs <= 0 {
+		cfg.DebounceMs = 1000
+	}
+
+	m.Stop(cfg.ID)
+
+	fw, err := newFolderWatcher(cfg, m.handler, m.onError)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.watchers[cfg.ID] = fw
+	m.mu.Unlock()
+	return nil
+}
+
+// Stop 停止监控任务
+func (m *Manager) Stop(id string) {
+	m.mu.Lock()
+	fw, ok := m.watchers[id]
+	delete(m.watchers, id)
+	m.mu.Unlock()
+
+	if ok {
+		fw.close()
+	}
+}
+
+// StopAll 停止所有监控任务
+func (m *Manager) StopAll() {
+	m.mu.Lock()
+	watchers := m.watchers
+	m.watchers = make(map[string]*folderWatcher)
+	m.mu.Unlock()
+
+	for _, fw := range watchers {
+		fw.close()
+	}
+}
+
+// Running 返回正在运行的监控任务 ID
+func (m *Manager) Running() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ids := make([]string, 0, len(m.watchers))
+	for id := range m.watchers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// IsRunning 判断监控任务是否在运行
+func (m *Manager) IsRunning(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.watchers[id]
+	return ok
+}
+
+// folderWatcher 单个目录的递归监控
+type folderWatcher struct {
+	cfg     Config
+	fs      *fsnotify.Watcher
+	handler Handler
+	onError ErrorHandler
+	exclude *ignore.Matcher // 只在 loop 协程中读写
+
+	pending map[string]string // 相对路径 -> 绝对路径
+	batches chan []ChangedFile
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newFolderWatcher(cfg Config, handler Handler, onError ErrorHandler) (*folderWatcher, error) {
+	exclude, err := ignore.Load(cfg.FolderPath, cfg.Excludes)
+	if err != nil {
+		return nil, err
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("创建监控失败: %v", err)
+	}
+
+	fw := &folderWatcher{
+		cfg:     cfg,
+		fs:      fsw,
+		handler: handler,
+		onError: onError,
+		exclude: exclude,
+		pending: make(map[string]string),
+		batches: make(chan []ChangedFile, 16),
+		done:    make(chan struct{}),
+	}
+
+	if err := fw.addRecursive(cfg.FolderPath); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+
+	fw.wg.Add(2)
+	go fw.loop()
+	go fw.dispatch()
+	return fw, nil
+}
+
+func (fw *folderWatcher) close() {
+	close(fw.done)
+	fw.fs.Close()
+	fw.wg.Wait()
+}
+
+// addRecursive 监控目录及其所有子目录 (fsnotify 本身不递归)，跳过被排除的目录
+func (fw *folderWatcher) addRecursive(root string) error {
+	return filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			// 子目录无权限等错误不影响其他目录
+			if p == root {
+				return err
+			}
+			return nil
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		if rel, err := filepath.Rel(fw.cfg.FolderPath, p); err == nil && fw.exclude.Match(rel, true) {
+			return filepath.SkipDir
+		}
+		if err := fw.fs.Add(p); err != nil {
+			return fmt.Errorf("监控目录失败 %s: %v", p, err)
+		}
+		return nil
+	})
+}
+
+// loop 接收文件系统事件，去抖后把一批变化交给 dispatch
+func (fw *folderWatcher) loop() {
+	defer fw.wg.Done()
+	defer close(fw.batches)
+
+	debounce := time.Duration(fw.cfg.DebounceMs) * time.Millisecond
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-fw.done:
+			timer.Stop()
+			return
+
+		case event, ok := <-fw.fs.Events:
+			if !ok {
+				return
+			}
+			if fw.handleEvent(event) {
+				timer.Reset(debounce)
+			}
+
+		case err, ok := <-fw.fs.Errors:
+			if !ok {
+				return
+			}
+			if fw.onError != nil {
+				fw.onError(fw.cfg, err)
+			}
+
+		case <-timer.C:
+			if len(fw.pending) == 0 {
+				continue
+			}
+			batch := make([]ChangedFile, 0, len(fw.pending))
+			for rel, abs := range fw.pending {
+				batch = append(batch, ChangedFile{AbsPath: abs, RelPath: rel})
+			}
+			fw.pending = make(map[string]string)
+
+			select {
+			case fw.batches <- batch:
+			case <-fw.done:
+				return
+			}
+		}
+	}
+}
+
+// handleEvent 记录一个事件，返回是否需要重置去抖计时
+func (fw *folderWatcher) handleEvent(event fsnotify.Event) bool {
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return false
+	}
+
+	info, err := os.Stat(event.Name)
+	if err != nil {
+		// 临时文件写完即删除等情况
+		return false
+	}
+
+	// 排除规则修改后立即生效
+	if !info.IsDir() && filepath.Join(fw.cfg.FolderPath, ignore.FileName) == filepath.Clean(event.Name) {
+		if exclude, err := ignore.Load(fw.cfg.FolderPath, fw.cfg.Excludes); err != nil {
+			if fw.onError != nil {
+				fw.onError(fw.cfg, err)
+			}
+		} else {
+			fw.exclude = exclude
+		}
+	}
+
+	// 新建的目录: 加入监控，并把其中已有的文件视为变化 (整目录复制进来的情况)
+	if info.IsDir() {
+		if event.Has(fsnotify.Create) {
+			if err := fw.addRecursive(event.Name); err != nil && fw.onError != nil {
+				fw.onError(fw.cfg, err)
+			}
+			added := false
+			filepath.Walk(event.Name, func(p string, fi os.FileInfo, err error) error {
+				if err == nil && !fi.IsDir() && fw.record(p) {
+					added = true
+				}
+				return nil
+			})
+			return added
+		}
+		return false
+	}
+
+	return fw.record(event.Name)
+}
+
+// record 按模式和排除规则过滤后加入待上传列表
+func (fw *folderWatcher) record(absPath string) bool {
+	rel, err := filepath.Rel(fw.cfg.FolderPath, absPath)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if !MatchPatterns(rel, fw.cfg.Patterns) || fw.exclude.Match(rel, false) {
+		return false
+	}
+	fw.pending[rel] = absPath
+	return true
+}
+
+// dispatch 顺序处理每一批变化，避免同一任务的上传并发交错
+func (fw *folderWatcher) dispatch() {
+	defer fw.wg.Done()
+	for batch := range fw.batches {
+		if fw.handler != nil {
+			fw.handler(fw.cfg, batch)
+		}
+	}
+}
+
+// MatchPatterns 判断相对路径是否匹配任一模式，模式为空时匹配所有文件
+//
+// 不含 "/" 的模式匹配文件名 (如 *.js)，含 "/" 的模式匹配完整相对路径 (如 assets/*.css)，
+// "**/" 前缀表示任意层级。
+func MatchPatterns(relPath string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	relPath = filepath.ToSlash(relPath)
+	base := path.Base(relPath)
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(filepath.ToSlash(pattern))
+		if pattern == "" {
+			continue
+		}
+		pattern = strings.TrimPrefix(pattern, "**/")
+
+		target := base
+		if strings.Contains(pattern, "/") {
+			target = relPath
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMatchPatterns(t *testing.T) {
+	tests := []struct {
+		rel      string
+		patterns []string
+		want     bool
+	}{
+		{"index.html", nil, true},
+		{"app.js", []string{"*.js"}, true},
+		{"lib/app.js", []string{"*.js"}, true},
+		{"app.css", []string{"*.js"}, false},
+		{"assets/site.css", []string{"assets/*.css"}, true},
+		{"other/site.css", []string{"assets/*.css"}, false},
+		{"deep/assets/x.js", []string{"**/x.js"}, true},
+		{"app.js", []string{" ", ""}, false},
+	}
+	for _, tt := range tests {
+		if got := MatchPatterns(tt.rel, tt.patterns); got != tt.want {
+			t.Errorf("MatchPatterns(%q, %q) = %v, want %v", tt.rel, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerBatchesChanges(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, ".deployignore"), "node_modules/\n")
+
+	batches := make(chan []ChangedFile, 16)
+	m := NewManager(func(cfg Config, files []ChangedFile) { batches <- files }, nil)
+	defer m.StopAll()
+
+	cfg := Config{ID: "w1", FolderPath: dir, Patterns: []string{"*.js"}, Excludes: []string{"*.min.js"}, DebounceMs: 50}
+	if err := m.Start(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !m.IsRunning("w1") || !reflect.DeepEqual(m.Running(), []string{"w1"}) {
+		t.Fatalf("Running = %v", m.Running())
+	}
+
+	writeFile(t, filepath.Join(dir, "app.js"), "a")
+	writeFile(t, filepath.Join(dir, "style.css"), "c")
+	writeFile(t, filepath.Join(dir, "vendor.min.js"), "m")
+	writeFile(t, filepath.Join(dir, "node_modules", "dep.js"), "d")
+	// 新建的目录连同其中的文件一起出现 (整目录复制)
+	writeFile(t, filepath.Join(dir, "lib", "util.js"), "u")
+
+	want := []string{"app.js", "lib/util.js"}
+	seen := make(map[string]string)
+	timeout := time.After(5 * time.Second)
+	for len(seen) < len(want) {
+		select {
+		case batch := <-batches:
+			for _, f := range batch {
+				seen[f.RelPath] = f.AbsPath
+			}
+		case <-timeout:
+			t.Fatalf("timed out, changes seen: %v", seen)
+		}
+	}
+	// 多等一个去抖周期，确认被过滤的文件不会稍后出现
+	select {
+	case batch := <-batches:
+		for _, f := range batch {
+			seen[f.RelPath] = f.AbsPath
+		}
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	var got []string
+	for rel, abs := range seen {
+		got = append(got, rel)
+		if abs != filepath.Join(dir, filepath.FromSlash(rel)) {
+			t.Errorf("%s: AbsPath = %s", rel, abs)
+		}
+	}
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changed files = %v, want %v", got, want)
+	}
+
+	m.Stop("w1")
+	if m.IsRunning("w1") {
+		t.Error("watch still running after Stop")
+	}
+}
+
+func TestManagerStartErrors(t *testing.T) {
+	m := NewManager(nil, nil)
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file.txt")
+	writeFile(t, file, "x")
+
+	for _, path := range []string{filepath.Join(dir, "missing"), file} {
+		if err := m.Start(Config{ID: "w", FolderPath: path}); err == nil {
+			t.Errorf("Start(%s) should fail", path)
+		}
+	}
+	if len(m.Running()) != 0 {
+		t.Errorf("Running = %v, want none", m.Running())
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+// trayUnsupported 其他平台的托盘需要与 Wails 共用主线程的事件循环，暂不支持
+const trayUnsupported = "当前系统不支持托盘，关闭窗口会停止文件夹监控、定时任务和上传队列；需要在后台持续运行时使用 daemon 命令"
+
+// startTray 不支持托盘，关闭窗口即退出
+func (a *App) startTray() {}
+
+func (a *App) stopTray() {}
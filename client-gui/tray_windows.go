@@ -0,0 +1,165 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	goruntime "runtime"
+	"time"
+
+	"client-gui/internal/database"
+
+	"github.com/getlantern/systray"
+)
+
+//go:embed build/windows/icon.ico
+var trayIcon []byte
+
+// trayUnsupported Windows 支持托盘
+const trayUnsupported = ""
+
+// trayRefreshInterval 刷新托盘菜单中最近上传和监控状态的间隔 (包括后台服务的上传)
+const trayRefreshInterval = 5 * time.Second
+
+// trayMenu 托盘菜单项，systray 不能删除菜单项，最近上传使用固定数量的菜单项显示或隐藏
+type trayMenu struct {
+	open   *systray.MenuItem
+	pause  *systray.MenuItem
+	recent *systray.MenuItem
+	empty  *systray.MenuItem
+	slots  []*systray.MenuItem
+	quit   *systray.MenuItem
+}
+
+// startTray 在单独的线程中运行托盘的消息循环，托盘创建失败时关闭窗口即退出
+func (a *App) startTray() {
+	go func() {
+		// 托盘窗口的消息只能由创建它的线程处理
+		goruntime.LockOSThread()
+		systray.Run(a.onTrayReady, nil)
+	}()
+}
+
+func (a *App) stopTray() {
+	if a.trayRunning.Swap(false) {
+		systray.Quit()
+	}
+}
+
+func (a *App) onTrayReady() {
+	systray.SetIcon(trayIcon)
+	systray.SetTitle("Deploy Receiver Client")
+	systray.SetTooltip("Deploy Receiver Client")
+
+	m := &trayMenu{}
+	m.open = systray.AddMenuItem("打开窗口", "显示主窗口")
+	m.pause = systray.AddMenuItemCheckbox("暂停所有监控", "暂停或恢复所有文件夹监控", false)
+	m.recent = systray.AddMenuItem("最近上传", "")
+	m.empty = m.recent.AddSubMenuItem("暂无上传记录", "")
+	m.empty.Disable()
+	for i := 0; i < trayRecentCount; i++ {
+		item := m.recent.AddSubMenuItem("", "")
+		item.Hide()
+		m.slots = append(m.slots, item)
+	}
+	systray.AddSeparator()
+	m.quit = systray.AddMenuItem("退出", "停止监控和定时任务并退出")
+
+	a.trayRunning.Store(true)
+	go a.trayLoop(a.refreshTray(m), m)
+}
+
+// trayLoop 处理菜单点击并定期刷新
+func (a *App) trayLoop(recent []database.HistoryEntry, m *trayMenu) {
+	ticker := time.NewTicker(trayRefreshInterval)
+	defer ticker.Stop()
+
+	// 每个最近上传菜单项点击后打开窗口并定位到历史记录
+	clicked := make(chan int)
+	for i, item := range m.slots {
+		go func(i int, item *systray.MenuItem) {
+			for range item.ClickedCh {
+				clicked <- i
+			}
+		}(i, item)
+	}
+
+	for {
+		select {
+		case <-m.open.ClickedCh:
+			a.showWindow()
+		case <-m.pause.ClickedCh:
+			var err error
+			if a.watchesPaused.Load() {
+				err = a.ResumeWatches()
+			} else {
+				err = a.PauseWatches()
+			}
+			if err != nil {
+				a.host.Notify("无法暂停监控", err.Error())
+			}
+			recent = a.refreshTray(m)
+		case i := <-clicked:
+			a.showWindow()
+			if i < len(recent) {
+				a.host.Emit("tray:history", recent[i].ID)
+			}
+		case <-m.quit.ClickedCh:
+			a.quit()
+			return
+		case <-ticker.C:
+			recent = a.refreshTray(m)
+		}
+	}
+}
+
+// refreshTray 更新监控暂停状态和最近上传，返回显示的上传记录
+func (a *App) refreshTray(m *trayMenu) []database.HistoryEntry {
+	if a.watchesPaused.Load() {
+		m.pause.Check()
+		systray.SetTooltip("Deploy Receiver Client - 监控已暂停")
+	} else {
+		m.pause.Uncheck()
+		systray.SetTooltip("Deploy Receiver Client")
+	}
+	if a.engines.Load() {
+		m.pause.Enable()
+	} else {
+		// 监控由后台服务运行
+		m.pause.Disable()
+	}
+
+	entries, err := a.db.GetHistory(trayRecentCount)
+	if err != nil {
+		return nil
+	}
+	if len(entries) == 0 {
+		m.empty.Show()
+	} else {
+		m.empty.Hide()
+	}
+	for i, item := range m.slots {
+		if i >= len(entries) {
+			item.Hide()
+			continue
+		}
+		h := entries[i]
+		mark := "✓"
+		if h.Status != "success" {
+			mark = "✗"
+		}
+		item.SetTitle(fmt.Sprintf("%s %s → %s", mark, h.Filename, h.ServerName))
+		item.SetTooltip(trayTime(h.UploadedAt) + " " + h.ErrorMsg)
+		item.Show()
+	}
+	return entries
+}
+
+// trayTime 上传时间 (数据库中为 UTC) 显示为本地时间
+func trayTime(s string) string {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Local().Format("01-02 15:04")
+		}
+	}
+	return s
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// 错误码 (对外稳定，客户端和 CI 脚本依赖这些值，只增不改)
+const (
+	CodeMethodNotAllowed = "METHOD_NOT_ALLOWED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeInvalidURL       = "INVALID_URL"
+	CodeInvalidParameter = "INVALID_PARAMETER"
+
+	CodeAuthMissing      = "AUTH_MISSING"
+	CodeAuthBadTimestamp = "AUTH_BAD_TIMESTAMP"
+	CodeAuthExpired      = "AUTH_EXPIRED"
+	CodeAuthBadSignature = "AUTH_BAD_SIGNATURE"
+	CodeAuthIPDenied     = "AUTH_IP_DENIED"
+
+	CodeUnknownPathKey  = "UNKNOWN_PATH_KEY"
+	CodeInvalidFilename = "INVALID_FILENAME"
+	CodePathTraversal   = "PATH_TRAVERSAL"
+	CodePathDisabled    = "PATH_DISABLED"
+	CodePathReadOnly    = "PATH_READ_ONLY"
+	CodeFileTypeDenied  = "FILE_TYPE_DENIED"
+	CodeFileExists      = "FILE_EXISTS"
+	CodeFileNotNewer    = "FILE_NOT_NEWER"
+	CodeTooLarge        = "TOO_LARGE"
+
+	CodeChecksumMismatch = "CHECKSUM_MISMATCH"
+
+	CodeExtractFailed = "EXTRACT_FAILED"
+	CodeExtractLimit  = "EXTRACT_LIMIT"
+
+	CodeIOError  = "IO_ERROR"
+	CodeInternal = "INTERNAL_ERROR"
+)
+
+// apiError 带 HTTP 状态码和错误码的错误
+type apiError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *apiError) Error() string { return e.Message }
+
+func newAPIError(status int, code, format string, args ...interface{}) *apiError {
+	return &apiError{Status: status, Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// asAPIError 提取 apiError，普通错误按 fallback 包装
+func asAPIError(err error, status int, code string) *apiError {
+	var ae *apiError
+	if errors.As(err, &ae) {
+		return ae
+	}
+	return &apiError{Status: status, Code: code, Message: err.Error()}
+}
+
+// errorResponse 错误响应体
+type errorResponse struct {
+	Status    string `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+// writeError 以 JSON 返回错误
+func writeError(w http.ResponseWriter, err *apiError) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.Status)
+	json.NewEncoder(w).Encode(errorResponse{
+		Status:    "error",
+		Code:      err.Code,
+		Message:   err.Message,
+		RequestID: w.Header().Get("X-Request-ID"),
+	})
+}
+
+// withRequestID 为每个请求分配请求 ID (沿用客户端传入的 X-Request-ID)
+func withRequestID(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" || len(id) > 64 {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		w.Header().Set("X-Request-ID", id)
+		next(w, r)
+	}
+}
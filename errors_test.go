@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteError(t *testing.T) {
+	handler := withRequestID(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, newAPIError(http.StatusConflict, CodeFileExists, "目标文件已存在: %s", "a.txt"))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/upload/web/a.txt", nil)
+	req.Header.Set("X-Request-ID", "ci-42")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if id := rec.Header().Get("X-Request-ID"); id != "ci-42" {
+		t.Errorf("X-Request-ID = %q, want ci-42", id)
+	}
+	var body errorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	want := errorResponse{Status: "error", Code: CodeFileExists, Message: "目标文件已存在: a.txt", RequestID: "ci-42"}
+	if body != want {
+		t.Errorf("body = %+v, want %+v", body, want)
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	tests := []struct {
+		incoming string
+		keep     bool
+	}{
+		{"", false},
+		{"client-id", true},
+		{strings.Repeat("x", 64), true},
+		{strings.Repeat("x", 65), false},
+	}
+	for _, tt := range tests {
+		var seen string
+		handler := withRequestID(func(w http.ResponseWriter, r *http.Request) {
+			seen = w.Header().Get("X-Request-ID")
+		})
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		if tt.incoming != "" {
+			req.Header.Set("X-Request-ID", tt.incoming)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+
+		got := rec.Header().Get("X-Request-ID")
+		if got != seen {
+			t.Errorf("incoming %q: handler saw %q, response has %q", tt.incoming, seen, got)
+		}
+		if tt.keep && got != tt.incoming {
+			t.Errorf("incoming %q: X-Request-ID = %q, want it kept", tt.incoming, got)
+		}
+		if !tt.keep && len(got) != 16 {
+			t.Errorf("incoming %q: X-Request-ID = %q, want a generated 16-char id", tt.incoming, got)
+		}
+	}
+}
+
+func TestAsAPIError(t *testing.T) {
+	ae := newAPIError(http.StatusForbidden, CodeFileTypeDenied, "denied")
+	if got := asAPIError(ae, http.StatusInternalServerError, CodeIOError); got != ae {
+		t.Errorf("asAPIError(apiError) = %+v, want the original error", got)
+	}
+
+	got := asAPIError(errors.New("disk full"), http.StatusInternalServerError, CodeIOError)
+	if got.Status != http.StatusInternalServerError || got.Code != CodeIOError || got.Message != "disk full" {
+		t.Errorf("asAPIError(plain) = %+v", got)
+	}
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractConfig 解压安全限制
+type ExtractConfig struct {
+	MaxUncompressedMB int64    `json:"max_uncompressed_mb"` // 解压后总大小上限 (MB)
+	MaxEntries        int      `json:"max_entries"`         // 压缩包内条目数上限
+	MaxRatio          int64    `json:"max_ratio"`           // 单个文件压缩比上限
+	Preserve          []string `json:"preserve"`            // 镜像模式下保留的旧文件 (glob)
+}
+
+// extractOptions 单次解压的选项 (来自查询参数)
+type extractOptions struct {
+	StripComponents int         // 去掉条目路径前 N 层目录
+	Mirror          bool        // mode=mirror: 目标目录与压缩包内容完全一致
+	Policy          *PathConfig // 路径标识策略，用于检查解压出的文件名和覆盖策略
+}
+
+// resolveExtractDir 根据 target 参数计算解压目录
+//
+//	""       -> 与 zip 同名的目录 (默认行为)
+//	"." "/"  -> 路径根目录 baseDir
+//	其他     -> baseDir 下的指定子目录
+func resolveExtractDir(baseDir, zipPath, target string) (string, error) {
+	switch target {
+	case "":
+		return strings.TrimSuffix(zipPath, filepath.Ext(zipPath)), nil
+	case ".", "/":
+		return filepath.Clean(baseDir), nil
+	}
+
+	target = strings.Trim(filepath.FromSlash(target), string(filepath.Separator))
+	if !isValidFilename(target) {
+		return "", newAPIError(http.StatusBadRequest, CodeInvalidParameter, "非法的解压目录: %s", target)
+	}
+
+	dir := filepath.Join(baseDir, target)
+	if !isWithinDir(baseDir, dir) {
+		return "", newAPIError(http.StatusBadRequest, CodePathTraversal, "解压目录超出路径范围: %s", target)
+	}
+	return dir, nil
+}
+
+// isWithinDir 判断 path 是否位于 dir 内部 (或等于 dir)
+func isWithinDir(dir, path string) bool {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+// stripComponents 去掉条目路径的前 n 层，全部去掉时返回空字符串
+func stripComponents(name string, n int) string {
+	name = strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "/")
+	if n <= 0 {
+		return name
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) <= n {
+		return ""
+	}
+	return strings.Join(parts[n:], "/")
+}
+
+// checkArchiveLimits 按文件头预先检查条目数、总大小和压缩比
+func checkArchiveLimits(files []*zip.File) error {
+	limits := config.Extract
+
+	if limits.MaxEntries > 0 && len(files) > limits.MaxEntries {
+		return newAPIError(http.StatusUnprocessableEntity, CodeExtractLimit, "压缩包条目过多: %d (上限 %d)", len(files), limits.MaxEntries)
+	}
+
+	maxBytes := limits.MaxUncompressedMB * 1024 * 1024
+	var total uint64
+	for _, f := range files {
+		total += f.UncompressedSize64
+		if maxBytes > 0 && total > uint64(maxBytes) {
+			return newAPIError(http.StatusUnprocessableEntity, CodeExtractLimit, "解压后大小超过上限 %dMB", limits.MaxUncompressedMB)
+		}
+		if limits.MaxRatio > 0 && f.CompressedSize64 > 0 &&
+			f.UncompressedSize64/f.CompressedSize64 > uint64(limits.MaxRatio) {
+			return newAPIError(http.StatusUnprocessableEntity, CodeExtractLimit, "压缩比异常: %s (上限 %d:1)", f.Name, limits.MaxRatio)
+		}
+	}
+	return nil
+}
+
+// fileMode 只保留可执行位，其余权限统一
+func fileMode(f *zip.File) os.FileMode {
+	if f.Mode().Perm()&0111 != 0 {
+		return 0755
+	}
+	return 0644
+}
+
+// unzipFile 解压到 dest，返回按 if-newer 策略跳过的条目数
+//
+// 写入前先检查所有条目的路径、文件名策略和覆盖策略，任何一个被拒绝都不写入。
+func unzipFile(src, dest string, opts extractOptions) (int, error) {
+	r, err := zip.OpenReader(src)
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	if err := checkArchiveLimits(r.File); err != nil {
+		return 0, err
+	}
+
+	entries, skipped, err := planEntries(r.File, dest, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return 0, err
+	}
+
+	// 文件头中的大小可以伪造，写入时按实际字节数再限制一次
+	remaining := config.Extract.MaxUncompressedMB * 1024 * 1024
+
+	for _, e := range entries {
+		if e.file.FileInfo().IsDir() {
+			if err := os.MkdirAll(e.path, 0755); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+			return 0, err
+		}
+
+		written, err := extractEntry(e.file, e.path, remaining)
+		if err != nil {
+			return 0, err
+		}
+		if remaining > 0 {
+			remaining -= written
+		}
+
+		// if-newer 依赖修改时间比较，保留压缩包中的时间
+		if opts.Policy != nil && opts.Policy.Overwrite == overwriteIfNewer {
+			if err := os.Chtimes(e.path, e.file.Modified, e.file.Modified); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return skipped, nil
+}
+
+// zipEntry 检查通过、需要写出的条目
+type zipEntry struct {
+	file *zip.File
+	path string
+}
+
+// planEntries 检查所有条目并计算写出路径，if-newer 策略下不比服务器新的文件被跳过
+func planEntries(files []*zip.File, dest string, opts extractOptions) ([]zipEntry, int, error) {
+	entries := make([]zipEntry, 0, len(files))
+	skipped := 0
+
+	for _, f := range files {
+		name := stripComponents(f.Name, opts.StripComponents)
+		if name == "" {
+			continue
+		}
+
+		fpath := filepath.Join(dest, filepath.FromSlash(name))
+		if !isWithinDir(dest, fpath) {
+			return nil, 0, newAPIError(http.StatusBadRequest, CodePathTraversal, "非法路径: %s", f.Name)
+		}
+
+		if f.Mode()&os.ModeSymlink != 0 {
+			return nil, 0, fmt.Errorf("不支持符号链接: %s", f.Name)
+		}
+
+		if opts.Policy != nil && !f.FileInfo().IsDir() {
+			if err := opts.Policy.checkName(name); err != nil {
+				return nil, 0, err
+			}
+			if err := opts.Policy.checkOverwrite(fpath, f.Modified.Unix()); err != nil {
+				var ae *apiError
+				if errors.As(err, &ae) && ae.Code == CodeFileNotNewer {
+					skipped++
+					continue
+				}
+				if errors.As(err, &ae) && ae.Code == CodeFileExists {
+					ae.Message = fmt.Sprintf("目标文件已存在且不允许覆盖: %s", name)
+				}
+				return nil, 0, err
+			}
+		}
+
+		entries = append(entries, zipEntry{file: f, path: fpath})
+	}
+	return entries, skipped, nil
+}
+
+// extractEntry 写出单个条目，limit > 0 时超出即中止
+func extractEntry(f *zip.File, fpath string, limit int64) (int64, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode(f))
+	if err != nil {
+		return 0, err
+	}
+	defer outFile.Close()
+
+	var reader io.Reader = rc
+	if limit > 0 {
+		reader = io.LimitReader(rc, limit+1)
+	}
+
+	written, err := io.Copy(outFile, reader)
+	if err != nil {
+		return written, err
+	}
+	if limit > 0 && written > limit {
+		return written, newAPIError(http.StatusUnprocessableEntity, CodeExtractLimit, "解压后大小超过上限 %dMB", config.Extract.MaxUncompressedMB)
+	}
+	return written, nil
+}
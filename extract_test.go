@@ -0,0 +1,260 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStripComponents(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		want string
+	}{
+		{"dist/index.html", 0, "dist/index.html"},
+		{"dist/index.html", 1, "index.html"},
+		{"dist/assets/app.js", 1, "assets/app.js"},
+		{"dist/assets/app.js", 2, "app.js"},
+		{"dist/index.html", 2, ""},
+		{"dist/", 1, ""},
+		{"dist/assets/", 1, "assets/"},
+		{"/dist/index.html", 1, "index.html"},
+		{`dist\assets\app.js`, 1, "assets/app.js"},
+		{"index.html", 1, ""},
+		{"index.html", -1, "index.html"},
+	}
+	for _, tt := range tests {
+		if got := stripComponents(tt.name, tt.n); got != tt.want {
+			t.Errorf("stripComponents(%q, %d) = %q, want %q", tt.name, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestIsWithinDir(t *testing.T) {
+	base := filepath.Join("srv", "web")
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{base, true},
+		{filepath.Join(base, "index.html"), true},
+		{filepath.Join(base, "a", "..", "b"), true},
+		{filepath.Join(base, ".."), false},
+		{filepath.Join(base, "..", "web2"), false},
+		{base + "2", false},
+		{filepath.Join(base, "..", "..", "etc"), false},
+	}
+	for _, tt := range tests {
+		if got := isWithinDir(base, tt.path); got != tt.want {
+			t.Errorf("isWithinDir(%q, %q) = %v, want %v", base, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResolveExtractDir(t *testing.T) {
+	base := filepath.Join("srv", "web")
+	zipPath := filepath.Join(base, "dist.zip")
+	tests := []struct {
+		target string
+		want   string
+		code   string
+	}{
+		{"", filepath.Join(base, "dist"), ""},
+		{".", base, ""},
+		{"/", base, ""},
+		{"app", filepath.Join(base, "app"), ""},
+		{"app/v2/", filepath.Join(base, "app", "v2"), ""},
+		{"../other", "", CodeInvalidParameter},
+		{".hidden", "", CodeInvalidParameter},
+	}
+	for _, tt := range tests {
+		got, err := resolveExtractDir(base, zipPath, tt.target)
+		if tt.code != "" {
+			var ae *apiError
+			if !errors.As(err, &ae) || ae.Code != tt.code {
+				t.Errorf("resolveExtractDir(%q) error = %v, want %s", tt.target, err, tt.code)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveExtractDir(%q) = %q, %v, want %q", tt.target, got, err, tt.want)
+		}
+	}
+}
+
+// zipEntryHeader 构造 checkArchiveLimits 使用的文件头
+func zipEntryHeader(name string, compressed, uncompressed uint64) *zip.File {
+	return &zip.File{FileHeader: zip.FileHeader{
+		Name:               name,
+		CompressedSize64:   compressed,
+		UncompressedSize64: uncompressed,
+	}}
+}
+
+func TestCheckArchiveLimits(t *testing.T) {
+	defer func(old ExtractConfig) { config.Extract = old }(config.Extract)
+
+	const mb = 1024 * 1024
+	tests := []struct {
+		name   string
+		limits ExtractConfig
+		files  []*zip.File
+		ok     bool
+	}{
+		{
+			name:   "within limits",
+			limits: ExtractConfig{MaxUncompressedMB: 10, MaxEntries: 3, MaxRatio: 100},
+			files:  []*zip.File{zipEntryHeader("a", mb, 2*mb), zipEntryHeader("b", mb, 2*mb)},
+			ok:     true,
+		},
+		{
+			name:   "too many entries",
+			limits: ExtractConfig{MaxEntries: 1},
+			files:  []*zip.File{zipEntryHeader("a", 1, 1), zipEntryHeader("b", 1, 1)},
+		},
+		{
+			name:   "total size over limit",
+			limits: ExtractConfig{MaxUncompressedMB: 3},
+			files:  []*zip.File{zipEntryHeader("a", mb, 2*mb), zipEntryHeader("b", mb, 2*mb)},
+		},
+		{
+			name:   "total size exactly at limit",
+			limits: ExtractConfig{MaxUncompressedMB: 4},
+			files:  []*zip.File{zipEntryHeader("a", mb, 2*mb), zipEntryHeader("b", mb, 2*mb)},
+			ok:     true,
+		},
+		{
+			name:   "compression ratio over limit",
+			limits: ExtractConfig{MaxRatio: 100},
+			files:  []*zip.File{zipEntryHeader("bomb", 1024, 1024*101)},
+		},
+		{
+			name:   "empty entry ignores ratio",
+			limits: ExtractConfig{MaxRatio: 100},
+			files:  []*zip.File{zipEntryHeader("empty", 0, 0)},
+			ok:     true,
+		},
+		{
+			name:   "zero limits disable checks",
+			limits: ExtractConfig{},
+			files:  []*zip.File{zipEntryHeader("a", 1, 1<<40), zipEntryHeader("b", 1, 1)},
+			ok:     true,
+		},
+	}
+	for _, tt := range tests {
+		config.Extract = tt.limits
+		err := checkArchiveLimits(tt.files)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		var ae *apiError
+		if !errors.As(err, &ae) || ae.Code != CodeExtractLimit {
+			t.Errorf("%s: error = %v, want %s", tt.name, err, CodeExtractLimit)
+		}
+	}
+}
+
+// writeTestZip 按顺序写入条目 (名称 -> 内容)
+func writeTestZip(t *testing.T, entries ...string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(entries); i += 2 {
+		w, err := zw.Create(entries[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(entries[i+1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "test.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUnzipFile(t *testing.T) {
+	defer func(old ExtractConfig) { config.Extract = old }(config.Extract)
+	config.Extract = ExtractConfig{MaxUncompressedMB: 1}
+
+	tests := []struct {
+		name    string
+		entries []string
+		opts    extractOptions
+		want    []string
+		code    string
+	}{
+		{
+			name:    "strip top directory",
+			entries: []string{"dist/", "", "dist/index.html", "i", "dist/js/app.js", "a"},
+			opts:    extractOptions{StripComponents: 1},
+			want:    []string{"index.html", "js/app.js"},
+		},
+		{
+			name:    "entries shorter than strip are dropped",
+			entries: []string{"README", "r", "dist/index.html", "i"},
+			opts:    extractOptions{StripComponents: 1},
+			want:    []string{"index.html"},
+		},
+		{
+			name:    "path traversal",
+			entries: []string{"../evil.txt", "x"},
+			code:    CodePathTraversal,
+		},
+		{
+			name:    "traversal after strip",
+			entries: []string{"dist/../../evil.txt", "x"},
+			opts:    extractOptions{StripComponents: 1},
+			code:    CodePathTraversal,
+		},
+		{
+			name:    "denied name rejects whole archive",
+			entries: []string{"ok.html", "o", "bad.exe", "x"},
+			opts:    extractOptions{Policy: &PathConfig{Deny: []string{"*.exe"}, Overwrite: overwriteAlways}},
+			code:    CodeFileTypeDenied,
+		},
+		{
+			name:    "actual size over limit",
+			entries: []string{"big.bin", strings.Repeat("x", 1024*1024+1)},
+			code:    CodeExtractLimit,
+		},
+	}
+	for _, tt := range tests {
+		src := writeTestZip(t, tt.entries...)
+		dest := filepath.Join(t.TempDir(), "out")
+		_, err := unzipFile(src, dest, tt.opts)
+		if tt.code != "" {
+			var ae *apiError
+			if !errors.As(err, &ae) || ae.Code != tt.code {
+				t.Errorf("%s: error = %v, want %s", tt.name, err, tt.code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		var got []string
+		filepath.Walk(dest, func(p string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				rel, _ := filepath.Rel(dest, p)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			return nil
+		})
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: extracted %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxListEntries 单次列出的文件数上限，避免误列整个磁盘时长时间计算哈希
+const maxListEntries = 100000
+
+// ListedFile 列出的单个文件
+type ListedFile struct {
+	Path    string `json:"path"` // 相对路径，使用 "/" 分隔
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"` // Unix 秒
+	SHA256  string `json:"sha256"`
+}
+
+// handleList 列出路径标识下的文件及其 SHA-256，供客户端部署前对比差异
+//
+// GET /list/{path_key}[/{sub/dir}]，签名方式与上传相同。以点开头的文件和目录
+// (包括镜像模式的暂存目录) 不会列出，与上传接口拒绝的文件名一致。
+func handleList(w http.ResponseWriter, r *http.Request) {
+	clientIP := getClientIP(r)
+
+	if r.Method != http.MethodGet {
+		writeError(w, newAPIError(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "仅支持GET请求"))
+		return
+	}
+
+	if authErr := verifyRequest(r); authErr != nil {
+		stats.Lock()
+		stats.failedAuth++
+		stats.Unlock()
+
+		logWarn("认证失败 [%s]: %s", clientIP, authErr.Message)
+		authErr.Message = "认证失败: " + authErr.Message
+		writeError(w, authErr)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/list/")
+	parts := strings.SplitN(path, "/", 2)
+	pathKey := parts[0]
+	if pathKey == "" {
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidURL, "URL格式错误，应为: /list/{path_key}[/{dir}]"))
+		return
+	}
+
+	pathCfg, exists := config.Paths[pathKey]
+	if !exists {
+		writeError(w, newAPIError(http.StatusBadRequest, CodeUnknownPathKey, "未知的路径标识: %s", pathKey))
+		logError("[%s] 未知的路径标识: %s", clientIP, pathKey)
+		return
+	}
+	if pathCfg.Disabled {
+		writeError(w, newAPIError(http.StatusForbidden, CodePathDisabled, "路径标识已禁用: %s", pathKey))
+		return
+	}
+
+	root := pathCfg.Dir
+	if len(parts) == 2 && strings.Trim(parts[1], "/") != "" {
+		sub := strings.Trim(parts[1], "/")
+		if !isValidFilename(sub) {
+			writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidFilename, "非法的目录名"))
+			logError("[%s] 非法目录名: %s", clientIP, sub)
+			return
+		}
+		root = filepath.Join(pathCfg.Dir, sub)
+		if !isWithinDir(pathCfg.Dir, root) {
+			writeError(w, newAPIError(http.StatusBadRequest, CodePathTraversal, "路径安全检查失败"))
+			logError("[%s] 路径遍历攻击: %s", clientIP, sub)
+			return
+		}
+	}
+
+	files, err := listFiles(root)
+	if err != nil {
+		writeError(w, asAPIError(err, http.StatusInternalServerError, CodeIOError))
+		logError("[%s] 列出文件失败: %v", clientIP, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":     "ok",
+		"request_id": w.Header().Get("X-Request-ID"),
+		"path_key":   pathKey,
+		"files":      files,
+	})
+
+	logInfo("[%s] 列出文件: %s (%d 个)", clientIP, strings.TrimPrefix(r.URL.Path, "/list/"), len(files))
+}
+
+// listFiles 递归列出目录中的文件并计算 SHA-256，目录不存在时返回空列表
+func listFiles(root string) ([]ListedFile, error) {
+	files := []ListedFile{}
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return files, nil
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if len(files) >= maxListEntries {
+			return newAPIError(http.StatusRequestEntityTooLarge, CodeTooLarge, "文件过多，最多列出 %d 个", maxListEntries)
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		sum, err := fileSHA256(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, ListedFile{
+			Path:    filepath.ToSlash(rel),
+			Size:    info.Size(),
+			ModTime: info.ModTime().Unix(),
+			SHA256:  hex.EncodeToString(sum),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHandleList(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "web")
+	writeTree(t, root, map[string]string{
+		"index.html":                 "index",
+		"assets/app.js":              "app",
+		".env":                       "secret",
+		".web.deploy-staging/x.html": "staging",
+		"assets/.cache/tmp":          "tmp",
+		"assets/img/logo.png":        "png",
+	})
+	srv, priv := newTestServer(t, map[string]PathConfig{
+		"web":   {Dir: root},
+		"empty": {Dir: filepath.Join(root, "missing")},
+		"off":   {Dir: root, Disabled: true},
+	})
+
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	tests := []struct {
+		name   string
+		path   string
+		sign   bool
+		status int
+		code   string
+		files  map[string]string // 相对路径 -> sha256
+	}{
+		{
+			name:   "root",
+			path:   "/list/web",
+			sign:   true,
+			status: http.StatusOK,
+			files:  map[string]string{"assets/app.js": sha("app"), "assets/img/logo.png": sha("png"), "index.html": sha("index")},
+		},
+		{
+			name:   "sub directory",
+			path:   "/list/web/assets/img/",
+			sign:   true,
+			status: http.StatusOK,
+			files:  map[string]string{"logo.png": sha("png")},
+		},
+		{name: "missing dir", path: "/list/empty", sign: true, status: http.StatusOK, files: map[string]string{}},
+		{name: "unsigned", path: "/list/web", status: http.StatusUnauthorized, code: CodeAuthMissing},
+		{name: "unknown key", path: "/list/nope", sign: true, status: http.StatusBadRequest, code: CodeUnknownPathKey},
+		{name: "no key", path: "/list/", sign: true, status: http.StatusBadRequest, code: CodeInvalidURL},
+		{name: "disabled", path: "/list/off", sign: true, status: http.StatusForbidden, code: CodePathDisabled},
+		{name: "hidden sub directory", path: "/list/web/.web.deploy-staging", sign: true, status: http.StatusBadRequest, code: CodeInvalidFilename},
+	}
+	for _, tt := range tests {
+		var req *http.Request
+		if tt.sign {
+			req = signedRequest(t, priv, http.MethodGet, srv.URL+tt.path, nil)
+		} else {
+			req = signedRequest(t, nil, http.MethodGet, srv.URL+tt.path, nil)
+		}
+		resp, body := doJSON(t, req)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d (%v)", tt.name, resp.StatusCode, tt.status, body)
+			continue
+		}
+		if tt.code != "" {
+			if body["code"] != tt.code {
+				t.Errorf("%s: code = %v, want %s", tt.name, body["code"], tt.code)
+			}
+			continue
+		}
+
+		list, _ := body["files"].([]interface{})
+		got := make(map[string]string)
+		var order []string
+		for _, item := range list {
+			f := item.(map[string]interface{})
+			got[f["path"].(string)] = f["sha256"].(string)
+			order = append(order, f["path"].(string))
+		}
+		if !reflect.DeepEqual(got, tt.files) {
+			t.Errorf("%s: files = %v, want %v", tt.name, got, tt.files)
+		}
+		for i := 1; i < len(order); i++ {
+			if order[i-1] > order[i] {
+				t.Errorf("%s: files not sorted: %v", tt.name, order)
+				break
+			}
+		}
+	}
+}
+
+func TestListFilesMetadata(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{"a.txt": "hello"})
+	info, err := os.Stat(filepath.Join(root, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := listFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("hello"))
+	want := []ListedFile{{Path: "a.txt", Size: 5, ModTime: info.ModTime().Unix(), SHA256: hex.EncodeToString(sum[:])}}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("listFiles = %+v, want %+v", files, want)
+	}
+}
@@ -1,10 +1,10 @@
 package main
 
 import (
-	"archive/zip"
 	"context"
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -27,10 +27,16 @@ const VERSION = "3.0.0"
 
 // Config 配置结构
 type Config struct {
-	Port      int               `json:"port"`
-	Paths     map[string]string `json:"paths"`
-	LogDir    string            `json:"log_dir"`
-	MaxUpload int64             `json:"max_upload_mb"`
+	Port      int                   `json:"port"`
+	Paths     map[string]PathConfig `json:"paths"`
+	LogDir    string                `json:"log_dir"`
+	MaxUpload int64                 `json:"max_upload_mb"`
+
+	// 解压安全限制
+	Extract ExtractConfig `json:"extract"`
+
+	// 解压后压缩包的处理
+	Archive ArchiveConfig `json:"archive"`
 
 	// 安全配置
 	Security SecurityConfig `json:"security"`
@@ -323,9 +329,7 @@ func runServiceMode() {
 // startServerWithShutdown 启动支持优雅关闭的HTTP服务器
 func startServerWithShutdown() {
 	mux := http.NewServeMux()
-	mux.HandleFunc("/upload/", handleUpload)
-	mux.HandleFunc("/health", handleHealth)
-	mux.HandleFunc("/", handleRoot)
+	registerRoutes(mux)
 
 	httpServer = &http.Server{
 		Addr:    fmt.Sprintf(":%d", config.Port),
@@ -353,8 +357,8 @@ func runConsoleMode() {
 		}
 	}
 	fmt.Println("配置的路径:")
-	for key, path := range config.Paths {
-		fmt.Printf("  %s -> %s\n", key, path)
+	for key, p := range config.Paths {
+		fmt.Printf("  %s -> %s\n", key, p.Dir)
 	}
 	fmt.Println("------------------------------------------------------------")
 	fmt.Println("按 Ctrl+C 停止服务")
@@ -388,6 +392,27 @@ func loadConfig() error {
 	if config.MaxUpload == 0 {
 		config.MaxUpload = 500
 	}
+	if config.Extract.MaxUncompressedMB == 0 {
+		config.Extract.MaxUncompressedMB = 2048
+	}
+	if config.Extract.MaxEntries == 0 {
+		config.Extract.MaxEntries = 20000
+	}
+	if config.Extract.MaxRatio == 0 {
+		config.Extract.MaxRatio = 200
+	}
+	for key, p := range config.Paths {
+		if err := p.validate(key); err != nil {
+			return err
+		}
+		config.Paths[key] = p
+	}
+	if config.Archive.Dir == "" {
+		config.Archive.Dir = "archives"
+	}
+	if config.Archive.Retention == 0 {
+		config.Archive.Retention = 10
+	}
 	if config.Security.TimestampLimit == 0 {
 		config.Security.TimestampLimit = 300
 	}
@@ -446,9 +471,7 @@ func writeLog(level, format string, args ...interface{}) {
 }
 
 func startServer() {
-	http.HandleFunc("/upload/", handleUpload)
-	http.HandleFunc("/health", handleHealth)
-	http.HandleFunc("/", handleRoot)
+	registerRoutes(http.DefaultServeMux)
 
 	addr := fmt.Sprintf(":%d", config.Port)
 	logInfo("HTTP服务器启动在 %s", addr)
@@ -458,7 +481,20 @@ func startServer() {
 	}
 }
 
+// registerRoutes 注册所有接口
+func registerRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/upload/", withRequestID(handleUpload))
+	mux.HandleFunc("/list/", withRequestID(handleList))
+	mux.HandleFunc("/health", withRequestID(handleHealth))
+	mux.HandleFunc("/", withRequestID(handleRoot))
+}
+
 func handleRoot(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		writeError(w, newAPIError(http.StatusNotFound, CodeNotFound, "接口不存在: %s", r.URL.Path))
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"service":  "Deploy Receiver",
@@ -477,10 +513,10 @@ func handleHealth(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// verifyRequest 验证请求安全性 (Ed25519 签名)
-func verifyRequest(r *http.Request) (bool, string) {
+// verifyRequest 验证请求安全性 (Ed25519 签名)，通过时返回 nil
+func verifyRequest(r *http.Request) *apiError {
 	if !config.Security.Enabled {
-		return true, ""
+		return nil
 	}
 
 	clientIP := getClientIP(r)
@@ -495,7 +531,7 @@ func verifyRequest(r *http.Request) (bool, string) {
 			}
 		}
 		if !allowed {
-			return false, fmt.Sprintf("IP不在白名单: %s", clientIP)
+			return newAPIError(http.StatusForbidden, CodeAuthIPDenied, "IP不在白名单: %s", clientIP)
 		}
 	}
 
@@ -505,13 +541,13 @@ func verifyRequest(r *http.Request) (bool, string) {
 	nonce := r.Header.Get("X-Nonce")
 
 	if timestamp == "" || signature == "" {
-		return false, "缺少认证头 (X-Timestamp, X-Signature)"
+		return newAPIError(http.StatusUnauthorized, CodeAuthMissing, "缺少认证头 (X-Timestamp, X-Signature)")
 	}
 
 	// 验证时间戳
 	ts, err := strconv.ParseInt(timestamp, 10, 64)
 	if err != nil {
-		return false, "无效的时间戳格式"
+		return newAPIError(http.StatusUnauthorized, CodeAuthBadTimestamp, "无效的时间戳格式")
 	}
 
 	now := time.Now().Unix()
@@ -520,21 +556,21 @@ func verifyRequest(r *http.Request) (bool, string) {
 		diff = -diff
 	}
 	if diff > config.Security.TimestampLimit {
-		return false, fmt.Sprintf("时间戳已过期 (差异: %d秒, 限制: %d秒)", diff, config.Security.TimestampLimit)
+		return newAPIError(http.StatusUnauthorized, CodeAuthExpired, "时间戳已过期 (差异: %d秒, 限制: %d秒)", diff, config.Security.TimestampLimit)
 	}
 
 	// 验证 Ed25519 签名
 	message := timestamp + nonce + r.URL.Path
 	sigBytes, err := hex.DecodeString(signature)
 	if err != nil {
-		return false, "无效的签名格式"
+		return newAPIError(http.StatusUnauthorized, CodeAuthBadSignature, "无效的签名格式")
 	}
 
 	if !ed25519.Verify(publicKey, []byte(message), sigBytes) {
-		return false, "签名验证失败"
+		return newAPIError(http.StatusUnauthorized, CodeAuthBadSignature, "签名验证失败")
 	}
 
-	return true, ""
+	return nil
 }
 
 func getClientIP(r *http.Request) string {
@@ -556,18 +592,19 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 	clientIP := getClientIP(r)
 
 	if r.Method != http.MethodPost {
-		http.Error(w, "仅支持POST请求", http.StatusMethodNotAllowed)
+		writeError(w, newAPIError(http.StatusMethodNotAllowed, CodeMethodNotAllowed, "仅支持POST请求"))
 		return
 	}
 
 	// 安全验证
-	if ok, reason := verifyRequest(r); !ok {
+	if authErr := verifyRequest(r); authErr != nil {
 		stats.Lock()
 		stats.failedAuth++
 		stats.Unlock()
 
-		logWarn("认证失败 [%s]: %s", clientIP, reason)
-		http.Error(w, "认证失败: "+reason, http.StatusUnauthorized)
+		logWarn("认证失败 [%s]: %s", clientIP, authErr.Message)
+		authErr.Message = "认证失败: " + authErr.Message
+		writeError(w, authErr)
 		return
 	}
 
@@ -576,7 +613,7 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 	parts := strings.SplitN(path, "/", 2)
 
 	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
-		http.Error(w, "URL格式错误，应为: /upload/{path_key}/{filename}", http.StatusBadRequest)
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidURL, "URL格式错误，应为: /upload/{path_key}/{filename}"))
 		logError("[%s] 无效的上传路径: %s", clientIP, r.URL.Path)
 		return
 	}
@@ -584,69 +621,181 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 	pathKey := parts[0]
 	filename := parts[1]
 
-	baseDir, exists := config.Paths[pathKey]
+	pathCfg, exists := config.Paths[pathKey]
 	if !exists {
-		http.Error(w, fmt.Sprintf("未知的路径标识: %s", pathKey), http.StatusBadRequest)
+		writeError(w, newAPIError(http.StatusBadRequest, CodeUnknownPathKey, "未知的路径标识: %s", pathKey))
 		logError("[%s] 未知的路径标识: %s", clientIP, pathKey)
 		return
 	}
+	baseDir := pathCfg.Dir
+
+	if err := pathCfg.checkWritable(pathKey); err != nil {
+		writeError(w, asAPIError(err, http.StatusForbidden, CodePathDisabled))
+		logWarn("[%s] %v", clientIP, err)
+		return
+	}
 
 	if !isValidFilename(filename) {
-		http.Error(w, "非法的文件名", http.StatusBadRequest)
+		writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidFilename, "非法的文件名"))
 		logError("[%s] 非法文件名: %s", clientIP, filename)
 		return
 	}
 
+	if err := pathCfg.checkName(filename); err != nil {
+		writeError(w, asAPIError(err, http.StatusForbidden, CodeFileTypeDenied))
+		logWarn("[%s] %v", clientIP, err)
+		return
+	}
+
 	fullPath := filepath.Join(baseDir, filename)
 
 	// 路径安全检查
-	absBase, _ := filepath.Abs(baseDir)
-	absPath, _ := filepath.Abs(fullPath)
-	if !strings.HasPrefix(absPath, absBase) {
-		http.Error(w, "路径安全检查失败", http.StatusBadRequest)
+	if !isWithinDir(baseDir, fullPath) {
+		writeError(w, newAPIError(http.StatusBadRequest, CodePathTraversal, "路径安全检查失败"))
 		logError("[%s] 路径遍历攻击: %s", clientIP, filename)
 		return
 	}
 
+	// 覆盖策略
+	query := r.URL.Query()
+	var mtime int64
+	if v := query.Get("mtime"); v != "" {
+		var err error
+		if mtime, err = strconv.ParseInt(v, 10, 64); err != nil {
+			writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidParameter, "无效的 mtime 参数"))
+			return
+		}
+	}
+	if err := pathCfg.checkOverwrite(fullPath, mtime); err != nil {
+		writeError(w, asAPIError(err, http.StatusInternalServerError, CodeIOError))
+		logWarn("[%s] %s: %v", clientIP, filename, err)
+		return
+	}
+
+	// 解压参数在写入前校验，避免参数错误时留下文件
+	autoExtract := query.Get("extract") == "true" && strings.HasSuffix(strings.ToLower(filename), ".zip")
+	opts := extractOptions{Policy: &pathCfg}
+	archiveAction := ""
+	extractDir := ""
+
+	if autoExtract {
+		if v := query.Get("strip_components"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidParameter, "无效的 strip_components 参数"))
+				return
+			}
+			opts.StripComponents = n
+		}
+		switch query.Get("mode") {
+		case "", "merge":
+		case "mirror":
+			// 镜像模式会替换和删除已有文件，只有允许覆盖的路径标识可以使用
+			if pathCfg.Overwrite != overwriteAlways {
+				writeError(w, newAPIError(http.StatusConflict, CodeFileExists, "路径标识的覆盖策略为 %s，不允许镜像模式", pathCfg.Overwrite))
+				return
+			}
+			opts.Mirror = true
+		default:
+			writeError(w, newAPIError(http.StatusBadRequest, CodeInvalidParameter, "无效的 mode 参数，应为 merge 或 mirror"))
+			return
+		}
+
+		var err error
+		archiveAction, err = archivePolicy(pathKey, query.Get("keep_archive"))
+		if err != nil {
+			writeError(w, asAPIError(err, http.StatusBadRequest, CodeInvalidParameter))
+			return
+		}
+
+		extractDir, err = resolveExtractDir(baseDir, fullPath, query.Get("target"))
+		if err != nil {
+			writeError(w, asAPIError(err, http.StatusBadRequest, CodeInvalidParameter))
+			logError("[%s] %v", clientIP, err)
+			return
+		}
+	}
+
 	dir := filepath.Dir(fullPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		http.Error(w, "创建目录失败", http.StatusInternalServerError)
+		writeError(w, newAPIError(http.StatusInternalServerError, CodeIOError, "创建目录失败"))
 		logError("[%s] 创建目录失败: %v", clientIP, err)
 		return
 	}
 
-	r.Body = http.MaxBytesReader(w, r.Body, config.MaxUpload*1024*1024)
+	maxBytes, maxMB := pathCfg.maxUploadBytes()
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 
-	data, err := io.ReadAll(r.Body)
+	// 接收时计算 SHA-256，与客户端声明的哈希不一致时不写入
+	hasher := sha256.New()
+	data, err := io.ReadAll(io.TeeReader(r.Body, hasher))
 	if err != nil {
 		if strings.Contains(err.Error(), "http: request body too large") {
-			http.Error(w, fmt.Sprintf("文件过大，最大 %dMB", config.MaxUpload), http.StatusRequestEntityTooLarge)
+			writeError(w, newAPIError(http.StatusRequestEntityTooLarge, CodeTooLarge, "文件过大，最大 %dMB", maxMB))
 		} else {
-			http.Error(w, "读取数据失败", http.StatusInternalServerError)
+			writeError(w, newAPIError(http.StatusInternalServerError, CodeIOError, "读取数据失败"))
 		}
 		logError("[%s] 读取失败: %v", clientIP, err)
 		return
 	}
+	sum := hex.EncodeToString(hasher.Sum(nil))
+
+	if err := checkContentHash(r, sum); err != nil {
+		writeError(w, err)
+		logWarn("[%s] %s: %s", clientIP, filename, err.Message)
+		return
+	}
 
 	if err := os.WriteFile(fullPath, data, 0644); err != nil {
-		http.Error(w, "保存文件失败", http.StatusInternalServerError)
+		writeError(w, newAPIError(http.StatusInternalServerError, CodeIOError, "保存文件失败"))
 		logError("[%s] 保存失败: %v", clientIP, err)
 		return
 	}
 
+	// if-newer 依赖修改时间比较，保存客户端文件的时间
+	if mtime > 0 {
+		t := time.Unix(mtime, 0)
+		if err := os.Chtimes(fullPath, t, t); err != nil {
+			logWarn("[%s] 设置修改时间失败，if-newer 比较可能不准确: %v", clientIP, err)
+		}
+	}
+
 	// 自动解压
-	autoExtract := r.URL.Query().Get("extract") == "true"
 	extracted := false
-	extractDir := ""
+	var mirrorStats *MirrorStats
+	var archiveResult *ArchiveResult
+	skipped := 0
 
-	if autoExtract && strings.HasSuffix(strings.ToLower(filename), ".zip") {
-		extractDir = strings.TrimSuffix(fullPath, filepath.Ext(fullPath))
-		if err := unzipFile(fullPath, extractDir); err != nil {
-			logWarn("[%s] 解压失败: %v", clientIP, err)
+	if autoExtract {
+		if opts.Mirror {
+			mirrorStats, err = mirrorExtract(fullPath, extractDir, opts, config.Extract.Preserve, fullPath)
+		} else {
+			skipped, err = unzipFile(fullPath, extractDir, opts)
+		}
+		if err != nil {
+			extractErr := asAPIError(err, http.StatusUnprocessableEntity, CodeExtractFailed)
+			extractErr.Message = "解压失败: " + extractErr.Message
+			writeError(w, extractErr)
+			logError("[%s] 解压失败: %v", clientIP, err)
+			return
+		}
+		extracted = true
+		if mirrorStats != nil {
+			logInfo("[%s] 已镜像解压到: %s (新增 %d, 修改 %d, 删除 %d)", clientIP, extractDir,
+				mirrorStats.Added, mirrorStats.Changed, mirrorStats.Removed)
+		} else if skipped > 0 {
+			logInfo("[%s] 已解压到: %s (跳过 %d 个不比服务器新的文件)", clientIP, extractDir, skipped)
 		} else {
-			extracted = true
 			logInfo("[%s] 已解压到: %s", clientIP, extractDir)
 		}
+
+		archiveResult, err = handleArchive(pathKey, fullPath, filename, archiveAction)
+		if err != nil {
+			logWarn("[%s] 处理压缩包失败: %v", clientIP, err)
+			archiveResult = &ArchiveResult{Action: "kept", Path: fullPath, Error: err.Error()}
+		} else if archiveResult.Action != "kept" {
+			logInfo("[%s] 压缩包处理: %s %s", clientIP, archiveResult.Action, filename)
+		}
 	}
 
 	stats.Lock()
@@ -656,16 +805,27 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 	stats.Unlock()
 
 	response := map[string]interface{}{
-		"status":    "ok",
-		"path":      fullPath,
-		"size":      len(data),
-		"path_key":  pathKey,
-		"filename":  filename,
-		"extracted": extracted,
+		"status":     "ok",
+		"request_id": w.Header().Get("X-Request-ID"),
+		"path":       fullPath,
+		"size":       len(data),
+		"sha256":     sum,
+		"path_key":   pathKey,
+		"filename":   filename,
+		"extracted":  extracted,
 	}
 	if extracted {
 		response["extract_dir"] = extractDir
 	}
+	if mirrorStats != nil {
+		response["mirror"] = mirrorStats
+	}
+	if skipped > 0 {
+		response["skipped"] = skipped
+	}
+	if archiveResult != nil {
+		response["archive"] = archiveResult
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
@@ -673,6 +833,26 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 	logInfo("[%s] 已保存: %s (%d bytes)", clientIP, filename, len(data))
 }
 
+// checkContentHash 校验客户端声明的 SHA-256 (请求头 X-Content-SHA256，分块上传时也可以放在 trailer 中)
+//
+// 未声明时不校验，兼容旧客户端；trailer 只有读完请求体后才能取得。
+func checkContentHash(r *http.Request, sum string) *apiError {
+	expected := r.Header.Get("X-Content-SHA256")
+	if expected == "" {
+		expected = r.Trailer.Get("X-Content-SHA256")
+	}
+	if expected == "" {
+		return nil
+	}
+	if len(expected) != sha256.Size*2 {
+		return newAPIError(http.StatusBadRequest, CodeInvalidParameter, "无效的 X-Content-SHA256")
+	}
+	if !strings.EqualFold(expected, sum) {
+		return newAPIError(http.StatusUnprocessableEntity, CodeChecksumMismatch, "内容校验失败: 收到的数据 SHA-256 为 %s，与声明的 %s 不一致", sum, strings.ToLower(expected))
+	}
+	return nil
+}
+
 func isValidFilename(filename string) bool {
 	cleaned := filepath.Clean(filename)
 
@@ -700,53 +880,6 @@ func isValidFilename(filename string) bool {
 	return true
 }
 
-func unzipFile(src, dest string) error {
-	r, err := zip.OpenReader(src)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-
-	os.MkdirAll(dest, 0755)
-
-	for _, f := range r.File {
-		fpath := filepath.Join(dest, f.Name)
-		if !strings.HasPrefix(fpath, filepath.Clean(dest)+string(os.PathSeparator)) {
-			return fmt.Errorf("非法路径: %s", f.Name)
-		}
-
-		if f.FileInfo().IsDir() {
-			os.MkdirAll(fpath, 0755)
-			continue
-		}
-
-		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
-			return err
-		}
-
-		outFile, err := os.OpenFile(fpath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, f.Mode())
-		if err != nil {
-			return err
-		}
-
-		rc, err := f.Open()
-		if err != nil {
-			outFile.Close()
-			return err
-		}
-
-		_, err = io.Copy(outFile, rc)
-		outFile.Close()
-		rc.Close()
-
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
 func getPathKeys() []string {
 	keys := make([]string, 0, len(config.Paths))
 	for k := range config.Paths {
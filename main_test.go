@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newTestServer 按给定路径标识启动启用签名验证的接收端，返回服务器和签名用的私钥
+func newTestServer(t *testing.T, paths map[string]PathConfig) (*httptest.Server, ed25519.PrivateKey) {
+	t.Helper()
+	oldConfig, oldKey, oldExe := config, publicKey, exePath
+	t.Cleanup(func() { config, publicKey, exePath = oldConfig, oldKey, oldExe })
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, p := range paths {
+		if err := p.validate(key); err != nil {
+			t.Fatal(err)
+		}
+		paths[key] = p
+	}
+	config = Config{
+		Paths:     paths,
+		MaxUpload: 1,
+		Extract:   ExtractConfig{MaxUncompressedMB: 1, MaxEntries: 100, MaxRatio: 1000},
+		Archive:   ArchiveConfig{Dir: "archives", Retention: 10},
+		Security:  SecurityConfig{Enabled: true, TimestampLimit: 300},
+	}
+	publicKey = pub
+	exePath = t.TempDir()
+
+	mux := http.NewServeMux()
+	registerRoutes(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, priv
+}
+
+// signedRequest 按客户端的方式签名: timestamp + nonce + URL 路径
+func signedRequest(t *testing.T, priv ed25519.PrivateKey, method, url string, body []byte) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if priv == nil {
+		return req
+	}
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	n := hex.EncodeToString(nonce)
+	req.Header.Set("X-Timestamp", ts)
+	req.Header.Set("X-Nonce", n)
+	req.Header.Set("X-Signature", hex.EncodeToString(ed25519.Sign(priv, []byte(ts+n+req.URL.Path))))
+	return req
+}
+
+// doJSON 发送请求并解析 JSON 响应
+func doJSON(t *testing.T, req *http.Request) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	var body map[string]interface{}
+	if err := json.Unmarshal(data, &body); err != nil {
+		t.Fatalf("%s %s: invalid JSON response %q", req.Method, req.URL.Path, data)
+	}
+	return resp, body
+}
+
+func TestHandleUpload(t *testing.T) {
+	root := t.TempDir()
+	existing := filepath.Join(root, "fixed", "index.html")
+	if err := os.MkdirAll(filepath.Dir(existing), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Unix(1700000000, 0)
+	os.Chtimes(existing, modTime, modTime)
+	os.MkdirAll(filepath.Join(root, "newer"), 0755)
+	os.WriteFile(filepath.Join(root, "newer", "index.html"), []byte("old"), 0644)
+	os.Chtimes(filepath.Join(root, "newer", "index.html"), modTime, modTime)
+
+	srv, priv := newTestServer(t, map[string]PathConfig{
+		"web":      {Dir: filepath.Join(root, "web"), Deny: []string{"*.exe"}},
+		"fixed":    {Dir: filepath.Join(root, "fixed"), Overwrite: overwriteNever},
+		"newer":    {Dir: filepath.Join(root, "newer"), Overwrite: overwriteIfNewer},
+		"off":      {Dir: filepath.Join(root, "off"), Disabled: true},
+		"readonly": {Dir: filepath.Join(root, "readonly"), ReadOnly: true},
+		"html":     {Dir: filepath.Join(root, "html"), Allow: []string{"*.html"}},
+	})
+
+	content := []byte("<h1>hello</h1>")
+	sum := sha256.Sum256(content)
+	tests := []struct {
+		name   string
+		path   string
+		query  string
+		header map[string]string
+		sign   bool
+		status int
+		code   string
+	}{
+		{name: "ok", path: "/upload/web/index.html", sign: true, status: http.StatusOK},
+		{name: "nested ok", path: "/upload/web/assets/app.html", sign: true, status: http.StatusOK},
+		{name: "unsigned", path: "/upload/web/index.html", status: http.StatusUnauthorized, code: CodeAuthMissing},
+		{name: "unknown key", path: "/upload/nope/index.html", sign: true, status: http.StatusBadRequest, code: CodeUnknownPathKey},
+		{name: "missing filename", path: "/upload/web/", sign: true, status: http.StatusBadRequest, code: CodeInvalidURL},
+		{name: "hidden file", path: "/upload/web/.env", sign: true, status: http.StatusBadRequest, code: CodeInvalidFilename},
+		{name: "denied", path: "/upload/web/setup.exe", sign: true, status: http.StatusForbidden, code: CodeFileTypeDenied},
+		{name: "not allowed", path: "/upload/html/app.js", sign: true, status: http.StatusForbidden, code: CodeFileTypeDenied},
+		{name: "disabled", path: "/upload/off/index.html", sign: true, status: http.StatusForbidden, code: CodePathDisabled},
+		{name: "read only", path: "/upload/readonly/index.html", sign: true, status: http.StatusForbidden, code: CodePathReadOnly},
+		{name: "never overwrite", path: "/upload/fixed/index.html", sign: true, status: http.StatusConflict, code: CodeFileExists},
+		{name: "never overwrite new file", path: "/upload/fixed/other.html", sign: true, status: http.StatusOK},
+		{name: "if-newer older", path: "/upload/newer/index.html", query: "mtime=" + strconv.FormatInt(modTime.Unix()-10, 10), sign: true, status: http.StatusConflict, code: CodeFileNotNewer},
+		{name: "if-newer without mtime", path: "/upload/newer/index.html", sign: true, status: http.StatusBadRequest, code: CodeInvalidParameter},
+		{name: "bad mtime", path: "/upload/web/index.html", query: "mtime=abc", sign: true, status: http.StatusBadRequest, code: CodeInvalidParameter},
+		{name: "checksum ok", path: "/upload/web/sum.html", header: map[string]string{"X-Content-SHA256": hex.EncodeToString(sum[:])}, sign: true, status: http.StatusOK},
+		{name: "checksum mismatch", path: "/upload/web/bad.html", header: map[string]string{"X-Content-SHA256": hex.EncodeToString(make([]byte, 32))}, sign: true, status: http.StatusUnprocessableEntity, code: CodeChecksumMismatch},
+		{name: "bad mode", path: "/upload/web/site.zip", query: "extract=true&mode=replace", sign: true, status: http.StatusBadRequest, code: CodeInvalidParameter},
+	}
+	for _, tt := range tests {
+		url := srv.URL + tt.path
+		if tt.query != "" {
+			url += "?" + tt.query
+		}
+		var key ed25519.PrivateKey
+		if tt.sign {
+			key = priv
+		}
+		req := signedRequest(t, key, http.MethodPost, url, content)
+		req.Header.Set("X-Request-ID", "req-"+strconv.Itoa(len(tt.name)))
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+
+		resp, body := doJSON(t, req)
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d (%v)", tt.name, resp.StatusCode, tt.status, body)
+			continue
+		}
+		if got := resp.Header.Get("X-Request-ID"); got != req.Header.Get("X-Request-ID") || body["request_id"] != got {
+			t.Errorf("%s: request id header %q body %v, want %q", tt.name, got, body["request_id"], req.Header.Get("X-Request-ID"))
+		}
+		if tt.code != "" {
+			if body["status"] != "error" || body["code"] != tt.code {
+				t.Errorf("%s: body = %v, want code %s", tt.name, body, tt.code)
+			}
+			continue
+		}
+		if body["status"] != "ok" || body["sha256"] != hex.EncodeToString(sum[:]) {
+			t.Errorf("%s: body = %v", tt.name, body)
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "web", "assets", "app.html")); err != nil || !bytes.Equal(data, content) {
+		t.Errorf("nested upload not written: %q, %v", data, err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "old" {
+		t.Errorf("never-overwrite file changed to %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(root, "web", "bad.html")); !os.IsNotExist(err) {
+		t.Errorf("checksum mismatch should not write the file, stat error = %v", err)
+	}
+}
+
+func TestHandleUploadIfNewerKeepsMtime(t *testing.T) {
+	root := t.TempDir()
+	srv, priv := newTestServer(t, map[string]PathConfig{
+		"web": {Dir: root, Overwrite: overwriteIfNewer},
+	})
+
+	mtime := int64(1700000000)
+	url := srv.URL + "/upload/web/index.html?mtime=" + strconv.FormatInt(mtime, 10)
+	resp, body := doJSON(t, signedRequest(t, priv, http.MethodPost, url, []byte("v1")))
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("first upload: status %d, %v", resp.StatusCode, body)
+	}
+	info, err := os.Stat(filepath.Join(root, "index.html"))
+	if err != nil || info.ModTime().Unix() != mtime {
+		t.Fatalf("mtime = %v, %v, want %d", info, err, mtime)
+	}
+
+	// 同一时间的再次上传不比服务器新，跳过
+	resp, body = doJSON(t, signedRequest(t, priv, http.MethodPost, url, []byte("v2")))
+	if resp.StatusCode != http.StatusConflict || body["code"] != CodeFileNotNewer {
+		t.Errorf("same mtime upload: status %d, %v, want %s", resp.StatusCode, body, CodeFileNotNewer)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	srv, priv := newTestServer(t, map[string]PathConfig{"web": {Dir: t.TempDir()}})
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	tests := []struct {
+		name   string
+		modify func(r *http.Request)
+		status int
+		code   string
+	}{
+		{"valid", func(r *http.Request) {}, http.StatusOK, ""},
+		{"missing signature", func(r *http.Request) { r.Header.Del("X-Signature") }, http.StatusUnauthorized, CodeAuthMissing},
+		{"bad timestamp", func(r *http.Request) { r.Header.Set("X-Timestamp", "soon") }, http.StatusUnauthorized, CodeAuthBadTimestamp},
+		{"expired", func(r *http.Request) {
+			r.Header.Set("X-Timestamp", strconv.FormatInt(time.Now().Unix()-3600, 10))
+		}, http.StatusUnauthorized, CodeAuthExpired},
+		{"tampered nonce", func(r *http.Request) { r.Header.Set("X-Nonce", "other") }, http.StatusUnauthorized, CodeAuthBadSignature},
+		{"not hex", func(r *http.Request) { r.Header.Set("X-Signature", "zz") }, http.StatusUnauthorized, CodeAuthBadSignature},
+		{"wrong key", func(r *http.Request) {
+			ts, n := r.Header.Get("X-Timestamp"), r.Header.Get("X-Nonce")
+			r.Header.Set("X-Signature", hex.EncodeToString(ed25519.Sign(otherKey, []byte(ts+n+r.URL.Path))))
+		}, http.StatusUnauthorized, CodeAuthBadSignature},
+	}
+	for _, tt := range tests {
+		req := signedRequest(t, priv, http.MethodGet, srv.URL+"/list/web", nil)
+		tt.modify(req)
+		resp, body := doJSON(t, req)
+		if resp.StatusCode != tt.status || (tt.code != "" && body["code"] != tt.code) {
+			t.Errorf("%s: status %d body %v, want %d %s", tt.name, resp.StatusCode, body, tt.status, tt.code)
+		}
+	}
+
+	// 签名覆盖路径，换一个路径重放同一组认证头失败
+	req := signedRequest(t, priv, http.MethodGet, srv.URL+"/list/web", nil)
+	replay, _ := http.NewRequest(http.MethodPost, srv.URL+"/upload/web/index.html", bytes.NewReader([]byte("x")))
+	for _, h := range []string{"X-Timestamp", "X-Nonce", "X-Signature"} {
+		replay.Header.Set(h, req.Header.Get(h))
+	}
+	if resp, body := doJSON(t, replay); body["code"] != CodeAuthBadSignature {
+		t.Errorf("replay on another path: status %d body %v, want %s", resp.StatusCode, body, CodeAuthBadSignature)
+	}
+}
+
+func TestIsValidFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"index.html", true},
+		{"assets/app.js", true},
+		{"../evil", false},
+		{"a/../../evil", false},
+		{".env", false},
+		{"assets/.git/config", false},
+		{"a:b", false},
+		{"a*b", false},
+		{"a|b", false},
+	}
+	for _, tt := range tests {
+		if got := isValidFilename(tt.name); got != tt.want {
+			t.Errorf("isValidFilename(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 镜像模式在目标目录旁边使用的暂存和备份目录后缀 (目录名以点开头，上传和列出文件接口都不会访问)
+const (
+	stagingSuffix = ".deploy-staging"
+	backupSuffix  = ".deploy-backup"
+)
+
+// MirrorStats 镜像解压的变更统计
+type MirrorStats struct {
+	Added     int `json:"added"`
+	Changed   int `json:"changed"`
+	Removed   int `json:"removed"`
+	Unchanged int `json:"unchanged"`
+	Preserved int `json:"preserved"`
+}
+
+// mirrorExtract 先解压到暂存目录，再整体替换 dest，让 dest 与压缩包内容完全一致
+//
+// 替换时 dest 改名为备份目录，暂存目录改名为 dest，再把匹配 preserve 的旧文件和 keep 中的
+// 绝对路径 (如上传的压缩包本身) 从备份复制回来；任何一步失败都恢复原来的 dest。
+func mirrorExtract(src, dest string, opts extractOptions, preserve []string, keep ...string) (*MirrorStats, error) {
+	dest, err := filepath.Abs(dest)
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest))
+	staging, backup := prefix+stagingSuffix, prefix+backupSuffix
+
+	// 上次在交换过程中中断时 dest 可能不存在，从备份恢复
+	if _, err := os.Stat(dest); os.IsNotExist(err) {
+		if _, err := os.Stat(backup); err == nil {
+			if err := os.Rename(backup, dest); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := os.RemoveAll(backup); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(staging); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	if _, err := unzipFile(src, staging, opts); err != nil {
+		return nil, err
+	}
+
+	keepSet := make(map[string]bool, len(keep))
+	for _, k := range keep {
+		if abs, err := filepath.Abs(k); err == nil && isWithinDir(dest, abs) {
+			if rel, err := filepath.Rel(dest, abs); err == nil {
+				keepSet[filepath.ToSlash(rel)] = true
+			}
+		}
+	}
+
+	stats, err := mirrorStats(staging, dest, preserve, keepSet)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(dest); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(staging, dest); err != nil {
+			return nil, err
+		}
+		return stats, nil
+	}
+
+	if err := os.Rename(dest, backup); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(staging, dest); err != nil {
+		return nil, restoreBackup(dest, backup, err)
+	}
+	if err := copyKept(backup, dest, preserve, keepSet); err != nil {
+		return nil, restoreBackup(dest, backup, err)
+	}
+
+	// 新内容已经就位，备份删除失败只留下隐藏目录，下次镜像时再清理
+	os.RemoveAll(backup)
+	return stats, nil
+}
+
+// restoreBackup 替换失败时删除新内容并把备份改回 dest，返回原来的错误
+func restoreBackup(dest, backup string, cause error) error {
+	if err := os.RemoveAll(dest); err != nil {
+		return fmt.Errorf("%v (恢复失败，原内容在 %s: %v)", cause, backup, err)
+	}
+	if err := os.Rename(backup, dest); err != nil {
+		return fmt.Errorf("%v (恢复失败，原内容在 %s: %v)", cause, backup, err)
+	}
+	return cause
+}
+
+// mirrorStats 对比暂存目录和当前的 dest，统计替换后的变化
+func mirrorStats(staging, dest string, preserve []string, keep map[string]bool) (*MirrorStats, error) {
+	stats := &MirrorStats{}
+	archived := make(map[string]bool)
+
+	err := filepath.Walk(staging, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staging, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		relSlash := filepath.ToSlash(rel)
+		archived[relSlash] = true
+		if info.IsDir() {
+			return nil
+		}
+
+		existing, statErr := os.Stat(filepath.Join(dest, rel))
+		switch {
+		case statErr != nil:
+			// 不存在，或旧版本中上层是同名文件
+			stats.Added++
+		case matchPreserve(relSlash, false, preserve):
+			// 旧文件会被复制回来，在下面统计
+		case existing.IsDir():
+			stats.Changed++
+		default:
+			same, err := sameContent(p, filepath.Join(dest, rel))
+			if err != nil {
+				return err
+			}
+			if same {
+				stats.Unchanged++
+			} else {
+				stats.Changed++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(dest); os.IsNotExist(err) {
+		return stats, nil
+	}
+	err = filepath.Walk(dest, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dest, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		relSlash := filepath.ToSlash(rel)
+		if info.IsDir() {
+			return nil
+		}
+		if matchPreserve(relSlash, false, preserve) {
+			stats.Preserved++
+			return nil
+		}
+		if !keep[relSlash] && (!archived[relSlash] || isDirIn(staging, rel)) {
+			stats.Removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// isDirIn 判断 rel 在 root 下是否为目录
+func isDirIn(root, rel string) bool {
+	info, err := os.Stat(filepath.Join(root, rel))
+	return err == nil && info.IsDir()
+}
+
+// copyKept 把备份中匹配 preserve 的旧文件和 keep 中的文件复制到新的 dest，覆盖压缩包中的同名内容
+func copyKept(backup, dest string, preserve []string, keep map[string]bool) error {
+	return filepath.Walk(backup, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(backup, p)
+		if err != nil || rel == "." {
+			return err
+		}
+		relSlash := filepath.ToSlash(rel)
+		if info.IsDir() {
+			if matchPreserve(relSlash, true, preserve) {
+				if err := copyTree(p, filepath.Join(dest, rel)); err != nil {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !keep[relSlash] && !matchPreserve(relSlash, false, preserve) {
+			return nil
+		}
+		return copyEntry(p, filepath.Join(dest, rel), info)
+	})
+}
+
+// copyTree 复制整个目录，目标中同名的内容被替换
+func copyTree(src, dst string) error {
+	if info, err := os.Stat(dst); err == nil && !info.IsDir() {
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			if existing, err := os.Stat(target); err == nil && !existing.IsDir() {
+				if err := os.Remove(target); err != nil {
+					return err
+				}
+			}
+			return os.MkdirAll(target, 0755)
+		}
+		return copyEntry(p, target, info)
+	})
+}
+
+// copyEntry 复制单个文件并保留权限和修改时间，目标为目录时先删除
+func copyEntry(src, dst string, info os.FileInfo) error {
+	if existing, err := os.Stat(dst); err == nil && existing.IsDir() {
+		if err := os.RemoveAll(dst); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+// matchPreserve 判断相对路径是否命中保留列表
+//
+// 规则与 .gitignore 类似: 以 "/" 结尾的模式只匹配目录 (及其下所有内容)，
+// 不含 "/" 的模式匹配任意层级的名称，含 "/" 的模式匹配从根开始的完整路径。
+func matchPreserve(rel string, isDir bool, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+
+	parts := strings.Split(rel, "/")
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(strings.TrimSpace(pattern)), "/")
+		if pattern == "" {
+			continue
+		}
+		dirOnly := strings.HasSuffix(pattern, "/")
+		pattern = strings.TrimSuffix(pattern, "/")
+		anchored := strings.Contains(pattern, "/")
+
+		for i := 1; i <= len(parts); i++ {
+			last := i == len(parts)
+			if dirOnly && last && !isDir {
+				continue
+			}
+			name := parts[i-1]
+			if anchored {
+				name = strings.Join(parts[:i], "/")
+			}
+			if ok, _ := path.Match(pattern, name); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sameContent 比较两个文件内容是否一致
+func sameContent(a, b string) (bool, error) {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	if infoA.Size() != infoB.Size() {
+		return false, nil
+	}
+
+	hashA, err := fileSHA256(a)
+	if err != nil {
+		return false, err
+	}
+	hashB, err := fileSHA256(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(hashA, hashB), nil
+}
+
+func fileSHA256(p string) ([]byte, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// replaceFile 用 src 覆盖 dst，Windows 上 Rename 不能覆盖已存在的文件
+func replaceFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Rename(src, dst)
+}
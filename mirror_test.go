@@ -0,0 +1,229 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatchPreserve(t *testing.T) {
+	tests := []struct {
+		rel      string
+		isDir    bool
+		patterns []string
+		want     bool
+	}{
+		{".env", false, []string{".env"}, true},
+		{"config/.env", false, []string{".env"}, true},
+		{"app.log", false, []string{"*.log"}, true},
+		{"logs/app.log", false, []string{"*.log"}, true},
+		{"uploads", true, []string{"uploads/"}, true},
+		{"uploads", false, []string{"uploads/"}, false},
+		{"uploads/a/b.png", false, []string{"uploads/"}, true},
+		{"static/uploads/b.png", false, []string{"uploads/"}, true},
+		{"data/db.sqlite", false, []string{"data/*.sqlite"}, true},
+		{"data/sub/db.sqlite", false, []string{"data/*.sqlite"}, false},
+		{"x/data/db.sqlite", false, []string{"data/*.sqlite"}, false},
+		{"data/db.sqlite", false, []string{"/data/db.sqlite"}, true},
+		{"index.html", false, []string{" ", ""}, false},
+		{"index.html", false, nil, false},
+	}
+	for _, tt := range tests {
+		if got := matchPreserve(tt.rel, tt.isDir, tt.patterns); got != tt.want {
+			t.Errorf("matchPreserve(%q, %v, %q) = %v, want %v", tt.rel, tt.isDir, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+// writeTree 在 root 下按 相对路径 -> 内容 创建文件
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree 读出 root 下所有文件，root 不存在时返回 nil
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil
+	}
+	files := make(map[string]string)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// assertNoSiblings 检查镜像结束后没有留下暂存和备份目录
+func assertNoSiblings(t *testing.T, name, dest string) {
+	t.Helper()
+	prefix := filepath.Join(filepath.Dir(dest), "."+filepath.Base(dest))
+	for _, dir := range []string{prefix + stagingSuffix, prefix + backupSuffix} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s: %s should be removed, stat error = %v", name, filepath.Base(dir), err)
+		}
+	}
+}
+
+func TestMirrorExtract(t *testing.T) {
+	defer func(old ExtractConfig) { config.Extract = old }(config.Extract)
+	config.Extract = ExtractConfig{MaxUncompressedMB: 1}
+
+	tests := []struct {
+		name     string
+		existing map[string]string // nil 表示目标目录不存在
+		entries  []string
+		preserve []string
+		keep     []string // 相对 dest 的路径
+		want     map[string]string
+		stats    MirrorStats
+	}{
+		{
+			name:     "replace tree and preserve globs",
+			existing: map[string]string{"index.html": "old", "app.js": "same", "stale.txt": "s", "uploads/a.png": "png", ".env": "local"},
+			entries:  []string{"index.html", "new", "app.js", "same", "new.js", "n", ".env", "from zip"},
+			preserve: []string{"uploads/", ".env"},
+			want:     map[string]string{"index.html": "new", "app.js": "same", "new.js": "n", "uploads/a.png": "png", ".env": "local"},
+			stats:    MirrorStats{Added: 1, Changed: 1, Unchanged: 1, Removed: 1, Preserved: 2},
+		},
+		{
+			name:     "keep uploaded archive",
+			existing: map[string]string{"dist.zip": "zip", "old.html": "o"},
+			entries:  []string{"index.html", "i"},
+			keep:     []string{"dist.zip"},
+			want:     map[string]string{"dist.zip": "zip", "index.html": "i"},
+			stats:    MirrorStats{Added: 1, Removed: 1},
+		},
+		{
+			name:     "file replaced by directory",
+			existing: map[string]string{"assets": "file"},
+			entries:  []string{"assets/app.js", "a"},
+			want:     map[string]string{"assets/app.js": "a"},
+			stats:    MirrorStats{Added: 1, Removed: 1},
+		},
+		{
+			name:    "target does not exist yet",
+			entries: []string{"index.html", "i", "js/app.js", "a"},
+			want:    map[string]string{"index.html": "i", "js/app.js": "a"},
+			stats:   MirrorStats{Added: 2},
+		},
+	}
+	for _, tt := range tests {
+		dest := filepath.Join(t.TempDir(), "site", "web")
+		if tt.existing != nil {
+			writeTree(t, dest, tt.existing)
+		}
+		var keep []string
+		for _, k := range tt.keep {
+			keep = append(keep, filepath.Join(dest, k))
+		}
+
+		stats, err := mirrorExtract(writeTestZip(t, tt.entries...), dest, extractOptions{Mirror: true}, tt.preserve, keep...)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if *stats != tt.stats {
+			t.Errorf("%s: stats = %+v, want %+v", tt.name, *stats, tt.stats)
+		}
+		if got := readTree(t, dest); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: tree = %v, want %v", tt.name, got, tt.want)
+		}
+		assertNoSiblings(t, tt.name, dest)
+	}
+}
+
+func TestMirrorExtractFailureKeepsOriginal(t *testing.T) {
+	defer func(old ExtractConfig) { config.Extract = old }(config.Extract)
+	config.Extract = ExtractConfig{MaxUncompressedMB: 1}
+
+	original := map[string]string{"index.html": "old", "uploads/a.png": "png"}
+	tests := []struct {
+		name    string
+		entries []string
+		code    string
+	}{
+		{"size over limit", []string{"index.html", "new", "big.bin", strings.Repeat("x", 1024*1024+1)}, CodeExtractLimit},
+		{"path traversal", []string{"index.html", "new", "../evil.txt", "x"}, CodePathTraversal},
+	}
+	for _, tt := range tests {
+		dest := filepath.Join(t.TempDir(), "web")
+		writeTree(t, dest, original)
+
+		_, err := mirrorExtract(writeTestZip(t, tt.entries...), dest, extractOptions{Mirror: true}, []string{"uploads/"})
+		var ae *apiError
+		if !errors.As(err, &ae) || ae.Code != tt.code {
+			t.Errorf("%s: error = %v, want %s", tt.name, err, tt.code)
+		}
+		if got := readTree(t, dest); !reflect.DeepEqual(got, original) {
+			t.Errorf("%s: tree = %v, want original %v", tt.name, got, original)
+		}
+		if _, err := os.Stat(filepath.Join(filepath.Dir(dest), "evil.txt")); !os.IsNotExist(err) {
+			t.Errorf("%s: file written outside target", tt.name)
+		}
+		assertNoSiblings(t, tt.name, dest)
+	}
+}
+
+func TestMirrorExtractRecoversBackup(t *testing.T) {
+	// 上次在 dest 改名为备份之后中断: dest 不存在，只剩备份目录
+	dest := filepath.Join(t.TempDir(), "web")
+	backup := filepath.Join(filepath.Dir(dest), ".web"+backupSuffix)
+	writeTree(t, backup, map[string]string{"index.html": "old", "uploads/a.png": "png"})
+
+	stats, err := mirrorExtract(writeTestZip(t, "index.html", "new"), dest, extractOptions{Mirror: true}, []string{"uploads/"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	want := map[string]string{"index.html": "new", "uploads/a.png": "png"}
+	if got := readTree(t, dest); !reflect.DeepEqual(got, want) {
+		t.Errorf("tree = %v, want %v", got, want)
+	}
+	if want := (MirrorStats{Changed: 1, Preserved: 1}); *stats != want {
+		t.Errorf("stats = %+v, want %+v", *stats, want)
+	}
+	assertNoSiblings(t, "recover", dest)
+}
+
+func TestRestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "web")
+	backup := filepath.Join(dir, ".web"+backupSuffix)
+	original := map[string]string{"index.html": "old", "uploads/a.png": "png"}
+	writeTree(t, backup, original)
+	writeTree(t, dest, map[string]string{"index.html": "half written"})
+
+	cause := errors.New("copy failed")
+	if err := restoreBackup(dest, backup, cause); err != cause {
+		t.Errorf("restoreBackup error = %v, want %v", err, cause)
+	}
+	if got := readTree(t, dest); !reflect.DeepEqual(got, original) {
+		t.Errorf("tree = %v, want %v", got, original)
+	}
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Errorf("backup should be renamed back, stat error = %v", err)
+	}
+}
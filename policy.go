@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// 覆盖策略
+const (
+	overwriteAlways  = "always"   // 总是覆盖 (默认)
+	overwriteNever   = "never"    // 目标已存在时拒绝
+	overwriteIfNewer = "if-newer" // 仅当客户端文件更新时覆盖
+)
+
+// PathConfig 单个路径标识的配置和策略
+//
+// 兼容旧的字符串写法: "web": "C:\\deploy\\web" 等价于 {"dir": "C:\\deploy\\web"}
+type PathConfig struct {
+	Dir         string   `json:"dir"`           // 目标目录
+	MaxUploadMB int64    `json:"max_upload_mb"` // 单次上传上限，0 表示使用全局 max_upload_mb
+	Allow       []string `json:"allow"`         // 允许的文件名 glob，空表示不限制
+	Deny        []string `json:"deny"`          // 禁止的文件名 glob，优先于 allow
+	Overwrite   string   `json:"overwrite"`     // always / never / if-newer
+	Disabled    bool     `json:"disabled"`      // 禁用该路径标识
+	ReadOnly    bool     `json:"read_only"`     // 只读，拒绝所有写入
+	KeepArchive string   `json:"keep_archive"`  // 解压后压缩包的处理方式
+}
+
+// UnmarshalJSON 同时支持字符串和对象两种写法
+func (p *PathConfig) UnmarshalJSON(data []byte) error {
+	var dir string
+	if err := json.Unmarshal(data, &dir); err == nil {
+		*p = PathConfig{Dir: dir}
+		return nil
+	}
+
+	type plain PathConfig
+	var v plain
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = PathConfig(v)
+	return nil
+}
+
+// validate 检查配置并补全默认值
+func (p *PathConfig) validate(key string) error {
+	if p.Dir == "" {
+		return fmt.Errorf("路径标识 %s 未配置 dir", key)
+	}
+	switch p.Overwrite {
+	case "":
+		p.Overwrite = overwriteAlways
+	case overwriteAlways, overwriteNever, overwriteIfNewer:
+	default:
+		return fmt.Errorf("路径标识 %s 的 overwrite 无效: %s (应为 always/never/if-newer)", key, p.Overwrite)
+	}
+	if p.KeepArchive != "" {
+		if _, err := parseKeepArchive(p.KeepArchive); err != nil {
+			return fmt.Errorf("路径标识 %s: %v", key, err)
+		}
+	}
+	for _, pattern := range append(append([]string{}, p.Allow...), p.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("路径标识 %s 的 glob 无效: %s", key, pattern)
+		}
+	}
+	return nil
+}
+
+// maxUploadBytes 该路径标识的上传上限 (字节) 和对应的 MB 数
+func (p *PathConfig) maxUploadBytes() (int64, int64) {
+	mb := config.MaxUpload
+	if p.MaxUploadMB > 0 {
+		mb = p.MaxUploadMB
+	}
+	return mb * 1024 * 1024, mb
+}
+
+// checkWritable 检查路径标识是否允许写入
+func (p *PathConfig) checkWritable(key string) error {
+	if p.Disabled {
+		return newAPIError(http.StatusForbidden, CodePathDisabled, "路径标识已禁用: %s", key)
+	}
+	if p.ReadOnly {
+		return newAPIError(http.StatusForbidden, CodePathReadOnly, "路径标识为只读: %s", key)
+	}
+	return nil
+}
+
+// checkName 按 allow / deny 检查文件名 (相对路径，使用 "/" 分隔)
+func (p *PathConfig) checkName(name string) error {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if matchNameGlob(name, p.Deny) {
+		return newAPIError(http.StatusForbidden, CodeFileTypeDenied, "文件类型被禁止: %s", name)
+	}
+	if len(p.Allow) > 0 && !matchNameGlob(name, p.Allow) {
+		return newAPIError(http.StatusForbidden, CodeFileTypeDenied, "文件类型不在允许列表: %s", name)
+	}
+	return nil
+}
+
+// checkOverwrite 按覆盖策略检查目标文件，mtime 为客户端文件的修改时间 (Unix 秒，0 表示未提供)
+//
+// mtime 来自未签名的查询参数，if-newer 只用于防止旧版本误覆盖，不能作为访问控制。
+func (p *PathConfig) checkOverwrite(fullPath string, mtime int64) error {
+	if p.Overwrite == overwriteAlways {
+		return nil
+	}
+
+	info, err := os.Stat(fullPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if p.Overwrite == overwriteNever {
+		return newAPIError(http.StatusConflict, CodeFileExists, "目标文件已存在且不允许覆盖")
+	}
+
+	if mtime <= 0 {
+		return newAPIError(http.StatusBadRequest, CodeInvalidParameter, "if-newer 策略需要 mtime 参数")
+	}
+	if !time.Unix(mtime, 0).After(info.ModTime().Truncate(time.Second)) {
+		return newAPIError(http.StatusConflict, CodeFileNotNewer, "上传文件不比服务器上的版本新，跳过覆盖")
+	}
+	return nil
+}
+
+// matchNameGlob 不含 "/" 的模式匹配文件名，含 "/" 的模式匹配完整相对路径
+func matchNameGlob(name string, patterns []string) bool {
+	base := path.Base(name)
+	for _, pattern := range patterns {
+		target := base
+		if strings.Contains(pattern, "/") {
+			target = name
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+		// 扩展名匹配不区分大小写，避免 .EXE 绕过 *.exe
+		if ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(target)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPathConfigUnmarshalJSON(t *testing.T) {
+	data := `{
+		"web": "C:\\deploy\\web",
+		"static": {
+			"dir": "C:\\deploy\\static",
+			"max_upload_mb": 50,
+			"allow": ["*.html", "*.zip"],
+			"deny": ["*.exe"],
+			"overwrite": "if-newer",
+			"read_only": true,
+			"keep_archive": "delete"
+		}
+	}`
+	var paths map[string]PathConfig
+	if err := json.Unmarshal([]byte(data), &paths); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	want := map[string]PathConfig{
+		"web": {Dir: `C:\deploy\web`},
+		"static": {
+			Dir:         `C:\deploy\static`,
+			MaxUploadMB: 50,
+			Allow:       []string{"*.html", "*.zip"},
+			Deny:        []string{"*.exe"},
+			Overwrite:   overwriteIfNewer,
+			ReadOnly:    true,
+			KeepArchive: "delete",
+		},
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %+v, want %+v", paths, want)
+	}
+
+	for _, bad := range []string{`123`, `["a"]`, `{"dir": 1}`} {
+		var p PathConfig
+		if err := json.Unmarshal([]byte(bad), &p); err == nil {
+			t.Errorf("Unmarshal(%s) should fail", bad)
+		}
+	}
+}
+
+func TestPathConfigValidate(t *testing.T) {
+	tests := []struct {
+		cfg       PathConfig
+		overwrite string
+		ok        bool
+	}{
+		{PathConfig{Dir: "web"}, overwriteAlways, true},
+		{PathConfig{Dir: "web", Overwrite: overwriteNever}, overwriteNever, true},
+		{PathConfig{Dir: "web", Overwrite: "sometimes"}, "", false},
+		{PathConfig{}, "", false},
+		{PathConfig{Dir: "web", KeepArchive: "shred"}, "", false},
+		{PathConfig{Dir: "web", Deny: []string{"[a-"}}, "", false},
+	}
+	for _, tt := range tests {
+		cfg := tt.cfg
+		err := cfg.validate("key")
+		if (err == nil) != tt.ok {
+			t.Errorf("validate(%+v) error = %v, want ok %v", tt.cfg, err, tt.ok)
+			continue
+		}
+		if tt.ok && cfg.Overwrite != tt.overwrite {
+			t.Errorf("validate(%+v) overwrite = %q, want %q", tt.cfg, cfg.Overwrite, tt.overwrite)
+		}
+	}
+}
+
+func TestCheckName(t *testing.T) {
+	tests := []struct {
+		allow []string
+		deny  []string
+		name  string
+		ok    bool
+	}{
+		{nil, nil, "anything.bin", true},
+		{nil, []string{"*.exe"}, "setup.exe", false},
+		{nil, []string{"*.exe"}, "SETUP.EXE", false},
+		{nil, []string{"*.exe"}, "bin/setup.exe", false},
+		{nil, []string{"*.exe"}, `bin\setup.exe`, false},
+		{[]string{"*.html", "*.js"}, nil, "index.html", true},
+		{[]string{"*.html", "*.js"}, nil, "app.css", false},
+		{[]string{"*.html"}, []string{"admin.html"}, "admin.html", false},
+		{[]string{"assets/*"}, nil, "assets/app.js", true},
+		{[]string{"assets/*"}, nil, "other/assets/app.js", false},
+	}
+	for _, tt := range tests {
+		p := PathConfig{Allow: tt.allow, Deny: tt.deny}
+		err := p.checkName(tt.name)
+		if tt.ok {
+			if err != nil {
+				t.Errorf("allow %q deny %q: checkName(%q) = %v, want nil", tt.allow, tt.deny, tt.name, err)
+			}
+			continue
+		}
+		var ae *apiError
+		if !errors.As(err, &ae) || ae.Code != CodeFileTypeDenied {
+			t.Errorf("allow %q deny %q: checkName(%q) = %v, want %s", tt.allow, tt.deny, tt.name, err, CodeFileTypeDenied)
+		}
+	}
+}
+
+func TestCheckOverwrite(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "index.html")
+	if err := os.WriteFile(existing, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Unix(1700000000, 0)
+	if err := os.Chtimes(existing, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(filepath.Dir(existing), "missing.html")
+
+	tests := []struct {
+		mode  string
+		path  string
+		mtime int64
+		code  string
+	}{
+		{overwriteAlways, existing, 0, ""},
+		{overwriteNever, missing, 0, ""},
+		{overwriteNever, existing, 0, CodeFileExists},
+		{overwriteIfNewer, missing, 0, ""},
+		{overwriteIfNewer, existing, 0, CodeInvalidParameter},
+		{overwriteIfNewer, existing, modTime.Unix() - 1, CodeFileNotNewer},
+		{overwriteIfNewer, existing, modTime.Unix(), CodeFileNotNewer},
+		{overwriteIfNewer, existing, modTime.Unix() + 1, ""},
+	}
+	for _, tt := range tests {
+		p := PathConfig{Overwrite: tt.mode}
+		err := p.checkOverwrite(tt.path, tt.mtime)
+		if tt.code == "" {
+			if err != nil {
+				t.Errorf("%s %s mtime %d: unexpected error %v", tt.mode, filepath.Base(tt.path), tt.mtime, err)
+			}
+			continue
+		}
+		var ae *apiError
+		if !errors.As(err, &ae) || ae.Code != tt.code {
+			t.Errorf("%s %s mtime %d: error = %v, want %s", tt.mode, filepath.Base(tt.path), tt.mtime, err, tt.code)
+		}
+	}
+}